@@ -10,23 +10,61 @@ import (
 	"github.com/bendbennett/go-api-demo/internal/bootstrap"
 )
 
-// main bootstraps and runs the application.
-// signalShutdownHandler is run in a go routine and cancels
-// the context when an interrupt or termination signal is received.
+// main bootstraps and runs the application in the role given by the
+// subcommand (serve-api, serve-consumers or all, which is the default),
+// or runs the migrate, import or export subcommand.
 func main() {
-	app := bootstrap.New()
-	defer app.Close()
+	subcommand := "all"
+
+	if len(os.Args) > 1 {
+		subcommand = os.Args[1]
+	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 
 	go signalShutdownHandler(cancelFunc)
 
+	switch subcommand {
+	case "serve-api":
+		os.Exit(serve(ctx, bootstrap.RoleAPI))
+	case "serve-consumers":
+		os.Exit(serve(ctx, bootstrap.RoleConsumers))
+	case "all":
+		os.Exit(serve(ctx, bootstrap.RoleAll))
+	case "migrate":
+		os.Exit(runMigrate(os.Args[2:]))
+	case "import":
+		os.Exit(runImport(ctx, os.Args[2:]))
+	case "export":
+		os.Exit(runExport(ctx, os.Args[2:]))
+	default:
+		log.Fatalf(
+			"unknown subcommand %q, expected serve-api, serve-consumers, all, migrate, import or export",
+			subcommand,
+		)
+	}
+}
+
+// serve runs the application in role until ctx is cancelled, returning a
+// non-zero exit code if it fails.
+func serve(
+	ctx context.Context,
+	role bootstrap.Role,
+) int {
+	app := bootstrap.New(role)
+	defer app.Close()
+
 	err := app.Run(ctx)
 	if err != nil {
 		log.Printf("app run error: %v\n", err)
+		return 1
 	}
+
+	return 0
 }
 
+// signalShutdownHandler is run in a go routine and cancels
+// the context when an interrupt or termination signal is received.
 func signalShutdownHandler(cancelFunc context.CancelFunc) {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
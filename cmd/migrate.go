@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bendbennett/go-api-demo/internal/bootstrap"
+)
+
+// runMigrate runs the up (the default), down, status or force migrate
+// subcommand against the migrations embedded in the binary.
+func runMigrate(
+	args []string,
+) int {
+	subcommand := "up"
+
+	if len(args) > 0 {
+		subcommand, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("migrate "+subcommand, flag.ExitOnError)
+
+	var steps *int
+
+	switch subcommand {
+	case "up":
+		steps = fs.Int("steps", 0, "number of migrations to apply (default all)")
+	case "down":
+		steps = fs.Int("steps", 1, "number of migrations to roll back")
+	case "status", "force":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate subcommand %q, expected up, down, status or force\n", subcommand)
+		return 1
+	}
+
+	_ = fs.Parse(args)
+
+	var version int
+
+	if subcommand == "force" {
+		var err error
+
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "migrate force requires a version")
+			return 1
+		}
+
+		if version, err = strconv.Atoi(fs.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	migrator, err := bootstrap.NewMigrator()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer migrator.Close()
+
+	switch subcommand {
+	case "up":
+		err = migrator.Up(*steps)
+	case "down":
+		err = migrator.Down(*steps)
+	case "force":
+		err = migrator.Force(version)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(status)
+
+	if status.Dirty {
+		return 2
+	}
+
+	return 0
+}
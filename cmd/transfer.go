@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bendbennett/go-api-demo/internal/bootstrap"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	usertransfer "github.com/bendbennett/go-api-demo/internal/user/transfer"
+)
+
+// runImport creates users of the tenant from a file (or stdin), printing
+// the import report to stdout. It returns a non-zero exit code if any rows fail.
+func runImport(
+	ctx context.Context,
+	args []string,
+) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", usertransfer.FormatCSV, "csv or ndjson")
+	tenantID := fs.String("tenant", tenant.Default, "tenant to import users into")
+	file := fs.String("file", "", "file to import (default stdin)")
+	_ = fs.Parse(args)
+
+	if !tenant.Valid(*tenantID) {
+		fmt.Fprintln(os.Stderr, tenant.ErrInvalid)
+		return 1
+	}
+
+	ctx = tenant.NewContext(ctx, *tenantID)
+
+	var r io.Reader = os.Stdin
+
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	interactor, closer, err := bootstrap.NewUserTransfer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closer.Close()
+
+	report, err := interactor.Import(ctx, *format, r)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if report.Failed > 0 {
+		return 2
+	}
+
+	return 0
+}
+
+// runExport writes all users of the tenant to a file (or stdout).
+func runExport(
+	ctx context.Context,
+	args []string,
+) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", usertransfer.FormatCSV, "csv or ndjson")
+	tenantID := fs.String("tenant", tenant.Default, "tenant to export users from")
+	file := fs.String("file", "", "file to export to (default stdout)")
+	_ = fs.Parse(args)
+
+	if !tenant.Valid(*tenantID) {
+		fmt.Fprintln(os.Stderr, tenant.ErrInvalid)
+		return 1
+	}
+
+	ctx = tenant.NewContext(ctx, *tenantID)
+
+	var w io.Writer = os.Stdout
+
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	interactor, closer, err := bootstrap.NewUserTransfer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closer.Close()
+
+	if err := interactor.Export(ctx, *format, w); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -20,8 +20,15 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type UserClient interface {
 	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	CreateBatch(ctx context.Context, opts ...grpc.CallOption) (User_CreateBatchClient, error)
+	CreateBatchStream(ctx context.Context, opts ...grpc.CallOption) (User_CreateBatchStreamClient, error)
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*UsersResponse, error)
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*UsersResponse, error)
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (User_ListUsersClient, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (User_WatchClient, error)
 }
 
 type userClient struct {
@@ -41,6 +48,98 @@ func (c *userClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *userClient) CreateBatch(ctx context.Context, opts ...grpc.CallOption) (User_CreateBatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &User_ServiceDesc.Streams[0], "/User/CreateBatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userCreateBatchClient{stream}
+	return x, nil
+}
+
+type User_CreateBatchClient interface {
+	Send(*CreateRequest) error
+	CloseAndRecv() (*CreateBatchResponse, error)
+	grpc.ClientStream
+}
+
+type userCreateBatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *userCreateBatchClient) Send(m *CreateRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *userCreateBatchClient) CloseAndRecv() (*CreateBatchResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(CreateBatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userClient) CreateBatchStream(ctx context.Context, opts ...grpc.CallOption) (User_CreateBatchStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &User_ServiceDesc.Streams[1], "/User/CreateBatchStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userCreateBatchStreamClient{stream}
+	return x, nil
+}
+
+type User_CreateBatchStreamClient interface {
+	Send(*CreateRequest) error
+	Recv() (*CreateBatchResponse, error)
+	grpc.ClientStream
+}
+
+type userCreateBatchStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *userCreateBatchStreamClient) Send(m *CreateRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *userCreateBatchStreamClient) Recv() (*CreateBatchResponse, error) {
+	m := new(CreateBatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, "/User/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/User/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userClient) Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, "/User/Restore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *userClient) Read(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (*UsersResponse, error) {
 	out := new(UsersResponse)
 	err := c.cc.Invoke(ctx, "/User/Read", in, out, opts...)
@@ -59,13 +158,84 @@ func (c *userClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *userClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (User_ListUsersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &User_ServiceDesc.Streams[2], "/User/ListUsers", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userListUsersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type User_ListUsersClient interface {
+	Recv() (*UsersResponse, error)
+	grpc.ClientStream
+}
+
+type userListUsersClient struct {
+	grpc.ClientStream
+}
+
+func (x *userListUsersClient) Recv() (*UsersResponse, error) {
+	m := new(UsersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (User_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &User_ServiceDesc.Streams[3], "/User/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type User_WatchClient interface {
+	Recv() (*UserEvent, error)
+	grpc.ClientStream
+}
+
+type userWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *userWatchClient) Recv() (*UserEvent, error) {
+	m := new(UserEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // UserServer is the server API for User service.
 // All implementations must embed UnimplementedUserServer
 // for forward compatibility
 type UserServer interface {
 	Create(context.Context, *CreateRequest) (*UserResponse, error)
+	CreateBatch(User_CreateBatchServer) error
+	CreateBatchStream(User_CreateBatchStreamServer) error
+	Update(context.Context, *UpdateRequest) (*UserResponse, error)
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	Restore(context.Context, *RestoreRequest) (*UserResponse, error)
 	Read(context.Context, *ReadRequest) (*UsersResponse, error)
 	Search(context.Context, *SearchRequest) (*UsersResponse, error)
+	ListUsers(*ListUsersRequest, User_ListUsersServer) error
+	Watch(*WatchRequest, User_WatchServer) error
 	mustEmbedUnimplementedUserServer()
 }
 
@@ -76,12 +246,33 @@ type UnimplementedUserServer struct {
 func (UnimplementedUserServer) Create(context.Context, *CreateRequest) (*UserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
 }
+func (UnimplementedUserServer) CreateBatch(User_CreateBatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method CreateBatch not implemented")
+}
+func (UnimplementedUserServer) CreateBatchStream(User_CreateBatchStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method CreateBatchStream not implemented")
+}
+func (UnimplementedUserServer) Update(context.Context, *UpdateRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedUserServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedUserServer) Restore(context.Context, *RestoreRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
 func (UnimplementedUserServer) Read(context.Context, *ReadRequest) (*UsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Read not implemented")
 }
 func (UnimplementedUserServer) Search(context.Context, *SearchRequest) (*UsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
 }
+func (UnimplementedUserServer) ListUsers(*ListUsersRequest, User_ListUsersServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedUserServer) Watch(*WatchRequest, User_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
 func (UnimplementedUserServer) mustEmbedUnimplementedUserServer() {}
 
 // UnsafeUserServer may be embedded to opt out of forward compatibility for this service.
@@ -113,6 +304,112 @@ func _User_Create_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _User_CreateBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UserServer).CreateBatch(&userCreateBatchServer{stream})
+}
+
+type User_CreateBatchServer interface {
+	SendAndClose(*CreateBatchResponse) error
+	Recv() (*CreateRequest, error)
+	grpc.ServerStream
+}
+
+type userCreateBatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *userCreateBatchServer) SendAndClose(m *CreateBatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *userCreateBatchServer) Recv() (*CreateRequest, error) {
+	m := new(CreateRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _User_CreateBatchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UserServer).CreateBatchStream(&userCreateBatchStreamServer{stream})
+}
+
+type User_CreateBatchStreamServer interface {
+	Send(*CreateBatchResponse) error
+	Recv() (*CreateRequest, error)
+	grpc.ServerStream
+}
+
+type userCreateBatchStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *userCreateBatchStreamServer) Send(m *CreateBatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *userCreateBatchStreamServer) Recv() (*CreateRequest, error) {
+	m := new(CreateRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _User_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/User/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServer).Update(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _User_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/User/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _User_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/User/Restore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServer).Restore(ctx, req.(*RestoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _User_Read_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ReadRequest)
 	if err := dec(in); err != nil {
@@ -149,6 +446,48 @@ func _User_Search_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _User_ListUsers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListUsersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServer).ListUsers(m, &userListUsersServer{stream})
+}
+
+type User_ListUsersServer interface {
+	Send(*UsersResponse) error
+	grpc.ServerStream
+}
+
+type userListUsersServer struct {
+	grpc.ServerStream
+}
+
+func (x *userListUsersServer) Send(m *UsersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _User_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServer).Watch(m, &userWatchServer{stream})
+}
+
+type User_WatchServer interface {
+	Send(*UserEvent) error
+	grpc.ServerStream
+}
+
+type userWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *userWatchServer) Send(m *UserEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // User_ServiceDesc is the grpc.ServiceDesc for User service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -160,6 +499,18 @@ var User_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Create",
 			Handler:    _User_Create_Handler,
 		},
+		{
+			MethodName: "Update",
+			Handler:    _User_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _User_Delete_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _User_Restore_Handler,
+		},
 		{
 			MethodName: "Read",
 			Handler:    _User_Read_Handler,
@@ -169,6 +520,28 @@ var User_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _User_Search_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CreateBatch",
+			Handler:       _User_CreateBatch_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "CreateBatchStream",
+			Handler:       _User_CreateBatchStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListUsers",
+			Handler:       _User_ListUsers_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _User_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "user.proto",
 }
@@ -0,0 +1,125 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrRevoked  = errors.New("revoked")
+)
+
+const (
+	secretPrefix = "ak_"
+	secretLen    = 32
+
+	// displayPrefixLen is the length of the prefix of keys that is stored
+	// in the clear, so that keys can be identified without the secret.
+	displayPrefixLen = len(secretPrefix) + 8
+)
+
+// Key is an API key, belonging to TenantID, that authenticates callers
+// with Roles and Scopes. Only the Hash of the key is stored. Quota is the
+// number of requests permitted per quota window, or 0 if unlimited.
+type Key struct {
+	CreatedAt  time.Time
+	RotatedAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	TenantID   string
+	ID         string
+	Name       string
+	Hash       string
+	Prefix     string
+	Roles      []string
+	Scopes     []string
+	Quota      int
+}
+
+// Revoked returns true if the key has been revoked.
+func (k Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Generate returns a new secret (e.g., ak_3f9c...), and its hash and
+// display prefix.
+func Generate() (secret string, hash string, prefix string, err error) {
+	b := make([]byte, secretLen)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	secret = secretPrefix + hex.EncodeToString(b)
+
+	return secret, Hash(secret), secret[:displayPrefixLen], nil
+}
+
+// Hash returns the hex-encoded SHA-256 hash of secret. As secrets are
+// random, a slow password hash is not required.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+type Storage interface {
+	Creator
+	Reader
+	ByHashReader
+	Rotator
+	Revoker
+	Toucher
+}
+
+type Creator interface {
+	CreateKey(context.Context, Key) error
+}
+
+// Reader reads the keys of the tenant carried by the context, returning
+// ErrNotFound from ReadKey if no key exists with the ID.
+type Reader interface {
+	ReadKeys(context.Context) ([]Key, error)
+	ReadKey(ctx context.Context, id string) (Key, error)
+}
+
+// ByHashReader returns ErrNotFound if no key, of any tenant, exists with
+// the hash.
+type ByHashReader interface {
+	ReadKeyByHash(ctx context.Context, hash string) (Key, error)
+}
+
+// Rotator replaces the hash and prefix of the key with the ID, returning
+// ErrNotFound if no key exists with the ID.
+type Rotator interface {
+	RotateKey(ctx context.Context, id string, hash string, prefix string, at time.Time) error
+}
+
+// Revoker returns ErrNotFound if no key exists with the ID.
+type Revoker interface {
+	RevokeKey(ctx context.Context, id string, at time.Time) error
+}
+
+// Toucher records the time at which the key with the ID was last used.
+type Toucher interface {
+	TouchKey(ctx context.Context, id string, at time.Time) error
+}
+
+// Cache holds keys by hash. ReadKeyByHash returns ErrNotFound on a miss.
+type Cache interface {
+	ByHashReader
+	CacheKey(context.Context, Key) error
+	EvictKey(ctx context.Context, hash string) error
+}
+
+// QuotaCounter counts the requests made with the key with the ID in fixed
+// windows, returning the count for the current window, including this
+// request, and the time at which the window ends.
+type QuotaCounter interface {
+	Increment(ctx context.Context, id string, window time.Duration) (int64, time.Time, error)
+}
@@ -0,0 +1,24 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	secret, hash, prefix, err := Generate()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(secret, secretPrefix))
+	assert.Len(t, secret, len(secretPrefix)+secretLen*2)
+	assert.Equal(t, Hash(secret), hash)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, secret[:displayPrefixLen], prefix)
+
+	other, _, _, err := Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
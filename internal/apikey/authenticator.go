@@ -0,0 +1,298 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/grpcutil"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrKeyInvalid    = errors.New("api key invalid")
+	ErrQuotaExceeded = errors.New("api key quota exceeded")
+)
+
+// subjectPrefix distinguishes the subjects of callers authenticated with
+// API keys from those of callers authenticated with tokens.
+const subjectPrefix = "apikey:"
+
+type authenticatorStorage interface {
+	ByHashReader
+	Toucher
+}
+
+type a struct {
+	storage       authenticatorStorage
+	cache         Cache
+	quota         QuotaCounter
+	logger        log.Logger
+	now           func() time.Time
+	touched       map[string]time.Time
+	header        string
+	quotaWindow   time.Duration
+	touchInterval time.Duration
+	mu            sync.Mutex
+}
+
+// NewAuthenticator returns an authenticator for requests carrying an API
+// key in the configured header (e.g., X-API-Key), or the corresponding
+// gRPC metadata. Requests without a key are passed on unauthenticated, so
+// that they can be authenticated by other means (e.g., bearer tokens).
+// The time at which each key was last used is recorded at most once per
+// touch interval.
+func NewAuthenticator(
+	conf config.APIKey,
+	storage authenticatorStorage,
+	cache Cache,
+	quota QuotaCounter,
+	logger log.Logger,
+) *a {
+	return &a{
+		storage:       storage,
+		cache:         cache,
+		quota:         quota,
+		logger:        logger,
+		now:           time.Now,
+		touched:       make(map[string]time.Time),
+		header:        conf.Header,
+		quotaWindow:   conf.QuotaWindow,
+		touchInterval: conf.TouchInterval,
+	}
+}
+
+// key reads the key through the cache. Cache failures are logged rather
+// than returned, so that keys can still be used if the cache is down.
+//
+// The key is read from storage again once cached, as it may have been
+// revoked or rotated, and evicted from cache, between being read and being
+// cached. The key is then evicted, as it would otherwise remain usable
+// until it expired from cache.
+func (a *a) key(ctx context.Context, hash string) (Key, error) {
+	k, err := a.cache.ReadKeyByHash(ctx, hash)
+	if err == nil {
+		return k, nil
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		a.logger.ErrorContext(ctx, err)
+	}
+
+	k, err = a.storage.ReadKeyByHash(ctx, hash)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if err := a.cache.CacheKey(ctx, k); err != nil {
+		a.logger.ErrorContext(ctx, err)
+		return k, nil
+	}
+
+	current, err := a.storage.ReadKeyByHash(ctx, hash)
+	if err == nil && !current.Revoked() {
+		return current, nil
+	}
+
+	if err := a.cache.EvictKey(ctx, hash); err != nil {
+		a.logger.ErrorContext(ctx, err)
+	}
+
+	switch {
+	case err == nil, errors.Is(err, ErrNotFound):
+		return current, err
+	default:
+		// The key was valid when read, and is evicted so that it is read
+		// from storage again on its next use.
+		a.logger.ErrorContext(ctx, err)
+		return k, nil
+	}
+}
+
+// authenticate returns a copy of ctx carrying claims with the roles and
+// scopes of the key, the subject for logging and the tenant of the key.
+// The end of the quota window is returned with ErrQuotaExceeded.
+func (a *a) authenticate(
+	ctx context.Context,
+	secret string,
+) (context.Context, time.Time, error) {
+	k, err := a.key(ctx, Hash(secret))
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil, time.Time{}, ErrKeyInvalid
+	case err != nil:
+		return nil, time.Time{}, err
+	case k.Revoked():
+		return nil, time.Time{}, ErrKeyInvalid
+	}
+
+	if k.Quota > 0 {
+		n, resetAt, err := a.quota.Increment(ctx, k.ID, a.quotaWindow)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		if n > int64(k.Quota) {
+			return nil, resetAt, ErrQuotaExceeded
+		}
+	}
+
+	a.touch(ctx, k.ID)
+
+	subject := subjectPrefix + k.ID
+
+	ctx = auth.NewContext(ctx, auth.Claims{
+		Subject: subject,
+		Roles:   k.Roles,
+		Scopes:  k.Scopes,
+	})
+	ctx = log.WithSubject(ctx, subject)
+	ctx = tenant.NewContext(ctx, k.TenantID)
+
+	return ctx, time.Time{}, nil
+}
+
+func (a *a) touch(ctx context.Context, id string) {
+	now := a.now()
+
+	a.mu.Lock()
+	if now.Sub(a.touched[id]) < a.touchInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.touched[id] = now
+	a.mu.Unlock()
+
+	if err := a.storage.TouchKey(ctx, id, now); err != nil {
+		a.logger.ErrorContext(ctx, err)
+	}
+}
+
+// retryAfter returns the number of whole seconds until resetAt.
+func (a *a) retryAfter(resetAt time.Time) string {
+	return strconv.Itoa(int(math.Ceil(resetAt.Sub(a.now()).Seconds())))
+}
+
+// Middleware authenticates HTTP requests carrying an API key, responding
+// with 401 Unauthorized if the key is invalid or revoked, and with 429 Too
+// Many Requests if the quota of the key is exhausted.
+func (a *a) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(a.header)
+		if secret == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, resetAt, err := a.authenticate(r.Context(), secret)
+
+		switch {
+		case err == nil:
+			next.ServeHTTP(w, r.WithContext(ctx))
+		case errors.Is(err, ErrKeyInvalid):
+			a.logger.InfofContext(r.Context(), "authentication failed: %s %s: %s", r.Method, r.URL.Path, err)
+			response.WriteErrorResponse(
+				w,
+				http.StatusUnauthorized,
+				err.Error(),
+				nil,
+			)
+		case errors.Is(err, ErrQuotaExceeded):
+			w.Header().Set("Retry-After", a.retryAfter(resetAt))
+			response.WriteErrorResponse(
+				w,
+				http.StatusTooManyRequests,
+				err.Error(),
+				nil,
+			)
+		default:
+			a.logger.ErrorContext(r.Context(), err)
+			response.Write500Response(w)
+		}
+	})
+}
+
+// grpcContext returns ctx unchanged if the call carries no API key, and
+// the header to send with the error if the quota of the key is exhausted.
+func (a *a) grpcContext(ctx context.Context) (context.Context, metadata.MD, error) {
+	var secret string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(strings.ToLower(a.header)); len(v) > 0 {
+			secret = v[0]
+		}
+	}
+
+	if secret == "" {
+		return ctx, nil, nil
+	}
+
+	authCtx, resetAt, err := a.authenticate(ctx, secret)
+
+	switch {
+	case err == nil:
+		return authCtx, nil, nil
+	case errors.Is(err, ErrKeyInvalid):
+		a.logger.InfofContext(ctx, "authentication failed: %s", err)
+		return nil, nil, status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrQuotaExceeded):
+		return nil, metadata.Pairs("retry-after", a.retryAfter(resetAt)), status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		a.logger.ErrorContext(ctx, err)
+		return nil, nil, status.Error(codes.Internal, "authentication failed")
+	}
+}
+
+// UnaryInterceptor authenticates unary gRPC calls carrying an API key in
+// their metadata.
+func (a *a) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	authCtx, header, err := a.grpcContext(ctx)
+	if err != nil {
+		if header != nil {
+			_ = grpc.SetHeader(ctx, header)
+		}
+
+		return nil, err
+	}
+
+	return handler(authCtx, req)
+}
+
+// StreamInterceptor authenticates streaming gRPC calls carrying an API
+// key in their metadata.
+func (a *a) StreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, header, err := a.grpcContext(ss.Context())
+	if err != nil {
+		if header != nil {
+			_ = ss.SetHeader(header)
+		}
+
+		return err
+	}
+
+	return handler(srv, grpcutil.NewServerStream(ctx, ss))
+}
@@ -0,0 +1,455 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type loggerMock struct{}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+type storageMock struct {
+	keys    map[string]Key
+	err     error
+	touched []string
+}
+
+func (m *storageMock) ReadKeyByHash(_ context.Context, hash string) (Key, error) {
+	if m.err != nil {
+		return Key{}, m.err
+	}
+
+	k, ok := m.keys[hash]
+	if !ok {
+		return Key{}, ErrNotFound
+	}
+
+	return k, nil
+}
+
+func (m *storageMock) TouchKey(_ context.Context, id string, _ time.Time) error {
+	m.touched = append(m.touched, id)
+	return nil
+}
+
+type cacheMock struct {
+	keys map[string]Key
+	err  error
+}
+
+func (m *cacheMock) ReadKeyByHash(_ context.Context, hash string) (Key, error) {
+	if m.err != nil {
+		return Key{}, m.err
+	}
+
+	k, ok := m.keys[hash]
+	if !ok {
+		return Key{}, ErrNotFound
+	}
+
+	return k, nil
+}
+
+func (m *cacheMock) CacheKey(_ context.Context, k Key) error {
+	if m.keys == nil {
+		m.keys = make(map[string]Key)
+	}
+
+	m.keys[k.Hash] = k
+
+	return nil
+}
+
+func (m *cacheMock) EvictKey(_ context.Context, hash string) error {
+	delete(m.keys, hash)
+	return nil
+}
+
+type quotaMock struct {
+	counts  map[string]int64
+	resetAt time.Time
+}
+
+func (m *quotaMock) Increment(_ context.Context, id string, _ time.Duration) (int64, time.Time, error) {
+	if m.counts == nil {
+		m.counts = make(map[string]int64)
+	}
+
+	m.counts[id]++
+
+	return m.counts[id], m.resetAt, nil
+}
+
+var apiKeyConf = config.APIKey{
+	Header:        "X-API-Key",
+	QuotaWindow:   time.Hour,
+	TouchInterval: time.Minute,
+}
+
+const (
+	validSecret   = "ak_valid"
+	revokedSecret = "ak_revoked"
+	limitedSecret = "ak_limited"
+)
+
+func newStorageMock() *storageMock {
+	revokedAt := time.Now()
+
+	return &storageMock{
+		keys: map[string]Key{
+			Hash(validSecret): {
+				TenantID: "acme",
+				ID:       "key-1",
+				Hash:     Hash(validSecret),
+				Roles:    []string{"viewer"},
+				Scopes:   []string{"user:read"},
+			},
+			Hash(revokedSecret): {
+				RevokedAt: &revokedAt,
+				TenantID:  "acme",
+				ID:        "key-2",
+				Hash:      Hash(revokedSecret),
+			},
+			Hash(limitedSecret): {
+				TenantID: "acme",
+				ID:       "key-3",
+				Hash:     Hash(limitedSecret),
+				Quota:    1,
+			},
+		},
+	}
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name               string
+		secrets            []string
+		storage            *storageMock
+		cache              *cacheMock
+		expectedStatus     int
+		expectedRetryAfter string
+		expectedSubject    string
+		expectedTenantID   string
+		expectedRoles      []string
+	}{
+		{
+			"no key",
+			nil,
+			newStorageMock(),
+			&cacheMock{},
+			http.StatusOK,
+			"",
+			"",
+			"",
+			nil,
+		},
+		{
+			"key invalid",
+			[]string{"ak_unknown"},
+			newStorageMock(),
+			&cacheMock{},
+			http.StatusUnauthorized,
+			"",
+			"",
+			"",
+			nil,
+		},
+		{
+			"key revoked",
+			[]string{revokedSecret},
+			newStorageMock(),
+			&cacheMock{},
+			http.StatusUnauthorized,
+			"",
+			"",
+			"",
+			nil,
+		},
+		{
+			"storage error",
+			[]string{validSecret},
+			&storageMock{err: errors.New("storage error")},
+			&cacheMock{},
+			http.StatusInternalServerError,
+			"",
+			"",
+			"",
+			nil,
+		},
+		{
+			"quota exceeded",
+			[]string{limitedSecret, limitedSecret},
+			newStorageMock(),
+			&cacheMock{},
+			http.StatusTooManyRequests,
+			"1800",
+			"",
+			"",
+			nil,
+		},
+		{
+			"success",
+			[]string{validSecret},
+			newStorageMock(),
+			&cacheMock{},
+			http.StatusOK,
+			"",
+			"apikey:key-1",
+			"acme",
+			[]string{"viewer"},
+		},
+		{
+			"success with cache error",
+			[]string{validSecret},
+			newStorageMock(),
+			&cacheMock{err: errors.New("cache error")},
+			http.StatusOK,
+			"",
+			"apikey:key-1",
+			"acme",
+			[]string{"viewer"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				claims   auth.Claims
+				tenantID string
+			)
+
+			authenticator := NewAuthenticator(
+				apiKeyConf,
+				c.storage,
+				c.cache,
+				&quotaMock{resetAt: now.Add(30 * time.Minute)},
+				loggerMock{},
+			)
+			authenticator.now = func() time.Time { return now }
+
+			handler := authenticator.Middleware(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					claims, _ = auth.FromContext(r.Context())
+					tenantID, _ = tenant.FromContext(r.Context())
+				}),
+			)
+
+			secrets := c.secrets
+			if len(secrets) == 0 {
+				secrets = []string{""}
+			}
+
+			var rec *httptest.ResponseRecorder
+
+			// Only the response to the last request is checked.
+			for _, secret := range secrets {
+				claims, tenantID = auth.Claims{}, ""
+
+				req := httptest.NewRequest(http.MethodGet, "/user", nil)
+
+				if secret != "" {
+					req.Header.Set("X-API-Key", secret)
+				}
+
+				rec = httptest.NewRecorder()
+
+				handler.ServeHTTP(rec, req)
+			}
+
+			assert.Equal(t, c.expectedStatus, rec.Code)
+			assert.Equal(t, c.expectedRetryAfter, rec.Header().Get("Retry-After"))
+			assert.Equal(t, c.expectedSubject, claims.Subject)
+			assert.Equal(t, c.expectedTenantID, tenantID)
+			assert.Equal(t, c.expectedRoles, claims.Roles)
+		})
+	}
+}
+
+func TestAuthenticator_CacheAndTouch(t *testing.T) {
+	now := time.Now()
+
+	storage := newStorageMock()
+	cache := &cacheMock{}
+
+	authenticator := NewAuthenticator(apiKeyConf, storage, cache, &quotaMock{}, loggerMock{})
+	authenticator.now = func() time.Time { return now }
+
+	for range 2 {
+		_, _, err := authenticator.authenticate(context.Background(), validSecret)
+		assert.NoError(t, err)
+	}
+
+	assert.Contains(t, cache.keys, Hash(validSecret))
+	assert.Equal(t, []string{"key-1"}, storage.touched)
+
+	now = now.Add(apiKeyConf.TouchInterval)
+
+	_, _, err := authenticator.authenticate(context.Background(), validSecret)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key-1", "key-1"}, storage.touched)
+}
+
+// revokingStorageMock revokes each key once it has been read, as if the
+// key were revoked between being read and being cached.
+type revokingStorageMock struct {
+	*storageMock
+}
+
+func (m *revokingStorageMock) ReadKeyByHash(ctx context.Context, hash string) (Key, error) {
+	k, err := m.storageMock.ReadKeyByHash(ctx, hash)
+	if err != nil {
+		return Key{}, err
+	}
+
+	revokedAt := time.Now()
+	revoked := k
+	revoked.RevokedAt = &revokedAt
+	m.keys[hash] = revoked
+
+	return k, nil
+}
+
+func TestAuthenticator_RevokedWhileCaching(t *testing.T) {
+	cache := &cacheMock{}
+
+	authenticator := NewAuthenticator(
+		apiKeyConf,
+		&revokingStorageMock{newStorageMock()},
+		cache,
+		&quotaMock{},
+		loggerMock{},
+	)
+
+	_, _, err := authenticator.authenticate(context.Background(), validSecret)
+
+	assert.ErrorIs(t, err, ErrKeyInvalid)
+	assert.NotContains(t, cache.keys, Hash(validSecret))
+}
+
+func TestAuthenticator_UnaryInterceptor(t *testing.T) {
+	cases := []struct {
+		name            string
+		md              metadata.MD
+		expectedCode    codes.Code
+		expectedSubject string
+	}{
+		{
+			"no key",
+			nil,
+			codes.OK,
+			"",
+		},
+		{
+			"key invalid",
+			metadata.Pairs("x-api-key", "ak_unknown"),
+			codes.Unauthenticated,
+			"",
+		},
+		{
+			"success",
+			metadata.Pairs("x-api-key", validSecret),
+			codes.OK,
+			"apikey:key-1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var subject string
+
+			_, err := NewAuthenticator(
+				apiKeyConf,
+				newStorageMock(),
+				&cacheMock{},
+				&quotaMock{},
+				loggerMock{},
+			).UnaryInterceptor(
+				metadata.NewIncomingContext(context.Background(), c.md),
+				nil,
+				&grpc.UnaryServerInfo{FullMethod: "/User/Read"},
+				func(ctx context.Context, _ interface{}) (interface{}, error) {
+					claims, _ := auth.FromContext(ctx)
+					subject = claims.Subject
+					return nil, nil
+				},
+			)
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+			assert.Equal(t, c.expectedSubject, subject)
+		})
+	}
+}
+
+type serverStreamMock struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (m *serverStreamMock) Context() context.Context {
+	return m.ctx
+}
+
+func (m *serverStreamMock) SetHeader(md metadata.MD) error {
+	m.header = md
+	return nil
+}
+
+func TestAuthenticator_StreamInterceptor_QuotaExceeded(t *testing.T) {
+	now := time.Now()
+
+	authenticator := NewAuthenticator(
+		apiKeyConf,
+		newStorageMock(),
+		&cacheMock{},
+		&quotaMock{resetAt: now.Add(90 * time.Second)},
+		loggerMock{},
+	)
+	authenticator.now = func() time.Time { return now }
+
+	var err error
+
+	ss := &serverStreamMock{
+		ctx: metadata.NewIncomingContext(
+			context.Background(),
+			metadata.Pairs("x-api-key", limitedSecret),
+		),
+	}
+
+	for range 2 {
+		err = authenticator.StreamInterceptor(
+			nil,
+			ss,
+			&grpc.StreamServerInfo{FullMethod: "/User/Watch"},
+			func(interface{}, grpc.ServerStream) error {
+				return nil
+			},
+		)
+	}
+
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"90"}, ss.header.Get("retry-after"))
+}
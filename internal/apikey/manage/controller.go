@@ -0,0 +1,11 @@
+package manage
+
+// inputData uses the default quota if Quota is nil. Quota cannot be 0
+// (i.e., unlimited), so that callers cannot exempt the keys they issue
+// from quotas; keys only have an unlimited quota if the default is 0.
+type inputData struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Roles  []string `json:"roles" validate:"max=20,dive,required,max=100"`
+	Scopes []string `json:"scopes" validate:"max=20,dive,required,max=100"`
+	Quota  *int     `json:"quota" validate:"omitempty,min=1"`
+}
@@ -0,0 +1,246 @@
+package manage
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/authz"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/gorilla/mux"
+)
+
+type httpController struct {
+	validator  validate.Validator
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Issue(w http.ResponseWriter, r *http.Request)
+	Read(w http.ResponseWriter, r *http.Request)
+	ReadByID(w http.ResponseWriter, r *http.Request)
+	Rotate(w http.ResponseWriter, r *http.Request)
+	Revoke(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	validator validate.Validator,
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		validator,
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+type output struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Key        string   `json:"key,omitempty"`
+	Prefix     string   `json:"prefix"`
+	Roles      []string `json:"roles"`
+	Scopes     []string `json:"scopes"`
+	Quota      int      `json:"quota"`
+	CreatedAt  string   `json:"created_at"`
+	RotatedAt  string   `json:"rotated_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+}
+
+func (c *httpController) Issue(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+	input := inputData{}
+
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		c.logger.ErrorfContext(ctx, "json body invalid: %v", err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{"body": "json invalid"},
+		)
+		return
+	}
+
+	errs := c.validator.ValidateStruct(input)
+	if errs != nil {
+		c.logger.InfofContext(ctx, "input invalid: %v", errs)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			errs,
+		)
+		return
+	}
+
+	od, err := c.interactor.issue(
+		ctx,
+		input,
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusCreated,
+		output(c.presenter.viewModel(od)),
+	)
+}
+
+// Read returns keys that have not been used since the time in the
+// unused_since query parameter (RFC 3339), if supplied, so that stale
+// keys can be found.
+func (c *httpController) Read(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	var unusedSince time.Time
+
+	if v := r.URL.Query().Get("unused_since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			response.WriteErrorResponse(
+				w,
+				http.StatusBadRequest,
+				"failed validation",
+				map[string]string{"unused_since": "unused_since must be an RFC 3339 time"},
+			)
+			return
+		}
+
+		unusedSince = t
+	}
+
+	od, err := c.interactor.read(
+		ctx,
+		unusedSince,
+	)
+	if err != nil {
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	keys := []output{}
+
+	for _, k := range od {
+		keys = append(
+			keys,
+			output(c.presenter.viewModel(k)),
+		)
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		keys,
+	)
+}
+
+func (c *httpController) ReadByID(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	od, err := c.interactor.readByID(
+		r.Context(),
+		mux.Vars(r)["id"],
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		output(c.presenter.viewModel(od)),
+	)
+}
+
+func (c *httpController) Rotate(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	od, err := c.interactor.rotate(
+		r.Context(),
+		mux.Vars(r)["id"],
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		output(c.presenter.viewModel(od)),
+	)
+}
+
+func (c *httpController) Revoke(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	err := c.interactor.revoke(
+		r.Context(),
+		mux.Vars(r)["id"],
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *httpController) writeError(
+	w http.ResponseWriter,
+	r *http.Request,
+	err error,
+) {
+	switch {
+	case errors.Is(err, apikey.ErrNotFound):
+		response.WriteErrorResponse(
+			w,
+			http.StatusNotFound,
+			"api key not found",
+			nil,
+		)
+	case errors.Is(err, apikey.ErrRevoked):
+		response.WriteErrorResponse(
+			w,
+			http.StatusConflict,
+			"api key revoked",
+			nil,
+		)
+	case errors.Is(err, authz.ErrNotGranted):
+		response.WriteErrorResponse(
+			w,
+			http.StatusForbidden,
+			err.Error(),
+			nil,
+		)
+	default:
+		c.logger.ErrorContext(r.Context(), err)
+		response.Write500Response(w)
+	}
+}
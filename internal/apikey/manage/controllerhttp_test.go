@@ -0,0 +1,259 @@
+package manage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/authz"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type interactorMock struct {
+	err error
+}
+
+func (m *interactorMock) issue(context.Context, inputData) (outputData, error) {
+	return outputData{}, m.err
+}
+
+func (m *interactorMock) read(context.Context, time.Time) ([]outputData, error) {
+	return []outputData{{}}, m.err
+}
+
+func (m *interactorMock) readByID(context.Context, string) (outputData, error) {
+	return outputData{}, m.err
+}
+
+func (m *interactorMock) rotate(context.Context, string) (outputData, error) {
+	return outputData{}, m.err
+}
+
+func (m *interactorMock) revoke(context.Context, string) error {
+	return m.err
+}
+
+type presenterMock struct {
+}
+
+func (pm *presenterMock) viewModel(outputData) viewModel {
+	return viewModel{
+		ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		Name:      "billing",
+		Key:       "ak_abc",
+		Prefix:    "ak_abc",
+		Roles:     []string{"viewer"},
+		Scopes:    []string{},
+		Quota:     1000,
+		CreatedAt: "2006-01-02T15:04:05-0700",
+	}
+}
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+const keyJSON = `{
+	"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+	"name": "billing",
+	"key": "ak_abc",
+	"prefix": "ak_abc",
+	"roles": ["viewer"],
+	"scopes": [],
+	"quota": 1000,
+	"created_at": "2006-01-02T15:04:05-0700"
+}`
+
+func TestHTTPController(t *testing.T) {
+	validator, err := validate.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                 string
+		method               string
+		path                 string
+		body                 io.Reader
+		interactor           interactor
+		expectedStatus       int
+		expectedResponseBody string
+	}{
+		{
+			"issue json invalid",
+			http.MethodPost,
+			"/apikey",
+			strings.NewReader(`{"name:}`),
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {"body": "json invalid"}}`,
+		},
+		{
+			"issue input invalid",
+			http.MethodPost,
+			"/apikey",
+			strings.NewReader(`{"roles": [""], "quota": 0}`),
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {
+				"name": "name is a required field",
+				"roles[0]": "roles[0] is a required field",
+				"quota": "quota must be 1 or greater"
+			}}`,
+		},
+		{
+			"issue interactor error",
+			http.MethodPost,
+			"/apikey",
+			strings.NewReader(`{"name": "billing"}`),
+			&interactorMock{errors.New("interactor issue error")},
+			http.StatusInternalServerError,
+			`{"message": "internal server error"}`,
+		},
+		{
+			"issue not granted",
+			http.MethodPost,
+			"/apikey",
+			strings.NewReader(`{"name": "billing", "roles": ["admin"]}`),
+			&interactorMock{fmt.Errorf("%w: role admin", authz.ErrNotGranted)},
+			http.StatusForbidden,
+			`{"message": "not granted: role admin"}`,
+		},
+		{
+			"issue success",
+			http.MethodPost,
+			"/apikey",
+			strings.NewReader(`{"name": "billing", "roles": ["viewer"]}`),
+			&interactorMock{},
+			http.StatusCreated,
+			keyJSON,
+		},
+		{
+			"read unused since invalid",
+			http.MethodGet,
+			"/apikey?unused_since=yesterday",
+			nil,
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {"unused_since": "unused_since must be an RFC 3339 time"}}`,
+		},
+		{
+			"read success",
+			http.MethodGet,
+			"/apikey?unused_since=2006-01-02T15:04:05Z",
+			nil,
+			&interactorMock{},
+			http.StatusOK,
+			"[" + keyJSON + "]",
+		},
+		{
+			"read by id not found",
+			http.MethodGet,
+			"/apikey/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{apikey.ErrNotFound},
+			http.StatusNotFound,
+			`{"message": "api key not found"}`,
+		},
+		{
+			"read by id success",
+			http.MethodGet,
+			"/apikey/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{},
+			http.StatusOK,
+			keyJSON,
+		},
+		{
+			"rotate revoked",
+			http.MethodPost,
+			"/apikey/0a81dec3-3638-4eb4-b04a-83d744f5f3a8/rotate",
+			nil,
+			&interactorMock{apikey.ErrRevoked},
+			http.StatusConflict,
+			`{"message": "api key revoked"}`,
+		},
+		{
+			"rotate success",
+			http.MethodPost,
+			"/apikey/0a81dec3-3638-4eb4-b04a-83d744f5f3a8/rotate",
+			nil,
+			&interactorMock{},
+			http.StatusOK,
+			keyJSON,
+		},
+		{
+			"revoke interactor error",
+			http.MethodDelete,
+			"/apikey/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{errors.New("interactor revoke error")},
+			http.StatusInternalServerError,
+			`{"message": "internal server error"}`,
+		},
+		{
+			"revoke success",
+			http.MethodDelete,
+			"/apikey/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{},
+			http.StatusNoContent,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewHTTPController(
+				validator,
+				c.interactor,
+				&presenterMock{},
+				loggerMock{},
+			)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/apikey", controller.Issue).Methods(http.MethodPost)
+			router.HandleFunc("/apikey", controller.Read).Methods(http.MethodGet)
+			router.HandleFunc("/apikey/{id}", controller.ReadByID).Methods(http.MethodGet)
+			router.HandleFunc("/apikey/{id}", controller.Revoke).Methods(http.MethodDelete)
+			router.HandleFunc("/apikey/{id}/rotate", controller.Rotate).Methods(http.MethodPost)
+
+			r := httptest.NewRequest(c.method, c.path, c.body)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+
+			if c.expectedResponseBody == "" {
+				assert.Empty(t, w.Body.String())
+				return
+			}
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package manage
+
+import (
+	"context"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/authz"
+	"github.com/google/uuid"
+)
+
+type storage interface {
+	apikey.Creator
+	apikey.Reader
+	apikey.Rotator
+	apikey.Revoker
+}
+
+type cache interface {
+	EvictKey(ctx context.Context, hash string) error
+}
+
+type delegator interface {
+	Delegates(claims auth.Claims, roles []string, scopes []string) error
+}
+
+type i struct {
+	storage      storage
+	cache        cache
+	delegator    delegator
+	defaultQuota int
+}
+
+type interactor interface {
+	issue(context.Context, inputData) (outputData, error)
+	read(ctx context.Context, unusedSince time.Time) ([]outputData, error)
+	readByID(context.Context, string) (outputData, error)
+	rotate(context.Context, string) (outputData, error)
+	revoke(context.Context, string) error
+}
+
+var _ interactor = (*i)(nil)
+
+// NewInteractor returns an interactor that issues keys with defaultQuota
+// unless a quota is supplied, and evicts rotated and revoked keys from
+// cache. Keys are only issued, or rotated, with roles and scopes that the
+// caller is permitted to delegate, unless delegator is nil (i.e., authz is
+// disabled).
+func NewInteractor(
+	storage storage,
+	cache cache,
+	delegator delegator,
+	defaultQuota int,
+) *i {
+	return &i{
+		storage,
+		cache,
+		delegator,
+		defaultQuota,
+	}
+}
+
+// outputData only includes the Key when the key is issued or rotated.
+type outputData struct {
+	CreatedAt  time.Time
+	RotatedAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	ID         string
+	Name       string
+	Key        string
+	Prefix     string
+	Roles      []string
+	Scopes     []string
+	Quota      int
+}
+
+func (i *i) issue(
+	ctx context.Context,
+	inputData inputData,
+) (outputData, error) {
+	err := i.delegates(ctx, inputData.Roles, inputData.Scopes)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	secret, hash, prefix, err := apikey.Generate()
+	if err != nil {
+		return outputData{}, err
+	}
+
+	k := apikey.Key{
+		CreatedAt: time.Now(),
+		ID:        uuid.New().String(),
+		Name:      inputData.Name,
+		Hash:      hash,
+		Prefix:    prefix,
+		Roles:     nonNil(inputData.Roles),
+		Scopes:    nonNil(inputData.Scopes),
+		Quota:     i.defaultQuota,
+	}
+
+	if inputData.Quota != nil {
+		k.Quota = *inputData.Quota
+	}
+
+	err = i.storage.CreateKey(
+		ctx,
+		k,
+	)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	od := toOutputData(k)
+	od.Key = secret
+
+	return od, nil
+}
+
+// read returns all keys, or only those that have not been used (or, if
+// never used, created) since unusedSince unless it is zero.
+func (i *i) read(
+	ctx context.Context,
+	unusedSince time.Time,
+) ([]outputData, error) {
+	keys, err := i.storage.ReadKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	od := make([]outputData, 0, len(keys))
+
+	for _, k := range keys {
+		lastActive := k.CreatedAt
+		if k.LastUsedAt != nil {
+			lastActive = *k.LastUsedAt
+		}
+
+		if !unusedSince.IsZero() && !lastActive.Before(unusedSince) {
+			continue
+		}
+
+		od = append(od, toOutputData(k))
+	}
+
+	return od, nil
+}
+
+func (i *i) readByID(
+	ctx context.Context,
+	id string,
+) (outputData, error) {
+	k, err := i.storage.ReadKey(ctx, id)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	return toOutputData(k), nil
+}
+
+// rotate replaces the key, which stops working immediately, returning
+// apikey.ErrRevoked if the key has been revoked, and authz.ErrNotGranted if
+// the caller could not have issued the key.
+func (i *i) rotate(
+	ctx context.Context,
+	id string,
+) (outputData, error) {
+	k, err := i.storage.ReadKey(ctx, id)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	if k.Revoked() {
+		return outputData{}, apikey.ErrRevoked
+	}
+
+	err = i.delegates(ctx, k.Roles, k.Scopes)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	secret, hash, prefix, err := apikey.Generate()
+	if err != nil {
+		return outputData{}, err
+	}
+
+	now := time.Now()
+
+	err = i.storage.RotateKey(ctx, id, hash, prefix, now)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	if err := i.cache.EvictKey(ctx, k.Hash); err != nil {
+		return outputData{}, err
+	}
+
+	k.Hash = hash
+	k.Prefix = prefix
+	k.RotatedAt = &now
+
+	od := toOutputData(k)
+	od.Key = secret
+
+	return od, nil
+}
+
+func (i *i) revoke(
+	ctx context.Context,
+	id string,
+) error {
+	k, err := i.storage.ReadKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = i.storage.RevokeKey(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return i.cache.EvictKey(ctx, k.Hash)
+}
+
+// delegates returns authz.ErrNotGranted unless the caller is permitted to
+// delegate roles and scopes, so that keys cannot be granted more than the
+// caller (e.g., the admin role).
+func (i *i) delegates(
+	ctx context.Context,
+	roles []string,
+	scopes []string,
+) error {
+	if i.delegator == nil {
+		return nil
+	}
+
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return authz.ErrNotGranted
+	}
+
+	return i.delegator.Delegates(claims, roles, scopes)
+}
+
+func toOutputData(k apikey.Key) outputData {
+	return outputData{
+		CreatedAt:  k.CreatedAt,
+		RotatedAt:  k.RotatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Roles:      k.Roles,
+		Scopes:     k.Scopes,
+		Quota:      k.Quota,
+	}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
@@ -0,0 +1,219 @@
+package manage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/authz"
+	"github.com/bendbennett/go-api-demo/internal/storage/memory"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheMock struct {
+	evicted []string
+}
+
+func (m *cacheMock) EvictKey(_ context.Context, hash string) error {
+	m.evicted = append(m.evicted, hash)
+	return nil
+}
+
+func TestInteractor_Issue(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	storage := memory.NewAPIKeyStorage()
+	interactor := NewInteractor(storage, &cacheMock{}, nil, 1000)
+
+	quota := 10
+
+	cases := []struct {
+		name          string
+		input         inputData
+		expectedQuota int
+	}{
+		{
+			"default quota",
+			inputData{Name: "billing", Roles: []string{"viewer"}},
+			1000,
+		},
+		{
+			"supplied quota",
+			inputData{Name: "reporting", Scopes: []string{"user:read"}, Quota: &quota},
+			10,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			od, err := interactor.issue(ctx, c.input)
+			require.NoError(t, err)
+
+			assert.True(t, validate.IsUUID(od.ID))
+			assert.False(t, od.CreatedAt.IsZero())
+			assert.True(t, strings.HasPrefix(od.Key, od.Prefix))
+			assert.Equal(t, c.expectedQuota, od.Quota)
+			assert.NotNil(t, od.Roles)
+			assert.NotNil(t, od.Scopes)
+
+			k, err := storage.ReadKeyByHash(ctx, apikey.Hash(od.Key))
+			require.NoError(t, err)
+			assert.Equal(t, od.ID, k.ID)
+			assert.Equal(t, "acme", k.TenantID)
+		})
+	}
+}
+
+func TestInteractor_IssueNotGranted(t *testing.T) {
+	policy := authz.NewPolicy(
+		map[string][]string{
+			"admin":   {"*"},
+			"manager": {authz.APIKeyManage},
+			"viewer":  {authz.UserRead},
+		},
+		map[string][]string{},
+	)
+
+	storage := memory.NewAPIKeyStorage()
+	interactor := NewInteractor(storage, &cacheMock{}, policy, 0)
+
+	manager := auth.NewContext(
+		context.Background(),
+		auth.Claims{Roles: []string{"manager"}},
+	)
+
+	_, err := interactor.issue(manager, inputData{Name: "escalate", Roles: []string{"admin"}})
+	assert.ErrorIs(t, err, authz.ErrNotGranted)
+
+	_, err = interactor.issue(manager, inputData{Name: "reporting", Roles: []string{"viewer"}})
+	assert.ErrorIs(t, err, authz.ErrNotGranted)
+
+	_, err = interactor.issue(context.Background(), inputData{Name: "anonymous"})
+	assert.ErrorIs(t, err, authz.ErrNotGranted)
+
+	keys, err := storage.ReadKeys(manager)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	admin := auth.NewContext(
+		context.Background(),
+		auth.Claims{Roles: []string{"admin"}},
+	)
+
+	issued, err := interactor.issue(admin, inputData{Name: "admin", Roles: []string{"admin"}})
+	require.NoError(t, err)
+
+	_, err = interactor.rotate(manager, issued.ID)
+	assert.ErrorIs(t, err, authz.ErrNotGranted)
+
+	_, err = interactor.rotate(admin, issued.ID)
+	assert.NoError(t, err)
+}
+
+func TestInteractor_ReadOmitsKey(t *testing.T) {
+	ctx := context.Background()
+	interactor := NewInteractor(memory.NewAPIKeyStorage(), &cacheMock{}, nil, 0)
+
+	issued, err := interactor.issue(ctx, inputData{Name: "billing"})
+	require.NoError(t, err)
+
+	od, err := interactor.read(ctx, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, od, 1)
+	assert.Equal(t, issued.ID, od[0].ID)
+	assert.Empty(t, od[0].Key)
+
+	byID, err := interactor.readByID(ctx, issued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, issued.ID, byID.ID)
+	assert.Empty(t, byID.Key)
+
+	_, err = interactor.readByID(tenant.NewContext(ctx, "acme"), issued.ID)
+	assert.ErrorIs(t, err, apikey.ErrNotFound)
+}
+
+func TestInteractor_ReadUnusedSince(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewAPIKeyStorage()
+	interactor := NewInteractor(storage, &cacheMock{}, nil, 0)
+
+	now := time.Now()
+
+	for _, k := range []apikey.Key{
+		{CreatedAt: now.Add(-48 * time.Hour), ID: "never-used"},
+		{CreatedAt: now.Add(-48 * time.Hour), ID: "used-recently"},
+		{CreatedAt: now.Add(-48 * time.Hour), ID: "used-long-ago"},
+		{CreatedAt: now, ID: "created-recently"},
+	} {
+		require.NoError(t, storage.CreateKey(ctx, k))
+	}
+
+	require.NoError(t, storage.TouchKey(ctx, "used-recently", now))
+	require.NoError(t, storage.TouchKey(ctx, "used-long-ago", now.Add(-36*time.Hour)))
+
+	od, err := interactor.read(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+
+	var ids []string
+
+	for _, k := range od {
+		ids = append(ids, k.ID)
+	}
+
+	assert.ElementsMatch(t, []string{"never-used", "used-long-ago"}, ids)
+}
+
+func TestInteractor_Rotate(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewAPIKeyStorage()
+	cache := &cacheMock{}
+	interactor := NewInteractor(storage, cache, nil, 0)
+
+	issued, err := interactor.issue(ctx, inputData{Name: "billing"})
+	require.NoError(t, err)
+
+	od, err := interactor.rotate(ctx, issued.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, issued.ID, od.ID)
+	assert.NotEqual(t, issued.Key, od.Key)
+	assert.NotNil(t, od.RotatedAt)
+	assert.Equal(t, []string{apikey.Hash(issued.Key)}, cache.evicted)
+
+	_, err = storage.ReadKeyByHash(ctx, apikey.Hash(issued.Key))
+	assert.ErrorIs(t, err, apikey.ErrNotFound)
+
+	k, err := storage.ReadKeyByHash(ctx, apikey.Hash(od.Key))
+	require.NoError(t, err)
+	assert.Equal(t, issued.ID, k.ID)
+
+	_, err = interactor.rotate(ctx, "unknown")
+	assert.ErrorIs(t, err, apikey.ErrNotFound)
+}
+
+func TestInteractor_Revoke(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewAPIKeyStorage()
+	cache := &cacheMock{}
+	interactor := NewInteractor(storage, cache, nil, 0)
+
+	issued, err := interactor.issue(ctx, inputData{Name: "billing"})
+	require.NoError(t, err)
+
+	require.NoError(t, interactor.revoke(ctx, issued.ID))
+	assert.Equal(t, []string{apikey.Hash(issued.Key)}, cache.evicted)
+
+	k, err := storage.ReadKey(ctx, issued.ID)
+	require.NoError(t, err)
+	assert.True(t, k.Revoked())
+
+	_, err = interactor.rotate(ctx, issued.ID)
+	assert.ErrorIs(t, err, apikey.ErrRevoked)
+
+	assert.ErrorIs(t, interactor.revoke(ctx, "unknown"), apikey.ErrNotFound)
+}
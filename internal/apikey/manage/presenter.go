@@ -0,0 +1,55 @@
+package manage
+
+import "time"
+
+type p struct {
+}
+
+type presenter interface {
+	viewModel(data outputData) viewModel
+}
+
+var _ presenter = (*p)(nil)
+
+func NewPresenter() presenter {
+	return &p{}
+}
+
+type viewModel struct {
+	ID         string
+	Name       string
+	Key        string
+	Prefix     string
+	Roles      []string
+	Scopes     []string
+	Quota      int
+	CreatedAt  string
+	RotatedAt  string
+	LastUsedAt string
+	RevokedAt  string
+}
+
+func (p *p) viewModel(od outputData) viewModel {
+	return viewModel{
+		ID:         od.ID,
+		Name:       od.Name,
+		Key:        od.Key,
+		Prefix:     od.Prefix,
+		Roles:      od.Roles,
+		Scopes:     od.Scopes,
+		Quota:      od.Quota,
+		CreatedAt:  od.CreatedAt.Format(time.RFC3339),
+		RotatedAt:  format(od.RotatedAt),
+		LastUsedAt: format(od.LastUsedAt),
+		RevokedAt:  format(od.RevokedAt),
+	}
+}
+
+// format returns an empty string if t is nil.
+func format(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,45 @@
+package manage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenter_ViewModel(t *testing.T) {
+	presenter := NewPresenter()
+
+	createdAt, err := time.Parse(
+		time.RFC3339,
+		"2015-09-15T14:23:12+07:00")
+	if err != nil {
+		t.Error(err)
+	}
+
+	lastUsedAt := createdAt.Add(time.Hour)
+
+	vm := presenter.viewModel(outputData{
+		CreatedAt:  createdAt,
+		LastUsedAt: &lastUsedAt,
+		ID:         "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		Name:       "billing",
+		Key:        "ak_abc",
+		Prefix:     "ak_abc",
+		Roles:      []string{"viewer"},
+		Scopes:     []string{"user:read"},
+		Quota:      1000,
+	})
+
+	assert.Equal(t, viewModel{
+		ID:         "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		Name:       "billing",
+		Key:        "ak_abc",
+		Prefix:     "ak_abc",
+		Roles:      []string{"viewer"},
+		Scopes:     []string{"user:read"},
+		Quota:      1000,
+		CreatedAt:  "2015-09-15T14:23:12+07:00",
+		LastUsedAt: "2015-09-15T15:23:12+07:00",
+	}, vm)
+}
@@ -2,9 +2,10 @@ package app
 
 import (
 	"context"
+	"errors"
 	"io"
-
-	"golang.org/x/sync/errgroup"
+	"sync"
+	"time"
 )
 
 // commitHash is populated with the git commit hash
@@ -20,43 +21,167 @@ type Component interface {
 	Run(ctx context.Context) error
 }
 
+// Starter is implemented by components that must complete set up (e.g.,
+// registering telemetry providers) before Run is called and before later
+// components are started.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Readier is implemented by components that are not ready as soon as Run
+// is called (e.g., servers that have yet to listen). Ready blocks until
+// the component is ready or ctx is done.
+type Readier interface {
+	Ready(ctx context.Context) error
+}
+
+// Stopper is implemented by components that drain in-flight work (e.g.,
+// requests) when stopping. Stop is called before the context passed to
+// Run is cancelled, and returns once draining is complete or ctx is done.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// logger is satisfied by log.Logger, which cannot be imported as it
+// depends on this package.
+type logger interface {
+	Error(error)
+}
+
 type App struct {
-	components []Component
-	closers    []io.Closer
+	components   []Component
+	closers      []io.Closer
+	drainTimeout time.Duration
+	logger       logger
 }
 
+// New returns an App that runs components in order, such that each
+// component is started once the components it depends on, which precede
+// it, are ready. Components are stopped, and closers are closed, in
+// reverse order.
 func New(
 	components []Component,
 	closers []io.Closer,
+	drainTimeout time.Duration,
+	logger logger,
 ) *App {
 	return &App{
 		components,
 		closers,
+		drainTimeout,
+		logger,
 	}
 }
 
-// Run uses an errgroup.WithContext for synchronisation,
-// error propagation, and Context cancellation,
-// facilitating graceful shutdown, on interrupt or
-// terminate, of HTTP & gRPC servers and Kafka consumers.
+type running struct {
+	component Component
+	cancel    context.CancelFunc
+}
+
+// Run starts the components in order, and runs until ctx is cancelled,
+// on interrupt or terminate, or a component fails. The components are
+// then stopped in reverse order, each being given the remainder of the
+// drain timeout to stop gracefully before the context passed to its Run
+// is cancelled.
 func (a *App) Run(ctx context.Context) error {
-	eg, ctx := errgroup.WithContext(ctx)
+	failCtx, fail := context.WithCancelCause(ctx)
+	defer fail(nil)
+
+	var (
+		wg      sync.WaitGroup
+		started []running
+		err     error
+	)
 
 	for _, c := range a.components {
-		f := func(c Component) func() error {
-			return func() error {
-				return c.Run(ctx)
+		if err = start(failCtx, c); err != nil {
+			break
+		}
+
+		// The context of Run is not cancelled with ctx, so that the
+		// component is stopped in order.
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		started = append(started, running{c, cancel})
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := c.Run(runCtx); err != nil {
+				fail(err)
 			}
+		}()
+
+		if err = ready(failCtx, c); err != nil {
+			break
 		}
+	}
 
-		eg.Go(f(c))
+	if err == nil {
+		<-failCtx.Done()
 	}
 
-	return eg.Wait()
+	if cause := context.Cause(failCtx); cause != nil && !errors.Is(cause, context.Canceled) {
+		err = cause
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.drainTimeout)
+	defer cancel()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		if s, ok := started[i].component.(Stopper); ok {
+			if stopErr := s.Stop(drainCtx); stopErr != nil {
+				a.logger.Error(stopErr)
+			}
+		}
+
+		started[i].cancel()
+	}
+
+	wg.Wait()
+
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
 }
 
-func (a *App) Close() {
-	for _, closer := range a.closers {
-		_ = closer.Close()
+func start(ctx context.Context, c Component) error {
+	s, ok := c.(Starter)
+	if !ok {
+		return nil
 	}
+
+	return s.Start(ctx)
+}
+
+func ready(ctx context.Context, c Component) error {
+	r, ok := c.(Readier)
+	if !ok {
+		return nil
+	}
+
+	return r.Ready(ctx)
+}
+
+// Close closes the closers in reverse order, so that resources (e.g.,
+// connections) are closed before those they were created from, and
+// returns, and logs, the errors aggregated.
+func (a *App) Close() error {
+	var errs []error
+
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		a.logger.Error(err)
+	}
+
+	return err
 }
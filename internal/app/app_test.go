@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +12,10 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+type loggerMock struct{}
+
+func (lm loggerMock) Error(error) {}
+
 type okComponent struct{}
 
 func (t *okComponent) Run(ctx context.Context) error {
@@ -24,6 +29,88 @@ func (t *errorComponent) Run(ctx context.Context) error {
 	return errors.New("error from component")
 }
 
+// events records the phases of lifecycleComponents in the order they occur.
+type events struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.events = append(e.events, event)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]string(nil), e.events...)
+}
+
+type lifecycleComponent struct {
+	name     string
+	events   *events
+	startErr error
+	ready    chan struct{}
+	drain    time.Duration
+}
+
+func (l *lifecycleComponent) Start(context.Context) error {
+	l.events.add(l.name + ":start")
+	return l.startErr
+}
+
+func (l *lifecycleComponent) Run(ctx context.Context) error {
+	l.events.add(l.name + ":run")
+
+	if l.ready != nil {
+		close(l.ready)
+	}
+
+	<-ctx.Done()
+	l.events.add(l.name + ":cancelled")
+
+	return nil
+}
+
+func (l *lifecycleComponent) Ready(ctx context.Context) error {
+	if l.ready == nil {
+		return nil
+	}
+
+	select {
+	case <-l.ready:
+		l.events.add(l.name + ":ready")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *lifecycleComponent) Stop(ctx context.Context) error {
+	select {
+	case <-time.After(l.drain):
+		l.events.add(l.name + ":stopped")
+		return nil
+	case <-ctx.Done():
+		l.events.add(l.name + ":timeout")
+		return ctx.Err()
+	}
+}
+
+type closerMock struct {
+	name   string
+	events *events
+	err    error
+}
+
+func (c *closerMock) Close() error {
+	c.events.add(c.name + ":closed")
+	return c.err
+}
+
 func TestRun_NoError(t *testing.T) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
@@ -32,7 +119,10 @@ func TestRun_NoError(t *testing.T) {
 		[]Component{
 			&okComponent{},
 		},
-		[]io.Closer{})
+		[]io.Closer{},
+		time.Second,
+		loggerMock{},
+	)
 
 	go func() {
 		<-time.After(time.Millisecond)
@@ -51,12 +141,133 @@ func TestRun_Error(t *testing.T) {
 		[]Component{
 			&errorComponent{},
 		},
-		[]io.Closer{})
+		[]io.Closer{},
+		time.Second,
+		loggerMock{},
+	)
 
 	err := a.Run(ctx)
 	require.Error(t, err)
 }
 
+func TestRun_Lifecycle(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	e := &events{}
+
+	a := New(
+		[]Component{
+			&lifecycleComponent{name: "first", events: e, ready: make(chan struct{})},
+			&lifecycleComponent{name: "second", events: e},
+		},
+		[]io.Closer{},
+		time.Second,
+		loggerMock{},
+	)
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			return len(e.get()) == 5
+		}, time.Second, time.Millisecond)
+		cancelFunc()
+	}()
+
+	err := a.Run(ctx)
+	require.NoError(t, err)
+
+	got := e.get()
+	require.Len(t, got, 9)
+
+	assert.Equal(
+		t,
+		[]string{"first:start", "first:run", "first:ready", "second:start", "second:run"},
+		got[:5],
+	)
+
+	// Cancellation is observed in the go routine calling Run, so is only
+	// ordered relative to the Stop of the same component.
+	assert.Less(t, indexOf(got, "second:stopped"), indexOf(got, "first:stopped"))
+	assert.Less(t, indexOf(got, "second:stopped"), indexOf(got, "second:cancelled"))
+	assert.Less(t, indexOf(got, "first:stopped"), indexOf(got, "first:cancelled"))
+}
+
+func indexOf(events []string, event string) int {
+	for i, e := range events {
+		if e == event {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestRun_StartError(t *testing.T) {
+	e := &events{}
+
+	a := New(
+		[]Component{
+			&lifecycleComponent{name: "first", events: e},
+			&lifecycleComponent{name: "second", events: e, startErr: errors.New("start error")},
+			&lifecycleComponent{name: "third", events: e},
+		},
+		[]io.Closer{},
+		time.Second,
+		loggerMock{},
+	)
+
+	err := a.Run(context.Background())
+	require.EqualError(t, err, "start error")
+
+	assert.NotContains(t, e.get(), "second:run")
+	assert.NotContains(t, e.get(), "third:start")
+	assert.Contains(t, e.get(), "first:stopped")
+	assert.Contains(t, e.get(), "first:cancelled")
+}
+
+func TestRun_DrainTimeout(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	cancelFunc()
+
+	e := &events{}
+
+	a := New(
+		[]Component{
+			&lifecycleComponent{name: "first", events: e, drain: time.Minute},
+			&lifecycleComponent{name: "second", events: e, drain: time.Minute},
+		},
+		[]io.Closer{},
+		10*time.Millisecond,
+		loggerMock{},
+	)
+
+	err := a.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Contains(t, e.get(), "second:timeout")
+	assert.Contains(t, e.get(), "first:timeout")
+}
+
+func TestApp_Close(t *testing.T) {
+	e := &events{}
+
+	a := New(
+		[]Component{},
+		[]io.Closer{
+			&closerMock{name: "first", events: e, err: errors.New("first error")},
+			&closerMock{name: "second", events: e},
+			&closerMock{name: "third", events: e, err: errors.New("third error")},
+		},
+		time.Second,
+		loggerMock{},
+	)
+
+	err := a.Close()
+	require.EqualError(t, err, "third error\nfirst error")
+
+	assert.Equal(t, []string{"third:closed", "second:closed", "first:closed"}, e.get())
+}
+
 func TestApp_CommitHash(t *testing.T) {
 	commitHash = "123abc"
 	assert.Equal(t, CommitHash(), "123abc")
@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Outcomes of audited calls. A pending entry is appended before a call is
+// handled, and is followed by an entry with the outcome of the call.
+const (
+	OutcomePending = "pending"
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+)
+
+var ErrChainBroken = errors.New("audit chain broken")
+
+// Entry records a call that mutated, or attempted to mutate, state. Each
+// entry holds the hash of the previous entry of the tenant, and its own
+// hash covers every other field, so that changes to, or removal of,
+// entries are evident.
+type Entry struct {
+	OccurredAt time.Time
+	TenantID   string
+	Actor      string
+	Operation  string
+	Resource   string
+	TargetID   string
+	RequestID  string
+	TraceID    string
+	ClientIP   string
+	Outcome    string
+	Status     string
+	PrevHash   string
+	Hash       string
+	Seq        int64
+}
+
+// Chain returns e as the entry following the entry with seq and hash in
+// the chain of its tenant.
+func Chain(e Entry, seq int64, hash string) Entry {
+	e.Seq = seq + 1
+	e.PrevHash = hash
+	e.Hash = e.hash()
+
+	return e
+}
+
+func (e Entry) hash() string {
+	b, _ := json.Marshal([]interface{}{
+		e.Seq,
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		e.TenantID,
+		e.Actor,
+		e.Operation,
+		e.Resource,
+		e.TargetID,
+		e.RequestID,
+		e.TraceID,
+		e.ClientIP,
+		e.Outcome,
+		e.Status,
+		e.PrevHash,
+	})
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify returns ErrChainBroken if any of entries, which must be
+// consecutive entries of a chain, has been changed, or if entries are
+// missing between them.
+func Verify(entries []Entry) error {
+	for n, e := range entries {
+		if e.Hash != e.hash() {
+			return fmt.Errorf("%w: entry %d: hash mismatch", ErrChainBroken, e.Seq)
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		prev := entries[n-1]
+
+		if e.Seq != prev.Seq+1 || e.PrevHash != prev.Hash {
+			return fmt.Errorf("%w: entry %d: does not follow entry %d", ErrChainBroken, e.Seq, prev.Seq)
+		}
+	}
+
+	return nil
+}
+
+// VerifyChain reads the chain of the tenant carried by ctx from reader, in
+// pages of pageSize entries, and returns the number of entries verified, or
+// ErrChainBroken if any entry has been changed, or any entry other than the
+// most recent entries has been removed. The removal of the most recent
+// entries cannot be detected from the chain alone.
+func VerifyChain(
+	ctx context.Context,
+	reader Reader,
+	pageSize int,
+) (int64, error) {
+	var (
+		last     *Entry
+		verified int64
+	)
+
+	for {
+		entries, err := reader.ReadEntries(
+			ctx,
+			Filter{
+				After: verified,
+				Limit: pageSize,
+			},
+		)
+		if err != nil {
+			return verified, err
+		}
+
+		if len(entries) == 0 {
+			return verified, nil
+		}
+
+		if last == nil && (entries[0].Seq != 1 || entries[0].PrevHash != "") {
+			return verified, fmt.Errorf("%w: entry %d: does not start the chain", ErrChainBroken, entries[0].Seq)
+		}
+
+		if last != nil {
+			entries = append([]Entry{*last}, entries...)
+		}
+
+		if err := Verify(entries); err != nil {
+			return verified, err
+		}
+
+		last = &entries[len(entries)-1]
+		verified = last.Seq
+	}
+}
+
+// Filter selects entries by Actor and TargetID, if set, that occurred in
+// [From, To), where zero times are unbounded. Only entries with a Seq
+// greater than After are selected, so that the Seq of the last entry read
+// can be used as a cursor. At most Limit entries are selected, in the order
+// in which they were appended.
+type Filter struct {
+	From     time.Time
+	To       time.Time
+	Actor    string
+	TargetID string
+	After    int64
+	Limit    int
+}
+
+type Appender interface {
+	AppendEntries(ctx context.Context, entries ...Entry) error
+}
+
+type Reader interface {
+	ReadEntries(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+type Storage interface {
+	Appender
+	Reader
+}
@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func chain(n int) []Entry {
+	var (
+		entries []Entry
+		seq     int64
+		hash    string
+	)
+
+	for i := 0; i < n; i++ {
+		e := Chain(Entry{
+			OccurredAt: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+			TenantID:   "acme",
+			Actor:      "alice",
+			Operation:  "user:update",
+			TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			Outcome:    OutcomeSuccess,
+		}, seq, hash)
+
+		seq, hash = e.Seq, e.Hash
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func TestChain(t *testing.T) {
+	entries := chain(2)
+
+	assert.Equal(t, int64(1), entries[0].Seq)
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Len(t, entries[0].Hash, 64)
+	assert.Equal(t, int64(2), entries[1].Seq)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.NotEqual(t, entries[0].Hash, entries[1].Hash)
+}
+
+func TestVerify(t *testing.T) {
+	cases := []struct {
+		name        string
+		tamper      func([]Entry) []Entry
+		expectedErr bool
+	}{
+		{
+			"intact",
+			func(e []Entry) []Entry { return e },
+			false,
+		},
+		{
+			"field changed",
+			func(e []Entry) []Entry {
+				e[1].Actor = "mallory"
+				return e
+			},
+			true,
+		},
+		{
+			"entry removed",
+			func(e []Entry) []Entry {
+				return append(e[:1], e[2:]...)
+			},
+			true,
+		},
+		{
+			"entry rehashed",
+			func(e []Entry) []Entry {
+				e[1].Outcome = OutcomeDenied
+				e[1] = Chain(e[1], e[0].Seq, e[0].Hash)
+				return e
+			},
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Verify(c.tamper(chain(3)))
+
+			if c.expectedErr {
+				assert.ErrorIs(t, err, ErrChainBroken)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// readerMock pages through entries as storage does, recording the filters.
+type readerMock struct {
+	entries []Entry
+	filters []Filter
+}
+
+func (m *readerMock) ReadEntries(_ context.Context, filter Filter) ([]Entry, error) {
+	m.filters = append(m.filters, filter)
+
+	var entries []Entry
+
+	for _, e := range m.entries {
+		if e.Seq > filter.After && len(entries) < filter.Limit {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func TestVerifyChain(t *testing.T) {
+	cases := []struct {
+		name             string
+		tamper           func([]Entry) []Entry
+		expectedVerified int64
+		expectedErr      bool
+	}{
+		{
+			"intact",
+			func(e []Entry) []Entry { return e },
+			5,
+			false,
+		},
+		{
+			"field changed in later page",
+			func(e []Entry) []Entry {
+				e[3].Actor = "mallory"
+				return e
+			},
+			2,
+			true,
+		},
+		{
+			"entry removed at page boundary",
+			func(e []Entry) []Entry {
+				return append(e[:2], e[3:]...)
+			},
+			2,
+			true,
+		},
+		{
+			"first entry removed",
+			func(e []Entry) []Entry {
+				return e[1:]
+			},
+			0,
+			true,
+		},
+		{
+			"empty",
+			func([]Entry) []Entry { return nil },
+			0,
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			reader := &readerMock{entries: c.tamper(chain(5))}
+
+			verified, err := VerifyChain(context.Background(), reader, 2)
+
+			assert.Equal(t, c.expectedVerified, verified)
+
+			if c.expectedErr {
+				assert.ErrorIs(t, err, ErrChainBroken)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	// Pages are read using the seq of the last entry read as the cursor.
+	reader := &readerMock{entries: chain(5)}
+
+	_, err := VerifyChain(context.Background(), reader, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []Filter{{Limit: 2}, {After: 2, Limit: 2}, {After: 4, Limit: 2}, {After: 5, Limit: 2}}, reader.filters)
+}
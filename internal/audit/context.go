@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+type targetsKey struct{}
+
+type targets struct {
+	ids []string
+	mu  sync.Mutex
+}
+
+func newContext(ctx context.Context) (context.Context, *targets) {
+	t := &targets{}
+
+	return context.WithValue(ctx, targetsKey{}, t), t
+}
+
+// AddTargets records the IDs of the resources (e.g., created users) that
+// are the targets of the audited call carried by ctx, if any.
+func AddTargets(ctx context.Context, ids ...string) {
+	t, ok := ctx.Value(targetsKey{}).(*targets)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ids = append(t.ids, ids...)
+}
+
+func (t *targets) get() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.ids
+}
+
+// Recorded reports whether ctx carries a call that is audited by a
+// recorder, in which case its outcome, including any denial, is recorded.
+func Recorded(ctx context.Context) bool {
+	_, ok := ctx.Value(targetsKey{}).(*targets)
+
+	return ok
+}
@@ -0,0 +1,15 @@
+package query
+
+import "time"
+
+// inputData selects entries by Actor and TargetID, if set, that occurred
+// in [From, To), where zero times are unbounded, with a seq greater than
+// After.
+type inputData struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Actor    string    `json:"actor" validate:"max=255"`
+	TargetID string    `json:"target_id" validate:"max=36"`
+	After    int64     `json:"after" validate:"min=0"`
+	Limit    int       `json:"limit" validate:"min=1,max=1000"`
+}
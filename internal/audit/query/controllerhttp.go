@@ -0,0 +1,186 @@
+package query
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+)
+
+// defaultLimit is the number of entries returned if no limit is supplied.
+const defaultLimit = 100
+
+type httpController struct {
+	validator  validate.Validator
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Read(w http.ResponseWriter, r *http.Request)
+	Verify(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	validator validate.Validator,
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		validator,
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+type output struct {
+	Seq        int64  `json:"seq"`
+	OccurredAt string `json:"occurred_at"`
+	Actor      string `json:"actor"`
+	Operation  string `json:"operation"`
+	Resource   string `json:"resource"`
+	TargetID   string `json:"target_id"`
+	RequestID  string `json:"request_id"`
+	TraceID    string `json:"trace_id"`
+	ClientIP   string `json:"client_ip"`
+	Outcome    string `json:"outcome"`
+	Status     string `json:"status"`
+	PrevHash   string `json:"prev_hash"`
+	Hash       string `json:"hash"`
+}
+
+// Read returns the entries of the tenant, in the order in which they were
+// appended, filtered by the actor, target_id, from and to (RFC 3339) query
+// parameters, if supplied. At most limit (default 100) entries are
+// returned. Subsequent entries are returned by supplying the seq of the
+// last entry returned as after.
+func (c *httpController) Read(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	input := inputData{
+		Actor:    q.Get("actor"),
+		TargetID: q.Get("target_id"),
+		Limit:    defaultLimit,
+	}
+
+	errs := make(map[string]string)
+
+	for _, t := range []struct {
+		param string
+		time  *time.Time
+	}{
+		{"from", &input.From},
+		{"to", &input.To},
+	} {
+		v := q.Get(t.param)
+		if v == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			errs[t.param] = t.param + " must be an RFC 3339 time"
+			continue
+		}
+
+		*t.time = parsed
+	}
+
+	if v := q.Get("after"); v != "" {
+		after, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs["after"] = "after must be an integer"
+		}
+
+		input.After = after
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			errs["limit"] = "limit must be an integer"
+		}
+
+		input.Limit = limit
+	}
+
+	if len(errs) == 0 {
+		errs = c.validator.ValidateStruct(input)
+	}
+
+	if len(errs) > 0 {
+		c.logger.InfofContext(ctx, "input invalid: %v", errs)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			errs,
+		)
+		return
+	}
+
+	od, err := c.interactor.read(
+		ctx,
+		input,
+	)
+	if err != nil {
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	out := make([]output, 0, len(od))
+
+	for _, o := range od {
+		out = append(out, output(c.presenter.viewModel(o)))
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		out,
+	)
+}
+
+type verificationOutput struct {
+	Verified        bool   `json:"verified"`
+	LastVerifiedSeq int64  `json:"last_verified_seq"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Verify verifies the hash chain of the entries of the tenant, and returns
+// whether it is intact, the seq of the last entry verified and, if the chain
+// is broken, the entry at which it breaks.
+func (c *httpController) Verify(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	od, err := c.interactor.verify(ctx)
+	if err != nil {
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	if !od.Verified {
+		c.logger.ErrorfContext(ctx, "audit chain verification failed: %s", od.Error)
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		verificationOutput(od),
+	)
+}
@@ -0,0 +1,222 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+type interactorMock struct {
+	input        inputData
+	verification verificationData
+	err          error
+}
+
+func (m *interactorMock) read(_ context.Context, in inputData) ([]outputData, error) {
+	m.input = in
+	return []outputData{{}}, m.err
+}
+
+func (m *interactorMock) verify(context.Context) (verificationData, error) {
+	return m.verification, m.err
+}
+
+type presenterMock struct {
+}
+
+func (pm *presenterMock) viewModel(outputData) viewModel {
+	return viewModel{
+		Seq:        1,
+		OccurredAt: "2006-01-02T15:04:05Z",
+		Actor:      "alice",
+		Operation:  "user:delete",
+		Resource:   "DELETE /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		RequestID:  "req-1",
+		ClientIP:   "10.0.0.1",
+		Outcome:    "success",
+		Status:     "204",
+		Hash:       "hash",
+	}
+}
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+func TestHTTPController_Read(t *testing.T) {
+	validator, err := validate.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                 string
+		path                 string
+		interactor           *interactorMock
+		expectedStatus       int
+		expectedResponseBody string
+		expectedLimit        int
+	}{
+		{
+			"time invalid",
+			"/audit?from=yesterday&to=today",
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {
+				"from": "from must be an RFC 3339 time",
+				"to": "to must be an RFC 3339 time"
+			}}`,
+			0,
+		},
+		{
+			"after invalid",
+			"/audit?after=first",
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {"after": "after must be an integer"}}`,
+			0,
+		},
+		{
+			"after out of range",
+			"/audit?after=-1",
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {"after": "after must be 0 or greater"}}`,
+			0,
+		},
+		{
+			"limit invalid",
+			"/audit?limit=all",
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {"limit": "limit must be an integer"}}`,
+			0,
+		},
+		{
+			"limit out of range",
+			"/audit?limit=1001",
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {"limit": "limit must be 1,000 or less"}}`,
+			0,
+		},
+		{
+			"interactor error",
+			"/audit",
+			&interactorMock{err: errors.New("interactor read error")},
+			http.StatusInternalServerError,
+			`{"message": "internal server error"}`,
+			defaultLimit,
+		},
+		{
+			"success",
+			"/audit?actor=alice&target_id=0a81dec3-3638-4eb4-b04a-83d744f5f3a8&from=2006-01-02T00:00:00Z&to=2006-01-03T00:00:00Z&after=0&limit=10",
+			&interactorMock{},
+			http.StatusOK,
+			`[{
+				"seq": 1,
+				"occurred_at": "2006-01-02T15:04:05Z",
+				"actor": "alice",
+				"operation": "user:delete",
+				"resource": "DELETE /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				"target_id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				"request_id": "req-1",
+				"trace_id": "",
+				"client_ip": "10.0.0.1",
+				"outcome": "success",
+				"status": "204",
+				"prev_hash": "",
+				"hash": "hash"
+			}]`,
+			10,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewHTTPController(
+				validator,
+				c.interactor,
+				&presenterMock{},
+				loggerMock{},
+			)
+
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			w := httptest.NewRecorder()
+
+			controller.Read(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.Equal(t, c.expectedLimit, c.interactor.input.Limit)
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
+
+func TestHTTPController_Verify(t *testing.T) {
+	cases := []struct {
+		name                 string
+		interactor           *interactorMock
+		expectedStatus       int
+		expectedResponseBody string
+	}{
+		{
+			"interactor error",
+			&interactorMock{err: errors.New("interactor verify error")},
+			http.StatusInternalServerError,
+			`{"message": "internal server error"}`,
+		},
+		{
+			"verified",
+			&interactorMock{verification: verificationData{Verified: true, LastVerifiedSeq: 3}},
+			http.StatusOK,
+			`{"verified": true, "last_verified_seq": 3}`,
+		},
+		{
+			"broken",
+			&interactorMock{verification: verificationData{LastVerifiedSeq: 1, Error: "audit chain broken: entry 2: hash mismatch"}},
+			http.StatusOK,
+			`{"verified": false, "last_verified_seq": 1, "error": "audit chain broken: entry 2: hash mismatch"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewHTTPController(
+				nil,
+				c.interactor,
+				&presenterMock{},
+				loggerMock{},
+			)
+
+			r := httptest.NewRequest(http.MethodGet, "/audit/verify", nil)
+			w := httptest.NewRecorder()
+
+			controller.Verify(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.JSONEq(t, c.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/audit"
+)
+
+type i struct {
+	reader audit.Reader
+}
+
+type interactor interface {
+	read(context.Context, inputData) ([]outputData, error)
+	verify(context.Context) (verificationData, error)
+}
+
+var _ interactor = (*i)(nil)
+
+func NewInteractor(
+	reader audit.Reader,
+) *i {
+	return &i{
+		reader,
+	}
+}
+
+type outputData struct {
+	OccurredAt time.Time
+	Seq        int64
+	Actor      string
+	Operation  string
+	Resource   string
+	TargetID   string
+	RequestID  string
+	TraceID    string
+	ClientIP   string
+	Outcome    string
+	Status     string
+	PrevHash   string
+	Hash       string
+}
+
+func (i *i) read(
+	ctx context.Context,
+	in inputData,
+) ([]outputData, error) {
+	entries, err := i.reader.ReadEntries(
+		ctx,
+		audit.Filter{
+			From:     in.From,
+			To:       in.To,
+			Actor:    in.Actor,
+			TargetID: in.TargetID,
+			After:    in.After,
+			Limit:    in.Limit,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	od := make([]outputData, 0, len(entries))
+
+	for _, e := range entries {
+		od = append(od, outputData{
+			OccurredAt: e.OccurredAt,
+			Seq:        e.Seq,
+			Actor:      e.Actor,
+			Operation:  e.Operation,
+			Resource:   e.Resource,
+			TargetID:   e.TargetID,
+			RequestID:  e.RequestID,
+			TraceID:    e.TraceID,
+			ClientIP:   e.ClientIP,
+			Outcome:    e.Outcome,
+			Status:     e.Status,
+			PrevHash:   e.PrevHash,
+			Hash:       e.Hash,
+		})
+	}
+
+	return od, nil
+}
+
+// verifyPageSize is the number of entries read at a time when verifying.
+const verifyPageSize = 1000
+
+// verificationData is the result of verifying the chain of the tenant.
+// Error describes where the chain breaks if it is not Verified.
+type verificationData struct {
+	Verified        bool
+	LastVerifiedSeq int64
+	Error           string
+}
+
+func (i *i) verify(ctx context.Context) (verificationData, error) {
+	seq, err := audit.VerifyChain(ctx, i.reader, verifyPageSize)
+
+	switch {
+	case errors.Is(err, audit.ErrChainBroken):
+		return verificationData{
+			LastVerifiedSeq: seq,
+			Error:           err.Error(),
+		}, nil
+	case err != nil:
+		return verificationData{}, err
+	}
+
+	return verificationData{
+		Verified:        true,
+		LastVerifiedSeq: seq,
+	}, nil
+}
@@ -0,0 +1,154 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/storage/memory"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractor_Read(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	storage := memory.NewAuditStorage()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, storage.AppendEntries(
+		ctx,
+		audit.Entry{OccurredAt: start, Actor: "alice", TargetID: "user-1"},
+		audit.Entry{OccurredAt: start.Add(time.Hour), Actor: "bob", TargetID: "user-1"},
+		audit.Entry{OccurredAt: start.Add(2 * time.Hour), Actor: "alice", TargetID: "user-2"},
+	))
+	require.NoError(t, storage.AppendEntries(
+		tenant.NewContext(context.Background(), "globex"),
+		audit.Entry{OccurredAt: start, Actor: "alice", TargetID: "user-1"},
+	))
+
+	interactor := NewInteractor(storage)
+
+	cases := []struct {
+		name        string
+		input       inputData
+		expectedSeq []int64
+	}{
+		{
+			"all",
+			inputData{Limit: 100},
+			[]int64{1, 2, 3},
+		},
+		{
+			"actor",
+			inputData{Actor: "alice", Limit: 100},
+			[]int64{1, 3},
+		},
+		{
+			"target",
+			inputData{TargetID: "user-1", Limit: 100},
+			[]int64{1, 2},
+		},
+		{
+			"time range",
+			inputData{From: start.Add(time.Hour), To: start.Add(2 * time.Hour), Limit: 100},
+			[]int64{2},
+		},
+		{
+			"limit",
+			inputData{Limit: 2},
+			[]int64{1, 2},
+		},
+		{
+			"after",
+			inputData{After: 2, Limit: 2},
+			[]int64{3},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			od, err := interactor.read(ctx, c.input)
+			require.NoError(t, err)
+
+			var seq []int64
+
+			for _, o := range od {
+				seq = append(seq, o.Seq)
+			}
+
+			assert.Equal(t, c.expectedSeq, seq)
+		})
+	}
+
+	od, err := interactor.read(ctx, inputData{Limit: 100})
+	require.NoError(t, err)
+
+	entries := make([]audit.Entry, 0, len(od))
+
+	for _, o := range od {
+		entries = append(entries, audit.Entry{
+			OccurredAt: o.OccurredAt,
+			TenantID:   "acme",
+			Actor:      o.Actor,
+			Operation:  o.Operation,
+			Resource:   o.Resource,
+			TargetID:   o.TargetID,
+			RequestID:  o.RequestID,
+			TraceID:    o.TraceID,
+			ClientIP:   o.ClientIP,
+			Outcome:    o.Outcome,
+			Status:     o.Status,
+			PrevHash:   o.PrevHash,
+			Hash:       o.Hash,
+			Seq:        o.Seq,
+		})
+	}
+
+	assert.NoError(t, audit.Verify(entries))
+}
+
+// readerMockTampered returns the entries of storage with the actor of the
+// entry with seq changed.
+type readerMockTampered struct {
+	audit.Reader
+	seq int64
+}
+
+func (m readerMockTampered) ReadEntries(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	entries, err := m.Reader.ReadEntries(ctx, filter)
+
+	for n := range entries {
+		if entries[n].Seq == m.seq {
+			entries[n].Actor = "mallory"
+		}
+	}
+
+	return entries, err
+}
+
+func TestInteractor_Verify(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	storage := memory.NewAuditStorage()
+
+	od, err := NewInteractor(storage).verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, verificationData{Verified: true}, od)
+
+	require.NoError(t, storage.AppendEntries(
+		ctx,
+		audit.Entry{Actor: "alice", TargetID: "user-1"},
+		audit.Entry{Actor: "bob", TargetID: "user-1"},
+		audit.Entry{Actor: "alice", TargetID: "user-2"},
+	))
+
+	od, err = NewInteractor(storage).verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, verificationData{Verified: true, LastVerifiedSeq: 3}, od)
+
+	od, err = NewInteractor(readerMockTampered{storage, 2}).verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, verificationData{LastVerifiedSeq: 0, Error: "audit chain broken: entry 2: hash mismatch"}, od)
+}
@@ -0,0 +1,50 @@
+package query
+
+import "time"
+
+type p struct {
+}
+
+type presenter interface {
+	viewModel(data outputData) viewModel
+}
+
+var _ presenter = (*p)(nil)
+
+func NewPresenter() presenter {
+	return &p{}
+}
+
+type viewModel struct {
+	Seq        int64
+	OccurredAt string
+	Actor      string
+	Operation  string
+	Resource   string
+	TargetID   string
+	RequestID  string
+	TraceID    string
+	ClientIP   string
+	Outcome    string
+	Status     string
+	PrevHash   string
+	Hash       string
+}
+
+func (p *p) viewModel(od outputData) viewModel {
+	return viewModel{
+		Seq:        od.Seq,
+		OccurredAt: od.OccurredAt.Format(time.RFC3339Nano),
+		Actor:      od.Actor,
+		Operation:  od.Operation,
+		Resource:   od.Resource,
+		TargetID:   od.TargetID,
+		RequestID:  od.RequestID,
+		TraceID:    od.TraceID,
+		ClientIP:   od.ClientIP,
+		Outcome:    od.Outcome,
+		Status:     od.Status,
+		PrevHash:   od.PrevHash,
+		Hash:       od.Hash,
+	}
+}
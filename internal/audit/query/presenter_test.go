@@ -0,0 +1,51 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenter_ViewModel(t *testing.T) {
+	presenter := NewPresenter()
+
+	occurredAt, err := time.Parse(
+		time.RFC3339Nano,
+		"2015-09-15T14:23:12.123456+07:00")
+	if err != nil {
+		t.Error(err)
+	}
+
+	vm := presenter.viewModel(outputData{
+		OccurredAt: occurredAt,
+		Seq:        2,
+		Actor:      "alice",
+		Operation:  "user:update",
+		Resource:   "PUT /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		RequestID:  "req-1",
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		ClientIP:   "10.0.0.1",
+		Outcome:    "success",
+		Status:     "200",
+		PrevHash:   "prev",
+		Hash:       "hash",
+	})
+
+	assert.Equal(t, viewModel{
+		Seq:        2,
+		OccurredAt: "2015-09-15T14:23:12.123456+07:00",
+		Actor:      "alice",
+		Operation:  "user:update",
+		Resource:   "PUT /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		RequestID:  "req-1",
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		ClientIP:   "10.0.0.1",
+		Outcome:    "success",
+		Status:     "200",
+		PrevHash:   "prev",
+		Hash:       "hash",
+	}, vm)
+}
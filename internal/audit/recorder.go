@@ -0,0 +1,307 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/grpcutil"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type r struct {
+	appender        Appender
+	grpcOperations  map[string]string
+	requestIDHeader string
+	now             func() time.Time
+	logger          log.Logger
+}
+
+// NewRecorder returns a recorder that appends an entry for each target of
+// every mutating call, whatever its outcome. grpcOperations maps the full
+// names of the mutating gRPC methods to the operations they perform.
+// Calls are identified by the request ID in the configured header, which
+// is generated if absent and returned to the caller.
+//
+// A pending entry is appended before each call is handled, and the call is
+// refused if it cannot be appended, so that a call whose outcome is lost
+// (e.g., by a crash, or a failure to append the outcome) remains evident
+// as a pending entry without a matching outcome.
+func NewRecorder(
+	conf config.Audit,
+	appender Appender,
+	grpcOperations map[string]string,
+	logger log.Logger,
+) *r {
+	return &r{
+		appender:        appender,
+		grpcOperations:  grpcOperations,
+		requestIDHeader: conf.RequestIDHeader,
+		now:             time.Now,
+		logger:          logger,
+	}
+}
+
+// entries returns e, attributed to the caller, for each of targetIDs, or
+// a single entry if there are none.
+func entries(ctx context.Context, e Entry, targetIDs []string) []Entry {
+	if claims, ok := auth.FromContext(ctx); ok {
+		e.Actor = claims.Subject
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		e.TraceID = sc.TraceID().String()
+	}
+
+	if len(targetIDs) == 0 {
+		targetIDs = []string{""}
+	}
+
+	es := make([]Entry, 0, len(targetIDs))
+
+	for _, id := range targetIDs {
+		e.TargetID = id
+		es = append(es, e)
+	}
+
+	return es
+}
+
+// begin appends a pending entry for each of the targets known before the
+// call is handled. The call must not be handled if an error is returned.
+func (rc *r) begin(ctx context.Context, e Entry, targetIDs []string) error {
+	e.Outcome = OutcomePending
+
+	if err := rc.appender.AppendEntries(ctx, entries(ctx, e, targetIDs)...); err != nil {
+		rc.logger.ErrorfContext(ctx, "audit: append failed: request id: %s: %s", e.RequestID, err)
+		return err
+	}
+
+	return nil
+}
+
+// record appends an entry with the outcome of the call for each of
+// targetIDs. Entries are appended even if the call was cancelled.
+func (rc *r) record(ctx context.Context, e Entry, targetIDs []string) {
+	ctx = context.WithoutCancel(ctx)
+
+	if err := rc.appender.AppendEntries(ctx, entries(ctx, e, targetIDs)...); err != nil {
+		rc.logger.ErrorfContext(ctx, "audit: append failed: request id: %s: %s", e.RequestID, err)
+	}
+}
+
+func (rc *r) requestID(id string) string {
+	if id == "" {
+		return uuid.New().String()
+	}
+
+	return id
+}
+
+// Middleware returns middleware that records requests with mutating
+// methods (i.e., other than GET, HEAD and OPTIONS) for operation. The
+// ID in the path of the route, if any, is recorded as the target.
+func (rc *r) Middleware(operation string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			e := Entry{
+				OccurredAt: rc.now(),
+				Operation:  operation,
+				Resource:   r.Method + " " + r.URL.Path,
+				RequestID:  rc.requestID(r.Header.Get(rc.requestIDHeader)),
+				ClientIP:   host(r.RemoteAddr),
+			}
+
+			w.Header().Set(rc.requestIDHeader, e.RequestID)
+
+			ctx, targets := newContext(r.Context())
+
+			if id := mux.Vars(r)["id"]; id != "" {
+				AddTargets(ctx, id)
+			}
+
+			if err := rc.begin(ctx, e, targets.get()); err != nil {
+				response.WriteErrorResponse(
+					w,
+					http.StatusServiceUnavailable,
+					"audit log unavailable",
+					nil,
+				)
+				return
+			}
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			e.Outcome = httpOutcome(sw.status)
+			e.Status = strconv.Itoa(sw.status)
+
+			rc.record(ctx, e, targets.get())
+		})
+	}
+}
+
+func httpOutcome(code int) string {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return OutcomeDenied
+	case code >= http.StatusBadRequest:
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// grpcEntry returns the entry for a call to method, and the context in
+// which targets are recorded, or false if method is not mutating.
+func (rc *r) grpcEntry(
+	ctx context.Context,
+	method string,
+) (Entry, context.Context, *targets, bool) {
+	operation, ok := rc.grpcOperations[method]
+	if !ok {
+		return Entry{}, nil, nil, false
+	}
+
+	var requestID, remoteAddr string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(strings.ToLower(rc.requestIDHeader)); len(v) > 0 {
+			requestID = v[0]
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+
+	e := Entry{
+		OccurredAt: rc.now(),
+		Operation:  operation,
+		Resource:   method,
+		RequestID:  rc.requestID(requestID),
+		ClientIP:   host(remoteAddr),
+	}
+
+	ctx, targets := newContext(ctx)
+
+	return e, ctx, targets, true
+}
+
+func grpcOutcome(err error) (string, string) {
+	code := status.Code(err)
+
+	switch code {
+	case codes.OK:
+		return OutcomeSuccess, code.String()
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return OutcomeDenied, code.String()
+	default:
+		return OutcomeFailure, code.String()
+	}
+}
+
+// UnaryInterceptor records calls to mutating unary gRPC methods. The ID
+// in the request, if any, is recorded as the target.
+func (rc *r) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	e, auditCtx, targets, ok := rc.grpcEntry(ctx, info.FullMethod)
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(rc.requestIDHeader), e.RequestID))
+
+	if r, ok := req.(interface{ GetId() string }); ok && r.GetId() != "" {
+		AddTargets(auditCtx, r.GetId())
+	}
+
+	if err := rc.begin(auditCtx, e, targets.get()); err != nil {
+		return nil, status.Error(codes.Unavailable, "audit log unavailable")
+	}
+
+	resp, err := handler(auditCtx, req)
+
+	e.Outcome, e.Status = grpcOutcome(err)
+
+	rc.record(auditCtx, e, targets.get())
+
+	return resp, err
+}
+
+// StreamInterceptor records calls to mutating streaming gRPC methods.
+func (rc *r) StreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	e, auditCtx, targets, ok := rc.grpcEntry(ss.Context(), info.FullMethod)
+	if !ok {
+		return handler(srv, ss)
+	}
+
+	_ = ss.SetHeader(metadata.Pairs(strings.ToLower(rc.requestIDHeader), e.RequestID))
+
+	if err := rc.begin(auditCtx, e, targets.get()); err != nil {
+		return status.Error(codes.Unavailable, "audit log unavailable")
+	}
+
+	err := handler(srv, grpcutil.NewServerStream(auditCtx, ss))
+
+	e.Outcome, e.Status = grpcOutcome(err)
+
+	rc.record(auditCtx, e, targets.get())
+
+	return err
+}
+
+// host returns the host of addr, or addr if it has no port.
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return h
+}
@@ -0,0 +1,415 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type loggerMock struct{}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+type appenderMock struct {
+	entries []Entry
+}
+
+func (m *appenderMock) AppendEntries(_ context.Context, entries ...Entry) error {
+	m.entries = append(m.entries, entries...)
+	return nil
+}
+
+var auditConf = config.Audit{
+	RequestIDHeader: "X-Request-ID",
+}
+
+func TestRecorder_Middleware(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name            string
+		method          string
+		path            string
+		requestID       string
+		subject         string
+		handler         http.HandlerFunc
+		expectedEntries []Entry
+	}{
+		{
+			"read not recorded",
+			http.MethodGet,
+			"/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			"",
+			"alice",
+			func(w http.ResponseWriter, r *http.Request) {},
+			nil,
+		},
+		{
+			"update recorded with path id",
+			http.MethodPut,
+			"/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			"req-1",
+			"alice",
+			func(w http.ResponseWriter, r *http.Request) {},
+			[]Entry{
+				{
+					OccurredAt: now,
+					Actor:      "alice",
+					Operation:  "user:update",
+					Resource:   "PUT /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					RequestID:  "req-1",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomePending,
+				},
+				{
+					OccurredAt: now,
+					Actor:      "alice",
+					Operation:  "user:update",
+					Resource:   "PUT /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					RequestID:  "req-1",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomeSuccess,
+					Status:     "200",
+				},
+			},
+		},
+		{
+			"denied",
+			http.MethodPut,
+			"/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			"req-2",
+			"bob",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			[]Entry{
+				{
+					OccurredAt: now,
+					Actor:      "bob",
+					Operation:  "user:update",
+					Resource:   "PUT /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					RequestID:  "req-2",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomePending,
+				},
+				{
+					OccurredAt: now,
+					Actor:      "bob",
+					Operation:  "user:update",
+					Resource:   "PUT /user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					TargetID:   "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					RequestID:  "req-2",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomeDenied,
+					Status:     "403",
+				},
+			},
+		},
+		{
+			"create recorded with added targets",
+			http.MethodPost,
+			"/user",
+			"req-3",
+			"",
+			func(w http.ResponseWriter, r *http.Request) {
+				AddTargets(r.Context(), "id-1", "id-2")
+				w.WriteHeader(http.StatusCreated)
+			},
+			[]Entry{
+				{
+					OccurredAt: now,
+					Operation:  "user:update",
+					Resource:   "POST /user",
+					RequestID:  "req-3",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomePending,
+				},
+				{
+					OccurredAt: now,
+					Operation:  "user:update",
+					Resource:   "POST /user",
+					TargetID:   "id-1",
+					RequestID:  "req-3",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomeSuccess,
+					Status:     "201",
+				},
+				{
+					OccurredAt: now,
+					Operation:  "user:update",
+					Resource:   "POST /user",
+					TargetID:   "id-2",
+					RequestID:  "req-3",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomeSuccess,
+					Status:     "201",
+				},
+			},
+		},
+		{
+			"failure",
+			http.MethodPost,
+			"/user",
+			"req-4",
+			"alice",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			},
+			[]Entry{
+				{
+					OccurredAt: now,
+					Actor:      "alice",
+					Operation:  "user:update",
+					Resource:   "POST /user",
+					RequestID:  "req-4",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomePending,
+				},
+				{
+					OccurredAt: now,
+					Actor:      "alice",
+					Operation:  "user:update",
+					Resource:   "POST /user",
+					RequestID:  "req-4",
+					ClientIP:   "10.0.0.1",
+					Outcome:    OutcomeFailure,
+					Status:     "400",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			appender := &appenderMock{}
+
+			recorder := NewRecorder(auditConf, appender, nil, loggerMock{})
+			recorder.now = func() time.Time { return now }
+
+			router := mux.NewRouter()
+			router.Handle("/user", recorder.Middleware("user:update")(c.handler))
+			router.Handle("/user/{id}", recorder.Middleware("user:update")(c.handler))
+
+			req := httptest.NewRequest(c.method, c.path, nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+
+			if c.requestID != "" {
+				req.Header.Set("X-Request-ID", c.requestID)
+			}
+
+			if c.subject != "" {
+				req = req.WithContext(auth.NewContext(req.Context(), auth.Claims{Subject: c.subject}))
+			}
+
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, c.expectedEntries, appender.entries)
+			assert.Equal(t, c.requestID, rec.Header().Get("X-Request-ID"))
+		})
+	}
+}
+
+func TestRecorder_Middleware_GeneratesRequestID(t *testing.T) {
+	appender := &appenderMock{}
+
+	handler := NewRecorder(auditConf, appender, nil, loggerMock{}).Middleware("user:delete")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/user/1", nil))
+
+	require.Len(t, appender.entries, 2)
+	assert.NotEmpty(t, appender.entries[0].RequestID)
+	assert.Equal(t, appender.entries[0].RequestID, appender.entries[1].RequestID)
+	assert.Equal(t, appender.entries[0].RequestID, rec.Header().Get("X-Request-ID"))
+}
+
+type appenderMockError struct{}
+
+func (m appenderMockError) AppendEntries(context.Context, ...Entry) error {
+	return errors.New("append error")
+}
+
+func TestRecorder_Middleware_AppendFailed(t *testing.T) {
+	var handled bool
+
+	handler := NewRecorder(auditConf, appenderMockError{}, nil, loggerMock{}).Middleware("user:delete")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handled = true
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/user/1", nil))
+
+	assert.False(t, handled)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+type idRequest struct {
+	id string
+}
+
+func (r idRequest) GetId() string {
+	return r.id
+}
+
+func TestRecorder_UnaryInterceptor(t *testing.T) {
+	cases := []struct {
+		name            string
+		method          string
+		req             interface{}
+		handlerErr      error
+		expectedEntries int
+		expectedOutcome string
+		expectedStatus  string
+		expectedTarget  string
+	}{
+		{
+			"read not recorded",
+			"/User/Read",
+			idRequest{"id-1"},
+			nil,
+			0,
+			"",
+			"",
+			"",
+		},
+		{
+			"delete recorded with request id",
+			"/User/Delete",
+			idRequest{"id-1"},
+			nil,
+			2,
+			OutcomeSuccess,
+			"OK",
+			"id-1",
+		},
+		{
+			"denied",
+			"/User/Delete",
+			idRequest{"id-1"},
+			status.Error(codes.PermissionDenied, "permission denied"),
+			2,
+			OutcomeDenied,
+			"PermissionDenied",
+			"id-1",
+		},
+		{
+			"failure",
+			"/User/Delete",
+			idRequest{"id-1"},
+			errors.New("handler error"),
+			2,
+			OutcomeFailure,
+			"Unknown",
+			"id-1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			appender := &appenderMock{}
+
+			recorder := NewRecorder(
+				auditConf,
+				appender,
+				map[string]string{"/User/Delete": "user:delete"},
+				loggerMock{},
+			)
+
+			addr, _ := net.ResolveTCPAddr("tcp", "10.0.0.1:1234")
+
+			ctx := peer.NewContext(
+				metadata.NewIncomingContext(
+					context.Background(),
+					metadata.Pairs("x-request-id", "req-1"),
+				),
+				&peer.Peer{Addr: addr},
+			)
+
+			_, err := recorder.UnaryInterceptor(
+				ctx,
+				c.req,
+				&grpc.UnaryServerInfo{FullMethod: c.method},
+				func(context.Context, interface{}) (interface{}, error) {
+					return nil, c.handlerErr
+				},
+			)
+
+			assert.Equal(t, c.handlerErr, err)
+			require.Len(t, appender.entries, c.expectedEntries)
+
+			if c.expectedEntries == 0 {
+				return
+			}
+
+			assert.Equal(t, OutcomePending, appender.entries[0].Outcome)
+			assert.Empty(t, appender.entries[0].Status)
+
+			e := appender.entries[1]
+
+			assert.Equal(t, "user:delete", e.Operation)
+			assert.Equal(t, c.method, e.Resource)
+			assert.Equal(t, c.expectedTarget, e.TargetID)
+			assert.Equal(t, "req-1", e.RequestID)
+			assert.Equal(t, "10.0.0.1", e.ClientIP)
+			assert.Equal(t, c.expectedOutcome, e.Outcome)
+			assert.Equal(t, c.expectedStatus, e.Status)
+		})
+	}
+}
+
+func TestRecorder_UnaryInterceptor_AppendFailed(t *testing.T) {
+	var handled bool
+
+	recorder := NewRecorder(
+		auditConf,
+		appenderMockError{},
+		map[string]string{"/User/Delete": "user:delete"},
+		loggerMock{},
+	)
+
+	_, err := recorder.UnaryInterceptor(
+		context.Background(),
+		idRequest{"id-1"},
+		&grpc.UnaryServerInfo{FullMethod: "/User/Delete"},
+		func(context.Context, interface{}) (interface{}, error) {
+			handled = true
+			return nil, nil
+		},
+	)
+
+	assert.False(t, handled)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/grpcutil"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type verifier interface {
+	Verify(ctx context.Context, token string) (Claims, error)
+}
+
+type a struct {
+	verifier          verifier
+	logger            log.Logger
+	tenantClaim       string
+	tenantHeaderRoles []string
+	exemptHTTP        []string
+	exemptGRPC        []string
+}
+
+// NewAuthenticator returns an authenticator that requires requests, other
+// than those for exempt HTTP paths and gRPC methods, to carry a bearer
+// token that is valid according to verifier. Exemptions ending in * match
+// by prefix (e.g., /debug/pprof/*).
+func NewAuthenticator(
+	conf config.Auth,
+	verifier verifier,
+	logger log.Logger,
+) *a {
+	return &a{
+		verifier:          verifier,
+		logger:            logger,
+		tenantClaim:       conf.TenantClaim,
+		tenantHeaderRoles: conf.TenantHeaderRoles,
+		exemptHTTP:        conf.ExemptHTTPPaths,
+		exemptGRPC:        conf.ExemptGRPCMethods,
+	}
+}
+
+func exempt(exemptions []string, name string) bool {
+	for _, e := range exemptions {
+		if prefix, ok := strings.CutSuffix(e, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+
+			continue
+		}
+
+		if e == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skip reports whether the request is exempt, or has already been
+// authenticated by other means (e.g., with an API key).
+func (a *a) skip(
+	ctx context.Context,
+	exemptions []string,
+	name string,
+) bool {
+	if _, ok := FromContext(ctx); ok {
+		return true
+	}
+
+	return exempt(exemptions, name)
+}
+
+// authenticate verifies the token and returns a copy of ctx carrying the
+// claims, the subject for logging and the tenant from the tenant claim.
+// Tokens without the claim are rejected unless they have a role permitting
+// the tenant to be chosen with the header, as the header is otherwise
+// chosen by the caller.
+func (a *a) authenticate(
+	ctx context.Context,
+	authorization string,
+) (context.Context, error) {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return nil, ErrTokenMissing
+	}
+
+	claims, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = NewContext(ctx, claims)
+	ctx = log.WithSubject(ctx, claims.Subject)
+
+	if a.tenantClaim != "" {
+		if tenantID := claims.String(a.tenantClaim); tenantID != "" {
+			return tenant.NewContext(ctx, tenantID), nil
+		}
+	}
+
+	for _, role := range a.tenantHeaderRoles {
+		if claims.HasRole(role) {
+			return ctx, nil
+		}
+	}
+
+	return nil, ErrTenantMissing
+}
+
+// unauthenticated reports whether err results from the token, rather than
+// from a failure to verify it (e.g., because a JWKS could not be fetched).
+func unauthenticated(err error) bool {
+	return errors.Is(err, ErrTokenMissing) ||
+		errors.Is(err, ErrTokenInvalid) ||
+		errors.Is(err, ErrTokenExpired)
+}
+
+// Middleware authenticates HTTP requests, responding with 401 Unauthorized
+// if the token is missing or invalid.
+func (a *a) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.skip(r.Context(), a.exemptHTTP, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := a.authenticate(r.Context(), r.Header.Get("Authorization"))
+
+		switch {
+		case err == nil:
+			next.ServeHTTP(w, r.WithContext(ctx))
+		case errors.Is(err, ErrTenantMissing):
+			a.logger.InfofContext(r.Context(), "authentication failed: %s %s: %s", r.Method, r.URL.Path, err)
+
+			response.WriteErrorResponse(
+				w,
+				http.StatusForbidden,
+				err.Error(),
+				nil,
+			)
+		case unauthenticated(err):
+			a.logger.InfofContext(r.Context(), "authentication failed: %s %s: %s", r.Method, r.URL.Path, err)
+
+			challenge := "Bearer"
+			if !errors.Is(err, ErrTokenMissing) {
+				challenge = `Bearer error="invalid_token"`
+			}
+
+			w.Header().Set("WWW-Authenticate", challenge)
+
+			response.WriteErrorResponse(
+				w,
+				http.StatusUnauthorized,
+				err.Error(),
+				nil,
+			)
+		default:
+			a.logger.ErrorContext(r.Context(), err)
+			response.Write500Response(w)
+		}
+	})
+}
+
+func (a *a) grpcContext(ctx context.Context) (context.Context, error) {
+	var authorization string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			authorization = v[0]
+		}
+	}
+
+	authCtx, err := a.authenticate(ctx, authorization)
+
+	switch {
+	case err == nil:
+		return authCtx, nil
+	case errors.Is(err, ErrTenantMissing):
+		a.logger.InfofContext(ctx, "authentication failed: %s", err)
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case unauthenticated(err):
+		a.logger.InfofContext(ctx, "authentication failed: %s", err)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	default:
+		a.logger.ErrorContext(ctx, err)
+		return nil, status.Error(codes.Internal, "authentication failed")
+	}
+}
+
+// UnaryInterceptor authenticates unary gRPC calls using the bearer token
+// in the authorization metadata.
+func (a *a) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if a.skip(ctx, a.exemptGRPC, info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	ctx, err := a.grpcContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamInterceptor authenticates streaming gRPC calls using the bearer
+// token in the authorization metadata.
+func (a *a) StreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if a.skip(ss.Context(), a.exemptGRPC, info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	ctx, err := a.grpcContext(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, grpcutil.NewServerStream(ctx, ss))
+}
@@ -0,0 +1,343 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type loggerMock struct{}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+type verifierMock struct {
+	claims Claims
+	err    error
+}
+
+func (m verifierMock) Verify(context.Context, string) (Claims, error) {
+	return m.claims, m.err
+}
+
+var authConf = config.Auth{
+	TenantClaim:       "tenant_id",
+	TenantHeaderRoles: []string{"admin"},
+	ExemptHTTPPaths:   []string{"/", "/debug/pprof/*"},
+	ExemptGRPCMethods: []string{"/grpc.*"},
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	claims := Claims{
+		Subject: "client-1",
+		Raw: map[string]interface{}{
+			"sub":       "client-1",
+			"tenant_id": "acme",
+		},
+	}
+
+	cases := []struct {
+		name              string
+		verifier          verifierMock
+		path              string
+		authorization     string
+		expectedStatus    int
+		expectedChallenge string
+		expectedSubject   string
+		expectedTenantID  string
+	}{
+		{
+			"exempt path",
+			verifierMock{err: ErrTokenInvalid},
+			"/",
+			"",
+			http.StatusOK,
+			"",
+			"",
+			"",
+		},
+		{
+			"exempt prefix",
+			verifierMock{err: ErrTokenInvalid},
+			"/debug/pprof/profile",
+			"",
+			http.StatusOK,
+			"",
+			"",
+			"",
+		},
+		{
+			"token missing",
+			verifierMock{claims: claims},
+			"/user",
+			"",
+			http.StatusUnauthorized,
+			"Bearer",
+			"",
+			"",
+		},
+		{
+			"not a bearer token",
+			verifierMock{claims: claims},
+			"/user",
+			"Basic dXNlcjpwYXNz",
+			http.StatusUnauthorized,
+			"Bearer",
+			"",
+			"",
+		},
+		{
+			"token invalid",
+			verifierMock{err: ErrTokenInvalid},
+			"/user",
+			"Bearer token",
+			http.StatusUnauthorized,
+			`Bearer error="invalid_token"`,
+			"",
+			"",
+		},
+		{
+			"token expired",
+			verifierMock{err: ErrTokenExpired},
+			"/user",
+			"Bearer token",
+			http.StatusUnauthorized,
+			`Bearer error="invalid_token"`,
+			"",
+			"",
+		},
+		{
+			"verification error",
+			verifierMock{err: errors.New("jwks: status: 503")},
+			"/user",
+			"Bearer token",
+			http.StatusInternalServerError,
+			"",
+			"",
+			"",
+		},
+		{
+			"tenant missing",
+			verifierMock{claims: Claims{Subject: "client-1", Roles: []string{"editor"}}},
+			"/user",
+			"Bearer token",
+			http.StatusForbidden,
+			"",
+			"",
+			"",
+		},
+		{
+			"tenant header role",
+			verifierMock{claims: Claims{Subject: "operator", Roles: []string{"admin"}}},
+			"/user",
+			"Bearer token",
+			http.StatusOK,
+			"",
+			"operator",
+			"",
+		},
+		{
+			"success",
+			verifierMock{claims: claims},
+			"/user",
+			"Bearer token",
+			http.StatusOK,
+			"",
+			"client-1",
+			"acme",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				subject  string
+				tenantID string
+			)
+
+			handler := NewAuthenticator(authConf, c.verifier, loggerMock{}).Middleware(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					claims, _ := FromContext(r.Context())
+					subject = claims.Subject
+					tenantID, _ = tenant.FromContext(r.Context())
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, c.path, nil)
+
+			if c.authorization != "" {
+				req.Header.Set("Authorization", c.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, c.expectedStatus, rec.Code)
+			assert.Equal(t, c.expectedChallenge, rec.Header().Get("WWW-Authenticate"))
+			assert.Equal(t, c.expectedSubject, subject)
+			assert.Equal(t, c.expectedTenantID, tenantID)
+		})
+	}
+}
+
+func TestAuthenticator_UnaryInterceptor(t *testing.T) {
+	claims := Claims{
+		Subject: "client-1",
+		Raw: map[string]interface{}{
+			"sub":       "client-1",
+			"tenant_id": "acme",
+		},
+	}
+
+	cases := []struct {
+		name            string
+		verifier        verifierMock
+		method          string
+		md              metadata.MD
+		expectedCode    codes.Code
+		expectedSubject string
+	}{
+		{
+			"exempt method",
+			verifierMock{err: ErrTokenInvalid},
+			"/grpc.health.v1.Health/Check",
+			nil,
+			codes.OK,
+			"",
+		},
+		{
+			"token missing",
+			verifierMock{claims: Claims{Subject: "client-1"}},
+			"/user.User/Read",
+			nil,
+			codes.Unauthenticated,
+			"",
+		},
+		{
+			"token invalid",
+			verifierMock{err: ErrTokenInvalid},
+			"/user.User/Read",
+			metadata.Pairs("authorization", "Bearer token"),
+			codes.Unauthenticated,
+			"",
+		},
+		{
+			"verification error",
+			verifierMock{err: errors.New("jwks: status: 503")},
+			"/user.User/Read",
+			metadata.Pairs("authorization", "Bearer token"),
+			codes.Internal,
+			"",
+		},
+		{
+			"tenant missing",
+			verifierMock{claims: Claims{Subject: "client-1"}},
+			"/user.User/Read",
+			metadata.Pairs("authorization", "Bearer token"),
+			codes.PermissionDenied,
+			"",
+		},
+		{
+			"success",
+			verifierMock{claims: claims},
+			"/user.User/Read",
+			metadata.Pairs("authorization", "Bearer token"),
+			codes.OK,
+			"client-1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var subject string
+
+			_, err := NewAuthenticator(authConf, c.verifier, loggerMock{}).UnaryInterceptor(
+				metadata.NewIncomingContext(context.Background(), c.md),
+				nil,
+				&grpc.UnaryServerInfo{FullMethod: c.method},
+				func(ctx context.Context, _ interface{}) (interface{}, error) {
+					claims, _ := FromContext(ctx)
+					subject = claims.Subject
+					return nil, nil
+				},
+			)
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+			assert.Equal(t, c.expectedSubject, subject)
+		})
+	}
+}
+
+type serverStreamMock struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (m *serverStreamMock) Context() context.Context {
+	return m.ctx
+}
+
+func TestAuthenticator_StreamInterceptor(t *testing.T) {
+	var subject string
+
+	err := NewAuthenticator(
+		authConf,
+		verifierMock{claims: Claims{Subject: "client-1", Roles: []string{"admin"}}},
+		loggerMock{},
+	).StreamInterceptor(
+		nil,
+		&serverStreamMock{
+			ctx: metadata.NewIncomingContext(
+				context.Background(),
+				metadata.Pairs("authorization", "Bearer token"),
+			),
+		},
+		&grpc.StreamServerInfo{FullMethod: "/user.User/Watch"},
+		func(_ interface{}, ss grpc.ServerStream) error {
+			claims, _ := FromContext(ss.Context())
+			subject = claims.Subject
+			return nil
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "client-1", subject)
+}
+
+func TestAuthenticator_Middleware_AlreadyAuthenticated(t *testing.T) {
+	var subject string
+
+	handler := NewAuthenticator(authConf, verifierMock{err: ErrTokenInvalid}, loggerMock{}).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, _ := FromContext(r.Context())
+			subject = claims.Subject
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req = req.WithContext(NewContext(req.Context(), Claims{Subject: "apikey:key-1"}))
+
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "apikey:key-1", subject)
+}
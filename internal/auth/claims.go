@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Claims are the verified claims of a token. Scopes are read from the
+// space separated scope claim or the scp array claim, and Roles from the
+// roles claim. Raw holds all claims, including those not mapped to a
+// field.
+type Claims struct {
+	ExpiresAt time.Time
+	NotBefore time.Time
+	Raw       map[string]interface{}
+	Subject   string
+	Issuer    string
+	Audience  []string
+	Scopes    []string
+	Roles     []string
+}
+
+// String returns the claim with the name if it is a string.
+func (c Claims) String(name string) string {
+	s, _ := c.Raw[name].(string)
+
+	return s
+}
+
+// HasScope reports whether the claims include the scope.
+func (c Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// HasRole reports whether the claims include the role.
+func (c Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the claims.
+func NewContext(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, ctxKey{}, claims)
+}
+
+// FromContext returns the claims carried by ctx, if any.
+func FromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(ctxKey{}).(Claims)
+
+	return claims, ok
+}
+
+// newClaims maps the registered claims (RFC 7519), together with scope,
+// scp and roles, to Claims. Registered claims of the wrong type are
+// rejected rather than ignored.
+func newClaims(raw map[string]interface{}) (Claims, error) {
+	c := Claims{
+		Raw: raw,
+	}
+
+	var err error
+
+	if c.Subject, err = stringClaim(raw, "sub"); err != nil {
+		return Claims{}, err
+	}
+
+	if c.Issuer, err = stringClaim(raw, "iss"); err != nil {
+		return Claims{}, err
+	}
+
+	if c.Audience, err = stringsClaim(raw, "aud"); err != nil {
+		return Claims{}, err
+	}
+
+	if c.ExpiresAt, err = timeClaim(raw, "exp"); err != nil {
+		return Claims{}, err
+	}
+
+	if c.NotBefore, err = timeClaim(raw, "nbf"); err != nil {
+		return Claims{}, err
+	}
+
+	if c.Roles, err = stringsClaim(raw, "roles"); err != nil {
+		return Claims{}, err
+	}
+
+	scope, err := stringClaim(raw, "scope")
+	if err != nil {
+		return Claims{}, err
+	}
+
+	c.Scopes = strings.Fields(scope)
+
+	scp, err := stringsClaim(raw, "scp")
+	if err != nil {
+		return Claims{}, err
+	}
+
+	c.Scopes = append(c.Scopes, scp...)
+
+	return c, nil
+}
+
+func stringClaim(raw map[string]interface{}, name string) (string, error) {
+	v, ok := raw[name]
+	if !ok {
+		return "", nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", claimError(name)
+	}
+
+	return s, nil
+}
+
+// stringsClaim returns the claim with the name, which may be either a
+// string or an array of strings (e.g., aud).
+func stringsClaim(raw map[string]interface{}, name string) ([]string, error) {
+	switch v := raw[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		ss := make([]string, 0, len(v))
+
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, claimError(name)
+			}
+
+			ss = append(ss, s)
+		}
+
+		return ss, nil
+	default:
+		return nil, claimError(name)
+	}
+}
+
+// timeClaim returns the claim with the name, which must be a NumericDate
+// (i.e., seconds since the epoch), as a time.
+func timeClaim(raw map[string]interface{}, name string) (time.Time, error) {
+	v, ok := raw[name]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, claimError(name)
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, claimError(name)
+	}
+
+	return time.UnixMilli(int64(f * 1000)), nil
+}
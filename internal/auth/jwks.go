@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits how often a JWKS is fetched when tokens carry
+// unknown key IDs, so that such tokens cannot be used to flood the source.
+const minRefreshInterval = 10 * time.Second
+
+// maxRetryInterval limits the backoff between fetches after failures, so
+// that keys are refreshed soon after the source recovers.
+const maxRetryInterval = 5 * time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+type jwkKey struct {
+	key interface{}
+	alg string
+}
+
+type jwks struct {
+	now             func() time.Time
+	client          *http.Client
+	keys            map[string]jwkKey
+	fetchedAt       time.Time
+	retryAt         time.Time
+	err             error
+	inflight        *call
+	source          string
+	refreshInterval time.Duration
+	failures        int
+	mu              sync.Mutex
+}
+
+// call is a fetch of the key set that is shared by the callers waiting on
+// it. err is set before done is closed.
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS returns a KeySet holding the RSA (RS256) and symmetric (HS256)
+// keys of the JSON Web Key Set at source, which is either an http(s) URL
+// or a file path. Keys are cached for refreshInterval, and are refetched
+// sooner if a token carries an unknown key ID (e.g., after key rotation).
+// If a fetch fails, the cached keys continue to be used, and fetching is
+// retried with exponential backoff rather than on every call.
+func NewJWKS(
+	source string,
+	refreshInterval time.Duration,
+	client *http.Client,
+) *jwks {
+	return &jwks{
+		now:             time.Now,
+		client:          client,
+		source:          source,
+		refreshInterval: refreshInterval,
+	}
+}
+
+func (j *jwks) Key(
+	ctx context.Context,
+	kid string,
+	alg string,
+) (interface{}, error) {
+	j.mu.Lock()
+
+	k, ok := j.keys[kid]
+	now := j.now()
+
+	due := j.keys == nil ||
+		now.Sub(j.fetchedAt) >= j.refreshInterval ||
+		!ok && now.Sub(j.fetchedAt) >= minRefreshInterval
+
+	var c *call
+
+	if due && (j.inflight != nil || !now.Before(j.retryAt)) {
+		c = j.refresh(ctx)
+	}
+
+	cached := j.keys != nil
+	err := j.err
+
+	j.mu.Unlock()
+
+	if c != nil {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		j.mu.Lock()
+		k, ok = j.keys[kid]
+		cached = j.keys != nil
+		err = c.err
+		j.mu.Unlock()
+	}
+
+	// Stale keys are used when a fetch fails, so an error is only returned
+	// if no keys have been fetched.
+	if !cached && err != nil {
+		return nil, err
+	}
+
+	if !ok || k.alg != alg {
+		return nil, ErrKeyNotFound
+	}
+
+	return k.key, nil
+}
+
+// refresh starts a fetch of the key set, unless one is in flight, and
+// returns the call on which callers wait. The fetch is shared, so it is not
+// cancelled with ctx, but is bounded by the client timeout. Cached keys are
+// retained on failure. The caller must hold the lock.
+func (j *jwks) refresh(ctx context.Context) *call {
+	if j.inflight != nil {
+		return j.inflight
+	}
+
+	c := &call{
+		done: make(chan struct{}),
+	}
+
+	j.inflight = c
+
+	go func() {
+		keys, err := j.load(context.WithoutCancel(ctx))
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		if err != nil {
+			j.failures++
+			j.retryAt = j.now().Add(j.retryBackoff())
+		} else {
+			j.keys = keys
+			j.fetchedAt = j.now()
+			j.failures = 0
+			j.retryAt = time.Time{}
+		}
+
+		j.err = err
+		j.inflight = nil
+
+		c.err = err
+		close(c.done)
+	}()
+
+	return c
+}
+
+// retryBackoff returns minRefreshInterval doubled for each consecutive
+// failure after the first, limited to maxRetryInterval.
+func (j *jwks) retryBackoff() time.Duration {
+	b := minRefreshInterval
+
+	for n := 1; n < j.failures && b < maxRetryInterval; n++ {
+		b *= 2
+	}
+
+	return min(b, maxRetryInterval)
+}
+
+// load fetches and parses the key set.
+func (j *jwks) load(ctx context.Context) (map[string]jwkKey, error) {
+	b, err := j.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make(map[string]jwkKey, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.parse()
+		if err != nil {
+			return nil, fmt.Errorf("jwks: kid %q: %w", k.Kid, err)
+		}
+
+		// Keys of unsupported types (e.g., EC) are skipped.
+		if key.key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+func (j *jwks) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(j.source, "http://") && !strings.HasPrefix(j.source, "https://") {
+		return os.ReadFile(strings.TrimPrefix(j.source, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parse returns the key, and the algorithm with which it is used. The key
+// is nil if the key type is not supported.
+func (k jwk) parse() (jwkKey, error) {
+	switch k.Kty {
+	case "RSA":
+		if k.Alg != "" && k.Alg != AlgRS256 {
+			return jwkKey{}, nil
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return jwkKey{}, fmt.Errorf("n: %w", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return jwkKey{}, fmt.Errorf("e: %w", err)
+		}
+
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return jwkKey{}, fmt.Errorf("e: out of range")
+		}
+
+		return jwkKey{
+			key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(exp.Int64()),
+			},
+			alg: AlgRS256,
+		}, nil
+	case "oct":
+		if k.Alg != "" && k.Alg != AlgHS256 {
+			return jwkKey{}, nil
+		}
+
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return jwkKey{}, fmt.Errorf("k: %w", err)
+		}
+
+		return jwkKey{
+			key: secret,
+			alg: AlgHS256,
+		}, nil
+	default:
+		return jwkKey{}, nil
+	}
+}
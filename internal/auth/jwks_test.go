@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func jwksJSON(t *testing.T, keys ...map[string]string) []byte {
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+
+	return b
+}
+
+func TestJWKS_Key_File(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+
+	require.NoError(t, os.WriteFile(path, jwksJSON(t,
+		rsaJWK("rsa-1", &rsaKey.PublicKey),
+		map[string]string{
+			"kty": "oct",
+			"kid": "oct-1",
+			"k":   base64.RawURLEncoding.EncodeToString(secret),
+		},
+		map[string]string{
+			"kty": "EC",
+			"kid": "ec-1",
+		},
+	), 0o600))
+
+	keys := NewJWKS(path, time.Hour, http.DefaultClient)
+
+	cases := []struct {
+		name        string
+		kid         string
+		alg         string
+		expectedKey interface{}
+		expectedErr error
+	}{
+		{
+			"rsa",
+			"rsa-1",
+			AlgRS256,
+			&rsaKey.PublicKey,
+			nil,
+		},
+		{
+			"oct",
+			"oct-1",
+			AlgHS256,
+			secret,
+			nil,
+		},
+		{
+			"algorithm mismatch",
+			"rsa-1",
+			AlgHS256,
+			nil,
+			ErrKeyNotFound,
+		},
+		{
+			"unsupported key type",
+			"ec-1",
+			AlgRS256,
+			nil,
+			ErrKeyNotFound,
+		},
+		{
+			"unknown kid",
+			"rsa-2",
+			AlgRS256,
+			nil,
+			ErrKeyNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			key, err := keys.Key(context.Background(), c.kid, c.alg)
+
+			assert.ErrorIs(t, err, c.expectedErr)
+			assert.Equal(t, c.expectedKey, key)
+		})
+	}
+}
+
+func TestJWKS_Key_URL(t *testing.T) {
+	rsaKey1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rsaKey2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var (
+		fetches atomic.Int32
+		rotated atomic.Bool
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+
+		keys := []map[string]string{rsaJWK("rsa-1", &rsaKey1.PublicKey)}
+
+		if rotated.Load() {
+			keys = append(keys, rsaJWK("rsa-2", &rsaKey2.PublicKey))
+		}
+
+		_, _ = w.Write(jwksJSON(t, keys...))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+
+	keys := NewJWKS(srv.URL, time.Hour, srv.Client())
+	keys.now = func() time.Time { return now }
+
+	key, err := keys.Key(context.Background(), "rsa-1", AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, &rsaKey1.PublicKey, key)
+
+	// Cached keys are used until the refresh interval has elapsed.
+	_, err = keys.Key(context.Background(), "rsa-1", AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	rotated.Store(true)
+
+	// Unknown key IDs do not trigger a refresh within the minimum
+	// refresh interval.
+	_, err = keys.Key(context.Background(), "rsa-2", AlgRS256)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now = now.Add(minRefreshInterval)
+
+	key, err = keys.Key(context.Background(), "rsa-2", AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, &rsaKey2.PublicKey, key)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWKS_Key_URLError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := NewJWKS(srv.URL, time.Hour, srv.Client()).Key(context.Background(), "rsa-1", AlgRS256)
+
+	assert.EqualError(t, err, "jwks: status: 503")
+}
+
+func TestJWKS_Key_URLErrorAfterExpiry(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var (
+		fetches atomic.Int32
+		failing atomic.Bool
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write(jwksJSON(t, rsaJWK("rsa-1", &rsaKey.PublicKey)))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+
+	keys := NewJWKS(srv.URL, time.Hour, srv.Client())
+	keys.now = func() time.Time { return now }
+
+	_, err = keys.Key(context.Background(), "rsa-1", AlgRS256)
+	require.NoError(t, err)
+
+	failing.Store(true)
+
+	// The stale key is used once the cache expires and the fetch fails.
+	now = now.Add(time.Hour)
+
+	key, err := keys.Key(context.Background(), "rsa-1", AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, key)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	// Fetches are retried with exponential backoff rather than on every
+	// call.
+	for _, c := range []struct {
+		advance time.Duration
+		fetches int32
+	}{
+		{0, 2},
+		{minRefreshInterval, 3},
+		{minRefreshInterval, 3},
+		{minRefreshInterval, 4},
+		{2 * minRefreshInterval, 4},
+		{2 * minRefreshInterval, 5},
+	} {
+		now = now.Add(c.advance)
+
+		key, err = keys.Key(context.Background(), "rsa-1", AlgRS256)
+		require.NoError(t, err)
+		assert.Equal(t, &rsaKey.PublicKey, key)
+		assert.Equal(t, c.fetches, fetches.Load())
+	}
+
+	// Once the source recovers, the keys are refreshed when the retry is
+	// due, and cached again.
+	failing.Store(false)
+	now = now.Add(8 * minRefreshInterval)
+
+	_, err = keys.Key(context.Background(), "rsa-1", AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, int32(6), fetches.Load())
+
+	now = now.Add(minRefreshInterval)
+
+	_, err = keys.Key(context.Background(), "rsa-1", AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, int32(6), fetches.Load())
+}
+
+func TestJWKS_Key_ConcurrentFetch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+
+		_, _ = w.Write(jwksJSON(t, rsaJWK("rsa-1", &rsaKey.PublicKey)))
+	}))
+	defer srv.Close()
+
+	keys := NewJWKS(srv.URL, time.Hour, srv.Client())
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			key, err := keys.Key(context.Background(), "rsa-1", AlgRS256)
+			assert.NoError(t, err)
+			assert.Equal(t, &rsaKey.PublicKey, key)
+		}()
+	}
+
+	// Callers share the fetch in flight, which is made without holding the
+	// lock, so a caller that gives up waiting is not blocked.
+	require.Eventually(t, func() bool {
+		return fetches.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = keys.Key(ctx, "rsa-1", AlgRS256)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fetches.Load())
+}
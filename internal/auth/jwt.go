@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+var (
+	// ErrTokenMissing is returned when a request carries no bearer token.
+	ErrTokenMissing = errors.New("token missing")
+	// ErrTokenInvalid is returned when a token is malformed, has an
+	// invalid signature or has claims that do not match those expected.
+	ErrTokenInvalid = errors.New("token invalid")
+	// ErrTokenExpired is returned when a token has expired or is not yet
+	// valid.
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTenantMissing is returned when a token has no tenant claim and
+	// none of the roles permitted to choose the tenant with the header.
+	ErrTenantMissing = errors.New("token tenant missing")
+	// ErrKeyNotFound is returned by a KeySet that holds no key with the
+	// key ID for the algorithm.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+func claimError(name string) error {
+	return fmt.Errorf("%w: claim %s", ErrTokenInvalid, name)
+}
+
+// KeySet returns the key with which to verify tokens signed using alg with
+// the key ID, which is empty if the token header has no kid. Keys are
+// []byte for HS256 and *rsa.PublicKey for RS256. ErrKeyNotFound is returned
+// if there is no such key.
+type KeySet interface {
+	Key(ctx context.Context, kid string, alg string) (interface{}, error)
+}
+
+// KeySets returns the key from the first KeySet that holds a key with the
+// key ID for the algorithm.
+type KeySets []KeySet
+
+func (ks KeySets) Key(
+	ctx context.Context,
+	kid string,
+	alg string,
+) (interface{}, error) {
+	for _, k := range ks {
+		key, err := k.Key(ctx, kid, alg)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+
+		return key, err
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+type hmacKey []byte
+
+// NewHMACKey returns a KeySet holding secret, for verifying HS256 tokens
+// with any key ID.
+func NewHMACKey(secret []byte) KeySet {
+	return hmacKey(secret)
+}
+
+func (k hmacKey) Key(
+	_ context.Context,
+	_ string,
+	alg string,
+) (interface{}, error) {
+	if alg != AlgHS256 || len(k) == 0 {
+		return nil, ErrKeyNotFound
+	}
+
+	return []byte(k), nil
+}
+
+type v struct {
+	keys     KeySet
+	now      func() time.Time
+	issuer   string
+	audience string
+	leeway   time.Duration
+}
+
+// NewVerifier returns a verifier for tokens signed with keys from keys.
+// Tokens must have an exp claim, and must have iss and aud claims that
+// match issuer and audience unless these are empty. Leeway allows for
+// clock skew when checking exp and nbf.
+func NewVerifier(
+	keys KeySet,
+	issuer string,
+	audience string,
+	leeway time.Duration,
+) *v {
+	return &v{
+		keys:     keys,
+		now:      time.Now,
+		issuer:   issuer,
+		audience: audience,
+		leeway:   leeway,
+	}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify returns the claims of the compact serialised token if its
+// signature and claims are valid. Errors wrap ErrTokenInvalid or
+// ErrTokenExpired, other than errors from the KeySet (e.g., when a JWKS
+// cannot be fetched).
+func (v *v) Verify(
+	ctx context.Context,
+	token string,
+) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("%w: malformed", ErrTokenInvalid)
+	}
+
+	h := header{}
+
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Claims{}, fmt.Errorf("%w: header: %s", ErrTokenInvalid, err)
+	}
+
+	// The algorithm is checked before the key is looked up so that, for
+	// example, an RSA public key is never used as an HMAC secret.
+	if h.Alg != AlgHS256 && h.Alg != AlgRS256 {
+		return Claims{}, fmt.Errorf("%w: alg %q not supported", ErrTokenInvalid, h.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: signature: %s", ErrTokenInvalid, err)
+	}
+
+	key, err := v.keys.Key(ctx, h.Kid, h.Alg)
+	if errors.Is(err, ErrKeyNotFound) {
+		return Claims{}, fmt.Errorf("%w: kid %q: %s", ErrTokenInvalid, h.Kid, err)
+	}
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if err = verifySignature(h.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return Claims{}, err
+	}
+
+	raw := map[string]interface{}{}
+
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return Claims{}, fmt.Errorf("%w: claims: %s", ErrTokenInvalid, err)
+	}
+
+	claims, err := newClaims(raw)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if err = v.validate(claims); err != nil {
+		return Claims{}, err
+	}
+
+	return claims, nil
+}
+
+func (v *v) validate(claims Claims) error {
+	now := v.now()
+
+	switch {
+	case claims.ExpiresAt.IsZero():
+		return claimError("exp")
+	case now.After(claims.ExpiresAt.Add(v.leeway)):
+		return ErrTokenExpired
+	case !claims.NotBefore.IsZero() && now.Add(v.leeway).Before(claims.NotBefore):
+		return ErrTokenExpired
+	case v.issuer != "" && claims.Issuer != v.issuer:
+		return claimError("iss")
+	case v.audience != "" && !slices.Contains(claims.Audience, v.audience):
+		return claimError("aud")
+	}
+
+	return nil
+}
+
+func verifySignature(
+	alg string,
+	key interface{},
+	signingInput string,
+	sig []byte,
+) error {
+	switch alg {
+	case AlgHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: key type mismatch", ErrTokenInvalid)
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return fmt.Errorf("%w: signature mismatch", ErrTokenInvalid)
+		}
+	case AlgRS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key type mismatch", ErrTokenInvalid)
+		}
+
+		sum := sha256.Sum256([]byte(signingInput))
+
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig); err != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrTokenInvalid)
+		}
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment into v, decoding
+// numbers as json.Number so that NumericDate claims keep their precision.
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signHS256(t *testing.T, key []byte, hdr map[string]interface{}, claims map[string]interface{}) string {
+	signingInput := encodeSegment(t, hdr) + "." + encodeSegment(t, claims)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signingInput := encodeSegment(t, map[string]interface{}{"alg": AlgRS256, "kid": kid}) +
+		"." + encodeSegment(t, claims)
+
+	sum := sha256.Sum256([]byte(signingInput))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	require.NoError(t, err)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+type keySetMock struct {
+	key interface{}
+	err error
+}
+
+func (m keySetMock) Key(context.Context, string, string) (interface{}, error) {
+	return m.key, m.err
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	hs256 := map[string]interface{}{"alg": AlgHS256}
+
+	validClaims := map[string]interface{}{
+		"sub":   "client-1",
+		"iss":   "https://issuer.example.com",
+		"aud":   []string{"go-api-demo", "other"},
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "user:read user:search",
+		"roles": []string{"reader"},
+	}
+
+	withClaim := func(name string, v interface{}) map[string]interface{} {
+		c := make(map[string]interface{}, len(validClaims))
+
+		for k, v := range validClaims {
+			c[k] = v
+		}
+
+		if v == nil {
+			delete(c, name)
+		} else {
+			c[name] = v
+		}
+
+		return c
+	}
+
+	cases := []struct {
+		name            string
+		keys            KeySet
+		token           string
+		expectedSubject string
+		expectedErr     error
+	}{
+		{
+			"hs256",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, validClaims),
+			"client-1",
+			nil,
+		},
+		{
+			"rs256",
+			keySetMock{key: &rsaKey.PublicKey},
+			signRS256(t, rsaKey, "key-1", validClaims),
+			"client-1",
+			nil,
+		},
+		{
+			"malformed",
+			NewHMACKey(secret),
+			"abc.def",
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"alg none",
+			NewHMACKey(secret),
+			encodeSegment(t, map[string]interface{}{"alg": "none"}) + "." + encodeSegment(t, validClaims) + ".",
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"signature mismatch",
+			NewHMACKey([]byte("another secret")),
+			signHS256(t, secret, hs256, validClaims),
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"rsa public key used as hmac secret",
+			keySetMock{key: &rsaKey.PublicKey},
+			signHS256(t, secret, hs256, validClaims),
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"key not found",
+			NewHMACKey(secret),
+			signRS256(t, rsaKey, "key-1", validClaims),
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"key set error",
+			keySetMock{err: errors.New("jwks: status: 503")},
+			signRS256(t, rsaKey, "key-1", validClaims),
+			"",
+			errors.New("jwks: status: 503"),
+		},
+		{
+			"expired",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, withClaim("exp", now.Add(-2*time.Minute).Unix())),
+			"",
+			ErrTokenExpired,
+		},
+		{
+			"expired within leeway",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, withClaim("exp", now.Add(-30*time.Second).Unix())),
+			"client-1",
+			nil,
+		},
+		{
+			"not yet valid",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, withClaim("nbf", now.Add(2*time.Minute).Unix())),
+			"",
+			ErrTokenExpired,
+		},
+		{
+			"exp missing",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, withClaim("exp", nil)),
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"issuer mismatch",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, withClaim("iss", "https://other.example.com")),
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"audience mismatch",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, withClaim("aud", "other")),
+			"",
+			ErrTokenInvalid,
+		},
+		{
+			"subject of wrong type",
+			NewHMACKey(secret),
+			signHS256(t, secret, hs256, withClaim("sub", 1)),
+			"",
+			ErrTokenInvalid,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v := NewVerifier(c.keys, "https://issuer.example.com", "go-api-demo", time.Minute)
+			v.now = func() time.Time { return now }
+
+			claims, err := v.Verify(context.Background(), c.token)
+
+			switch {
+			case c.expectedErr == nil:
+				assert.NoError(t, err)
+			case errors.Is(c.expectedErr, ErrTokenInvalid), errors.Is(c.expectedErr, ErrTokenExpired):
+				assert.ErrorIs(t, err, c.expectedErr)
+			default:
+				assert.EqualError(t, err, c.expectedErr.Error())
+			}
+
+			assert.Equal(t, c.expectedSubject, claims.Subject)
+		})
+	}
+}
+
+func TestVerifier_Verify_Claims(t *testing.T) {
+	now := time.Now()
+
+	token := signHS256(t, secret, map[string]interface{}{"alg": AlgHS256}, map[string]interface{}{
+		"sub":       "client-1",
+		"aud":       "go-api-demo",
+		"exp":       now.Add(time.Hour).Unix(),
+		"scope":     "user:read",
+		"scp":       []string{"user:search"},
+		"roles":     "reader",
+		"tenant_id": "acme",
+	})
+
+	claims, err := NewVerifier(NewHMACKey(secret), "", "", 0).Verify(context.Background(), token)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"go-api-demo"}, claims.Audience)
+	assert.Equal(t, []string{"user:read", "user:search"}, claims.Scopes)
+	assert.Equal(t, []string{"reader"}, claims.Roles)
+	assert.Equal(t, now.Add(time.Hour).Unix(), claims.ExpiresAt.Unix())
+	assert.Equal(t, "acme", claims.String("tenant_id"))
+	assert.True(t, claims.HasScope("user:search"))
+	assert.False(t, claims.HasScope("user:create"))
+	assert.True(t, claims.HasRole("reader"))
+}
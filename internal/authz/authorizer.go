@@ -0,0 +1,197 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type policy interface {
+	Allows(claims auth.Claims, operation string) bool
+}
+
+// grpcInfraPrefix is the prefix of the full method names of services,
+// such as health and reflection, that are not authorized.
+const grpcInfraPrefix = "/grpc."
+
+type a struct {
+	policy         policy
+	grpcOperations map[string]string
+	appender       audit.Appender
+	now            func() time.Time
+	logger         log.Logger
+}
+
+// NewAuthorizer returns an authorizer that permits requests only if the
+// claims of the authenticated caller are granted the requested operation
+// by policy. grpcOperations maps the full names of gRPC methods to
+// operations; methods that are not mapped are denied, other than those of
+// infrastructure services (e.g., reflection), which are not authorized.
+// Denials are appended to the audit log by appender, if not nil.
+func NewAuthorizer(
+	policy policy,
+	grpcOperations map[string]string,
+	appender audit.Appender,
+	logger log.Logger,
+) *a {
+	return &a{
+		policy,
+		grpcOperations,
+		appender,
+		time.Now,
+		logger,
+	}
+}
+
+// authorize returns an error describing the denial if the caller is not
+// permitted operation. Denials are recorded with the caller and the
+// resource (e.g., the HTTP route or gRPC method) that was requested, and
+// the status with which the request was refused.
+func (a *a) authorize(
+	ctx context.Context,
+	operation string,
+	resource string,
+	status string,
+) error {
+	claims, ok := auth.FromContext(ctx)
+	if ok && a.policy.Allows(claims, operation) {
+		return nil
+	}
+
+	a.deny(ctx, claims, operation, resource, status)
+
+	return fmt.Errorf("permission denied: %s", operation)
+}
+
+// deny logs the denial, and appends it to the audit log unless the call
+// is audited by a recorder, which records the denial as its outcome.
+func (a *a) deny(
+	ctx context.Context,
+	claims auth.Claims,
+	operation string,
+	resource string,
+	status string,
+) {
+	a.logger.InfofContext(
+		ctx,
+		"authorization denied: subject: %q, roles: %v, scopes: %v, operation: %s, resource: %s",
+		claims.Subject,
+		claims.Roles,
+		claims.Scopes,
+		operation,
+		resource,
+	)
+
+	if a.appender == nil || audit.Recorded(ctx) {
+		return
+	}
+
+	e := audit.Entry{
+		OccurredAt: a.now(),
+		Actor:      claims.Subject,
+		Operation:  operation,
+		Resource:   resource,
+		Outcome:    audit.OutcomeDenied,
+		Status:     status,
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		e.TraceID = sc.TraceID().String()
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	if err := a.appender.AppendEntries(ctx, e); err != nil {
+		a.logger.ErrorfContext(ctx, "authz: audit append failed: %s", err)
+	}
+}
+
+// Middleware returns middleware that responds with 403 Forbidden to HTTP
+// requests by callers that are not permitted operation.
+func (a *a) Middleware(operation string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := a.authorize(
+				r.Context(),
+				operation,
+				r.Method+" "+r.URL.Path,
+				strconv.Itoa(http.StatusForbidden),
+			)
+			if err != nil {
+				response.WriteErrorResponse(
+					w,
+					http.StatusForbidden,
+					err.Error(),
+					nil,
+				)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (a *a) grpcAuthorize(ctx context.Context, method string) error {
+	operation, ok := a.grpcOperations[method]
+	if !ok {
+		if strings.HasPrefix(method, grpcInfraPrefix) {
+			return nil
+		}
+
+		claims, _ := auth.FromContext(ctx)
+		a.deny(ctx, claims, "", method, codes.PermissionDenied.String())
+
+		return status.Error(codes.PermissionDenied, "permission denied: method not mapped")
+	}
+
+	if err := a.authorize(ctx, operation, method, codes.PermissionDenied.String()); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return nil
+}
+
+// UnaryInterceptor returns PermissionDenied for unary gRPC calls by
+// callers that are not permitted the operation of the method.
+func (a *a) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := a.grpcAuthorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamInterceptor returns PermissionDenied for streaming gRPC calls by
+// callers that are not permitted the operation of the method.
+func (a *a) StreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := a.grpcAuthorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
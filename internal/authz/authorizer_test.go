@@ -0,0 +1,276 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type loggerMock struct {
+	infos []string
+}
+
+func (lm *loggerMock) Panic(error)                                           {}
+func (lm *loggerMock) Panicf(string, ...interface{})                         {}
+func (lm *loggerMock) Error(error)                                           {}
+func (lm *loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm *loggerMock) Errorf(string, ...interface{})                         {}
+func (lm *loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm *loggerMock) Infof(string, ...interface{})                          {}
+
+func (lm *loggerMock) InfofContext(_ context.Context, msg string, args ...interface{}) {
+	lm.infos = append(lm.infos, fmt.Sprintf(msg, args...))
+}
+
+var viewerPolicy = NewPolicy(
+	map[string][]string{
+		"viewer": {UserRead, UserSearch},
+	},
+	nil,
+)
+
+var grpcOperations = map[string]string{
+	"/User/Create": UserCreate,
+	"/User/Read":   UserRead,
+	"/User/Watch":  UserRead,
+}
+
+func TestAuthorizer_Middleware(t *testing.T) {
+	cases := []struct {
+		name           string
+		ctx            context.Context
+		operation      string
+		expectedStatus int
+		expectedAudit  []string
+	}{
+		{
+			"permitted",
+			auth.NewContext(context.Background(), auth.Claims{Subject: "client-1", Roles: []string{"viewer"}}),
+			UserRead,
+			http.StatusOK,
+			nil,
+		},
+		{
+			"denied",
+			auth.NewContext(context.Background(), auth.Claims{Subject: "client-1", Roles: []string{"viewer"}}),
+			UserCreate,
+			http.StatusForbidden,
+			[]string{
+				`authorization denied: subject: "client-1", roles: [viewer], scopes: [], operation: user:create, resource: POST /user`,
+			},
+		},
+		{
+			"unauthenticated",
+			context.Background(),
+			UserRead,
+			http.StatusForbidden,
+			[]string{
+				`authorization denied: subject: "", roles: [], scopes: [], operation: user:read, resource: POST /user`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			logger := &loggerMock{}
+
+			handler := NewAuthorizer(viewerPolicy, grpcOperations, nil, logger).Middleware(c.operation)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			)
+
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/user", nil).WithContext(c.ctx))
+
+			assert.Equal(t, c.expectedStatus, rec.Code)
+			assert.Equal(t, c.expectedAudit, logger.infos)
+		})
+	}
+}
+
+func TestAuthorizer_UnaryInterceptor(t *testing.T) {
+	viewer := auth.NewContext(context.Background(), auth.Claims{Roles: []string{"viewer"}})
+
+	cases := []struct {
+		name           string
+		ctx            context.Context
+		method         string
+		expectedCode   codes.Code
+		expectedCalled bool
+	}{
+		{
+			"permitted",
+			viewer,
+			"/User/Read",
+			codes.OK,
+			true,
+		},
+		{
+			"denied",
+			viewer,
+			"/User/Create",
+			codes.PermissionDenied,
+			false,
+		},
+		{
+			"infrastructure method not mapped",
+			context.Background(),
+			"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+			codes.OK,
+			true,
+		},
+		{
+			"method not mapped",
+			viewer,
+			"/User/Purge",
+			codes.PermissionDenied,
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			called := false
+
+			_, err := NewAuthorizer(viewerPolicy, grpcOperations, nil, &loggerMock{}).UnaryInterceptor(
+				c.ctx,
+				nil,
+				&grpc.UnaryServerInfo{FullMethod: c.method},
+				func(context.Context, interface{}) (interface{}, error) {
+					called = true
+					return nil, nil
+				},
+			)
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+			assert.Equal(t, c.expectedCalled, called)
+		})
+	}
+}
+
+type serverStreamMock struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (m *serverStreamMock) Context() context.Context {
+	return m.ctx
+}
+
+func TestAuthorizer_StreamInterceptor(t *testing.T) {
+	err := NewAuthorizer(viewerPolicy, grpcOperations, nil, &loggerMock{}).StreamInterceptor(
+		nil,
+		&serverStreamMock{ctx: context.Background()},
+		&grpc.StreamServerInfo{FullMethod: "/User/Watch"},
+		func(interface{}, grpc.ServerStream) error {
+			return nil
+		},
+	)
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+type appenderMock struct {
+	entries []audit.Entry
+}
+
+func (m *appenderMock) AppendEntries(_ context.Context, entries ...audit.Entry) error {
+	m.entries = append(m.entries, entries...)
+	return nil
+}
+
+func TestAuthorizer_AuditsDenials(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	viewer := auth.NewContext(context.Background(), auth.Claims{Subject: "client-1", Roles: []string{"viewer"}})
+
+	cases := []struct {
+		name            string
+		ctx             context.Context
+		method          string
+		expectedEntries []audit.Entry
+	}{
+		{
+			"permitted",
+			viewer,
+			"/User/Read",
+			nil,
+		},
+		{
+			"denied",
+			auth.NewContext(context.Background(), auth.Claims{Subject: "client-2"}),
+			"/User/Read",
+			[]audit.Entry{
+				{
+					OccurredAt: now,
+					Actor:      "client-2",
+					Operation:  UserRead,
+					Resource:   "/User/Read",
+					Outcome:    audit.OutcomeDenied,
+					Status:     "PermissionDenied",
+				},
+			},
+		},
+		{
+			"method not mapped",
+			viewer,
+			"/User/Purge",
+			[]audit.Entry{
+				{
+					OccurredAt: now,
+					Actor:      "client-1",
+					Resource:   "/User/Purge",
+					Outcome:    audit.OutcomeDenied,
+					Status:     "PermissionDenied",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			appender := &appenderMock{}
+
+			authorizer := NewAuthorizer(viewerPolicy, grpcOperations, appender, &loggerMock{})
+			authorizer.now = func() time.Time { return now }
+
+			_, _ = authorizer.UnaryInterceptor(
+				c.ctx,
+				nil,
+				&grpc.UnaryServerInfo{FullMethod: c.method},
+				func(context.Context, interface{}) (interface{}, error) {
+					return nil, nil
+				},
+			)
+
+			assert.Equal(t, c.expectedEntries, appender.entries)
+		})
+	}
+}
+
+func TestAuthorizer_Middleware_AuditsDenials(t *testing.T) {
+	appender := &appenderMock{}
+
+	handler := NewAuthorizer(viewerPolicy, grpcOperations, appender, &loggerMock{}).Middleware(UserRead)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user", nil))
+
+	require.Len(t, appender.entries, 1)
+	assert.Equal(t, UserRead, appender.entries[0].Operation)
+	assert.Equal(t, "GET /user", appender.entries[0].Resource)
+	assert.Equal(t, audit.OutcomeDenied, appender.entries[0].Outcome)
+	assert.Equal(t, "403", appender.entries[0].Status)
+}
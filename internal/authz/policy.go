@@ -0,0 +1,145 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+)
+
+// Operations to which access is controlled.
+const (
+	UserCreate    = "user:create"
+	UserRead      = "user:read"
+	UserSearch    = "user:search"
+	UserUpdate    = "user:update"
+	UserDelete    = "user:delete"
+	UserAdmin     = "user:admin"
+	WebhookManage = "webhook:manage"
+	APIKeyManage  = "apikey:manage"
+	AuditRead     = "audit:read"
+)
+
+// ErrNotGranted is returned when a caller attempts to delegate (e.g., to
+// an API key) a role or scope granting operations that the caller is not
+// granted.
+var ErrNotGranted = errors.New("not granted")
+
+type p struct {
+	roles  map[string][]string
+	scopes map[string][]string
+}
+
+// NewPolicy returns a policy granting the operations mapped to each role
+// and scope. Operations ending in * match by prefix (e.g., user:*).
+func NewPolicy(
+	roles map[string][]string,
+	scopes map[string][]string,
+) *p {
+	return &p{
+		roles,
+		scopes,
+	}
+}
+
+// Allows reports whether any of the roles or scopes in claims grants
+// operation.
+func (p *p) Allows(claims auth.Claims, operation string) bool {
+	for _, role := range claims.Roles {
+		if grants(p.roles[role], operation) {
+			return true
+		}
+	}
+
+	for _, scope := range claims.Scopes {
+		if grants(p.scopes[scope], operation) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Delegates returns ErrNotGranted if any of roles or scopes grants an
+// operation that claims are not granted, so that callers cannot delegate
+// more than they are granted (e.g., issue API keys with the admin role).
+// Roles and scopes missing from the policy may only be delegated by callers
+// holding them, as they may be granted operations once configured.
+func (p *p) Delegates(claims auth.Claims, roles []string, scopes []string) error {
+	var granted []string
+
+	for _, role := range claims.Roles {
+		granted = append(granted, p.roles[role]...)
+	}
+
+	for _, scope := range claims.Scopes {
+		granted = append(granted, p.scopes[scope]...)
+	}
+
+	for _, role := range roles {
+		operations, ok := p.roles[role]
+		if !claims.HasRole(role) && (!ok || !covers(granted, operations)) {
+			return fmt.Errorf("%w: role %s", ErrNotGranted, role)
+		}
+	}
+
+	for _, scope := range scopes {
+		operations, ok := p.scopes[scope]
+		if !claims.HasScope(scope) && (!ok || !covers(granted, operations)) {
+			return fmt.Errorf("%w: scope %s", ErrNotGranted, scope)
+		}
+	}
+
+	return nil
+}
+
+// covers reports whether granted grants every operation, including every
+// operation matched by those ending in *.
+func covers(granted []string, operations []string) bool {
+	for _, o := range operations {
+		if prefix, ok := strings.CutSuffix(o, "*"); ok {
+			if !grantsPrefix(granted, prefix) {
+				return false
+			}
+
+			continue
+		}
+
+		if !grants(granted, o) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// grantsPrefix reports whether granted grants every operation beginning
+// with prefix.
+func grantsPrefix(granted []string, prefix string) bool {
+	for _, g := range granted {
+		if p, ok := strings.CutSuffix(g, "*"); ok && strings.HasPrefix(prefix, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func grants(operations []string, operation string) bool {
+	for _, o := range operations {
+		if prefix, ok := strings.CutSuffix(o, "*"); ok {
+			if strings.HasPrefix(operation, prefix) {
+				return true
+			}
+
+			continue
+		}
+
+		if o == operation {
+			return true
+		}
+	}
+
+	return false
+}
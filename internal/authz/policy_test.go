@@ -0,0 +1,195 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Allows(t *testing.T) {
+	policy := NewPolicy(
+		map[string][]string{
+			"admin":  {"*"},
+			"editor": {"user:*"},
+			"viewer": {UserRead, UserSearch},
+		},
+		map[string][]string{
+			"user:create": {UserCreate},
+		},
+	)
+
+	cases := []struct {
+		name      string
+		claims    auth.Claims
+		operation string
+		expected  bool
+	}{
+		{
+			"no roles or scopes",
+			auth.Claims{},
+			UserRead,
+			false,
+		},
+		{
+			"role grants operation",
+			auth.Claims{Roles: []string{"viewer"}},
+			UserSearch,
+			true,
+		},
+		{
+			"role does not grant operation",
+			auth.Claims{Roles: []string{"viewer"}},
+			UserCreate,
+			false,
+		},
+		{
+			"role grants operation by prefix",
+			auth.Claims{Roles: []string{"editor"}},
+			UserDelete,
+			true,
+		},
+		{
+			"role does not grant operation by prefix",
+			auth.Claims{Roles: []string{"editor"}},
+			WebhookManage,
+			false,
+		},
+		{
+			"role grants all operations",
+			auth.Claims{Roles: []string{"admin"}},
+			WebhookManage,
+			true,
+		},
+		{
+			"unknown role",
+			auth.Claims{Roles: []string{"owner"}},
+			UserRead,
+			false,
+		},
+		{
+			"scope grants operation",
+			auth.Claims{Scopes: []string{"user:create"}},
+			UserCreate,
+			true,
+		},
+		{
+			"scope does not grant operation",
+			auth.Claims{Scopes: []string{"user:create"}},
+			UserRead,
+			false,
+		},
+		{
+			"any of roles and scopes grants operation",
+			auth.Claims{Roles: []string{"viewer"}, Scopes: []string{"user:create"}},
+			UserCreate,
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, policy.Allows(c.claims, c.operation))
+		})
+	}
+}
+
+func TestPolicy_Delegates(t *testing.T) {
+	policy := NewPolicy(
+		map[string][]string{
+			"admin":   {"*"},
+			"editor":  {"user:*"},
+			"viewer":  {UserRead, UserSearch},
+			"manager": {APIKeyManage},
+		},
+		map[string][]string{
+			"user:read":  {UserRead},
+			"user:write": {UserCreate, UserUpdate},
+		},
+	)
+
+	cases := []struct {
+		name        string
+		claims      auth.Claims
+		roles       []string
+		scopes      []string
+		expectedErr bool
+	}{
+		{
+			"nothing delegated",
+			auth.Claims{},
+			nil,
+			nil,
+			false,
+		},
+		{
+			"role held",
+			auth.Claims{Roles: []string{"viewer"}},
+			[]string{"viewer"},
+			nil,
+			false,
+		},
+		{
+			"role granted by all operations",
+			auth.Claims{Roles: []string{"admin"}},
+			[]string{"editor", "manager"},
+			[]string{"user:write"},
+			false,
+		},
+		{
+			"role granted by prefix",
+			auth.Claims{Roles: []string{"editor"}},
+			[]string{"viewer"},
+			nil,
+			false,
+		},
+		{
+			"admin role not granted",
+			auth.Claims{Roles: []string{"manager"}},
+			[]string{"admin"},
+			nil,
+			true,
+		},
+		{
+			"prefix role not granted by operations",
+			auth.Claims{Roles: []string{"viewer"}, Scopes: []string{"user:write"}},
+			[]string{"editor"},
+			nil,
+			true,
+		},
+		{
+			"unknown role not held",
+			auth.Claims{Roles: []string{"admin"}},
+			[]string{"owner"},
+			nil,
+			true,
+		},
+		{
+			"scope granted by role",
+			auth.Claims{Roles: []string{"viewer"}},
+			nil,
+			[]string{"user:read"},
+			false,
+		},
+		{
+			"scope not granted",
+			auth.Claims{Roles: []string{"viewer"}},
+			nil,
+			[]string{"user:write"},
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := policy.Delegates(c.claims, c.roles, c.scopes)
+
+			if c.expectedErr {
+				assert.ErrorIs(t, err, ErrNotGranted)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/authz"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/routing"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+)
+
+// jwksTimeout is the timeout for fetching a JWKS from a URL.
+const jwksTimeout = 5 * time.Second
+
+type authenticator interface {
+	Middleware(next http.Handler) http.Handler
+	UnaryInterceptor(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error)
+	StreamInterceptor(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error
+}
+
+// newAuthenticator returns nil if authentication is disabled. Tokens are
+// verified with the HMAC secret and the JWKS, whichever are configured.
+func newAuthenticator(
+	conf config.Auth,
+	logger log.Logger,
+) (authenticator, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	var keys auth.KeySets
+
+	if conf.HMACSecret != "" {
+		keys = append(keys, auth.NewHMACKey([]byte(conf.HMACSecret)))
+	}
+
+	if conf.JWKSSource != "" {
+		keys = append(keys, auth.NewJWKS(
+			conf.JWKSSource,
+			conf.JWKSRefreshInterval,
+			&http.Client{
+				Timeout: jwksTimeout,
+			},
+		))
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("auth enabled without an HMAC secret or JWKS source")
+	}
+
+	return auth.NewAuthenticator(
+		conf,
+		auth.NewVerifier(
+			keys,
+			conf.Issuer,
+			conf.Audience,
+			conf.Leeway,
+		),
+		logger,
+	), nil
+}
+
+type apiKeyCache interface {
+	apikey.Cache
+	apikey.QuotaCounter
+}
+
+type authorizer interface {
+	Middleware(operation string) mux.MiddlewareFunc
+	UnaryInterceptor(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error)
+	StreamInterceptor(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error
+}
+
+// newAuthorizer returns nil if authorization is disabled. Authorization
+// requires authentication, with tokens or API keys, as the policy is
+// applied to the claims of the caller. Denials are audited if
+// auditAppender is not nil.
+func newAuthorizer(
+	conf config.Config,
+	auditAppender audit.Appender,
+	logger log.Logger,
+) (authorizer, error) {
+	if !conf.Authz.Enabled {
+		return nil, nil
+	}
+
+	if !conf.Auth.Enabled && !conf.APIKey.Enabled {
+		return nil, errors.New("authz enabled without auth or api keys")
+	}
+
+	return authz.NewAuthorizer(
+		authz.NewPolicy(
+			conf.Authz.Roles,
+			conf.Authz.Scopes,
+		),
+		routing.GRPCOperations,
+		auditAppender,
+		logger,
+	), nil
+}
+
+type delegator interface {
+	Delegates(claims auth.Claims, roles []string, scopes []string) error
+}
+
+// newDelegator returns nil if authorization is disabled, in which case
+// roles and scopes are not enforced so may be delegated by anyone.
+func newDelegator(conf config.Config) delegator {
+	if !conf.Authz.Enabled {
+		return nil
+	}
+
+	return authz.NewPolicy(
+		conf.Authz.Roles,
+		conf.Authz.Scopes,
+	)
+}
@@ -1,22 +1,63 @@
 package bootstrap
 
 import (
+	"context"
+	"database/sql"
 	"io"
 
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/apikey"
 	"github.com/bendbennett/go-api-demo/internal/app"
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/broadcast"
 	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/health"
 	"github.com/bendbennett/go-api-demo/internal/log"
 	"github.com/bendbennett/go-api-demo/internal/storage/elastic"
 	"github.com/bendbennett/go-api-demo/internal/storage/redis"
 	"github.com/bendbennett/go-api-demo/internal/telemetry"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	userpurge "github.com/bendbennett/go-api-demo/internal/user/purge"
+	userwatch "github.com/bendbennett/go-api-demo/internal/user/watch"
+	"github.com/bendbennett/go-api-demo/internal/webhook/deliver"
+	goredis "github.com/redis/go-redis/v9"
 )
 
+// Role determines the components that are run, and the dependencies that
+// are connected to, so that the API and consumers can be scaled
+// independently.
+type Role string
+
+const (
+	RoleAll       Role = "all"
+	RoleAPI       Role = "api"
+	RoleConsumers Role = "consumers"
+)
+
+func (r Role) api() bool {
+	return r == RoleAll || r == RoleAPI
+}
+
+func (r Role) consumers() bool {
+	return r == RoleAll || r == RoleConsumers
+}
+
 // New configures a logger for use throughout the application,
 // retrieves configuration application, configures HTTP and
 // gRPC routers, populates an app.App struct with the configured
 // routers and returns a pointer to the populated app.App.
+//
+// The API role runs the HTTP and gRPC routers, and the consumers role
+// runs the consumers and background workers (e.g., the outbox relay),
+// serving only the health routes over HTTP for probes.
+//
+// Components are started in the order they are appended, and stopped in
+// reverse. Telemetry is started first, and the routers and health registry
+// last, so that requests are only served once the components they depend
+// on are running, and so that the application is reported as not ready
+// before the routers drain.
 // nolint:gocyclo
-func New() *app.App {
+func New(role Role) *app.App {
 	var (
 		components []app.Component
 		closers    []io.Closer
@@ -38,47 +79,237 @@ func New() *app.App {
 	}
 	components = append(components, telemetry)
 
-	userCache, closer, err := redis.NewUserCache(
-		conf.Redis,
-		conf.Telemetry.Enabled,
+	var services []string
+
+	if role.api() {
+		services = append(services, pb.User_ServiceDesc.ServiceName)
+	}
+
+	healthRegistry := health.NewRegistry(
+		conf.Health,
+		logger,
+		services...,
 	)
-	if err != nil {
-		logger.Panic(err)
+
+	// The API role reads users from the cache and search index, whereas the
+	// consumers role only connects to those that its enabled consumers
+	// upsert users into.
+	cacheConsumed := role.consumers() && conf.UserConsumerCache.IsEnabled
+	searchConsumed := role.consumers() && conf.UserConsumerSearch.IsEnabled
+
+	var (
+		userCache  userCacheStore
+		userSearch userSearchIndex
+		rdb        *goredis.Client
+	)
+
+	if role.api() || cacheConsumed {
+		cache, client, err := redis.NewUserCache(
+			conf.Redis,
+			conf.UserCache.VersionTTL,
+			conf.Telemetry.Enabled,
+		)
+		if err != nil {
+			logger.Panic(err)
+		}
+
+		userCache, rdb = cache, client
+		closers = addCloser(closers, rdb)
+		healthRegistry.Register("redis", health.CheckerFunc(func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		}))
+	}
+
+	if role.api() || searchConsumed {
+		search, err := elastic.NewUserSearch(
+			conf.Elasticsearch,
+			conf.Telemetry.Enabled,
+		)
+		if err != nil {
+			logger.Panic(err)
+		}
+
+		userSearch = search
+		healthRegistry.Register("elasticsearch", userSearch)
 	}
-	closers = addCloser(closers, closer)
 
-	userSearch, err := elastic.NewUserSearch(
-		conf.Elasticsearch,
-		conf.Telemetry.Enabled,
+	var (
+		userStorage user.Storage
+		db          *sql.DB
 	)
-	if err != nil {
-		logger.Panic(err)
+
+	// The consumers role only connects to MySQL for the enabled workers that
+	// use it (i.e., the webhook dispatcher, purger and outbox relay).
+	if role.api() || role.consumers() && (conf.Webhook.Enabled || conf.UserPurge.Enabled || conf.Outbox.Enabled) {
+		if conf.Storage.Type == config.StorageTypeSQL {
+			if err = migrateOnStart(conf); err != nil {
+				logger.Panic(err)
+			}
+		}
+
+		userStorage, db, err = newUserStorage(
+			conf.MySQL,
+			conf.Storage,
+			conf.Outbox.Enabled,
+			conf.Telemetry.Enabled,
+		)
+		if err != nil {
+			logger.Panic(err)
+		}
+		if db != nil {
+			closers = addCloser(closers, db)
+			healthRegistry.Register("mysql", health.CheckerFunc(db.PingContext))
+		}
 	}
 
-	routers, closrs := newRouters(conf, logger, userCache, userSearch)
-	components = append(components, routers...)
-	closers = addCloser(closers, closrs...)
+	webhookStorage := newWebhookStorage(db, conf.Storage)
 
-	consumers, closrs, err := newConsumers(conf, logger, userCache, userSearch)
-	if err != nil {
-		logger.Panic(err)
+	var (
+		userEventHandler     user.EventHandler
+		userEventBroadcaster user.EventHandler
+		routers              []app.Component
+		broadcasters         []app.Component
+	)
+
+	if role.api() {
+		// The rate limiter shares the connection of the user cache.
+		rateLimiter, err := newRateLimiter(conf.RateLimit, rdb, logger)
+		if err != nil {
+			logger.Panic(err)
+		}
+
+		var (
+			apiKeyStorage apikey.Storage
+			apiKeyCache   apiKeyCache
+		)
+
+		if conf.APIKey.Enabled {
+			apiKeyStorage = newAPIKeyStorage(db, conf.Storage)
+
+			// The API key cache shares the connection of the user cache.
+			apiKeyCache = redis.NewAPIKeyCache(
+				rdb,
+				conf.APIKey.CacheTTL,
+			)
+		}
+
+		var auditStorage audit.Storage
+
+		if conf.Audit.Enabled {
+			auditStorage = newAuditStorage(db, conf.Storage)
+		}
+
+		idempotencyStore, err := newIdempotencyStore(conf, rdb, logger)
+		if err != nil {
+			logger.Panic(err)
+		}
+
+		var userEventsSubscriber userwatch.Subscriber
+
+		if conf.UserEvents.Enabled {
+			broadcaster := broadcast.NewBroadcaster(
+				conf.UserEvents.BufferSize,
+				conf.UserEvents.SubscriberBufferSize,
+			)
+
+			broadcasters = append(broadcasters, broadcaster)
+			userEventBroadcaster = broadcaster
+			userEventsSubscriber = broadcaster
+		}
+
+		gdprStores, closer := newGDPRStores(conf.Erasure, userStorage, userCache, userSearch)
+		closers = addCloser(closers, closer)
+
+		var closrs []io.Closer
+
+		routers, closrs = newRouters(conf, logger, userStorage, userCache, userSearch, webhookStorage, apiKeyStorage, apiKeyCache, rateLimiter, auditStorage, idempotencyStore, gdprStores, userEventsSubscriber, healthRegistry)
+		closers = addCloser(closers, closrs...)
+	} else {
+		routers = append(routers, newHealthRouter(conf, logger, healthRegistry))
+	}
+
+	if role.consumers() && conf.Webhook.Enabled {
+		dispatcher := deliver.NewDispatcher(
+			conf.Webhook,
+			conf.CloudEvents.Source,
+			webhookStorage,
+			webhookStorage,
+			webhookStorage,
+			deliver.NewClient(conf.Webhook.Timeout),
+			logger,
+		)
+
+		components = append(components, dispatcher)
+		userEventHandler = dispatcher
 	}
 
-	components = append(components, consumers...)
-	closers = addCloser(closers, closrs...)
+	var (
+		consumerCache  user.UpserterDeleter
+		consumerSearch user.UpserterDeleter
+	)
+
+	if cacheConsumed {
+		consumerCache = userCache
+	}
+
+	if searchConsumed {
+		consumerSearch = userSearch
+	}
+
+	if consumerCache != nil || consumerSearch != nil || userEventHandler != nil || userEventBroadcaster != nil {
+		consumers, closrs, err := newConsumers(conf, logger, consumerCache, consumerSearch, userEventHandler, userEventBroadcaster, healthRegistry)
+		if err != nil {
+			logger.Panic(err)
+		}
+
+		components = append(components, consumers...)
+		closers = addCloser(closers, closrs...)
+	}
+
+	if role.consumers() && conf.UserPurge.Enabled {
+		components = append(components, userpurge.NewPurger(
+			conf.UserPurge,
+			userStorage,
+			logger,
+		))
+	}
+
+	if role.consumers() && conf.Outbox.Enabled {
+		relay, closer, err := newRelay(conf, logger, db)
+		if err != nil {
+			logger.Panic(err)
+		}
+
+		components = append(components, relay)
+		closers = addCloser(closers, closer)
+	}
+
+	components = append(components, routers...)
+
+	// The broadcaster follows the routers so that it is stopped first,
+	// ending the event streams that would otherwise hold up the routers
+	// draining until the drain timeout.
+	components = append(components, broadcasters...)
+	components = append(components, healthRegistry)
 
 	return app.New(
 		components,
 		closers,
+		conf.Shutdown.DrainTimeout,
+		logger,
 	)
 }
 
+// addCloser appends the non-nil closers, as constructors return a nil
+// closer when there is nothing to close.
 func addCloser(
 	closers []io.Closer,
 	closer ...io.Closer,
 ) []io.Closer {
-	if closer != nil {
-		closers = append(closers, closer...)
+	for _, c := range closer {
+		if c != nil {
+			closers = append(closers, c)
+		}
 	}
 
 	return closers
@@ -3,23 +3,40 @@ package bootstrap
 import (
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/bendbennett/go-api-demo/internal/schema"
 
+	pb "github.com/bendbennett/go-api-demo/generated"
 	"github.com/bendbennett/go-api-demo/internal/app"
 	"github.com/bendbennett/go-api-demo/internal/config"
 	"github.com/bendbennett/go-api-demo/internal/consume"
+	"github.com/bendbennett/go-api-demo/internal/health"
 	"github.com/bendbennett/go-api-demo/internal/log"
 	"github.com/bendbennett/go-api-demo/internal/metrics"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	userconsume "github.com/bendbennett/go-api-demo/internal/user/consume"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
 )
 
+// newConsumers returns the consumers that upsert users into userCache and
+// userSearch, and that pass events to userEventHandler and
+// userEventBroadcaster. Consumers are not returned for those that are nil,
+// as they are not needed by the role.
+//
+// Events are shared between the instances consuming them for
+// userEventHandler (i.e., webhooks are dispatched once), whereas each
+// instance consumes every event for userEventBroadcaster, as watchers are
+// connected to a single instance.
 func newConsumers(
 	conf config.Config,
 	logger log.Logger,
-	userCache user.CreatorReader,
-	userSearch user.CreatorSearcher,
+	userCache user.UpserterDeleter,
+	userSearch user.UpserterDeleter,
+	userEventHandler user.EventHandler,
+	userEventBroadcaster user.EventHandler,
+	healthRegistry healthRegistry,
 ) ([]app.Component, []io.Closer, error) {
 	var (
 		components []app.Component
@@ -31,42 +48,207 @@ func newConsumers(
 		return nil, nil, err
 	}
 
-	schemaClient := schema.NewClient(conf.SchemaRegistry.ClientTimeout)
-	userDecoder, err := schemaClient.GetDecoder(
-		fmt.Sprintf(
-			"%s%s",
-			conf.SchemaRegistry.Domain,
-			conf.SchemaRegistry.Endpoints["usersValue"],
-		),
-	)
-	if err != nil {
-		return nil, nil, err
-	}
+	healthRegistry.Register("kafka", consume.Brokers(conf.TopicConfigs.Brokers))
 
 	userConsumerMetrics, err := metrics.NewConsumerMetrics(conf.Telemetry.Enabled)
 	if err != nil {
 		panic(err)
 	}
 
-	userConsumerMetricsLabelsCache := metrics.NewConsumerMetricsLabels(
+	if userCache != nil {
+		userConsumerMetricsLabelsCache := metrics.NewConsumerMetricsLabels(
+			"user",
+			"cache",
+		)
+
+		userConsumerMetricsCollectorCache := metrics.NewConsumerMetricsCollector(
+			userConsumerMetrics,
+			userConsumerMetricsLabelsCache,
+		)
+
+		userDecoderCache, err := newUserDecoder(
+			conf.UserConsumerCache,
+			conf.SchemaRegistry,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		userProcessorCache := userconsume.NewProcessor(
+			userCache,
+			conf.UserConsumerCache.RecordNamespace,
+		)
+
+		consumers, closrs, err := consume.NewConsumers(
+			conf.UserConsumerCache,
+			conf.Telemetry.Enabled,
+			userConsumerMetricsLabelsCache,
+			userConsumerMetricsCollectorCache,
+			userProcessorCache,
+			userDecoderCache,
+			logger,
+		)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, consumer := range consumers {
+			components = append(components, consumer)
+			healthRegistry.Register(
+				"consumer:"+consumer.GroupID(),
+				health.MaxLag(consumer, conf.Health.MaxConsumerLag),
+			)
+		}
+
+		closers = addCloser(closers, closrs...)
+	}
+
+	if userSearch != nil {
+		userConsumerMetricsLabelsSearch := metrics.NewConsumerMetricsLabels(
+			"user",
+			"search",
+		)
+
+		userConsumerMetricsCollectorSearch := metrics.NewConsumerMetricsCollector(
+			userConsumerMetrics,
+			userConsumerMetricsLabelsSearch,
+		)
+
+		userDecoderSearch, err := newUserDecoder(
+			conf.UserConsumerSearch,
+			conf.SchemaRegistry,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		userProcessorSearch := userconsume.NewProcessor(
+			userSearch,
+			conf.UserConsumerSearch.RecordNamespace,
+		)
+
+		consumers, closrs, err := consume.NewConsumers(
+			conf.UserConsumerSearch,
+			conf.Telemetry.Enabled,
+			userConsumerMetricsLabelsSearch,
+			userConsumerMetricsCollectorSearch,
+			userProcessorSearch,
+			userDecoderSearch,
+			logger,
+		)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, consumer := range consumers {
+			components = append(components, consumer)
+			healthRegistry.Register(
+				"consumer:"+consumer.GroupID(),
+				health.MaxLag(consumer, conf.Health.MaxConsumerLag),
+			)
+		}
+
+		closers = addCloser(closers, closrs...)
+	}
+
+	for _, h := range []struct {
+		destination  string
+		handler      user.EventHandler
+		consumerConf config.KafkaConsumer
+	}{
+		{"events", userEventHandler, conf.UserConsumerEvents},
+		{"broadcast", userEventBroadcaster, broadcastConsumerConf(conf.UserConsumerEvents)},
+	} {
+		if h.handler == nil {
+			continue
+		}
+
+		consumers, closrs, err := newEventConsumers(
+			conf,
+			logger,
+			h.consumerConf,
+			h.destination,
+			h.handler,
+			userConsumerMetrics,
+			healthRegistry,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		components = append(components, consumers...)
+		closers = addCloser(closers, closrs...)
+	}
+
+	return components, closers, nil
+}
+
+// broadcastConsumerConf returns the configuration of the events consumer
+// with a group that is unique to the instance, so that the instance
+// receives the events of every partition, starting from the latest as
+// watchers are only sent events that occur once they are connected. The
+// groups of stopped instances are removed by Kafka once their offsets
+// expire (i.e., after offsets.retention.minutes).
+func broadcastConsumerConf(consumerConf config.KafkaConsumer) config.KafkaConsumer {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+
+	consumerConf.ReaderConfig.GroupID = fmt.Sprintf(
+		"%s-broadcast-%s-%s",
+		consumerConf.ReaderConfig.GroupID,
+		instance,
+		uuid.NewString(),
+	)
+	consumerConf.ReaderConfig.StartOffset = kafka.LastOffset
+
+	return consumerConf
+}
+
+func newEventConsumers(
+	conf config.Config,
+	logger log.Logger,
+	consumerConf config.KafkaConsumer,
+	destination string,
+	userEventHandler user.EventHandler,
+	userConsumerMetrics metrics.ConsumerMetrics,
+	healthRegistry healthRegistry,
+) ([]app.Component, []io.Closer, error) {
+	var components []app.Component
+
+	userConsumerMetricsLabelsEvents := metrics.NewConsumerMetricsLabels(
 		"user",
-		"cache",
+		destination,
 	)
 
-	userConsumerMetricsCollectorCache := metrics.NewConsumerMetricsCollector(
+	userConsumerMetricsCollectorEvents := metrics.NewConsumerMetricsCollector(
 		userConsumerMetrics,
-		userConsumerMetricsLabelsCache,
+		userConsumerMetricsLabelsEvents,
 	)
 
-	userProcessorCache := userconsume.NewProcessor(userCache)
+	userDecoderEvents, err := newUserDecoder(
+		consumerConf,
+		conf.SchemaRegistry,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userProcessorEvents := userconsume.NewEventProcessor(
+		userEventHandler,
+		consumerConf.RecordNamespace,
+	)
 
-	consumers, closrs, err := consume.NewConsumers(
-		conf.UserConsumerCache,
+	consumers, closers, err := consume.NewConsumers(
+		consumerConf,
 		conf.Telemetry.Enabled,
-		userConsumerMetricsLabelsCache,
-		userConsumerMetricsCollectorCache,
-		userProcessorCache,
-		userDecoder,
+		userConsumerMetricsLabelsEvents,
+		userConsumerMetricsCollectorEvents,
+		userProcessorEvents,
+		userDecoderEvents,
 		logger,
 	)
 
@@ -76,41 +258,102 @@ func newConsumers(
 
 	for _, consumer := range consumers {
 		components = append(components, consumer)
+		healthRegistry.Register(
+			"consumer:"+consumer.GroupID(),
+			health.MaxLag(consumer, conf.Health.MaxConsumerLag),
+		)
 	}
 
-	closers = addCloser(closers, closrs...)
-
-	userConsumerMetricsLabelsSearch := metrics.NewConsumerMetricsLabels(
-		"user",
-		"search",
-	)
+	return components, closers, nil
+}
 
-	userConsumerMetricsCollectorSearch := metrics.NewConsumerMetricsCollector(
-		userConsumerMetrics,
-		userConsumerMetricsLabelsSearch,
-	)
+type decoder interface {
+	Decode([]byte) (interface{}, error)
+}
 
-	userProcessorSearch := userconsume.NewProcessor(userSearch)
+type valueNamer interface {
+	ValueName() (string, error)
+}
 
-	consumers, closrs, err = consume.NewConsumers(
-		conf.UserConsumerSearch,
-		conf.Telemetry.Enabled,
-		userConsumerMetricsLabelsSearch,
-		userConsumerMetricsCollectorSearch,
-		userProcessorSearch,
-		userDecoder,
-		logger,
+// newUserDecoder returns the decoder configured for the consumer. The Avro
+// decoder retrieves the schema from the schema registry (or a directory of
+// schema files when the registry type is file), whereas the JSON
+// decoder reads the schema embedded in each message and the protobuf decoder
+// uses the compiled users_value.proto envelope.
+//
+// Decoders that know the schema upfront are checked against the configured
+// record namespace so that a mismatch fails at start-up rather than
+// resulting in every message being rejected.
+func newUserDecoder(
+	consumerConf config.KafkaConsumer,
+	schemaRegistryConf config.SchemaRegistry,
+) (decoder, error) {
+	d, err := userDecoder(
+		consumerConf,
+		schemaRegistryConf,
 	)
+	if err != nil {
+		return nil, err
+	}
 
+	vn, ok := d.(valueNamer)
+	if !ok {
+		return d, nil
+	}
+
+	valueName, err := vn.ValueName()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	for _, consumer := range consumers {
-		components = append(components, consumer)
+	if valueName != fmt.Sprintf("%s.Value", consumerConf.RecordNamespace) {
+		return nil, fmt.Errorf(
+			"record namespace %s does not match schema value %s",
+			consumerConf.RecordNamespace,
+			valueName,
+		)
 	}
 
-	closers = addCloser(closers, closrs...)
+	return d, nil
+}
+
+func userDecoder(
+	consumerConf config.KafkaConsumer,
+	schemaRegistryConf config.SchemaRegistry,
+) (decoder, error) {
+	switch consumerConf.Decoder {
+	case config.DecoderAvro:
+		schemaClient := schema.NewClient(schemaRegistryConf.ClientTimeout)
 
-	return components, closers, nil
+		if schemaRegistryConf.Type == config.SchemaRegistryTypeFile {
+			var err error
+
+			schemaClient, err = schema.NewFileClient(schemaRegistryConf.Dir)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		avroDecoder, err := schemaClient.GetDecoder(
+			fmt.Sprintf(
+				"%s%s",
+				schemaRegistryConf.Domain,
+				schemaRegistryConf.Endpoints["usersValue"],
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return avroDecoder, nil
+	case config.DecoderJSON:
+		return schema.NewJSONDecoder(), nil
+	case config.DecoderProtobuf:
+		return schema.NewProtobufDecoder((&pb.Envelope{}).ProtoReflect().Type()), nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown decoder: %s",
+			consumerConf.Decoder,
+		)
+	}
 }
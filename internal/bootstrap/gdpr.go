@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	"io"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	kafkastorage "github.com/bendbennett/go-api-demo/internal/storage/kafka"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	usergdpr "github.com/bendbennett/go-api-demo/internal/user/gdpr"
+	"github.com/segmentio/kafka-go"
+)
+
+// newGDPRStores returns every store that holds personal data about users,
+// in the order in which users are erased. The source of truth is erased
+// first so that the user is not cached or indexed again, and Kafka last so
+// that the tombstone follows any events relayed from the outbox. Logs are
+// listed, but not erased, as they are removed by retention.
+func newGDPRStores(
+	conf config.Erasure,
+	userStorage user.Storage,
+	userCache user.SubjectExporterEraser,
+	userSearch user.SubjectExporterEraser,
+) ([]usergdpr.Store, io.Closer) {
+	writer := &kafka.Writer{
+		Addr:     kafka.TCP(conf.Brokers...),
+		Balancer: &kafka.Hash{},
+	}
+
+	return []usergdpr.Store{
+		{
+			Name:     "mysql",
+			Exporter: userStorage,
+			Eraser:   userStorage,
+		},
+		{
+			Name:     "redis",
+			Exporter: userCache,
+			Eraser:   userCache,
+		},
+		{
+			Name:     "elasticsearch",
+			Exporter: userSearch,
+			Eraser:   userSearch,
+		},
+		{
+			Name:   "kafka",
+			Eraser: kafkastorage.NewUserTopics(writer, conf.TombstoneTopics...),
+		},
+		{
+			Name: "logs",
+		},
+	}, writer
+}
@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"net/http"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/health"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/routing"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthRegistry is used by each component to register the checks of its
+// dependencies.
+type healthRegistry interface {
+	Register(name string, checker health.Checker)
+	Liveness(w http.ResponseWriter, r *http.Request)
+	Readiness(w http.ResponseWriter, r *http.Request)
+	GRPCServer() healthgrpc.HealthServer
+}
+
+// newHealthRouter returns an HTTP router serving only the health routes,
+// for roles that do not serve the API.
+func newHealthRouter(
+	conf config.Config,
+	logger log.Logger,
+	healthRegistry healthRegistry,
+) *routing.HTTPRouter {
+	return routing.NewHTTPRouter(
+		routing.HTTPControllers{
+			HealthzController: healthRegistry.Liveness,
+			ReadyzController:  healthRegistry.Readiness,
+		},
+		routing.HTTPMiddleware{},
+		logger,
+		conf.Telemetry.Enabled,
+		conf.HTTP.Port,
+		conf.HTTP.ReadHeaderTimeout,
+	)
+}
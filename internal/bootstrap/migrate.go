@@ -0,0 +1,74 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/storage/mysql"
+)
+
+// Migrator applies the migrations embedded in the binary to MySQL.
+type Migrator interface {
+	Up(steps int) error
+	Down(steps int) error
+	Force(version int) error
+	Status() (mysql.MigrationStatus, error)
+	UpLocked(ctx context.Context, timeout time.Duration) error
+	Close() error
+}
+
+// NewMigrator returns the migrator used by the migrate subcommand, which
+// must be closed.
+func NewMigrator() (Migrator, error) {
+	return newMigrator(config.New())
+}
+
+func newMigrator(conf config.Config) (Migrator, error) {
+	if conf.Storage.Type != config.StorageTypeSQL {
+		return nil, fmt.Errorf(
+			"migrate requires storage type %s",
+			config.StorageTypeSQL,
+		)
+	}
+
+	// Migrations (e.g., those creating triggers) hold multiple statements.
+	mySQLConf := conf.MySQL.Clone()
+	mySQLConf.MultiStatements = true
+
+	db, err := sqlDB(
+		mySQLConf,
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := mysql.NewMigrator(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return migrator, nil
+}
+
+// migrateOnStart applies pending migrations if configured to, holding an
+// advisory lock so that replicas starting together do not race.
+func migrateOnStart(conf config.Config) error {
+	if !conf.Storage.MigrateOnStart {
+		return nil
+	}
+
+	migrator, err := newMigrator(conf)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return migrator.UpLocked(
+		context.Background(),
+		conf.Storage.MigrateLockTimeout,
+	)
+}
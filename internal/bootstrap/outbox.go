@@ -0,0 +1,99 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/bendbennett/go-api-demo/internal/app"
+	"github.com/bendbennett/go-api-demo/internal/cloudevents"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/publish"
+	"github.com/bendbennett/go-api-demo/internal/schema"
+	"github.com/bendbennett/go-api-demo/internal/storage/mysql"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/segmentio/kafka-go"
+)
+
+// newRelay returns a relay that publishes events from the outbox table.
+// Using the avro format allows the user consumers to process the events
+// without Kafka Connect being deployed.
+func newRelay(
+	conf config.Config,
+	logger log.Logger,
+	db *sql.DB,
+) (app.Component, io.Closer, error) {
+	marshaller, err := newMarshaller(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	writer := &kafka.Writer{
+		Addr:     kafka.TCP(conf.Outbox.Brokers...),
+		Topic:    conf.Outbox.Topic,
+		Balancer: &kafka.Hash{},
+	}
+
+	relay := publish.NewRelay(
+		conf.Outbox,
+		mysql.NewOutboxStorage(
+			db,
+			conf.Storage.QueryTimeout,
+		),
+		writer,
+		marshaller,
+		logger,
+	)
+
+	return relay, writer, nil
+}
+
+type marshaller interface {
+	Marshal(context.Context, user.Event) (kafka.Message, error)
+}
+
+func newMarshaller(conf config.Config) (marshaller, error) {
+	switch conf.Outbox.Format {
+	case config.OutboxFormatAvro:
+		schemaClient := schema.NewClient(conf.SchemaRegistry.ClientTimeout)
+
+		if conf.SchemaRegistry.Type == config.SchemaRegistryTypeFile {
+			var err error
+
+			schemaClient, err = schema.NewFileClient(conf.SchemaRegistry.Dir)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		avroEncoder, err := schemaClient.GetEncoder(
+			fmt.Sprintf(
+				"%s%s",
+				conf.SchemaRegistry.Domain,
+				conf.SchemaRegistry.Endpoints["usersValue"],
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return publish.NewAvroMarshaller(avroEncoder)
+	case config.OutboxFormatCloudEventsStructured:
+		return publish.NewCloudEventsMarshaller(
+			conf.CloudEvents.Source,
+			cloudevents.ModeStructured,
+		), nil
+	case config.OutboxFormatCloudEventsBinary:
+		return publish.NewCloudEventsMarshaller(
+			conf.CloudEvents.Source,
+			cloudevents.ModeBinary,
+		), nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown outbox format: %s",
+			conf.Outbox.Format,
+		)
+	}
+}
@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/ratelimit"
+	"github.com/bendbennett/go-api-demo/internal/storage/memory"
+	"github.com/bendbennett/go-api-demo/internal/storage/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// newRateLimiter returns nil if rate limiting is disabled. Limits are
+// shared by all instances through rdb, falling back to limits local to
+// this instance while rdb fails.
+func newRateLimiter(
+	conf config.RateLimit,
+	rdb goredis.Scripter,
+	logger log.Logger,
+) (ratelimit.Limiter, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	var algorithm ratelimit.Algorithm
+
+	switch conf.Algorithm {
+	case config.RateLimitAlgorithmTokenBucket:
+		algorithm = ratelimit.TokenBucket
+	case config.RateLimitAlgorithmSlidingWindow:
+		algorithm = ratelimit.SlidingWindow
+	default:
+		return nil, fmt.Errorf(
+			"rate limit algorithm %q not supported",
+			conf.Algorithm,
+		)
+	}
+
+	return ratelimit.NewFallback(
+		redis.NewRateLimiter(rdb, algorithm),
+		memory.NewRateLimiter(algorithm),
+		logger,
+	), nil
+}
@@ -3,23 +3,49 @@ package bootstrap
 import (
 	"io"
 
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	apikeymanage "github.com/bendbennett/go-api-demo/internal/apikey/manage"
 	"github.com/bendbennett/go-api-demo/internal/app"
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	auditquery "github.com/bendbennett/go-api-demo/internal/audit/query"
 	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
 	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/ratelimit"
 	"github.com/bendbennett/go-api-demo/internal/routing"
 	"github.com/bendbennett/go-api-demo/internal/sanitise"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	usercreate "github.com/bendbennett/go-api-demo/internal/user/create"
+	usergdpr "github.com/bendbennett/go-api-demo/internal/user/gdpr"
 	userread "github.com/bendbennett/go-api-demo/internal/user/read"
+	userremove "github.com/bendbennett/go-api-demo/internal/user/remove"
+	userrestore "github.com/bendbennett/go-api-demo/internal/user/restore"
 	usersearch "github.com/bendbennett/go-api-demo/internal/user/search"
+	usertransfer "github.com/bendbennett/go-api-demo/internal/user/transfer"
+	userupdate "github.com/bendbennett/go-api-demo/internal/user/update"
+	userwatch "github.com/bendbennett/go-api-demo/internal/user/watch"
 	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+	webhooksubscription "github.com/bendbennett/go-api-demo/internal/webhook/subscription"
+	"github.com/gorilla/mux"
 )
 
 func newRouters(
 	conf config.Config,
 	logger log.Logger,
+	userStorage user.Storage,
 	userCache user.CreatorReader,
 	userSearch user.Searcher,
+	webhookStorage webhook.Storage,
+	apiKeyStorage apikey.Storage,
+	apiKeyCache apiKeyCache,
+	rateLimiter ratelimit.Limiter,
+	auditStorage audit.Storage,
+	idempotencyStore idempotency.Store,
+	gdprStores []usergdpr.Store,
+	userEventsSubscriber userwatch.Subscriber,
+	healthRegistry healthRegistry,
 ) ([]app.Component, []io.Closer) {
 	var (
 		components []app.Component
@@ -31,17 +57,17 @@ func newRouters(
 		logger.Panic(err)
 	}
 
-	userStorage, closer, err := newUserStorage(
-		conf.MySQL,
-		conf.Storage,
-		conf.Telemetry.Enabled,
-	)
+	authenticator, err := newAuthenticator(conf.Auth, logger)
+	if err != nil {
+		logger.Panic(err)
+	}
+
+	authorizer, err := newAuthorizer(conf, auditStorage, logger)
 	if err != nil {
 		logger.Panic(err)
 	}
-	closers = addCloser(closers, closer)
 
-	userCreateInteractor := usercreate.NewInteractor(userStorage)
+	userCreateInteractor := usercreate.NewInteractor(userStorage, idempotencyStore)
 	userCreatePresenter := usercreate.NewPresenter()
 
 	userCreateControllerHTTP := usercreate.NewHTTPController(
@@ -51,7 +77,7 @@ func newRouters(
 		logger,
 	)
 
-	userReadInteractor := userread.NewInteractor(userCache)
+	userReadInteractor := userread.NewInteractor(userCache, userStorage)
 	userReadPresenter := userread.NewPresenter()
 
 	userReadControllerHTTP := userread.NewHTTPController(
@@ -60,6 +86,32 @@ func newRouters(
 		logger,
 	)
 
+	userUpdateInteractor := userupdate.NewInteractor(userStorage)
+	userUpdatePresenter := userupdate.NewPresenter()
+
+	userUpdateControllerHTTP := userupdate.NewHTTPController(
+		validator,
+		userUpdateInteractor,
+		userUpdatePresenter,
+		logger,
+	)
+
+	userRemoveInteractor := userremove.NewInteractor(userStorage)
+
+	userRemoveControllerHTTP := userremove.NewHTTPController(
+		userRemoveInteractor,
+		logger,
+	)
+
+	userRestoreInteractor := userrestore.NewInteractor(userStorage)
+	userRestorePresenter := userrestore.NewPresenter()
+
+	userRestoreControllerHTTP := userrestore.NewHTTPController(
+		userRestoreInteractor,
+		userRestorePresenter,
+		logger,
+	)
+
 	userSearchInteractor := usersearch.NewInteractor(userSearch)
 	userSearchPresenter := usersearch.NewPresenter()
 
@@ -70,14 +122,168 @@ func newRouters(
 		logger,
 	)
 
+	userTransferControllerHTTP := usertransfer.NewHTTPController(
+		usertransfer.NewInteractor(validator, userStorage),
+		logger,
+	)
+
+	userGDPRControllerHTTP := usergdpr.NewHTTPController(
+		usergdpr.NewInteractor(gdprStores...),
+		usergdpr.NewPresenter(),
+		logger,
+	)
+
+	webhookSubscriptionInteractor := webhooksubscription.NewInteractor(webhookStorage)
+	webhookSubscriptionPresenter := webhooksubscription.NewPresenter()
+
+	webhookSubscriptionControllerHTTP := webhooksubscription.NewHTTPController(
+		validator,
+		webhookSubscriptionInteractor,
+		webhookSubscriptionPresenter,
+		logger,
+	)
+
 	httpControllers := routing.HTTPControllers{
-		UserCreateController: userCreateControllerHTTP.Create,
-		UserReadController:   userReadControllerHTTP.Read,
-		UserSearchController: userSearchControllerHTTP.Search,
+		UserCreateController:      userCreateControllerHTTP.Create,
+		UserCreateBatchController: userCreateControllerHTTP.CreateBatch,
+		UserReadController:        userReadControllerHTTP.Read,
+		UserReadByIDController:    userReadControllerHTTP.ReadByID,
+		UserUpdateController:      userUpdateControllerHTTP.Update,
+		UserDeleteController:      userRemoveControllerHTTP.Delete,
+		UserRestoreController:     userRestoreControllerHTTP.Restore,
+		UserSearchController:      userSearchControllerHTTP.Search,
+		UserExportController:      userTransferControllerHTTP.Export,
+		UserImportController:      userTransferControllerHTTP.Import,
+
+		AdminUserExportController: userGDPRControllerHTTP.Export,
+		AdminUserEraseController:  userGDPRControllerHTTP.Erase,
+
+		WebhookCreateController:     webhookSubscriptionControllerHTTP.Create,
+		WebhookReadController:       webhookSubscriptionControllerHTTP.Read,
+		WebhookReadByIDController:   webhookSubscriptionControllerHTTP.ReadByID,
+		WebhookUpdateController:     webhookSubscriptionControllerHTTP.Update,
+		WebhookDeleteController:     webhookSubscriptionControllerHTTP.Delete,
+		WebhookDeliveriesController: webhookSubscriptionControllerHTTP.Deliveries,
+
+		HealthzController: healthRegistry.Liveness,
+		ReadyzController:  healthRegistry.Readiness,
+	}
+
+	if apiKeyStorage != nil {
+		apiKeyControllerHTTP := apikeymanage.NewHTTPController(
+			validator,
+			apikeymanage.NewInteractor(apiKeyStorage, apiKeyCache, newDelegator(conf), conf.APIKey.DefaultQuota),
+			apikeymanage.NewPresenter(),
+			logger,
+		)
+
+		httpControllers.APIKeyIssueController = apiKeyControllerHTTP.Issue
+		httpControllers.APIKeyReadController = apiKeyControllerHTTP.Read
+		httpControllers.APIKeyReadByIDController = apiKeyControllerHTTP.ReadByID
+		httpControllers.APIKeyRotateController = apiKeyControllerHTTP.Rotate
+		httpControllers.APIKeyRevokeController = apiKeyControllerHTTP.Revoke
+	}
+
+	if auditStorage != nil {
+		auditQueryControllerHTTP := auditquery.NewHTTPController(
+			validator,
+			auditquery.NewInteractor(auditStorage),
+			auditquery.NewPresenter(),
+			logger,
+		)
+
+		httpControllers.AuditReadController = auditQueryControllerHTTP.Read
+		httpControllers.AuditVerifyController = auditQueryControllerHTTP.Verify
+	}
+
+	if userEventsSubscriber != nil {
+		userWatchControllerHTTP := userwatch.NewHTTPController(
+			userEventsSubscriber,
+			userwatch.NewPresenter(),
+			logger,
+			conf.UserEvents.HeartbeatInterval,
+		)
+
+		httpControllers.UserEventsController = userWatchControllerHTTP.Events
+	}
+
+	tenantResolver := tenant.NewResolver(
+		conf.Tenant.Header,
+		conf.Tenant.Default,
+	)
+
+	httpMiddleware := routing.HTTPMiddleware{
+		Routes: []mux.MiddlewareFunc{
+			tenantResolver.Middleware,
+		},
+	}
+
+	grpcInterceptors := routing.GRPCInterceptors{}
+
+	// Authentication precedes tenant identification so that the tenant
+	// can be taken from the API key or a token claim. API keys are checked
+	// first, as token authentication is skipped for requests with a key.
+	if apiKeyStorage != nil {
+		apiKeyAuthenticator := apikey.NewAuthenticator(
+			conf.APIKey,
+			apiKeyStorage,
+			apiKeyCache,
+			apiKeyCache,
+			logger,
+		)
+
+		httpMiddleware.Router = append(httpMiddleware.Router, apiKeyAuthenticator.Middleware)
+		grpcInterceptors.Unary = append(grpcInterceptors.Unary, apiKeyAuthenticator.UnaryInterceptor)
+		grpcInterceptors.Stream = append(grpcInterceptors.Stream, apiKeyAuthenticator.StreamInterceptor)
+	}
+
+	if authenticator != nil {
+		httpMiddleware.Router = append(httpMiddleware.Router, authenticator.Middleware)
+		grpcInterceptors.Unary = append(grpcInterceptors.Unary, authenticator.UnaryInterceptor)
+		grpcInterceptors.Stream = append(grpcInterceptors.Stream, authenticator.StreamInterceptor)
+	}
+
+	grpcInterceptors.Unary = append(grpcInterceptors.Unary, tenantResolver.UnaryInterceptor)
+	grpcInterceptors.Stream = append(grpcInterceptors.Stream, tenantResolver.StreamInterceptor)
+
+	// Rate limits apply per client, so follow authentication, and precede
+	// authorization so that denied requests count towards the limits.
+	if rateLimiter != nil {
+		rateLimitEnforcer := ratelimit.NewEnforcer(
+			conf.RateLimit,
+			rateLimiter,
+			logger,
+		)
+
+		httpMiddleware.Routes = append(httpMiddleware.Routes, rateLimitEnforcer.Middleware)
+		grpcInterceptors.Unary = append(grpcInterceptors.Unary, rateLimitEnforcer.UnaryInterceptor)
+		grpcInterceptors.Stream = append(grpcInterceptors.Stream, rateLimitEnforcer.StreamInterceptor)
+	}
+
+	// Mutating calls are audited whether or not they are authorized.
+	if auditStorage != nil {
+		auditRecorder := audit.NewRecorder(
+			conf.Audit,
+			auditStorage,
+			routing.GRPCMutations,
+			logger,
+		)
+
+		httpMiddleware.Operations = append(httpMiddleware.Operations, auditRecorder.Middleware)
+		grpcInterceptors.Unary = append(grpcInterceptors.Unary, auditRecorder.UnaryInterceptor)
+		grpcInterceptors.Stream = append(grpcInterceptors.Stream, auditRecorder.StreamInterceptor)
+	}
+
+	// Authorization is enforced before controllers run.
+	if authorizer != nil {
+		httpMiddleware.Operations = append(httpMiddleware.Operations, authorizer.Middleware)
+		grpcInterceptors.Unary = append(grpcInterceptors.Unary, authorizer.UnaryInterceptor)
+		grpcInterceptors.Stream = append(grpcInterceptors.Stream, authorizer.StreamInterceptor)
 	}
 
 	httpRouter := routing.NewHTTPRouter(
 		httpControllers,
+		httpMiddleware,
 		logger,
 		conf.Telemetry.Enabled,
 		conf.HTTP.Port,
@@ -99,6 +305,24 @@ func newRouters(
 		logger,
 	)
 
+	userUpdateControllerGRPC := userupdate.NewGRPCController(
+		validator,
+		userUpdateInteractor,
+		userUpdatePresenter,
+		logger,
+	)
+
+	userRemoveControllerGRPC := userremove.NewGRPCController(
+		userRemoveInteractor,
+		logger,
+	)
+
+	userRestoreControllerGRPC := userrestore.NewGRPCController(
+		userRestoreInteractor,
+		userRestorePresenter,
+		logger,
+	)
+
 	userSearchControllerGRPC := usersearch.NewGRPCController(
 		sanitise.AlphaWithHyphen,
 		userSearchInteractor,
@@ -107,13 +331,32 @@ func newRouters(
 	)
 
 	grpcControllers := routing.GRPCControllers{
-		UserCreate: userCreateControllerGRPC.Create,
-		UserRead:   userReadControllerGRPC.Read,
-		UserSearch: userSearchControllerGRPC.Search,
+		UserCreate:            userCreateControllerGRPC.Create,
+		UserCreateBatch:       userCreateControllerGRPC.CreateBatch,
+		UserCreateBatchStream: userCreateControllerGRPC.CreateBatchStream,
+		UserUpdate:            userUpdateControllerGRPC.Update,
+		UserDelete:            userRemoveControllerGRPC.Delete,
+		UserRestore:           userRestoreControllerGRPC.Restore,
+		UserRead:              userReadControllerGRPC.Read,
+		UserSearch:            userSearchControllerGRPC.Search,
+		UserList:              userReadControllerGRPC.ListUsers,
+
+		Health: healthRegistry.GRPCServer(),
+	}
+
+	if userEventsSubscriber != nil {
+		userWatchControllerGRPC := userwatch.NewGRPCController(
+			userEventsSubscriber,
+			userwatch.NewPresenter(),
+			logger,
+		)
+
+		grpcControllers.UserWatch = userWatchControllerGRPC.Watch
 	}
 
 	grpcRouter := routing.NewGRPCRouter(
 		grpcControllers,
+		grpcInterceptors,
 		logger,
 		conf.Telemetry.Enabled,
 		conf.GRPCPort,
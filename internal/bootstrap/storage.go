@@ -3,22 +3,50 @@ package bootstrap
 import (
 	"database/sql"
 	"fmt"
-	"io"
 
 	"github.com/XSAM/otelsql"
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/audit"
 	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/health"
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
+	"github.com/bendbennett/go-api-demo/internal/log"
 	"github.com/bendbennett/go-api-demo/internal/storage/memory"
 	"github.com/bendbennett/go-api-demo/internal/storage/mysql"
+	"github.com/bendbennett/go-api-demo/internal/storage/redis"
 	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
 	sqldriver "github.com/go-sql-driver/mysql"
+	goredis "github.com/redis/go-redis/v9"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
+// userCacheStore is the cache of users, which the API role reads from and
+// the consumers role upserts users into.
+type userCacheStore interface {
+	user.CreatorReader
+	user.UpserterDeleter
+	user.SubjectExporterEraser
+}
+
+// userSearchIndex is the search index of users, which the API role
+// searches and the consumers role upserts users into.
+type userSearchIndex interface {
+	user.Searcher
+	user.UpserterDeleter
+	user.SubjectExporterEraser
+	health.Checker
+}
+
+// newUserStorage returns the *sql.DB alongside the storage when the
+// storage type is sql, so that it can be closed and shared with the
+// outbox relay, and nil otherwise.
 func newUserStorage(
 	mySQLConf *sqldriver.Config,
 	storageConf config.Storage,
+	outboxEnabled bool,
 	telemetryEnabled bool,
-) (user.CreatorReader, io.Closer, error) {
+) (user.Storage, *sql.DB, error) {
 	var (
 		handle interface{}
 		err    error
@@ -39,12 +67,103 @@ func newUserStorage(
 		return mysql.NewUserStorage(
 			h,
 			storageConf.QueryTimeout,
+			outboxEnabled,
 		), h, nil
 	default:
+		if outboxEnabled {
+			return nil, nil, fmt.Errorf(
+				"outbox requires storage type %s",
+				config.StorageTypeSQL,
+			)
+		}
+
 		return memory.NewUserStorage(), nil, nil
 	}
 }
 
+// newIdempotencyStore returns the store configured for idempotency keys,
+// which shares the connection of rdb when the store is Redis, falling back
+// to a store local to this instance while rdb fails.
+func newIdempotencyStore(
+	conf config.Config,
+	rdb *goredis.Client,
+	logger log.Logger,
+) (idempotency.Store, error) {
+	switch conf.Idempotency.Store {
+	case config.IdempotencyStoreRedis:
+		return idempotency.NewFallback(
+			redis.NewIdempotencyStore(
+				rdb,
+				conf.Idempotency.TTL,
+				conf.Idempotency.LockTTL,
+			),
+			memory.NewIdempotencyStore(
+				conf.Idempotency.TTL,
+				conf.Idempotency.LockTTL,
+			),
+			logger,
+		), nil
+	case config.IdempotencyStoreMemory:
+		return memory.NewIdempotencyStore(
+			conf.Idempotency.TTL,
+			conf.Idempotency.LockTTL,
+		), nil
+	default:
+		return nil, fmt.Errorf(
+			"idempotency store %q not supported",
+			conf.Idempotency.Store,
+		)
+	}
+}
+
+// newWebhookStorage returns storage backed by db, or in-memory
+// storage when db is nil.
+func newWebhookStorage(
+	db *sql.DB,
+	storageConf config.Storage,
+) webhook.Storage {
+	if db == nil {
+		return memory.NewWebhookStorage()
+	}
+
+	return mysql.NewWebhookStorage(
+		db,
+		storageConf.QueryTimeout,
+	)
+}
+
+// newAPIKeyStorage returns storage backed by db, or in-memory
+// storage when db is nil.
+func newAPIKeyStorage(
+	db *sql.DB,
+	storageConf config.Storage,
+) apikey.Storage {
+	if db == nil {
+		return memory.NewAPIKeyStorage()
+	}
+
+	return mysql.NewAPIKeyStorage(
+		db,
+		storageConf.QueryTimeout,
+	)
+}
+
+// newAuditStorage returns storage backed by db, or in-memory
+// storage when db is nil.
+func newAuditStorage(
+	db *sql.DB,
+	storageConf config.Storage,
+) audit.Storage {
+	if db == nil {
+		return memory.NewAuditStorage()
+	}
+
+	return mysql.NewAuditStorage(
+		db,
+		storageConf.QueryTimeout,
+	)
+}
+
 func sqlDB(
 	conf *sqldriver.Config,
 	telemetryEnabled bool,
@@ -0,0 +1,43 @@
+package bootstrap
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	usertransfer "github.com/bendbennett/go-api-demo/internal/user/transfer"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+)
+
+// NewUserTransfer returns the interactor used by the import and export
+// subcommands, and a closer for its storage. Users are read from and written
+// to MySQL directly, so the storage type must be sql. When the outbox is
+// enabled, imported users are published in the same way as users created
+// through the API.
+func NewUserTransfer() (usertransfer.Interactor, io.Closer, error) {
+	conf := config.New()
+
+	if conf.Storage.Type != config.StorageTypeSQL {
+		return nil, nil, fmt.Errorf(
+			"import and export require storage type %s",
+			config.StorageTypeSQL,
+		)
+	}
+
+	validator, err := validate.NewValidator()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userStorage, db, err := newUserStorage(
+		conf.MySQL,
+		conf.Storage,
+		conf.Outbox.Enabled,
+		false,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return usertransfer.NewInteractor(validator, userStorage), db, nil
+}
@@ -0,0 +1,164 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+// Message is a user.Event with an ID that increases monotonically for
+// the lifetime of the broadcaster. IDs are not shared between processes:
+// they start from the time (in microseconds) at which the broadcaster was
+// created, so that IDs issued by a previous process (i.e., before a
+// restart) are recognised as unknown, but the IDs of replicas running
+// concurrently may overlap. Clients should therefore reconnect to the same
+// replica (e.g., with sticky sessions) to resume reliably.
+type Message struct {
+	Event user.Event
+	ID    uint64
+}
+
+type b struct {
+	subscribers      map[chan Message]struct{}
+	buffer           []Message
+	mu               sync.Mutex
+	base             uint64
+	lastID           uint64
+	bufferSize       int
+	subscriberBuffer int
+	closed           bool
+}
+
+var _ user.EventHandler = (*b)(nil)
+
+// NewBroadcaster returns a broadcaster that fans out events to subscribers,
+// retaining the most recent bufferSize messages so that subscribers can
+// resume after reconnecting. Each subscriber can have up to subscriberBuffer
+// messages pending; subscribers that fall further behind are disconnected
+// rather than blocking the broadcaster.
+func NewBroadcaster(
+	bufferSize int,
+	subscriberBuffer int,
+) *b {
+	base := uint64(time.Now().UnixMicro())
+
+	return &b{
+		subscribers:      make(map[chan Message]struct{}),
+		base:             base,
+		lastID:           base,
+		bufferSize:       bufferSize,
+		subscriberBuffer: subscriberBuffer,
+	}
+}
+
+func (b *b) Handle(
+	_ context.Context,
+	evt user.Event,
+) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	b.lastID++
+
+	msg := Message{
+		Event: evt,
+		ID:    b.lastID,
+	}
+
+	b.buffer = append(b.buffer, msg)
+
+	if len(b.buffer) > b.bufferSize {
+		b.buffer = b.buffer[len(b.buffer)-b.bufferSize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns buffered messages with an ID greater than lastID, and a
+// channel on which subsequent messages are received. If lastID is 0, or was
+// not issued by this broadcaster (e.g., was issued before a restart), no
+// messages are replayed, as the messages missed cannot be determined. If
+// messages after lastID have been evicted from the buffer, all buffered
+// messages are replayed. The channel is closed when the subscriber falls
+// behind, unsubscribe is called or the broadcaster stops.
+func (b *b) Subscribe(lastID uint64) ([]Message, <-chan Message, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Message
+
+	if lastID >= b.base && lastID <= b.lastID {
+		for _, msg := range b.buffer {
+			if msg.ID > lastID {
+				replay = append(replay, msg)
+			}
+		}
+	}
+
+	ch := make(chan Message, b.subscriberBuffer)
+
+	if b.closed {
+		close(ch)
+		return replay, ch, func() {}
+	}
+
+	b.subscribers[ch] = struct{}{}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return replay, ch, unsubscribe
+}
+
+// Run closes all subscriptions when ctx is cancelled, if Stop has not
+// already done so.
+func (b *b) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	b.close()
+
+	return nil
+}
+
+// Stop closes all subscriptions, which ends any streams (e.g., server-sent
+// events) that are reading from them, so that servers are not held up
+// draining them. Subsequent subscriptions are closed immediately.
+func (b *b) Stop(context.Context) error {
+	b.close()
+
+	return nil
+}
+
+func (b *b) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,134 @@
+package broadcast
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func handle(t *testing.T, b *b, ids ...string) {
+	t.Helper()
+
+	for _, id := range ids {
+		require.NoError(t, b.Handle(context.Background(), user.Event{ID: id}))
+	}
+}
+
+func eventIDs(msgs []Message) []string {
+	var ids []string
+
+	for _, msg := range msgs {
+		ids = append(ids, msg.Event.ID)
+	}
+
+	return ids
+}
+
+func TestBroadcaster_Subscribe_Replay(t *testing.T) {
+	cases := []struct {
+		name     string
+		lastID   func(b *b) uint64
+		expected []string
+	}{
+		{
+			"no last id replays nothing",
+			func(*b) uint64 { return 0 },
+			nil,
+		},
+		{
+			"replays messages after last id",
+			func(b *b) uint64 { return b.base + 3 },
+			[]string{"d", "e"},
+		},
+		{
+			"replays buffered messages when last id evicted",
+			func(b *b) uint64 { return b.base + 1 },
+			[]string{"c", "d", "e"},
+		},
+		{
+			"up to date replays nothing",
+			func(b *b) uint64 { return b.base + 5 },
+			nil,
+		},
+		{
+			"last id issued before broadcaster created replays nothing",
+			func(b *b) uint64 { return b.base - 1 },
+			nil,
+		},
+		{
+			"last id not yet issued replays nothing",
+			func(b *b) uint64 { return b.base + 6 },
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := NewBroadcaster(3, 1)
+			handle(t, b, "a", "b", "c", "d", "e")
+
+			replay, _, unsubscribe := b.Subscribe(c.lastID(b))
+			defer unsubscribe()
+
+			assert.Equal(t, c.expected, eventIDs(replay))
+		})
+	}
+}
+
+func TestBroadcaster_Handle(t *testing.T) {
+	b := NewBroadcaster(10, 2)
+
+	_, messages, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+
+	handle(t, b, "a", "b")
+
+	msg := <-messages
+	assert.Equal(t, b.base+1, msg.ID)
+	assert.Equal(t, "a", msg.Event.ID)
+
+	msg = <-messages
+	assert.Equal(t, b.base+2, msg.ID)
+	assert.Equal(t, "b", msg.Event.ID)
+}
+
+func TestBroadcaster_Handle_SlowSubscriberDisconnected(t *testing.T) {
+	b := NewBroadcaster(10, 1)
+
+	_, slow, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+
+	handle(t, b, "a", "b")
+
+	msg, ok := <-slow
+	assert.True(t, ok)
+	assert.Equal(t, "a", msg.Event.ID)
+
+	_, ok = <-slow
+	assert.False(t, ok)
+}
+
+func TestBroadcaster_Run(t *testing.T) {
+	b := NewBroadcaster(10, 1)
+
+	_, messages, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NoError(t, b.Run(ctx))
+
+	_, ok := <-messages
+	assert.False(t, ok)
+
+	_, messages, _ = b.Subscribe(0)
+
+	_, ok = <-messages
+	assert.False(t, ok)
+
+	assert.NoError(t, b.Handle(context.Background(), user.Event{ID: "a"}))
+}
@@ -0,0 +1,201 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// SpecVersion is the version of the CloudEvents specification implemented.
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md
+const SpecVersion = "1.0"
+
+// Content modes. In structured mode the event, including its data, is
+// serialised as JSON in the message body. In binary mode the attributes
+// are carried in headers and the body contains only the data.
+const (
+	ModeStructured = "structured"
+	ModeBinary     = "binary"
+)
+
+const (
+	contentTypeJSON       = "application/json"
+	contentTypeCloudEvent = "application/cloudevents+json"
+)
+
+// Distributed tracing extension attributes.
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/extensions/distributed-tracing.md
+const (
+	extTraceParent = "traceparent"
+	extTraceState  = "tracestate"
+)
+
+type Event struct {
+	Data            json.RawMessage `json:"data,omitempty"`
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	TraceParent     string          `json:"traceparent,omitempty"`
+	TraceState      string          `json:"tracestate,omitempty"`
+}
+
+type userData struct {
+	Before *usr `json:"before"`
+	After  *usr `json:"after"`
+}
+
+type usr struct {
+	TenantID  string `json:"tenant_id"`
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+	Version   int64  `json:"version"`
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// FromUserEvent returns a CloudEvent for evt, with the user before and after
+// the change as data. The trace context in ctx, as serialised by the global
+// propagator (see telemetry.tracerProvider), is set as the traceparent and
+// tracestate extensions.
+func FromUserEvent(
+	ctx context.Context,
+	source string,
+	evt user.Event,
+) (Event, error) {
+	data, err := json.Marshal(userData{
+		Before: toUsr(evt.Before),
+		After:  toUsr(evt.After),
+	})
+	if err != nil {
+		return Event{}, err
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	return Event{
+		Data:            data,
+		SpecVersion:     SpecVersion,
+		ID:              evt.ID,
+		Source:          source,
+		Type:            evt.Type,
+		Subject:         evt.UserID(),
+		Time:            evt.OccurredAt.UTC().Format(time.RFC3339Nano),
+		DataContentType: contentTypeJSON,
+		TraceParent:     carrier.Get(extTraceParent),
+		TraceState:      carrier.Get(extTraceState),
+	}, nil
+}
+
+func toUsr(u *user.User) *usr {
+	if u == nil {
+		return nil
+	}
+
+	out := &usr{
+		TenantID:  u.TenantID,
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		CreatedAt: u.CreatedAt.Format(time.RFC3339),
+		Version:   u.Version,
+	}
+
+	if u.DeletedAt != nil {
+		out.DeletedAt = u.DeletedAt.Format(time.RFC3339)
+	}
+
+	return out
+}
+
+// Context returns a copy of ctx containing the trace context from
+// the traceparent and tracestate extensions.
+func (e Event) Context(ctx context.Context) context.Context {
+	return otel.GetTextMapPropagator().Extract(
+		ctx,
+		propagation.MapCarrier{
+			extTraceParent: e.TraceParent,
+			extTraceState:  e.TraceState,
+		},
+	)
+}
+
+// Validate checks that the attributes required by the specification are set.
+func (e Event) Validate() error {
+	var errs []error
+
+	if e.SpecVersion != SpecVersion {
+		errs = append(errs, fmt.Errorf("specversion %q: not supported", e.SpecVersion))
+	}
+
+	for name, val := range map[string]string{
+		"id":     e.ID,
+		"source": e.Source,
+		"type":   e.Type,
+	} {
+		if val == "" {
+			errs = append(errs, fmt.Errorf("%s: required", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// attributes returns the context attributes, including extensions, keyed
+// by name, omitting those that are not set.
+func (e Event) attributes() map[string]string {
+	attrs := map[string]string{
+		"specversion":     e.SpecVersion,
+		"id":              e.ID,
+		"source":          e.Source,
+		"type":            e.Type,
+		"subject":         e.Subject,
+		"time":            e.Time,
+		"datacontenttype": e.DataContentType,
+		extTraceParent:    e.TraceParent,
+		extTraceState:     e.TraceState,
+	}
+
+	for k, v := range attrs {
+		if v == "" {
+			delete(attrs, k)
+		}
+	}
+
+	return attrs
+}
+
+// setAttribute is the inverse of attributes. Unknown attributes are ignored.
+func (e *Event) setAttribute(name, val string) {
+	switch name {
+	case "specversion":
+		e.SpecVersion = val
+	case "id":
+		e.ID = val
+	case "source":
+		e.Source = val
+	case "type":
+		e.Type = val
+	case "subject":
+		e.Subject = val
+	case "time":
+		e.Time = val
+	case "datacontenttype":
+		e.DataContentType = val
+	case extTraceParent:
+		e.TraceParent = val
+	case extTraceState:
+		e.TraceState = val
+	}
+}
@@ -0,0 +1,162 @@
+package cloudevents
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func userEvent() user.Event {
+	return user.Event{
+		OccurredAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		After: &user.User{
+			CreatedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			TenantID:  "acme",
+			ID:        "f1e1c3b4-3b0a-4b8a-9b1a-1f1d1e1c1b1a",
+			FirstName: "john",
+			LastName:  "smith",
+			Version:   1,
+		},
+		ID:   "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+		Type: user.EventCreated,
+	}
+}
+
+func tracedContext(t *testing.T) context.Context {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx := otel.GetTextMapPropagator().Extract(
+		context.Background(),
+		propagation.MapCarrier{extTraceParent: traceParent},
+	)
+	require.True(t, trace.SpanContextFromContext(ctx).IsValid())
+
+	return ctx
+}
+
+func TestFromUserEvent(t *testing.T) {
+	e, err := FromUserEvent(tracedContext(t), "/go-api-demo", userEvent())
+	require.NoError(t, err)
+
+	assert.Equal(t, Event{
+		Data:            []byte(`{"before":null,"after":{"tenant_id":"acme","id":"f1e1c3b4-3b0a-4b8a-9b1a-1f1d1e1c1b1a","first_name":"john","last_name":"smith","created_at":"2022-01-01T00:00:00Z","version":1}}`),
+		SpecVersion:     SpecVersion,
+		ID:              "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+		Source:          "/go-api-demo",
+		Type:            user.EventCreated,
+		Subject:         "f1e1c3b4-3b0a-4b8a-9b1a-1f1d1e1c1b1a",
+		Time:            "2022-01-01T00:00:00Z",
+		DataContentType: contentTypeJSON,
+		TraceParent:     traceParent,
+	}, e)
+
+	assert.Equal(
+		t,
+		trace.SpanContextFromContext(tracedContext(t)).TraceID(),
+		trace.SpanContextFromContext(e.Context(context.Background())).TraceID(),
+	)
+}
+
+func TestEvent_Validate(t *testing.T) {
+	assert.EqualError(t, Event{SpecVersion: "0.3", ID: "id", Source: "src", Type: "type"}.Validate(), `specversion "0.3": not supported`)
+	assert.EqualError(t, Event{SpecVersion: SpecVersion, Source: "src", Type: "type"}.Validate(), "id: required")
+	assert.NoError(t, Event{SpecVersion: SpecVersion, ID: "id", Source: "src", Type: "type"}.Validate())
+}
+
+func TestKafkaMessage(t *testing.T) {
+	e, err := FromUserEvent(tracedContext(t), "/go-api-demo", userEvent())
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		mode        string
+		contentType string
+	}{
+		{
+			"structured mode round trips",
+			ModeStructured,
+			contentTypeCloudEvent,
+		},
+		{
+			"binary mode round trips",
+			ModeBinary,
+			contentTypeJSON,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			msg, err := KafkaMessage([]byte("key"), e, c.mode)
+			require.NoError(t, err)
+
+			for _, h := range msg.Headers {
+				if h.Key == kafkaHeaderContentType {
+					assert.Equal(t, c.contentType, string(h.Value))
+				}
+			}
+
+			actual, err := FromKafkaMessage(msg)
+			require.NoError(t, err)
+
+			assert.Equal(t, []byte("key"), msg.Key)
+			assert.JSONEq(t, string(e.Data), string(actual.Data))
+			actual.Data = e.Data
+			assert.Equal(t, e, actual)
+		})
+	}
+
+	_, err = KafkaMessage(nil, e, "unknown")
+	assert.EqualError(t, err, `mode "unknown": not implemented`)
+}
+
+func TestNewHTTPRequest(t *testing.T) {
+	e, err := FromUserEvent(tracedContext(t), "/go-api-demo", userEvent())
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		mode        string
+		contentType string
+	}{
+		{
+			"structured mode round trips",
+			ModeStructured,
+			contentTypeCloudEvent,
+		},
+		{
+			"binary mode round trips",
+			ModeBinary,
+			contentTypeJSON,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := NewHTTPRequest(context.Background(), "http://localhost/hook", e, c.mode)
+			require.NoError(t, err)
+
+			assert.Equal(t, c.contentType, req.Header.Get(httpHeaderContentType))
+
+			// Round trip through a server-side request to check header canonicalisation.
+			srvReq := httptest.NewRequest(req.Method, req.URL.String(), req.Body)
+			srvReq.Header = req.Header
+
+			actual, err := FromHTTPRequest(srvReq)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, string(e.Data), string(actual.Data))
+			actual.Data = e.Data
+			assert.Equal(t, e, actual)
+		})
+	}
+}
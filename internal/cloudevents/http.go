@@ -0,0 +1,101 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/bindings/http-protocol-binding.md
+const (
+	httpHeaderPrefix      = "Ce-"
+	httpHeaderContentType = "Content-Type"
+)
+
+// NewHTTPRequest returns a POST request to url containing e, encoded using mode.
+func NewHTTPRequest(
+	ctx context.Context,
+	url string,
+	e Event,
+	mode string,
+) (*http.Request, error) {
+	var (
+		body        []byte
+		contentType string
+		headers     = http.Header{}
+	)
+
+	switch mode {
+	case ModeStructured:
+		var err error
+
+		body, err = json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+
+		contentType = contentTypeCloudEvent
+	case ModeBinary:
+		for name, val := range e.attributes() {
+			if name == "datacontenttype" {
+				continue
+			}
+
+			headers.Set(httpHeaderPrefix+name, val)
+		}
+
+		body = e.Data
+		contentType = e.DataContentType
+	default:
+		return nil, fmt.Errorf("mode %q: not implemented", mode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = headers
+	req.Header.Set(httpHeaderContentType, contentType)
+
+	return req, nil
+}
+
+// FromHTTPRequest returns the event contained in req. The mode is
+// determined from the Content-Type header.
+func FromHTTPRequest(req *http.Request) (Event, error) {
+	var e Event
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return Event{}, err
+	}
+
+	contentType := req.Header.Get(httpHeaderContentType)
+
+	if strings.HasPrefix(contentType, contentTypeCloudEvent) {
+		if err := json.Unmarshal(body, &e); err != nil {
+			return Event{}, err
+		}
+
+		return e, e.Validate()
+	}
+
+	for key, vals := range req.Header {
+		name, ok := strings.CutPrefix(http.CanonicalHeaderKey(key), httpHeaderPrefix)
+		if !ok || len(vals) == 0 {
+			continue
+		}
+
+		e.setAttribute(strings.ToLower(name), vals[0])
+	}
+
+	e.DataContentType = contentType
+	e.Data = body
+
+	return e, e.Validate()
+}
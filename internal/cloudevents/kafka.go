@@ -0,0 +1,95 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/bindings/kafka-protocol-binding.md
+const (
+	kafkaHeaderPrefix      = "ce_"
+	kafkaHeaderContentType = "content-type"
+)
+
+// KafkaMessage returns a message containing e, encoded using mode, keyed by key.
+func KafkaMessage(
+	key []byte,
+	e Event,
+	mode string,
+) (kafka.Message, error) {
+	msg := kafka.Message{
+		Key: key,
+	}
+
+	switch mode {
+	case ModeStructured:
+		value, err := json.Marshal(e)
+		if err != nil {
+			return kafka.Message{}, err
+		}
+
+		msg.Value = value
+		msg.Headers = []kafka.Header{
+			{
+				Key:   kafkaHeaderContentType,
+				Value: []byte(contentTypeCloudEvent),
+			},
+		}
+	case ModeBinary:
+		for name, val := range e.attributes() {
+			if name == "datacontenttype" {
+				name = kafkaHeaderContentType
+			} else {
+				name = kafkaHeaderPrefix + name
+			}
+
+			msg.Headers = append(msg.Headers, kafka.Header{
+				Key:   name,
+				Value: []byte(val),
+			})
+		}
+
+		msg.Value = e.Data
+	default:
+		return kafka.Message{}, fmt.Errorf("mode %q: not implemented", mode)
+	}
+
+	return msg, nil
+}
+
+// FromKafkaMessage returns the event contained in msg. The mode is
+// determined from the content-type header.
+func FromKafkaMessage(msg kafka.Message) (Event, error) {
+	var (
+		e           Event
+		contentType string
+	)
+
+	for _, h := range msg.Headers {
+		if strings.EqualFold(h.Key, kafkaHeaderContentType) {
+			contentType = string(h.Value)
+		}
+	}
+
+	if strings.HasPrefix(contentType, contentTypeCloudEvent) {
+		if err := json.Unmarshal(msg.Value, &e); err != nil {
+			return Event{}, err
+		}
+
+		return e, e.Validate()
+	}
+
+	for _, h := range msg.Headers {
+		if name, ok := strings.CutPrefix(h.Key, kafkaHeaderPrefix); ok {
+			e.setAttribute(name, string(h.Value))
+		}
+	}
+
+	e.DataContentType = contentType
+	e.Data = msg.Value
+
+	return e, e.Validate()
+}
@@ -17,16 +17,50 @@ import (
 const StorageTypeMemory = "memory"
 const StorageTypeSQL = "sql"
 
+const IdempotencyStoreMemory = "memory"
+const IdempotencyStoreRedis = "redis"
+
+const RateLimitAlgorithmTokenBucket = "token_bucket"
+const RateLimitAlgorithmSlidingWindow = "sliding_window"
+
+const SchemaRegistryTypeHTTP = "http"
+const SchemaRegistryTypeFile = "file"
+
+const DecoderAvro = "avro"
+const DecoderJSON = "json"
+const DecoderProtobuf = "protobuf"
+
+const OutboxFormatAvro = "avro"
+const OutboxFormatCloudEventsStructured = "cloudevents-structured"
+const OutboxFormatCloudEventsBinary = "cloudevents-binary"
+
 type Config struct {
 	MySQL              *mysql.Config
 	Storage            Storage
 	Redis              redis.Options
+	UserCache          UserCache
 	Elasticsearch      elasticsearch.Config
 	TopicConfigs       TopicConfigs
 	SchemaRegistry     SchemaRegistry
 	Telemetry          Telemetry
 	UserConsumerCache  KafkaConsumer
 	UserConsumerSearch KafkaConsumer
+	UserConsumerEvents KafkaConsumer
+	Outbox             Outbox
+	CloudEvents        CloudEvents
+	Webhook            Webhook
+	UserEvents         UserEvents
+	UserPurge          UserPurge
+	Erasure            Erasure
+	Idempotency        Idempotency
+	Tenant             Tenant
+	Auth               Auth
+	Authz              Authz
+	APIKey             APIKey
+	RateLimit          RateLimit
+	Audit              Audit
+	Health             Health
+	Shutdown           Shutdown
 	Logging            Logging
 	HTTP               HTTP
 	GRPCPort           int
@@ -37,9 +71,125 @@ type HTTP struct {
 	ReadHeaderTimeout time.Duration
 }
 
+// Storage configures the storage of users. When MigrateOnStart is set,
+// pending MySQL migrations are applied on start, with instances waiting up
+// to MigrateLockTimeout for another instance that is migrating.
 type Storage struct {
-	Type         string
-	QueryTimeout time.Duration
+	Type               string
+	QueryTimeout       time.Duration
+	MigrateOnStart     bool
+	MigrateLockTimeout time.Duration
+}
+
+type Idempotency struct {
+	Store   string
+	TTL     time.Duration
+	LockTTL time.Duration
+}
+
+// Tenant configures the identification of the tenant of requests from
+// Header. Requests without the header belong to Default, or are rejected
+// if Default is empty.
+type Tenant struct {
+	Header  string
+	Default string
+}
+
+// Auth configures the authentication of requests with JWT bearer tokens.
+// HS256 tokens are verified with HMACSecret, and both HS256 and RS256
+// tokens with the keys of the JWKS at JWKSSource (a URL or file path).
+// Requests for ExemptHTTPPaths and ExemptGRPCMethods are not authenticated.
+// The tenant of requests is taken from TenantClaim, and tokens without it
+// are rejected unless they have one of TenantHeaderRoles, which permit the
+// tenant to be chosen with the tenant header (e.g., by operators).
+type Auth struct {
+	HMACSecret          string
+	JWKSSource          string
+	Issuer              string
+	Audience            string
+	TenantClaim         string
+	TenantHeaderRoles   []string
+	ExemptHTTPPaths     []string
+	ExemptGRPCMethods   []string
+	JWKSRefreshInterval time.Duration
+	Leeway              time.Duration
+	Enabled             bool
+}
+
+// Authz configures the authorization of authenticated requests. Roles and
+// Scopes map the roles and scopes in token claims to the operations (e.g.,
+// user:create) they are granted.
+type Authz struct {
+	Roles   map[string][]string
+	Scopes  map[string][]string
+	Enabled bool
+}
+
+// APIKey configures the authentication of requests with API keys in
+// Header. Keys are cached for CacheTTL, and the requests made with each
+// key are limited to its quota (DefaultQuota unless set when the key is
+// issued) per QuotaWindow. The time at which keys were last used is
+// recorded at most once per TouchInterval.
+type APIKey struct {
+	Header        string
+	CacheTTL      time.Duration
+	QuotaWindow   time.Duration
+	TouchInterval time.Duration
+	DefaultQuota  int
+	Enabled       bool
+}
+
+// RateLimit configures limits on the rate of requests by each client (the
+// authenticated subject, or else the IP address) to each route. Rules are
+// keyed by HTTP method and route (e.g., GET /user/search/{searchTerm}) or
+// by gRPC method (e.g., /User/Search). Routes without a rule are limited by
+// Default, and are unlimited if Default is not set.
+type RateLimit struct {
+	Algorithm string
+	Rules     map[string]RateLimitRule
+	Default   RateLimitRule
+	Enabled   bool
+}
+
+// RateLimitRule permits Requests per Period, in bursts of up to Burst
+// requests with the token bucket algorithm. Rules without Requests are
+// unlimited.
+type RateLimitRule struct {
+	Requests int
+	Burst    int
+	Period   time.Duration
+}
+
+// UserCache configures the cache of users populated from change events.
+// The versions of cached users, which are retained after users are
+// deleted, expire after VersionTTL, which must be well beyond the maximum
+// lag of the consumer, as events for a user that are older than its
+// expired version are no longer skipped.
+type UserCache struct {
+	VersionTTL time.Duration
+}
+
+// Audit configures the audit log of mutating calls. Calls are identified
+// by the request ID in RequestIDHeader, which is generated if absent.
+type Audit struct {
+	RequestIDHeader string
+	Enabled         bool
+}
+
+// Health configures the checks of the dependencies of the application,
+// which are run every CheckInterval, and fail if they take longer than
+// CheckTimeout. Consumers are not ready if their lag exceeds
+// MaxConsumerLag messages.
+type Health struct {
+	CheckInterval  time.Duration
+	CheckTimeout   time.Duration
+	MaxConsumerLag int64
+}
+
+// Shutdown configures how long components are given to drain in-flight
+// work (e.g., requests) once the application is stopping.
+type Shutdown struct {
+	DrainTimeout time.Duration
 }
 
 type Telemetry struct {
@@ -57,9 +207,57 @@ type Message struct {
 }
 
 type KafkaConsumer struct {
-	ReaderConfig kafka.ReaderConfig
-	IsEnabled    bool
-	Num          int
+	ReaderConfig    kafka.ReaderConfig
+	Decoder         string
+	RecordNamespace string
+	IsEnabled       bool
+	Num             int
+}
+
+type Outbox struct {
+	Brokers      []string
+	Topic        string
+	Format       string
+	PollInterval time.Duration
+	BatchSize    int
+	Enabled      bool
+}
+
+type Webhook struct {
+	Workers        int
+	PollInterval   time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+	Enabled        bool
+}
+
+type UserEvents struct {
+	BufferSize           int
+	SubscriberBufferSize int
+	HeartbeatInterval    time.Duration
+	Enabled              bool
+}
+
+// UserPurge configures the hard deletion of users that were soft deleted
+// longer ago than Retention.
+type UserPurge struct {
+	Retention time.Duration
+	Interval  time.Duration
+	BatchSize int
+	Enabled   bool
+}
+
+// Erasure configures the erasure of users on request. A tombstone keyed by
+// the ID of the erased user is written to each of TombstoneTopics.
+type Erasure struct {
+	Brokers         []string
+	TombstoneTopics []string
+}
+
+type CloudEvents struct {
+	Source string
 }
 
 type TopicConfigs struct {
@@ -69,7 +267,9 @@ type TopicConfigs struct {
 
 type SchemaRegistry struct {
 	Endpoints     map[string]string
+	Type          string
 	Domain        string
+	Dir           string
 	ClientTimeout time.Duration
 }
 
@@ -116,6 +316,28 @@ func New() Config {
 				"STORAGE_QUERY_TIMEOUT",
 				3*time.Second,
 			),
+			MigrateOnStart: GetEnvAsBool(
+				"STORAGE_MIGRATE_ON_START",
+				false,
+			),
+			MigrateLockTimeout: GetEnvAsDuration(
+				"STORAGE_MIGRATE_LOCK_TIMEOUT",
+				time.Minute,
+			),
+		},
+		Idempotency: Idempotency{
+			Store: GetEnvAsString(
+				"IDEMPOTENCY_STORE",
+				IdempotencyStoreRedis,
+			),
+			TTL: GetEnvAsDuration(
+				"IDEMPOTENCY_TTL",
+				24*time.Hour,
+			),
+			LockTTL: GetEnvAsDuration(
+				"IDEMPOTENCY_LOCK_TTL",
+				time.Minute,
+			),
 		},
 		Telemetry: Telemetry{
 			ServiceName: GetEnvAsString(
@@ -171,9 +393,17 @@ func New() Config {
 					"",
 				),
 			},
+			Decoder: GetEnvAsString(
+				"KAFKA_USER_CONSUMER_CACHE_DECODER",
+				DecoderAvro,
+			),
+			RecordNamespace: GetEnvAsString(
+				"KAFKA_USER_CONSUMER_CACHE_RECORD_NAMESPACE",
+				"mysql.go_api_demo.users",
+			),
 			IsEnabled: GetEnvAsBool(
 				"KAFKA_USER_CONSUMER_CACHE_IS_ENABLED",
-				false,
+				true,
 			),
 			Num: GetEnvAsInt(
 				"KAFKA_USER_CONSUMER_CACHE_NUM",
@@ -208,15 +438,349 @@ func New() Config {
 					"",
 				),
 			},
+			Decoder: GetEnvAsString(
+				"KAFKA_USER_CONSUMER_SEARCH_DECODER",
+				DecoderAvro,
+			),
+			RecordNamespace: GetEnvAsString(
+				"KAFKA_USER_CONSUMER_SEARCH_RECORD_NAMESPACE",
+				"mysql.go_api_demo.users",
+			),
 			IsEnabled: GetEnvAsBool(
 				"KAFKA_USER_CONSUMER_SEARCH_IS_ENABLED",
-				false,
+				true,
 			),
 			Num: GetEnvAsInt(
 				"KAFKA_USER_CONSUMER_SEARCH_NUM",
 				2,
 			),
 		},
+		UserConsumerEvents: KafkaConsumer{
+			ReaderConfig: kafka.ReaderConfig{
+				Brokers: GetEnvAsSliceOfStrings(
+					"KAFKA_BROKERS",
+					",",
+					[]string{},
+				),
+				GroupID: GetEnvAsString(
+					"KAFKA_USER_CONSUMER_EVENTS_GROUP_ID",
+					"",
+				),
+				MaxBytes: GetEnvAsInt(
+					"KAFKA_USER_CONSUMER_EVENTS_MAX_BYTES",
+					200e3,
+				),
+				MaxWait: GetEnvAsDuration(
+					"KAFKA_USER_CONSUMER_EVENTS_MAX_WAIT",
+					30*time.Second,
+				),
+				RebalanceTimeout: GetEnvAsDuration(
+					"KAFKA_USER_CONSUMER_EVENTS_REBALANCE_TIMEOUT",
+					30*time.Second,
+				),
+				Topic: GetEnvAsString(
+					"KAFKA_USER_CONSUMER_EVENTS_TOPIC",
+					"",
+				),
+			},
+			Decoder: GetEnvAsString(
+				"KAFKA_USER_CONSUMER_EVENTS_DECODER",
+				DecoderAvro,
+			),
+			RecordNamespace: GetEnvAsString(
+				"KAFKA_USER_CONSUMER_EVENTS_RECORD_NAMESPACE",
+				"mysql.go_api_demo.users",
+			),
+			IsEnabled: GetEnvAsBool(
+				"KAFKA_USER_CONSUMER_EVENTS_IS_ENABLED",
+				false,
+			),
+			Num: GetEnvAsInt(
+				"KAFKA_USER_CONSUMER_EVENTS_NUM",
+				1,
+			),
+		},
+		Webhook: Webhook{
+			Workers: GetEnvAsInt(
+				"WEBHOOK_WORKERS",
+				4,
+			),
+			PollInterval: GetEnvAsDuration(
+				"WEBHOOK_POLL_INTERVAL",
+				time.Second,
+			),
+			MaxAttempts: GetEnvAsInt(
+				"WEBHOOK_MAX_ATTEMPTS",
+				5,
+			),
+			InitialBackoff: GetEnvAsDuration(
+				"WEBHOOK_INITIAL_BACKOFF",
+				time.Second,
+			),
+			MaxBackoff: GetEnvAsDuration(
+				"WEBHOOK_MAX_BACKOFF",
+				time.Minute,
+			),
+			Timeout: GetEnvAsDuration(
+				"WEBHOOK_TIMEOUT",
+				10*time.Second,
+			),
+			Enabled: GetEnvAsBool(
+				"WEBHOOK_ENABLED",
+				false,
+			),
+		},
+		UserEvents: UserEvents{
+			BufferSize: GetEnvAsInt(
+				"USER_EVENTS_BUFFER_SIZE",
+				1000,
+			),
+			SubscriberBufferSize: GetEnvAsInt(
+				"USER_EVENTS_SUBSCRIBER_BUFFER_SIZE",
+				100,
+			),
+			HeartbeatInterval: GetEnvAsDuration(
+				"USER_EVENTS_HEARTBEAT_INTERVAL",
+				15*time.Second,
+			),
+			Enabled: GetEnvAsBool(
+				"USER_EVENTS_ENABLED",
+				false,
+			),
+		},
+		UserPurge: UserPurge{
+			Retention: GetEnvAsDuration(
+				"USER_PURGE_RETENTION",
+				30*24*time.Hour,
+			),
+			Interval: GetEnvAsDuration(
+				"USER_PURGE_INTERVAL",
+				time.Hour,
+			),
+			BatchSize: GetEnvAsInt(
+				"USER_PURGE_BATCH_SIZE",
+				1000,
+			),
+			Enabled: GetEnvAsBool(
+				"USER_PURGE_ENABLED",
+				true,
+			),
+		},
+		Tenant: Tenant{
+			Header: GetEnvAsString(
+				"TENANT_HEADER",
+				"X-Tenant-ID",
+			),
+			Default: GetEnvAsString(
+				"TENANT_DEFAULT",
+				"default",
+			),
+		},
+		Auth: Auth{
+			HMACSecret: GetEnvAsString(
+				"AUTH_HMAC_SECRET",
+				"",
+			),
+			JWKSSource: GetEnvAsString(
+				"AUTH_JWKS_SOURCE",
+				"",
+			),
+			Issuer: GetEnvAsString(
+				"AUTH_ISSUER",
+				"",
+			),
+			Audience: GetEnvAsString(
+				"AUTH_AUDIENCE",
+				"",
+			),
+			TenantClaim: GetEnvAsString(
+				"AUTH_TENANT_CLAIM",
+				"tenant_id",
+			),
+			TenantHeaderRoles: GetEnvAsSliceOfStrings(
+				"AUTH_TENANT_HEADER_ROLES",
+				",",
+				[]string{"admin"},
+			),
+			ExemptHTTPPaths: GetEnvAsSliceOfStrings(
+				"AUTH_EXEMPT_HTTP_PATHS",
+				",",
+				[]string{"/", "/healthz", "/readyz", "/debug/pprof/*"},
+			),
+			ExemptGRPCMethods: GetEnvAsSliceOfStrings(
+				"AUTH_EXEMPT_GRPC_METHODS",
+				",",
+				[]string{"/grpc.*"},
+			),
+			JWKSRefreshInterval: GetEnvAsDuration(
+				"AUTH_JWKS_REFRESH_INTERVAL",
+				time.Hour,
+			),
+			Leeway: GetEnvAsDuration(
+				"AUTH_LEEWAY",
+				time.Minute,
+			),
+			Enabled: GetEnvAsBool(
+				"AUTH_ENABLED",
+				false,
+			),
+		},
+		Authz: Authz{
+			Roles: GetEnvAsMapOfSliceOfStrings(
+				"AUTHZ_ROLES",
+				";",
+				",",
+				map[string][]string{
+					"admin":  {"*"},
+					"editor": {"user:create", "user:read", "user:search", "user:update", "user:delete"},
+					"viewer": {"user:read", "user:search"},
+				},
+			),
+			Scopes: GetEnvAsMapOfSliceOfStrings(
+				"AUTHZ_SCOPES",
+				";",
+				",",
+				map[string][]string{
+					"user:create": {"user:create"},
+					"user:read":   {"user:read"},
+					"user:search": {"user:search"},
+				},
+			),
+			Enabled: GetEnvAsBool(
+				"AUTHZ_ENABLED",
+				false,
+			),
+		},
+		APIKey: APIKey{
+			Header: GetEnvAsString(
+				"APIKEY_HEADER",
+				"X-API-Key",
+			),
+			CacheTTL: GetEnvAsDuration(
+				"APIKEY_CACHE_TTL",
+				5*time.Minute,
+			),
+			QuotaWindow: GetEnvAsDuration(
+				"APIKEY_QUOTA_WINDOW",
+				time.Hour,
+			),
+			TouchInterval: GetEnvAsDuration(
+				"APIKEY_TOUCH_INTERVAL",
+				time.Minute,
+			),
+			DefaultQuota: GetEnvAsInt(
+				"APIKEY_DEFAULT_QUOTA",
+				0,
+			),
+			Enabled: GetEnvAsBool(
+				"APIKEY_ENABLED",
+				false,
+			),
+		},
+		RateLimit: RateLimit{
+			Algorithm: GetEnvAsString(
+				"RATELIMIT_ALGORITHM",
+				RateLimitAlgorithmTokenBucket,
+			),
+			Rules: GetEnvAsRateLimitRules(
+				"RATELIMIT_RULES",
+				map[string]RateLimitRule{
+					"GET /user/search/{searchTerm}": {
+						Requests: 10,
+						Burst:    20,
+						Period:   time.Second,
+					},
+					"/User/Search": {
+						Requests: 10,
+						Burst:    20,
+						Period:   time.Second,
+					},
+				},
+			),
+			Default: GetEnvAsRateLimitRule(
+				"RATELIMIT_DEFAULT",
+				RateLimitRule{},
+			),
+			Enabled: GetEnvAsBool(
+				"RATELIMIT_ENABLED",
+				false,
+			),
+		},
+		Audit: Audit{
+			RequestIDHeader: GetEnvAsString(
+				"AUDIT_REQUEST_ID_HEADER",
+				"X-Request-ID",
+			),
+			Enabled: GetEnvAsBool(
+				"AUDIT_ENABLED",
+				false,
+			),
+		},
+		Health: Health{
+			CheckInterval: GetEnvAsDuration(
+				"HEALTH_CHECK_INTERVAL",
+				10*time.Second,
+			),
+			CheckTimeout: GetEnvAsDuration(
+				"HEALTH_CHECK_TIMEOUT",
+				2*time.Second,
+			),
+			MaxConsumerLag: int64(GetEnvAsInt(
+				"HEALTH_MAX_CONSUMER_LAG",
+				10000,
+			)),
+		},
+		Shutdown: Shutdown{
+			DrainTimeout: GetEnvAsDuration(
+				"SHUTDOWN_DRAIN_TIMEOUT",
+				30*time.Second,
+			),
+		},
+		Erasure: Erasure{
+			Brokers: GetEnvAsSliceOfStrings(
+				"KAFKA_BROKERS",
+				",",
+				[]string{},
+			),
+			TombstoneTopics: GetEnvAsSliceOfStrings(
+				"ERASURE_TOMBSTONE_TOPICS",
+				",",
+				[]string{"mysql.go_api_demo.users"},
+			),
+		},
+		Outbox: Outbox{
+			Brokers: GetEnvAsSliceOfStrings(
+				"KAFKA_BROKERS",
+				",",
+				[]string{},
+			),
+			Topic: GetEnvAsString(
+				"OUTBOX_TOPIC",
+				"mysql.go_api_demo.users",
+			),
+			Format: GetEnvAsString(
+				"OUTBOX_FORMAT",
+				OutboxFormatAvro,
+			),
+			PollInterval: GetEnvAsDuration(
+				"OUTBOX_POLL_INTERVAL",
+				time.Second,
+			),
+			BatchSize: GetEnvAsInt(
+				"OUTBOX_BATCH_SIZE",
+				100,
+			),
+			Enabled: GetEnvAsBool(
+				"OUTBOX_ENABLED",
+				false,
+			),
+		},
+		CloudEvents: CloudEvents{
+			Source: GetEnvAsString(
+				"CLOUDEVENTS_SOURCE",
+				"/go-api-demo",
+			),
+		},
 		TopicConfigs: TopicConfigs{
 			Brokers: GetEnvAsSliceOfStrings(
 				"KAFKA_BROKERS",
@@ -242,6 +806,14 @@ func New() Config {
 			},
 		},
 		SchemaRegistry: SchemaRegistry{
+			Type: GetEnvAsString(
+				"KAFKA_SCHEMA_REGISTRY_TYPE",
+				SchemaRegistryTypeHTTP,
+			),
+			Dir: GetEnvAsString(
+				"KAFKA_SCHEMA_REGISTRY_DIR",
+				"docker/schema/subjects",
+			),
 			ClientTimeout: GetEnvAsDuration(
 				"KAFKA_SCHEMA_REGISTRY_CLIENT_TIMEOUT",
 				3*time.Second),
@@ -304,6 +876,12 @@ func New() Config {
 				"pass",
 			),
 		},
+		UserCache: UserCache{
+			VersionTTL: GetEnvAsDuration(
+				"USER_CACHE_VERSION_TTL",
+				7*24*time.Hour,
+			),
+		},
 		Elasticsearch: elasticsearch.Config{
 			Addresses: GetEnvAsSliceOfStrings(
 				"ELASTICSEARCH_ADDRESSES",
@@ -369,6 +947,106 @@ func GetEnvAsSliceOfStrings(
 	return defaultVal
 }
 
+// GetEnvAsMapOfSliceOfStrings parses values of the form k1=v1,v2;k2=v3,
+// in which entries are separated by entrySeparator (;) and the values of
+// each entry by separator (,).
+func GetEnvAsMapOfSliceOfStrings(
+	key string,
+	entrySeparator string,
+	separator string,
+	defaultVal map[string][]string,
+) map[string][]string {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultVal
+	}
+
+	m := make(map[string][]string)
+
+	for _, entry := range strings.Split(val, entrySeparator) {
+		if entry == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(entry, "=")
+		if !ok || k == "" {
+			panic(fmt.Sprintf("%s: invalid entry: %q", key, entry))
+		}
+
+		m[k] = strings.Split(v, separator)
+	}
+
+	return m
+}
+
+// GetEnvAsRateLimitRule parses values of the form requests,period[,burst]
+// (e.g., 10,1s,20), in which burst defaults to requests.
+func GetEnvAsRateLimitRule(
+	key string,
+	defaultVal RateLimitRule,
+) RateLimitRule {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultVal
+	}
+
+	if val == "" {
+		return RateLimitRule{}
+	}
+
+	return parseRateLimitRule(key, strings.Split(val, ","))
+}
+
+// GetEnvAsRateLimitRules parses values of the form
+// k1=requests,period[,burst];k2=requests,period[,burst].
+func GetEnvAsRateLimitRules(
+	key string,
+	defaultVal map[string]RateLimitRule,
+) map[string]RateLimitRule {
+	if _, ok := os.LookupEnv(key); !ok {
+		return defaultVal
+	}
+
+	rules := make(map[string]RateLimitRule)
+
+	for k, v := range GetEnvAsMapOfSliceOfStrings(key, ";", ",", nil) {
+		rules[k] = parseRateLimitRule(key, v)
+	}
+
+	return rules
+}
+
+func parseRateLimitRule(key string, v []string) RateLimitRule {
+	if len(v) < 2 || len(v) > 3 {
+		panic(fmt.Sprintf("%s: invalid rule: %q", key, strings.Join(v, ",")))
+	}
+
+	requests, err := strconv.Atoi(v[0])
+	if err != nil || requests < 0 {
+		panic(fmt.Sprintf("%s: invalid requests: %q", key, v[0]))
+	}
+
+	period, err := time.ParseDuration(v[1])
+	if err != nil || period < time.Millisecond {
+		panic(fmt.Sprintf("%s: invalid period: %q", key, v[1]))
+	}
+
+	burst := requests
+
+	if len(v) == 3 {
+		burst, err = strconv.Atoi(v[2])
+		if err != nil || burst < 1 {
+			panic(fmt.Sprintf("%s: invalid burst: %q", key, v[2]))
+		}
+	}
+
+	return RateLimitRule{
+		Requests: requests,
+		Burst:    burst,
+		Period:   period,
+	}
+}
+
 func GetEnvAsDuration(
 	key string,
 	defaultVal time.Duration,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/bendbennett/go-api-demo/internal/config"
 	"github.com/bendbennett/go-api-demo/internal/log"
@@ -35,6 +36,8 @@ type c struct {
 	log         log.Logger
 	decoder     decoder
 	groupID     string
+	mu          sync.Mutex
+	carried     kafka.ReaderStats
 }
 
 func NewConsumers(
@@ -62,20 +65,22 @@ func NewConsumers(
 
 		groupID := fmt.Sprintf("%v-%v", conf.ReaderConfig.GroupID, i)
 
-		err := consumerMetricsCollector.RegisterMetrics(telemetryEnabled, reader.Stats, groupID)
-
-		if err != nil {
-			return nil, nil, err
-		}
-
-		consumers = append(consumers, &c{
+		consumer := &c{
 			reader:      reader,
 			consumeFunc: consumeFunc,
 			processor:   processor,
 			log:         log,
 			groupID:     groupID,
 			decoder:     decoder,
-		})
+		}
+
+		err := consumerMetricsCollector.RegisterMetrics(telemetryEnabled, consumer.stats, groupID)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		consumers = append(consumers, consumer)
 
 		closers = append(closers, reader)
 	}
@@ -83,6 +88,57 @@ func NewConsumers(
 	return consumers, closers, nil
 }
 
+// GroupID returns the ID of the consumer, which is the group ID of the
+// reader suffixed with the index of the consumer.
+func (c *c) GroupID() string {
+	return c.groupID
+}
+
+// Lag returns the lag of the reader. The counters (e.g., Messages) of
+// kafka.ReaderStats are reset each time Stats is called, so they are
+// carried over to the next call of stats, for metrics.
+func (c *c) Lag() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.reader.Stats()
+	c.carry(s)
+
+	return s.Lag
+}
+
+// stats returns the stats of the reader, including the counters carried
+// over from calls of Lag since the last call of stats.
+func (c *c) stats() kafka.ReaderStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.reader.Stats()
+	c.carry(s)
+
+	s.Dials = c.carried.Dials
+	s.Fetches = c.carried.Fetches
+	s.Messages = c.carried.Messages
+	s.Bytes = c.carried.Bytes
+	s.Rebalances = c.carried.Rebalances
+	s.Timeouts = c.carried.Timeouts
+	s.Errors = c.carried.Errors
+
+	c.carried = kafka.ReaderStats{}
+
+	return s
+}
+
+func (c *c) carry(s kafka.ReaderStats) {
+	c.carried.Dials += s.Dials
+	c.carried.Fetches += s.Fetches
+	c.carried.Messages += s.Messages
+	c.carried.Bytes += s.Bytes
+	c.carried.Rebalances += s.Rebalances
+	c.carried.Timeouts += s.Timeouts
+	c.carried.Errors += s.Errors
+}
+
 // Run is executed in a loop to continuously consume messages.
 // TODO: Implement retry topic for error cases.
 func (c *c) Run(ctx context.Context) error {
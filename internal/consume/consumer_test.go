@@ -101,3 +101,38 @@ func TestUserConsumer_Consume(t *testing.T) {
 		})
 	}
 }
+
+type statsReaderMock struct {
+	readerMock
+	stats []kafka.ReaderStats
+}
+
+func (r *statsReaderMock) Stats() kafka.ReaderStats {
+	s := r.stats[0]
+	r.stats = r.stats[1:]
+
+	return s
+}
+
+func TestUserConsumer_Lag(t *testing.T) {
+	consumer := &c{
+		reader: &statsReaderMock{
+			stats: []kafka.ReaderStats{
+				{Messages: 3, Lag: 10},
+				{Messages: 2, Lag: 5},
+				{Messages: 1, Lag: 1},
+				{Messages: 4, Lag: 0},
+			},
+		},
+	}
+
+	assert.Equal(t, int64(10), consumer.Lag())
+	assert.Equal(t, int64(5), consumer.Lag())
+
+	stats := consumer.stats()
+	assert.Equal(t, int64(6), stats.Messages)
+	assert.Equal(t, int64(1), stats.Lag)
+
+	stats = consumer.stats()
+	assert.Equal(t, int64(4), stats.Messages)
+}
@@ -1,6 +1,8 @@
 package consume
 
 import (
+	"context"
+	"errors"
 	"net"
 	"strconv"
 
@@ -46,3 +48,28 @@ func CreateTopics(topicConfigs config.TopicConfigs) error {
 
 	return nil
 }
+
+// Brokers checks that at least one of the brokers is reachable.
+type Brokers []string
+
+func (b Brokers) Check(ctx context.Context) error {
+	var (
+		dialer kafka.Dialer
+		err    error
+	)
+
+	for _, broker := range b {
+		var conn *kafka.Conn
+
+		conn, err = dialer.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+	}
+
+	if err == nil {
+		err = errors.New("no brokers")
+	}
+
+	return err
+}
@@ -0,0 +1,28 @@
+package grpcutil
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// NewServerStream returns ss with its context overridden by ctx, so that
+// stream interceptors can pass values (e.g., claims) to handlers.
+func NewServerStream(
+	ctx context.Context,
+	ss grpc.ServerStream,
+) *serverStream {
+	return &serverStream{
+		ss,
+		ctx,
+	}
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
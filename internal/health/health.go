@@ -0,0 +1,41 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// Statuses of checks.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker returns an error if a dependency (e.g., a database) is
+// unavailable.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function (e.g., sql.DB.PingContext) to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type lagger interface {
+	Lag() int64
+}
+
+// MaxLag returns a checker that fails if the lag of l (e.g., a consumer)
+// exceeds max.
+func MaxLag(l lagger, max int64) Checker {
+	return CheckerFunc(func(context.Context) error {
+		if lag := l.Lag(); lag > max {
+			return fmt.Errorf("lag %d exceeds %d", lag, max)
+		}
+
+		return nil
+	})
+}
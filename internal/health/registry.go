@@ -0,0 +1,248 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type check struct {
+	name    string
+	checker Checker
+}
+
+// Result is the outcome of the latest run of a check.
+type Result struct {
+	Status  string
+	Error   string
+	Latency time.Duration
+}
+
+type r struct {
+	checks     []check
+	results    map[string]Result
+	grpcServer *health.Server
+	services   []string
+	interval   time.Duration
+	timeout    time.Duration
+	logger     log.Logger
+	mu         sync.RWMutex
+	ready      bool
+	stopped    bool
+}
+
+// NewRegistry returns a registry of the checks of the dependencies of the
+// application. The checks are run every interval rather than per request,
+// so that probes do not load the dependencies. The statuses of services
+// (e.g., User) served over gRPC are reported by the gRPC health service.
+func NewRegistry(
+	conf config.Health,
+	logger log.Logger,
+	services ...string,
+) *r {
+	grpcServer := health.NewServer()
+
+	for _, service := range append([]string{""}, services...) {
+		grpcServer.SetServingStatus(service, healthgrpc.HealthCheckResponse_NOT_SERVING)
+	}
+
+	return &r{
+		results:    make(map[string]Result),
+		grpcServer: grpcServer,
+		services:   services,
+		interval:   conf.CheckInterval,
+		timeout:    conf.CheckTimeout,
+		logger:     logger,
+	}
+}
+
+// Register adds a check with name. Checks must be registered before the
+// registry is run.
+func (rg *r) Register(name string, checker Checker) {
+	rg.checks = append(rg.checks, check{name, checker})
+}
+
+// Run runs the checks immediately, and then every interval, until ctx is
+// cancelled.
+func (rg *r) Run(ctx context.Context) error {
+	ticker := time.NewTicker(rg.interval)
+	defer ticker.Stop()
+
+	for {
+		rg.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return rg.Stop(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop reports the application as not ready, so that traffic is no
+// longer routed to it while it drains. The registry is therefore stopped
+// before the routers.
+func (rg *r) Stop(context.Context) error {
+	rg.mu.Lock()
+	rg.ready = false
+	rg.stopped = true
+	rg.mu.Unlock()
+
+	rg.grpcServer.Shutdown()
+
+	return nil
+}
+
+// check runs the checks concurrently, and updates the results and the
+// statuses reported by the gRPC health service.
+func (rg *r) check(ctx context.Context) {
+	results := make([]Result, len(rg.checks))
+
+	var wg sync.WaitGroup
+
+	for n, c := range rg.checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, rg.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.checker.Check(checkCtx)
+
+			results[n] = Result{
+				Status:  StatusUp,
+				Latency: time.Since(start),
+			}
+
+			if err != nil {
+				results[n].Status = StatusDown
+				results[n].Error = err.Error()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if rg.stopped {
+		return
+	}
+
+	ready := true
+
+	for n, c := range rg.checks {
+		prev, ok := rg.results[c.name]
+
+		switch {
+		case results[n].Status == StatusDown:
+			ready = false
+
+			if !ok || prev.Status != StatusDown {
+				rg.logger.Errorf("health: %s down: %s", c.name, results[n].Error)
+			}
+		case ok && prev.Status == StatusDown:
+			rg.logger.Infof("health: %s up", c.name)
+		}
+
+		rg.results[c.name] = results[n]
+	}
+
+	rg.ready = ready
+
+	status := healthgrpc.HealthCheckResponse_SERVING
+	if !ready {
+		status = healthgrpc.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, service := range append([]string{""}, rg.services...) {
+		rg.grpcServer.SetServingStatus(service, status)
+	}
+}
+
+type checkOutput struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type output struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkOutput `json:"checks"`
+}
+
+func (rg *r) output() (output, bool) {
+	rg.mu.RLock()
+	defer rg.mu.RUnlock()
+
+	out := output{
+		Status: StatusUp,
+		Checks: make(map[string]checkOutput, len(rg.results)),
+	}
+
+	for name, res := range rg.results {
+		out.Checks[name] = checkOutput{
+			Status:    res.Status,
+			LatencyMS: float64(res.Latency.Microseconds()) / 1000,
+			Error:     res.Error,
+		}
+	}
+
+	if !rg.ready {
+		out.Status = StatusDown
+	}
+
+	return out, rg.ready
+}
+
+// Liveness responds with 200 OK while the application is running, and
+// reports the statuses of its dependencies, which do not affect liveness
+// as restarting the application would not restore them.
+func (rg *r) Liveness(w http.ResponseWriter, _ *http.Request) {
+	out, _ := rg.output()
+	out.Status = StatusUp
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		out,
+	)
+}
+
+// Readiness responds with 503 Service Unavailable until all checks have
+// passed, if any check has since failed, and once the application is
+// stopping.
+func (rg *r) Readiness(w http.ResponseWriter, _ *http.Request) {
+	out, ready := rg.output()
+
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+
+	response.WriteResponse(
+		w,
+		code,
+		out,
+	)
+}
+
+// GRPCServer returns the gRPC health service.
+func (rg *r) GRPCServer() healthgrpc.HealthServer {
+	return rg.grpcServer
+}
@@ -0,0 +1,173 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type loggerMock struct{}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+type laggerMock int64
+
+func (l laggerMock) Lag() int64 {
+	return int64(l)
+}
+
+var healthConf = config.Health{
+	CheckInterval: time.Minute,
+	CheckTimeout:  time.Second,
+}
+
+func TestRegistry_Readiness(t *testing.T) {
+	up := CheckerFunc(func(context.Context) error { return nil })
+	down := CheckerFunc(func(context.Context) error { return errors.New("unreachable") })
+
+	cases := []struct {
+		name           string
+		checks         map[string]Checker
+		run            bool
+		expectedCode   int
+		expectedStatus string
+		expectedChecks map[string]string
+	}{
+		{
+			"not ready before checks have run",
+			map[string]Checker{"mysql": up},
+			false,
+			http.StatusServiceUnavailable,
+			StatusDown,
+			map[string]string{},
+		},
+		{
+			"ready when all checks pass",
+			map[string]Checker{"mysql": up, "redis": up},
+			true,
+			http.StatusOK,
+			StatusUp,
+			map[string]string{"mysql": StatusUp, "redis": StatusUp},
+		},
+		{
+			"not ready when a check fails",
+			map[string]Checker{"mysql": up, "redis": down},
+			true,
+			http.StatusServiceUnavailable,
+			StatusDown,
+			map[string]string{"mysql": StatusUp, "redis": StatusDown},
+		},
+		{
+			"not ready when lag exceeds max",
+			map[string]Checker{"consumer": MaxLag(laggerMock(11), 10)},
+			true,
+			http.StatusServiceUnavailable,
+			StatusDown,
+			map[string]string{"consumer": StatusDown},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			registry := NewRegistry(healthConf, loggerMock{})
+
+			for name, checker := range c.checks {
+				registry.Register(name, checker)
+			}
+
+			if c.run {
+				registry.check(context.Background())
+			}
+
+			rec := httptest.NewRecorder()
+			registry.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, c.expectedCode, rec.Code)
+
+			var out output
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&out))
+
+			assert.Equal(t, c.expectedStatus, out.Status)
+
+			checks := make(map[string]string)
+			for name, check := range out.Checks {
+				checks[name] = check.Status
+			}
+
+			assert.Equal(t, c.expectedChecks, checks)
+
+			rec = httptest.NewRecorder()
+			registry.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestRegistry_GRPCServer(t *testing.T) {
+	var err error
+
+	registry := NewRegistry(healthConf, loggerMock{}, "User")
+	registry.Register("mysql", CheckerFunc(func(context.Context) error { return err }))
+
+	status := func(service string) healthgrpc.HealthCheckResponse_ServingStatus {
+		resp, err := registry.GRPCServer().Check(
+			context.Background(),
+			&healthgrpc.HealthCheckRequest{Service: service},
+		)
+		require.NoError(t, err)
+
+		return resp.Status
+	}
+
+	assert.Equal(t, healthgrpc.HealthCheckResponse_NOT_SERVING, status("User"))
+
+	registry.check(context.Background())
+
+	assert.Equal(t, healthgrpc.HealthCheckResponse_SERVING, status(""))
+	assert.Equal(t, healthgrpc.HealthCheckResponse_SERVING, status("User"))
+
+	err = errors.New("unreachable")
+	registry.check(context.Background())
+
+	assert.Equal(t, healthgrpc.HealthCheckResponse_NOT_SERVING, status("User"))
+}
+
+func TestRegistry_Run(t *testing.T) {
+	registry := NewRegistry(healthConf, loggerMock{})
+	registry.Register("mysql", CheckerFunc(func(context.Context) error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- registry.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, ready := registry.output()
+		return ready
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	_, ready := registry.output()
+	assert.False(t, ready)
+}
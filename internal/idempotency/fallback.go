@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+)
+
+type f struct {
+	primary  Store
+	fallback Store
+	logger   log.Logger
+	failing  atomic.Bool
+}
+
+// NewFallback returns a store that uses fallback for as long as primary
+// (e.g., a store shared by all instances) returns errors, so that requests
+// are not rejected while primary is unavailable. Keys are only deduplicated
+// by this instance while falling back. Failures and recoveries of primary
+// are logged once each, rather than per request.
+func NewFallback(
+	primary Store,
+	fallback Store,
+	logger log.Logger,
+) *f {
+	return &f{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+func (f *f) Begin(
+	ctx context.Context,
+	key string,
+	fingerprint string,
+) (Record, bool, error) {
+	rec, ok, err := f.primary.Begin(ctx, key, fingerprint)
+	if err == nil {
+		f.recovered(ctx)
+
+		return rec, ok, nil
+	}
+
+	f.failed(ctx, err)
+
+	return f.fallback.Begin(ctx, key, fingerprint)
+}
+
+func (f *f) Complete(
+	ctx context.Context,
+	key string,
+	record Record,
+) error {
+	err := f.primary.Complete(ctx, key, record)
+	if err == nil {
+		f.recovered(ctx)
+
+		return nil
+	}
+
+	f.failed(ctx, err)
+
+	return f.fallback.Complete(ctx, key, record)
+}
+
+func (f *f) Release(
+	ctx context.Context,
+	key string,
+) error {
+	err := f.primary.Release(ctx, key)
+	if err == nil {
+		f.recovered(ctx)
+
+		return nil
+	}
+
+	f.failed(ctx, err)
+
+	return f.fallback.Release(ctx, key)
+}
+
+func (f *f) recovered(ctx context.Context) {
+	if f.failing.CompareAndSwap(true, false) {
+		f.logger.InfofContext(ctx, "idempotency store recovered")
+	}
+}
+
+func (f *f) failed(
+	ctx context.Context,
+	err error,
+) {
+	if f.failing.CompareAndSwap(false, true) {
+		f.logger.ErrorfContext(ctx, "idempotency store failed, falling back: %s", err)
+	}
+}
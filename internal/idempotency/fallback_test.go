@@ -0,0 +1,98 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type loggerMock struct{}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+// failingStoreMock returns err, when set, rather than using its records.
+type failingStoreMock struct {
+	storeMock
+	err error
+}
+
+func (m *failingStoreMock) Begin(ctx context.Context, key string, fingerprint string) (Record, bool, error) {
+	if m.err != nil {
+		return Record{}, false, m.err
+	}
+
+	return m.storeMock.Begin(ctx, key, fingerprint)
+}
+
+func (m *failingStoreMock) Complete(ctx context.Context, key string, record Record) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	return m.storeMock.Complete(ctx, key, record)
+}
+
+func (m *failingStoreMock) Release(ctx context.Context, key string) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	return m.storeMock.Release(ctx, key)
+}
+
+func TestFallback(t *testing.T) {
+	type response struct {
+		ID string
+	}
+
+	primary := &failingStoreMock{storeMock: storeMock{records: map[string]Record{}}}
+	fallback := &storeMock{records: map[string]Record{}}
+
+	store := NewFallback(primary, fallback, loggerMock{})
+
+	calls := 0
+
+	f := func() (response, error) {
+		calls++
+		return response{ID: "id"}, nil
+	}
+
+	_, replayed, err := Do(context.Background(), store, "key-1", "request", f)
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Contains(t, primary.records, "key-1")
+	assert.False(t, store.failing.Load())
+
+	primary.err = errors.New("primary error")
+
+	// Requests are processed, and deduplicated, using fallback while
+	// primary fails.
+	for _, expectedReplayed := range []bool{false, true} {
+		resp, replayed, err := Do(context.Background(), store, "key-2", "request", f)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedReplayed, replayed)
+		assert.Equal(t, response{ID: "id"}, resp)
+	}
+
+	assert.Equal(t, 2, calls)
+	assert.Contains(t, fallback.records, "key-2")
+	assert.NotContains(t, primary.records, "key-2")
+	assert.True(t, store.failing.Load())
+
+	primary.err = nil
+
+	_, replayed, err = Do(context.Background(), store, "key-1", "request", f)
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, 2, calls)
+	assert.False(t, store.failing.Load())
+}
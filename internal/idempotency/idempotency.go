@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	// ErrKeyReused is returned when a key is reused for a request that
+	// differs from the request with which the key was first used.
+	ErrKeyReused = errors.New("idempotency key reused with a different request")
+	// ErrInProgress is returned when a request with the same key is
+	// still being processed.
+	ErrInProgress = errors.New("request with idempotency key in progress")
+)
+
+// Record holds the fingerprint of the request with which a key was first
+// used and, once that request has completed, its response.
+type Record struct {
+	Fingerprint string `json:"fingerprint"`
+	Response    []byte `json:"response,omitempty"`
+}
+
+// Store holds records by key. Records expire so that keys can eventually
+// be reused.
+type Store interface {
+	// Begin stores a record for key without a response, unless a record
+	// for key already exists, in which case the existing record is returned
+	// with false. Records without a response expire sooner than completed
+	// records so that keys are released if the request is never completed.
+	Begin(ctx context.Context, key string, fingerprint string) (Record, bool, error)
+	// Complete stores the record, with its response, for key.
+	Complete(ctx context.Context, key string, record Record) error
+	// Release deletes the record for key so that the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint returns a hash of the JSON encoding of request.
+func Fingerprint(request any) (string, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Do calls f, and stores its response, unless key has previously been used
+// for a request with the same fingerprint, in which case the stored response
+// is returned instead, together with true. The response is stored as JSON
+// and decoded into response. If f returns an error the key is released.
+func Do[T any](
+	ctx context.Context,
+	store Store,
+	key string,
+	request any,
+	f func() (T, error),
+) (T, bool, error) {
+	var response T
+
+	fingerprint, err := Fingerprint(request)
+	if err != nil {
+		return response, false, err
+	}
+
+	rec, ok, err := store.Begin(ctx, key, fingerprint)
+	if err != nil {
+		return response, false, err
+	}
+
+	if !ok {
+		switch {
+		case rec.Fingerprint != fingerprint:
+			return response, false, ErrKeyReused
+		case rec.Response == nil:
+			return response, false, ErrInProgress
+		}
+
+		if err := json.Unmarshal(rec.Response, &response); err != nil {
+			return response, false, err
+		}
+
+		return response, true, nil
+	}
+
+	response, err = f()
+	if err != nil {
+		_ = store.Release(ctx, key)
+		return response, false, err
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		return response, false, err
+	}
+
+	// The response is returned even if it cannot be stored, as the request
+	// has completed. Retries are processed again once the record expires.
+	_ = store.Complete(
+		ctx,
+		key,
+		Record{
+			Fingerprint: fingerprint,
+			Response:    b,
+		},
+	)
+
+	return response, false, nil
+}
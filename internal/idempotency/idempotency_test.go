@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type storeMock struct {
+	records map[string]Record
+}
+
+func (m *storeMock) Begin(_ context.Context, key string, fingerprint string) (Record, bool, error) {
+	if r, ok := m.records[key]; ok {
+		return r, false, nil
+	}
+
+	m.records[key] = Record{Fingerprint: fingerprint}
+
+	return Record{}, true, nil
+}
+
+func (m *storeMock) Complete(_ context.Context, key string, record Record) error {
+	m.records[key] = record
+
+	return nil
+}
+
+func (m *storeMock) Release(_ context.Context, key string) error {
+	delete(m.records, key)
+
+	return nil
+}
+
+func TestDo(t *testing.T) {
+	type response struct {
+		ID string
+	}
+
+	t.Run("replays response for same request", func(t *testing.T) {
+		store := &storeMock{records: map[string]Record{}}
+		calls := 0
+
+		f := func() (response, error) {
+			calls++
+			return response{ID: "id"}, nil
+		}
+
+		resp, replayed, err := Do(context.Background(), store, "key", "request", f)
+		assert.NoError(t, err)
+		assert.False(t, replayed)
+		assert.Equal(t, response{ID: "id"}, resp)
+
+		resp, replayed, err = Do(context.Background(), store, "key", "request", f)
+		assert.NoError(t, err)
+		assert.True(t, replayed)
+		assert.Equal(t, response{ID: "id"}, resp)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("key reused for different request", func(t *testing.T) {
+		store := &storeMock{records: map[string]Record{}}
+		f := func() (response, error) {
+			return response{ID: "id"}, nil
+		}
+
+		_, _, err := Do(context.Background(), store, "key", "request", f)
+		assert.NoError(t, err)
+
+		_, _, err = Do(context.Background(), store, "key", "other request", f)
+		assert.ErrorIs(t, err, ErrKeyReused)
+	})
+
+	t.Run("request in progress", func(t *testing.T) {
+		store := &storeMock{records: map[string]Record{}}
+
+		_, _, err := Do(
+			context.Background(),
+			store,
+			"key",
+			"request",
+			func() (response, error) {
+				_, _, err := Do(
+					context.Background(),
+					store,
+					"key",
+					"request",
+					func() (response, error) {
+						return response{}, nil
+					},
+				)
+
+				return response{}, err
+			},
+		)
+
+		assert.ErrorIs(t, err, ErrInProgress)
+	})
+
+	t.Run("key released on error", func(t *testing.T) {
+		store := &storeMock{records: map[string]Record{}}
+
+		_, _, err := Do(
+			context.Background(),
+			store,
+			"key",
+			"request",
+			func() (response, error) {
+				return response{}, errors.New("error")
+			},
+		)
+		assert.Error(t, err)
+		assert.Empty(t, store.records)
+
+		resp, replayed, err := Do(
+			context.Background(),
+			store,
+			"key",
+			"request",
+			func() (response, error) {
+				return response{ID: "id"}, nil
+			},
+		)
+		assert.NoError(t, err)
+		assert.False(t, replayed)
+		assert.Equal(t, response{ID: "id"}, resp)
+	})
+}
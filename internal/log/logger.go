@@ -73,7 +73,7 @@ func (l logger) Error(err error) {
 func (l logger) ErrorContext(ctx context.Context, err error) {
 	msg := fmt.Sprintf("%+v", err)
 	span := spanFromContext(ctx)
-	spanFields := span.spanFields()
+	spanFields := append(span.spanFields(), contextFields(ctx)...)
 	span.logToSpan("error", msg)
 	l.logger.Error(msg, spanFields...)
 }
@@ -85,7 +85,7 @@ func (l logger) Errorf(msg string, args ...interface{}) {
 func (l logger) ErrorfContext(ctx context.Context, msg string, args ...interface{}) {
 	m := fmt.Sprintf(msg, args...)
 	span := spanFromContext(ctx)
-	spanFields := span.spanFields()
+	spanFields := append(span.spanFields(), contextFields(ctx)...)
 	span.logToSpan("error", m)
 	l.logger.Error(m, spanFields...)
 }
@@ -97,11 +97,29 @@ func (l logger) Infof(msg string, args ...interface{}) {
 func (l logger) InfofContext(ctx context.Context, msg string, args ...interface{}) {
 	m := fmt.Sprintf(msg, args...)
 	span := spanFromContext(ctx)
-	spanFields := span.spanFields()
+	spanFields := append(span.spanFields(), contextFields(ctx)...)
 	span.logToSpan("info", m)
 	l.logger.Info(m, spanFields...)
 }
 
+type subjectKey struct{}
+
+// WithSubject returns a copy of ctx carrying the subject (e.g., the
+// authenticated caller), which is logged by the context methods.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+func contextFields(ctx context.Context) []zapcore.Field {
+	var fields []zapcore.Field
+
+	if subject, ok := ctx.Value(subjectKey{}).(string); ok && subject != "" {
+		fields = append(fields, zap.String("subject", subject))
+	}
+
+	return fields
+}
+
 type span struct {
 	trace.Span
 }
@@ -0,0 +1,155 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/segmentio/kafka-go"
+)
+
+// connector is used as source.connector in published envelopes so that
+// events relayed from the outbox can be distinguished from those emitted
+// by Debezium.
+const connector = "outbox"
+
+// Debezium op codes.
+var eventOps = map[string]string{
+	user.EventCreated:  "c",
+	user.EventUpdated:  "u",
+	user.EventDeleted:  "d",
+	user.EventRestored: "u",
+	user.EventPurged:   "d",
+}
+
+type encoder interface {
+	Encode(interface{}) ([]byte, error)
+	ValueName() (string, error)
+}
+
+type source struct {
+	name  string
+	db    string
+	table string
+}
+
+type avroMarshaller struct {
+	encoder   encoder
+	valueName string
+	source    source
+}
+
+var _ marshaller = (*avroMarshaller)(nil)
+
+// NewAvroMarshaller returns a marshaller that encodes events as Debezium
+// envelopes, using the schema held by encoder, so that they can be consumed
+// in the same way as events emitted by Kafka Connect. The server name,
+// database and table are resolved from the name of the value record in the
+// schema (e.g., mysql.go_api_demo.users.Value).
+func NewAvroMarshaller(encoder encoder) (*avroMarshaller, error) {
+	valueName, err := encoder.ValueName()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(valueName, ".")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf(
+			"value name %s is not of the form <server>.<database>.<table>.Value",
+			valueName,
+		)
+	}
+
+	return &avroMarshaller{
+		encoder:   encoder,
+		valueName: valueName,
+		source: source{
+			name:  strings.Join(parts[:len(parts)-3], "."),
+			db:    parts[len(parts)-3],
+			table: parts[len(parts)-2],
+		},
+	}, nil
+}
+
+func (m *avroMarshaller) Marshal(
+	_ context.Context,
+	evt user.Event,
+) (kafka.Message, error) {
+	op, ok := eventOps[evt.Type]
+	if !ok {
+		return kafka.Message{}, fmt.Errorf("event type %q: not implemented", evt.Type)
+	}
+
+	if evt.UserID() == "" {
+		return kafka.Message{}, fmt.Errorf("event type %q: user missing", evt.Type)
+	}
+
+	value, err := m.encoder.Encode(m.native(op, evt))
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	return kafka.Message{
+		Key:   []byte(evt.Key()),
+		Value: value,
+	}, nil
+}
+
+// native returns the event in the native form expected by goavro for a
+// Debezium envelope. Source fields without a default in the schema, that
+// have no equivalent outside of the binlog (e.g., file, pos), are zeroed.
+func (m *avroMarshaller) native(
+	op string,
+	evt user.Event,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"before": m.nativeValue(evt.Before),
+		"after":  m.nativeValue(evt.After),
+		"source": map[string]interface{}{
+			"version":   "",
+			"connector": connector,
+			"name":      m.source.name,
+			"ts_ms":     evt.OccurredAt.UnixMilli(),
+			"db":        m.source.db,
+			"table": map[string]interface{}{
+				"string": m.source.table,
+			},
+			"server_id": int64(0),
+			"file":      "",
+			"pos":       int64(0),
+			"row":       int32(0),
+		},
+		"op": op,
+		"ts_ms": map[string]interface{}{
+			"long": time.Now().UnixMilli(),
+		},
+	}
+}
+
+func (m *avroMarshaller) nativeValue(u *user.User) interface{} {
+	if u == nil {
+		return nil
+	}
+
+	var deletedAt interface{}
+
+	if u.DeletedAt != nil {
+		deletedAt = map[string]interface{}{
+			"long": u.DeletedAt.UnixMilli(),
+		}
+	}
+
+	return map[string]interface{}{
+		m.valueName: map[string]interface{}{
+			"id":         u.ID,
+			"first_name": u.FirstName,
+			"last_name":  u.LastName,
+			"created_at": u.CreatedAt.UnixMilli(),
+			"version":    u.Version,
+			"deleted_at": deletedAt,
+			"tenant_id":  u.TenantID,
+		},
+	}
+}
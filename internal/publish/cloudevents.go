@@ -0,0 +1,40 @@
+package publish
+
+import (
+	"context"
+
+	"github.com/bendbennett/go-api-demo/internal/cloudevents"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/segmentio/kafka-go"
+)
+
+type cloudEventsMarshaller struct {
+	source string
+	mode   string
+}
+
+var _ marshaller = (*cloudEventsMarshaller)(nil)
+
+// NewCloudEventsMarshaller returns a marshaller that encodes events as
+// CloudEvents with the given source, in structured or binary mode.
+func NewCloudEventsMarshaller(
+	source string,
+	mode string,
+) *cloudEventsMarshaller {
+	return &cloudEventsMarshaller{
+		source: source,
+		mode:   mode,
+	}
+}
+
+func (m *cloudEventsMarshaller) Marshal(
+	ctx context.Context,
+	evt user.Event,
+) (kafka.Message, error) {
+	e, err := cloudevents.FromUserEvent(ctx, m.source, evt)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	return cloudevents.KafkaMessage([]byte(evt.Key()), e, m.mode)
+}
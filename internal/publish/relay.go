@@ -0,0 +1,122 @@
+package publish
+
+import (
+	"context"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type outbox interface {
+	Relay(context.Context, int, func(context.Context, []user.Event) error, func(context.Context, error)) (int, error)
+}
+
+type writer interface {
+	WriteMessages(context.Context, ...kafka.Message) error
+}
+
+type marshaller interface {
+	Marshal(context.Context, user.Event) (kafka.Message, error)
+}
+
+type r struct {
+	outbox       outbox
+	writer       writer
+	marshaller   marshaller
+	log          log.Logger
+	pollInterval time.Duration
+	batchSize    int
+}
+
+// NewRelay returns a relay that polls the outbox and publishes
+// events using the format provided by marshaller.
+func NewRelay(
+	conf config.Outbox,
+	outbox outbox,
+	writer writer,
+	marshaller marshaller,
+	log log.Logger,
+) *r {
+	return &r{
+		outbox:       outbox,
+		writer:       writer,
+		marshaller:   marshaller,
+		log:          log,
+		pollInterval: conf.PollInterval,
+		batchSize:    conf.BatchSize,
+	}
+}
+
+// Run polls the outbox every pollInterval, draining it in batches
+// of batchSize, until ctx is cancelled.
+func (r *r) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.log.Infof("%s", ctx.Err())
+			return nil
+		case <-ticker.C:
+		}
+
+		for {
+			n, err := r.outbox.Relay(ctx, r.batchSize, r.publish, r.deadLettered)
+			if err != nil {
+				r.log.ErrorContext(ctx, err)
+				break
+			}
+
+			if n < r.batchSize {
+				break
+			}
+		}
+	}
+}
+
+// publish writes events keyed by user ID, so that events for the same
+// user are written to the same partition and compaction retains the latest.
+// The trace context stored with each event is restored so that publishing
+// is linked to the request that made the change.
+func (r *r) publish(
+	ctx context.Context,
+	events []user.Event,
+) error {
+	msgs := make([]kafka.Message, 0, len(events))
+
+	for _, evt := range events {
+		evtCtx := otel.GetTextMapPropagator().Extract(
+			ctx,
+			propagation.MapCarrier(evt.TraceContext),
+		)
+
+		msg, err := r.marshaller.Marshal(evtCtx, evt)
+		if err != nil {
+			return err
+		}
+
+		msg.Headers = append(msg.Headers, kafka.Header{
+			Key:   "event_type",
+			Value: []byte(evt.Type),
+		})
+
+		msgs = append(msgs, msg)
+	}
+
+	return r.writer.WriteMessages(ctx, msgs...)
+}
+
+// deadLettered logs events that were removed from the outbox without being
+// published as they could not be decoded.
+func (r *r) deadLettered(
+	ctx context.Context,
+	err error,
+) {
+	r.log.ErrorfContext(ctx, "event dead-lettered: %v", err)
+}
@@ -0,0 +1,381 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/cloudevents"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/schema"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const endpoint = "/subjects/mysql.go_api_demo.users-value/versions/1"
+
+type outboxMock struct {
+	events      []user.Event
+	deadLetters []error
+}
+
+func (o *outboxMock) Relay(
+	ctx context.Context,
+	_ int,
+	publish func(context.Context, []user.Event) error,
+	deadLettered func(context.Context, error),
+) (int, error) {
+	if err := publish(ctx, o.events); err != nil {
+		return 0, err
+	}
+
+	for _, err := range o.deadLetters {
+		deadLettered(ctx, err)
+	}
+
+	return len(o.events) + len(o.deadLetters), nil
+}
+
+type writerMock struct {
+	msgs []kafka.Message
+	err  error
+}
+
+func (w *writerMock) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+
+	return w.err
+}
+
+type logMock struct {
+	errors []string
+}
+
+func (l *logMock) Panic(error) {
+	panic("implement me")
+}
+
+func (l *logMock) Panicf(string, ...interface{}) {
+	panic("implement me")
+}
+
+func (l *logMock) Error(error) {
+	panic("implement me")
+}
+
+func (l *logMock) ErrorContext(context.Context, error) {
+	panic("implement me")
+}
+
+func (l *logMock) Errorf(string, ...interface{}) {
+	panic("implement me")
+}
+
+func (l *logMock) ErrorfContext(_ context.Context, format string, args ...interface{}) {
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+func (l *logMock) Infof(string, ...interface{}) {
+}
+
+func (l *logMock) InfofContext(context.Context, string, ...interface{}) {
+	panic("implement me")
+}
+
+func TestRelay_Publish_Avro(t *testing.T) {
+	client, err := schema.NewFileClient("../../docker/schema/subjects")
+	require.NoError(t, err)
+
+	enc, err := client.GetEncoder(endpoint)
+	require.NoError(t, err)
+
+	dec, err := client.GetDecoder(endpoint)
+	require.NoError(t, err)
+
+	createdAt := time.UnixMilli(1640995200000).UTC()
+
+	usr := user.User{
+		CreatedAt: createdAt,
+		TenantID:  "acme",
+		ID:        "f1e1c3b4-3b0a-4b8a-9b1a-1f1d1e1c1b1a",
+		FirstName: "john",
+		LastName:  "smith",
+		Version:   1,
+	}
+
+	deletedUsr := usr
+	deletedUsr.DeletedAt = &createdAt
+
+	cases := []struct {
+		name      string
+		events    []user.Event
+		expected  map[string]interface{}
+		expectErr string
+	}{
+		{
+			"created event is published as create envelope",
+			[]user.Event{
+				{
+					Type:       user.EventCreated,
+					After:      &usr,
+					OccurredAt: createdAt,
+				},
+			},
+			map[string]interface{}{
+				"before": nil,
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         usr.ID,
+						"first_name": usr.FirstName,
+						"last_name":  usr.LastName,
+						"created_at": createdAt.UnixMilli(),
+						"version":    usr.Version,
+						"deleted_at": nil,
+						"tenant_id":  usr.TenantID,
+					},
+				},
+				"op": "c",
+			},
+			"",
+		},
+		{
+			"updated event is published as update envelope",
+			[]user.Event{
+				{
+					Type:       user.EventUpdated,
+					Before:     &usr,
+					After:      &usr,
+					OccurredAt: createdAt,
+				},
+			},
+			map[string]interface{}{
+				"before": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         usr.ID,
+						"first_name": usr.FirstName,
+						"last_name":  usr.LastName,
+						"created_at": createdAt.UnixMilli(),
+						"version":    usr.Version,
+						"deleted_at": nil,
+						"tenant_id":  usr.TenantID,
+					},
+				},
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         usr.ID,
+						"first_name": usr.FirstName,
+						"last_name":  usr.LastName,
+						"created_at": createdAt.UnixMilli(),
+						"version":    usr.Version,
+						"deleted_at": nil,
+						"tenant_id":  usr.TenantID,
+					},
+				},
+				"op": "u",
+			},
+			"",
+		},
+		{
+			"deleted event is published as delete envelope",
+			[]user.Event{
+				{
+					Type:       user.EventDeleted,
+					Before:     &usr,
+					OccurredAt: createdAt,
+				},
+			},
+			map[string]interface{}{
+				"before": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         usr.ID,
+						"first_name": usr.FirstName,
+						"last_name":  usr.LastName,
+						"created_at": createdAt.UnixMilli(),
+						"version":    usr.Version,
+						"deleted_at": nil,
+						"tenant_id":  usr.TenantID,
+					},
+				},
+				"after": nil,
+				"op":    "d",
+			},
+			"",
+		},
+		{
+			"purged event is published as delete envelope",
+			[]user.Event{
+				{
+					Type:       user.EventPurged,
+					Before:     &deletedUsr,
+					OccurredAt: createdAt,
+				},
+			},
+			map[string]interface{}{
+				"before": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         usr.ID,
+						"first_name": usr.FirstName,
+						"last_name":  usr.LastName,
+						"created_at": createdAt.UnixMilli(),
+						"version":    usr.Version,
+						"deleted_at": map[string]interface{}{
+							"long": createdAt.UnixMilli(),
+						},
+						"tenant_id": usr.TenantID,
+					},
+				},
+				"after": nil,
+				"op":    "d",
+			},
+			"",
+		},
+		{
+			"unknown event type returns error",
+			[]user.Event{
+				{
+					Type:  "user.unknown",
+					After: &usr,
+				},
+			},
+			nil,
+			`event type "user.unknown": not implemented`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := &writerMock{}
+
+			m, err := NewAvroMarshaller(enc)
+			require.NoError(t, err)
+
+			relay := NewRelay(
+				config.Outbox{BatchSize: 10},
+				&outboxMock{events: c.events},
+				w,
+				m,
+				&logMock{},
+			)
+
+			n, err := relay.outbox.Relay(context.Background(), relay.batchSize, relay.publish, relay.deadLettered)
+
+			if c.expectErr != "" {
+				assert.EqualError(t, err, c.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, len(c.events), n)
+			require.Len(t, w.msgs, 1)
+			assert.Equal(t, []byte(usr.TenantID+":"+usr.ID), w.msgs[0].Key)
+
+			native, err := dec.Decode(w.msgs[0].Value)
+			require.NoError(t, err)
+
+			env := native.(map[string]interface{})
+			src := env["source"].(map[string]interface{})
+
+			assert.Equal(t, c.expected["before"], env["before"])
+			assert.Equal(t, c.expected["after"], env["after"])
+			assert.Equal(t, c.expected["op"], env["op"])
+			assert.Equal(t, createdAt.UnixMilli(), src["ts_ms"])
+			assert.Equal(t, "mysql", src["name"])
+			assert.Equal(t, "go_api_demo", src["db"])
+		})
+	}
+}
+
+func TestRelay_Publish_WriteError(t *testing.T) {
+	client, err := schema.NewFileClient("../../docker/schema/subjects")
+	require.NoError(t, err)
+
+	enc, err := client.GetEncoder(endpoint)
+	require.NoError(t, err)
+
+	m, err := NewAvroMarshaller(enc)
+	require.NoError(t, err)
+
+	relay := NewRelay(
+		config.Outbox{BatchSize: 10},
+		&outboxMock{},
+		&writerMock{err: errors.New("write error")},
+		m,
+		&logMock{},
+	)
+
+	err = relay.publish(context.Background(), []user.Event{
+		{
+			Type:  user.EventCreated,
+			After: &user.User{ID: "id"},
+		},
+	})
+
+	assert.EqualError(t, err, "write error")
+}
+
+func TestRelay_Publish_CloudEvents(t *testing.T) {
+	w := &writerMock{}
+
+	relay := NewRelay(
+		config.Outbox{BatchSize: 10},
+		&outboxMock{},
+		w,
+		NewCloudEventsMarshaller("/go-api-demo", cloudevents.ModeBinary),
+		&logMock{},
+	)
+
+	err := relay.publish(context.Background(), []user.Event{
+		{
+			ID:    "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+			Type:  user.EventCreated,
+			After: &user.User{TenantID: "acme", ID: "id"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, w.msgs, 1)
+
+	e, err := cloudevents.FromKafkaMessage(w.msgs[0])
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("acme:id"), w.msgs[0].Key)
+	assert.Equal(t, "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77", e.ID)
+	assert.Equal(t, user.EventCreated, e.Type)
+	assert.Equal(t, "id", e.Subject)
+}
+
+func TestRelay_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	relay := &r{
+		outbox:       &outboxMock{},
+		writer:       &writerMock{},
+		log:          &logMock{},
+		pollInterval: time.Millisecond,
+		batchSize:    10,
+	}
+
+	assert.NoError(t, relay.Run(ctx))
+}
+
+func TestRelay_DeadLettered(t *testing.T) {
+	l := &logMock{}
+
+	relay := NewRelay(
+		config.Outbox{BatchSize: 10},
+		&outboxMock{deadLetters: []error{errors.New("outbox id 1: unexpected end of JSON input")}},
+		&writerMock{},
+		nil,
+		l,
+	)
+
+	n, err := relay.outbox.Relay(context.Background(), relay.batchSize, relay.publish, relay.deadLettered)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"event dead-lettered: outbox id 1: unexpected end of JSON input"}, l.errors)
+}
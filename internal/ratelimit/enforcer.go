@@ -0,0 +1,207 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const errRateLimited = "rate limit exceeded"
+
+// grpcInfraPrefix is the prefix of the full method names of services,
+// such as reflection and health, that are not rate limited.
+const grpcInfraPrefix = "/grpc."
+
+type e struct {
+	limiter      Limiter
+	rules        map[string]Limit
+	defaultLimit Limit
+	logger       log.Logger
+}
+
+// NewEnforcer returns an enforcer that limits the rate of requests by each
+// client to each route, as configured by conf. Clients are identified by
+// the subject of their claims if authenticated, and otherwise by their IP
+// address. Requests are allowed if limiter fails.
+func NewEnforcer(
+	conf config.RateLimit,
+	limiter Limiter,
+	logger log.Logger,
+) *e {
+	rules := make(map[string]Limit, len(conf.Rules))
+
+	for route, rule := range conf.Rules {
+		rules[route] = limit(rule)
+	}
+
+	return &e{
+		limiter:      limiter,
+		rules:        rules,
+		defaultLimit: limit(conf.Default),
+		logger:       logger,
+	}
+}
+
+func limit(rule config.RateLimitRule) Limit {
+	burst := rule.Burst
+	if burst < 1 {
+		burst = rule.Requests
+	}
+
+	return Limit{
+		Requests: rule.Requests,
+		Burst:    burst,
+		Period:   rule.Period,
+	}
+}
+
+// allow returns the time after which the request would be allowed, or
+// zero if it is allowed.
+func (e *e) allow(
+	ctx context.Context,
+	route string,
+	remoteAddr string,
+) time.Duration {
+	l, ok := e.rules[route]
+	if !ok {
+		l = e.defaultLimit
+	}
+
+	if l.Requests < 1 {
+		return 0
+	}
+
+	allowed, retryAfter, err := e.limiter.Allow(ctx, route+":"+identity(ctx, remoteAddr), l)
+	if err != nil {
+		e.logger.ErrorContext(ctx, err)
+		return 0
+	}
+
+	if allowed {
+		return 0
+	}
+
+	return retryAfter
+}
+
+// identity returns the subject of the claims in ctx, if any, and otherwise
+// the host of remoteAddr.
+func identity(ctx context.Context, remoteAddr string) string {
+	if claims, ok := auth.FromContext(ctx); ok && claims.Subject != "" {
+		return "sub:" + claims.Subject
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// retryAfter returns the number of whole seconds in d, rounded up.
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Middleware responds with 429 Too Many Requests, and the number of
+// seconds after which to retry, to HTTP requests exceeding the limit of
+// the route. Routes are identified by method and path template (e.g.,
+// GET /user/{id}).
+func (e *e) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		wait := e.allow(r.Context(), r.Method+" "+route, r.RemoteAddr)
+		if wait > 0 {
+			w.Header().Set("Retry-After", retryAfter(wait))
+			response.WriteErrorResponse(
+				w,
+				http.StatusTooManyRequests,
+				errRateLimited,
+				nil,
+			)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// grpcAllow returns ResourceExhausted, and the header holding the number
+// of seconds after which to retry, for calls exceeding the limit of method.
+// Calls to services such as health are not limited, so that probes are not
+// rejected.
+func (e *e) grpcAllow(ctx context.Context, method string) (metadata.MD, error) {
+	if strings.HasPrefix(method, grpcInfraPrefix) {
+		return nil, nil
+	}
+
+	var remoteAddr string
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+
+	wait := e.allow(ctx, method, remoteAddr)
+	if wait > 0 {
+		return metadata.Pairs("retry-after", retryAfter(wait)), status.Error(codes.ResourceExhausted, errRateLimited)
+	}
+
+	return nil, nil
+}
+
+// UnaryInterceptor limits the rate of unary gRPC calls to each method.
+func (e *e) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	header, err := e.grpcAllow(ctx, info.FullMethod)
+	if err != nil {
+		_ = grpc.SetHeader(ctx, header)
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamInterceptor limits the rate of streaming gRPC calls to each
+// method.
+func (e *e) StreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	header, err := e.grpcAllow(ss.Context(), info.FullMethod)
+	if err != nil {
+		_ = ss.SetHeader(header)
+		return err
+	}
+
+	return handler(srv, ss)
+}
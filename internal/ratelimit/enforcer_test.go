@@ -0,0 +1,290 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/auth"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type loggerMock struct{}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+// limiterMock allows the number of requests per key in its limit.
+type limiterMock struct {
+	counts map[string]int
+	err    error
+}
+
+func (m *limiterMock) Allow(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if m.err != nil {
+		return false, 0, m.err
+	}
+
+	if m.counts == nil {
+		m.counts = make(map[string]int)
+	}
+
+	if m.counts[key] >= limit.Requests {
+		return false, 1500 * time.Millisecond, nil
+	}
+
+	m.counts[key]++
+
+	return true, 0, nil
+}
+
+var rateLimitConf = config.RateLimit{
+	Rules: map[string]config.RateLimitRule{
+		"GET /user/{id}": {Requests: 1, Period: time.Second},
+		"/User/Read":     {Requests: 1, Period: time.Second},
+	},
+}
+
+func TestEnforcer_Middleware(t *testing.T) {
+	cases := []struct {
+		name               string
+		conf               config.RateLimit
+		limiter            *limiterMock
+		requests           []*http.Request
+		expectedStatus     int
+		expectedRetryAfter string
+	}{
+		{
+			"within limit",
+			rateLimitConf,
+			&limiterMock{},
+			[]*http.Request{
+				newRequest("/user/1", "10.0.0.1:1234", ""),
+			},
+			http.StatusOK,
+			"",
+		},
+		{
+			"limit exceeded across route",
+			rateLimitConf,
+			&limiterMock{},
+			[]*http.Request{
+				newRequest("/user/1", "10.0.0.1:1234", ""),
+				newRequest("/user/2", "10.0.0.1:5678", ""),
+			},
+			http.StatusTooManyRequests,
+			"2",
+		},
+		{
+			"limit per ip",
+			rateLimitConf,
+			&limiterMock{},
+			[]*http.Request{
+				newRequest("/user/1", "10.0.0.1:1234", ""),
+				newRequest("/user/1", "10.0.0.2:1234", ""),
+			},
+			http.StatusOK,
+			"",
+		},
+		{
+			"limit per subject",
+			rateLimitConf,
+			&limiterMock{},
+			[]*http.Request{
+				newRequest("/user/1", "10.0.0.1:1234", "alice"),
+				newRequest("/user/1", "10.0.0.1:1234", "bob"),
+			},
+			http.StatusOK,
+			"",
+		},
+		{
+			"route without rule",
+			rateLimitConf,
+			&limiterMock{},
+			[]*http.Request{
+				newRequest("/user", "10.0.0.1:1234", ""),
+				newRequest("/user", "10.0.0.1:1234", ""),
+			},
+			http.StatusOK,
+			"",
+		},
+		{
+			"route limited by default",
+			config.RateLimit{
+				Default: config.RateLimitRule{Requests: 1, Period: time.Second},
+			},
+			&limiterMock{},
+			[]*http.Request{
+				newRequest("/user", "10.0.0.1:1234", ""),
+				newRequest("/user", "10.0.0.1:1234", ""),
+			},
+			http.StatusTooManyRequests,
+			"2",
+		},
+		{
+			"limiter error",
+			rateLimitConf,
+			&limiterMock{err: errors.New("limiter error")},
+			[]*http.Request{
+				newRequest("/user/1", "10.0.0.1:1234", ""),
+			},
+			http.StatusOK,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			enforcer := NewEnforcer(c.conf, c.limiter, loggerMock{})
+
+			handler := func(w http.ResponseWriter, r *http.Request) {}
+
+			router := mux.NewRouter()
+			router.Handle("/user", enforcer.Middleware(http.HandlerFunc(handler))).Methods(http.MethodGet)
+			router.Handle("/user/{id}", enforcer.Middleware(http.HandlerFunc(handler))).Methods(http.MethodGet)
+
+			var rec *httptest.ResponseRecorder
+
+			// Only the response to the last request is checked.
+			for _, r := range c.requests {
+				rec = httptest.NewRecorder()
+				router.ServeHTTP(rec, r)
+			}
+
+			assert.Equal(t, c.expectedStatus, rec.Code)
+			assert.Equal(t, c.expectedRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func newRequest(path, remoteAddr, subject string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.RemoteAddr = remoteAddr
+
+	if subject != "" {
+		r = r.WithContext(auth.NewContext(r.Context(), auth.Claims{Subject: subject}))
+	}
+
+	return r
+}
+
+func TestEnforcer_UnaryInterceptor(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		addrs        []string
+		expectedCode codes.Code
+	}{
+		{
+			"within limit",
+			"/User/Read",
+			[]string{"10.0.0.1:1234"},
+			codes.OK,
+		},
+		{
+			"limit exceeded",
+			"/User/Read",
+			[]string{"10.0.0.1:1234", "10.0.0.1:5678"},
+			codes.ResourceExhausted,
+		},
+		{
+			"limit per ip",
+			"/User/Read",
+			[]string{"10.0.0.1:1234", "10.0.0.2:1234"},
+			codes.OK,
+		},
+		{
+			"method without rule",
+			"/User/Create",
+			[]string{"10.0.0.1:1234", "10.0.0.1:1234"},
+			codes.OK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			enforcer := NewEnforcer(rateLimitConf, &limiterMock{}, loggerMock{})
+
+			var err error
+
+			for _, addr := range c.addrs {
+				tcpAddr, _ := net.ResolveTCPAddr("tcp", addr)
+
+				_, err = enforcer.UnaryInterceptor(
+					peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr}),
+					nil,
+					&grpc.UnaryServerInfo{FullMethod: c.method},
+					func(context.Context, interface{}) (interface{}, error) {
+						return nil, nil
+					},
+				)
+			}
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+		})
+	}
+}
+
+type serverStreamMock struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (m *serverStreamMock) Context() context.Context {
+	return m.ctx
+}
+
+func (m *serverStreamMock) SetHeader(md metadata.MD) error {
+	m.header = md
+	return nil
+}
+
+func TestEnforcer_StreamInterceptor(t *testing.T) {
+	enforcer := NewEnforcer(
+		config.RateLimit{
+			Rules: map[string]config.RateLimitRule{
+				"/User/Watch": {Requests: 1, Period: time.Second},
+			},
+		},
+		&limiterMock{},
+		loggerMock{},
+	)
+
+	ss := &serverStreamMock{
+		ctx: auth.NewContext(context.Background(), auth.Claims{Subject: "alice"}),
+	}
+
+	var err error
+
+	for range 2 {
+		err = enforcer.StreamInterceptor(
+			nil,
+			ss,
+			&grpc.StreamServerInfo{FullMethod: "/User/Watch"},
+			func(interface{}, grpc.ServerStream) error {
+				return nil
+			},
+		)
+	}
+
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"2"}, ss.header.Get("retry-after"))
+}
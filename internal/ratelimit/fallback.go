@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+)
+
+type f struct {
+	primary  Limiter
+	fallback Limiter
+	logger   log.Logger
+	failing  atomic.Bool
+}
+
+// NewFallback returns a limiter that uses fallback for as long as primary
+// (e.g., a limiter shared by all instances) returns errors. Failures and
+// recoveries of primary are logged once each, rather than per request.
+func NewFallback(
+	primary Limiter,
+	fallback Limiter,
+	logger log.Logger,
+) *f {
+	return &f{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+func (f *f) Allow(
+	ctx context.Context,
+	key string,
+	limit Limit,
+) (bool, time.Duration, error) {
+	allowed, retryAfter, err := f.primary.Allow(ctx, key, limit)
+	if err == nil {
+		if f.failing.CompareAndSwap(true, false) {
+			f.logger.InfofContext(ctx, "rate limiter recovered")
+		}
+
+		return allowed, retryAfter, nil
+	}
+
+	if f.failing.CompareAndSwap(false, true) {
+		f.logger.ErrorfContext(ctx, "rate limiter failed, falling back: %s", err)
+	}
+
+	return f.fallback.Allow(ctx, key, limit)
+}
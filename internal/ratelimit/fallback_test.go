@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallback_Allow(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Second}
+
+	primary := &limiterMock{}
+	fallback := &limiterMock{}
+
+	limiter := NewFallback(primary, fallback, loggerMock{})
+
+	allowed, _, err := limiter.Allow(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, primary.counts["key"])
+
+	primary.err = errors.New("primary error")
+
+	allowed, _, err = limiter.Allow(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, fallback.counts["key"])
+	assert.True(t, limiter.failing.Load())
+
+	allowed, retryAfter, err := limiter.Allow(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 1500*time.Millisecond, retryAfter)
+
+	primary.err = nil
+
+	allowed, _, err = limiter.Allow(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.False(t, limiter.failing.Load())
+}
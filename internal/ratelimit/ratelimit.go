@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Algorithm is the algorithm by which requests are limited.
+type Algorithm string
+
+// Algorithms by which requests are limited.
+const (
+	TokenBucket   Algorithm = "token_bucket"
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// Limit permits Requests per Period. With the token bucket algorithm,
+// bursts of up to Burst requests are permitted.
+type Limit struct {
+	Requests int
+	Burst    int
+	Period   time.Duration
+}
+
+// Limiter reports whether a request identified by key is within limit,
+// and if not, the time after which it would be.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Bucket holds tokens that are refilled at the rate of the limit, up
+// to its burst, and of which each request takes one.
+type Bucket struct {
+	UpdatedAt time.Time
+	Tokens    float64
+}
+
+// Take takes a token from the bucket, returning the time until a token
+// is available if the bucket is empty.
+func (b *Bucket) Take(now time.Time, limit Limit) (bool, time.Duration) {
+	rate := float64(limit.Requests) / float64(limit.Period)
+
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = float64(limit.Burst)
+		b.UpdatedAt = now
+	}
+
+	if now.After(b.UpdatedAt) {
+		b.Tokens = math.Min(
+			float64(limit.Burst),
+			b.Tokens+float64(now.Sub(b.UpdatedAt))*rate,
+		)
+		b.UpdatedAt = now
+	}
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return true, 0
+	}
+
+	return false, time.Duration(math.Ceil((1 - b.Tokens) / rate))
+}
+
+// Window counts the requests in the current and previous periods. The
+// requests in the previous period are weighted by the proportion of it
+// that overlaps a window of one period ending now.
+type Window struct {
+	Start    time.Time
+	Current  int
+	Previous int
+}
+
+// Take counts a request if the weighted count is within the limit,
+// returning the time until it would be otherwise.
+func (w *Window) Take(now time.Time, limit Limit) (bool, time.Duration) {
+	start := now.Truncate(limit.Period)
+
+	switch {
+	case w.Start.Equal(start):
+	case w.Start.Equal(start.Add(-limit.Period)):
+		w.Previous, w.Current = w.Current, 0
+	default:
+		w.Previous, w.Current = 0, 0
+	}
+
+	w.Start = start
+
+	period := float64(limit.Period)
+	elapsed := float64(now.Sub(start))
+	requests := float64(limit.Requests)
+	current := float64(w.Current)
+	previous := float64(w.Previous)
+
+	if previous*(1-elapsed/period)+current+1 <= requests {
+		w.Current++
+		return true, 0
+	}
+
+	// The wait is until the weighted count, in this period or the next,
+	// leaves room for the request.
+	if current >= requests {
+		return false, time.Duration(math.Ceil(period - elapsed + period*(1-(requests-1)/current)))
+	}
+
+	return false, time.Duration(math.Ceil(period*(1-(requests-1-current)/previous) - elapsed))
+}
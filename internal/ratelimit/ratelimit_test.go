@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type take struct {
+	after           time.Duration
+	expectedAllowed bool
+	expectedWait    time.Duration
+}
+
+func TestBucket_Take(t *testing.T) {
+	limit := Limit{Requests: 2, Burst: 3, Period: time.Second}
+
+	cases := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			"burst",
+			[]take{
+				{0, true, 0},
+				{0, true, 0},
+				{0, true, 0},
+				{0, false, 500 * time.Millisecond},
+			},
+		},
+		{
+			"refill",
+			[]take{
+				{0, true, 0},
+				{0, true, 0},
+				{0, true, 0},
+				{250 * time.Millisecond, false, 250 * time.Millisecond},
+				{250 * time.Millisecond, true, 0},
+				{0, false, 500 * time.Millisecond},
+			},
+		},
+		{
+			"refill limited to burst",
+			[]take{
+				{0, true, 0},
+				{time.Hour, true, 0},
+				{0, true, 0},
+				{0, true, 0},
+				{0, false, 500 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := Bucket{}
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			for i, tk := range c.takes {
+				now = now.Add(tk.after)
+
+				allowed, wait := b.Take(now, limit)
+
+				assert.Equal(t, tk.expectedAllowed, allowed, "take %d", i)
+				assert.Equal(t, tk.expectedWait, wait, "take %d", i)
+			}
+		})
+	}
+}
+
+func TestWindow_Take(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Second}
+
+	cases := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			"current period",
+			[]take{
+				{0, true, 0},
+				{0, true, 0},
+				{0, false, time.Second + 500*time.Millisecond},
+			},
+		},
+		{
+			"previous period weighted",
+			[]take{
+				{0, true, 0},
+				{0, true, 0},
+				{time.Second + 250*time.Millisecond, false, 250 * time.Millisecond},
+				{250 * time.Millisecond, true, 0},
+				{0, false, 500 * time.Millisecond},
+				{500 * time.Millisecond, true, 0},
+			},
+		},
+		{
+			"expired periods",
+			[]take{
+				{0, true, 0},
+				{0, true, 0},
+				{2 * time.Second, true, 0},
+				{0, true, 0},
+				{0, false, time.Second + 500*time.Millisecond},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := Window{}
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			for i, tk := range c.takes {
+				now = now.Add(tk.after)
+
+				allowed, wait := w.Take(now, limit)
+
+				assert.Equal(t, tk.expectedAllowed, allowed, "take %d", i)
+				assert.Equal(t, tk.expectedWait, wait, "take %d", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package response
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errETagInvalid = errors.New("entity tag invalid")
+
+// ETag returns a strong entity tag (e.g., "3") for version.
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ParseETag returns the version from an entity tag returned by ETag.
+// Weak entity tags (e.g., W/"3") are rejected as If-Match requires
+// strong comparison.
+func ParseETag(tag string) (int64, error) {
+	tag = strings.TrimSpace(tag)
+
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, errETagInvalid
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, errETagInvalid
+	}
+
+	return version, nil
+}
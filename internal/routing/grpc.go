@@ -6,55 +6,146 @@ import (
 	"net"
 
 	user "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/authz"
 	"github.com/bendbennett/go-api-demo/internal/log"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 type GRPCRouter struct {
-	userServer       *userServer
-	logger           log.Logger
-	telemetryEnabled bool
-	port             int
+	server *grpc.Server
+	logger log.Logger
+	port   int
+	ready  chan struct{}
 }
 
 type GRPCControllers struct {
-	UserCreate func(ctx context.Context, in *user.CreateRequest) (*user.UserResponse, error)
-	UserRead   func(ctx context.Context, in *user.ReadRequest) (*user.UsersResponse, error)
-	UserSearch func(ctx context.Context, in *user.SearchRequest) (*user.UsersResponse, error)
+	UserCreate            func(ctx context.Context, in *user.CreateRequest) (*user.UserResponse, error)
+	UserCreateBatch       func(stream user.User_CreateBatchServer) error
+	UserCreateBatchStream func(stream user.User_CreateBatchStreamServer) error
+	UserUpdate            func(ctx context.Context, in *user.UpdateRequest) (*user.UserResponse, error)
+	UserDelete            func(ctx context.Context, in *user.DeleteRequest) (*user.DeleteResponse, error)
+	UserRestore           func(ctx context.Context, in *user.RestoreRequest) (*user.UserResponse, error)
+	UserRead              func(ctx context.Context, in *user.ReadRequest) (*user.UsersResponse, error)
+	UserSearch            func(ctx context.Context, in *user.SearchRequest) (*user.UsersResponse, error)
+	UserList              func(in *user.ListUsersRequest, stream user.User_ListUsersServer) error
+	UserWatch             func(in *user.WatchRequest, stream user.User_WatchServer) error
+
+	Health healthgrpc.HealthServer
+}
+
+// GRPCOperations maps the full names of the methods of the user service
+// to the operations they perform, for authorization.
+var GRPCOperations = map[string]string{
+	"/User/Create":            authz.UserCreate,
+	"/User/CreateBatch":       authz.UserCreate,
+	"/User/CreateBatchStream": authz.UserCreate,
+	"/User/Update":            authz.UserUpdate,
+	"/User/Delete":            authz.UserDelete,
+	"/User/Restore":           authz.UserUpdate,
+	"/User/Read":              authz.UserRead,
+	"/User/Search":            authz.UserSearch,
+	"/User/ListUsers":         authz.UserRead,
+	"/User/Watch":             authz.UserRead,
+}
+
+// GRPCMutations maps the full names of the methods of the user service
+// that change state to the operations they perform, for auditing.
+var GRPCMutations = map[string]string{
+	"/User/Create":            authz.UserCreate,
+	"/User/CreateBatch":       authz.UserCreate,
+	"/User/CreateBatchStream": authz.UserCreate,
+	"/User/Update":            authz.UserUpdate,
+	"/User/Delete":            authz.UserDelete,
+	"/User/Restore":           authz.UserUpdate,
+}
+
+// GRPCInterceptors are chained, in order, around every call.
+type GRPCInterceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
 }
 
 // NewGRPCRouter returns a pointer to a GRPCRouter struct
-// populated with the port for the server and a logger.
+// populated with a configured server, the port for the
+// server and a logger.
 func NewGRPCRouter(
 	controllers GRPCControllers,
+	interceptors GRPCInterceptors,
 	logger log.Logger,
 	telemetryEnabled bool,
 	port int,
 ) *GRPCRouter {
-	return &GRPCRouter{
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(interceptors.Unary...),
+		grpc.ChainStreamInterceptor(interceptors.Stream...),
+	}
+
+	if telemetryEnabled {
+		serverOptions = append(serverOptions, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+
+	s := grpc.NewServer(serverOptions...)
+	user.RegisterUserServer(
+		s,
 		&userServer{
 			UnimplementedUserServer: user.UnimplementedUserServer{},
 			UserCreate:              controllers.UserCreate,
+			UserCreateBatch:         controllers.UserCreateBatch,
+			UserCreateBatchStream:   controllers.UserCreateBatchStream,
+			UserUpdate:              controllers.UserUpdate,
+			UserDelete:              controllers.UserDelete,
+			UserRestore:             controllers.UserRestore,
 			UserRead:                controllers.UserRead,
 			UserSearch:              controllers.UserSearch,
+			UserList:                controllers.UserList,
+			UserWatch:               controllers.UserWatch,
 		},
+	)
+
+	if controllers.Health != nil {
+		healthgrpc.RegisterHealthServer(
+			s,
+			controllers.Health,
+		)
+	}
+
+	// TODO: This should be configurable as it publicly exposes the gRPC endpoints.
+	reflection.Register(s)
+
+	return &GRPCRouter{
+		s,
 		logger,
-		telemetryEnabled,
 		port,
+		make(chan struct{}),
 	}
 }
 
 type UserCreate func(ctx context.Context, in *user.CreateRequest) (*user.UserResponse, error)
+type UserCreateBatch func(stream user.User_CreateBatchServer) error
+type UserCreateBatchStream func(stream user.User_CreateBatchStreamServer) error
+type UserUpdate func(ctx context.Context, in *user.UpdateRequest) (*user.UserResponse, error)
+type UserDelete func(ctx context.Context, in *user.DeleteRequest) (*user.DeleteResponse, error)
+type UserRestore func(ctx context.Context, in *user.RestoreRequest) (*user.UserResponse, error)
 type UserRead func(ctx context.Context, in *user.ReadRequest) (*user.UsersResponse, error)
 type UserSearch func(ctx context.Context, in *user.SearchRequest) (*user.UsersResponse, error)
+type UserList func(in *user.ListUsersRequest, stream user.User_ListUsersServer) error
+type UserWatch func(in *user.WatchRequest, stream user.User_WatchServer) error
 
 type userServer struct {
 	user.UnimplementedUserServer
 	UserCreate
+	UserCreateBatch
+	UserCreateBatchStream
+	UserUpdate
+	UserDelete
+	UserRestore
 	UserRead
 	UserSearch
+	UserList
+	UserWatch
 }
 
 func (us *userServer) Create(
@@ -64,6 +155,39 @@ func (us *userServer) Create(
 	return us.UserCreate(ctx, createReq)
 }
 
+func (us *userServer) CreateBatch(
+	stream user.User_CreateBatchServer,
+) error {
+	return us.UserCreateBatch(stream)
+}
+
+func (us *userServer) CreateBatchStream(
+	stream user.User_CreateBatchStreamServer,
+) error {
+	return us.UserCreateBatchStream(stream)
+}
+
+func (us *userServer) Update(
+	ctx context.Context,
+	updateReq *user.UpdateRequest,
+) (*user.UserResponse, error) {
+	return us.UserUpdate(ctx, updateReq)
+}
+
+func (us *userServer) Delete(
+	ctx context.Context,
+	deleteReq *user.DeleteRequest,
+) (*user.DeleteResponse, error) {
+	return us.UserDelete(ctx, deleteReq)
+}
+
+func (us *userServer) Restore(
+	ctx context.Context,
+	restoreReq *user.RestoreRequest,
+) (*user.UserResponse, error) {
+	return us.UserRestore(ctx, restoreReq)
+}
+
 func (us *userServer) Read(
 	ctx context.Context,
 	readReq *user.ReadRequest,
@@ -80,9 +204,28 @@ func (us *userServer) Search(
 	return us.UserSearch(ctx, sr)
 }
 
-// Run configures and starts a gRPC server. A go routine is
-// used to listen for context cancellation and triggers
-// a call to server stop.
+func (us *userServer) ListUsers(
+	listReq *user.ListUsersRequest,
+	stream user.User_ListUsersServer,
+) error {
+	return us.UserList(listReq, stream)
+}
+
+// Watch returns an Unimplemented error when user events are disabled.
+func (us *userServer) Watch(
+	watchReq *user.WatchRequest,
+	stream user.User_WatchServer,
+) error {
+	if us.UserWatch == nil {
+		return us.UnimplementedUserServer.Watch(watchReq, stream)
+	}
+
+	return us.UserWatch(watchReq, stream)
+}
+
+// Run starts a gRPC server. A go routine is used to listen
+// for context cancellation and triggers server stop, which
+// cuts calls that have not drained during Stop.
 func (r *GRPCRouter) Run(ctx context.Context) error {
 	listener, err := net.Listen(
 		"tcp",
@@ -92,27 +235,14 @@ func (r *GRPCRouter) Run(ctx context.Context) error {
 		),
 	)
 	if err != nil {
-		r.logger.Panicf("failed to listen: %v", err)
-	}
-
-	serverOptions := []grpc.ServerOption{}
-
-	if r.telemetryEnabled {
-		serverOptions = append(serverOptions, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+		return err
 	}
 
-	s := grpc.NewServer(serverOptions...)
-	user.RegisterUserServer(
-		s,
-		r.userServer,
-	)
-
-	// TODO: This should be configurable as it publicly exposes the gRPC endpoints.
-	reflection.Register(s)
+	close(r.ready)
 
 	go func() {
 		<-ctx.Done()
-		s.Stop()
+		r.server.Stop()
 	}()
 
 	r.logger.Infof(
@@ -120,5 +250,40 @@ func (r *GRPCRouter) Run(ctx context.Context) error {
 		r.port,
 	)
 
-	return s.Serve(listener)
+	err = r.server.Serve(listener)
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
+
+	return err
+}
+
+// Ready returns once the server is listening.
+func (r *GRPCRouter) Ready(ctx context.Context) error {
+	select {
+	case <-r.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop stops the server accepting connections, and returns once
+// in-flight calls have completed, or stops the server, cutting the
+// calls, once ctx is done.
+func (r *GRPCRouter) Stop(ctx context.Context) error {
+	stopped := make(chan struct{})
+
+	go func() {
+		r.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		r.server.Stop()
+		return ctx.Err()
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"net/http/pprof"
 	"time"
 
+	"github.com/bendbennett/go-api-demo/internal/authz"
 	"github.com/bendbennett/go-api-demo/internal/log"
 	"github.com/gorilla/mux"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -15,28 +16,72 @@ import (
 )
 
 type HTTPRouter struct {
-	handler           http.Handler
-	logger            log.Logger
-	port              int
-	readHeaderTimeout time.Duration
+	server *http.Server
+	logger log.Logger
+	port   int
+	ready  chan struct{}
 }
 
 type HTTPControllers struct {
-	UserCreateController func(w http.ResponseWriter, r *http.Request)
-	UserReadController   func(w http.ResponseWriter, r *http.Request)
-	UserSearchController func(w http.ResponseWriter, r *http.Request)
+	UserCreateController      func(w http.ResponseWriter, r *http.Request)
+	UserCreateBatchController func(w http.ResponseWriter, r *http.Request)
+	UserReadController        func(w http.ResponseWriter, r *http.Request)
+	UserReadByIDController    func(w http.ResponseWriter, r *http.Request)
+	UserUpdateController      func(w http.ResponseWriter, r *http.Request)
+	UserDeleteController      func(w http.ResponseWriter, r *http.Request)
+	UserRestoreController     func(w http.ResponseWriter, r *http.Request)
+	UserSearchController      func(w http.ResponseWriter, r *http.Request)
+	UserEventsController      func(w http.ResponseWriter, r *http.Request)
+	UserExportController      func(w http.ResponseWriter, r *http.Request)
+	UserImportController      func(w http.ResponseWriter, r *http.Request)
+
+	AdminUserExportController func(w http.ResponseWriter, r *http.Request)
+	AdminUserEraseController  func(w http.ResponseWriter, r *http.Request)
+
+	WebhookCreateController     func(w http.ResponseWriter, r *http.Request)
+	WebhookReadController       func(w http.ResponseWriter, r *http.Request)
+	WebhookReadByIDController   func(w http.ResponseWriter, r *http.Request)
+	WebhookUpdateController     func(w http.ResponseWriter, r *http.Request)
+	WebhookDeleteController     func(w http.ResponseWriter, r *http.Request)
+	WebhookDeliveriesController func(w http.ResponseWriter, r *http.Request)
+
+	APIKeyIssueController    func(w http.ResponseWriter, r *http.Request)
+	APIKeyReadController     func(w http.ResponseWriter, r *http.Request)
+	APIKeyReadByIDController func(w http.ResponseWriter, r *http.Request)
+	APIKeyRotateController   func(w http.ResponseWriter, r *http.Request)
+	APIKeyRevokeController   func(w http.ResponseWriter, r *http.Request)
+
+	AuditReadController   func(w http.ResponseWriter, r *http.Request)
+	AuditVerifyController func(w http.ResponseWriter, r *http.Request)
+
+	HealthzController func(w http.ResponseWriter, r *http.Request)
+	ReadyzController  func(w http.ResponseWriter, r *http.Request)
+}
+
+// HTTPMiddleware is applied, in order, to requests. Router middleware
+// (e.g., authentication) is applied to every request, whereas Routes
+// middleware (e.g., tenant identification) is applied to the API routes
+// but not to the root, health or profiling routes. Operations return the
+// middleware (e.g., auditing, authorization) for the operation of each
+// API route, which is applied after the Routes middleware.
+type HTTPMiddleware struct {
+	Router     []mux.MiddlewareFunc
+	Routes     []mux.MiddlewareFunc
+	Operations []func(operation string) mux.MiddlewareFunc
 }
 
 type route struct {
 	path        string
 	handlerFunc http.HandlerFunc
 	method      string
+	operation   string
 }
 
 // NewHTTPRouter returns a pointer to an HTTPRouter struct populated
 // with the port for the server, a configured router and a logger.
 func NewHTTPRouter(
 	controllers HTTPControllers,
+	middleware HTTPMiddleware,
 	logger log.Logger,
 	telemetryEnabled bool,
 	port int,
@@ -51,6 +96,22 @@ func NewHTTPRouter(
 		},
 	)
 
+	// Health routes are registered without the Routes middleware so that
+	// probes are neither tenant-scoped nor rate limited.
+	if controllers.HealthzController != nil {
+		router.HandleFunc(
+			"/healthz",
+			controllers.HealthzController,
+		).Methods(http.MethodGet)
+	}
+
+	if controllers.ReadyzController != nil {
+		router.HandleFunc(
+			"/readyz",
+			controllers.ReadyzController,
+		).Methods(http.MethodGet)
+	}
+
 	router.HandleFunc(
 		"/debug/pprof/profile",
 		pprof.Profile,
@@ -61,16 +122,159 @@ func NewHTTPRouter(
 			path:        "/user",
 			handlerFunc: controllers.UserCreateController,
 			method:      http.MethodPost,
+			operation:   authz.UserCreate,
 		},
 		{
 			path:        "/user",
 			handlerFunc: controllers.UserReadController,
 			method:      http.MethodGet,
+			operation:   authz.UserRead,
+		},
+		{
+			path:        "/users/batch",
+			handlerFunc: controllers.UserCreateBatchController,
+			method:      http.MethodPost,
+			operation:   authz.UserCreate,
 		},
 		{
 			path:        "/user/search/{searchTerm}",
 			handlerFunc: controllers.UserSearchController,
 			method:      http.MethodGet,
+			operation:   authz.UserSearch,
+		},
+		{
+			path:        "/user/events",
+			handlerFunc: controllers.UserEventsController,
+			method:      http.MethodGet,
+			operation:   authz.UserRead,
+		},
+		{
+			path:        "/user/export",
+			handlerFunc: controllers.UserExportController,
+			method:      http.MethodGet,
+			operation:   authz.UserRead,
+		},
+		{
+			path:        "/user/import",
+			handlerFunc: controllers.UserImportController,
+			method:      http.MethodPost,
+			operation:   authz.UserCreate,
+		},
+		// Registered after the other /user/ routes so that paths such as
+		// /user/events are not matched as IDs.
+		{
+			path:        "/user/{id}",
+			handlerFunc: controllers.UserReadByIDController,
+			method:      http.MethodGet,
+			operation:   authz.UserRead,
+		},
+		{
+			path:        "/user/{id}",
+			handlerFunc: controllers.UserUpdateController,
+			method:      http.MethodPut,
+			operation:   authz.UserUpdate,
+		},
+		{
+			path:        "/user/{id}",
+			handlerFunc: controllers.UserDeleteController,
+			method:      http.MethodDelete,
+			operation:   authz.UserDelete,
+		},
+		{
+			path:        "/user/{id}/restore",
+			handlerFunc: controllers.UserRestoreController,
+			method:      http.MethodPost,
+			operation:   authz.UserUpdate,
+		},
+		{
+			path:        "/admin/user/{id}/export",
+			handlerFunc: controllers.AdminUserExportController,
+			method:      http.MethodGet,
+			operation:   authz.UserAdmin,
+		},
+		{
+			path:        "/admin/user/{id}/erase",
+			handlerFunc: controllers.AdminUserEraseController,
+			method:      http.MethodPost,
+			operation:   authz.UserAdmin,
+		},
+		{
+			path:        "/webhook",
+			handlerFunc: controllers.WebhookCreateController,
+			method:      http.MethodPost,
+			operation:   authz.WebhookManage,
+		},
+		{
+			path:        "/webhook",
+			handlerFunc: controllers.WebhookReadController,
+			method:      http.MethodGet,
+			operation:   authz.WebhookManage,
+		},
+		{
+			path:        "/webhook/{id}",
+			handlerFunc: controllers.WebhookReadByIDController,
+			method:      http.MethodGet,
+			operation:   authz.WebhookManage,
+		},
+		{
+			path:        "/webhook/{id}",
+			handlerFunc: controllers.WebhookUpdateController,
+			method:      http.MethodPut,
+			operation:   authz.WebhookManage,
+		},
+		{
+			path:        "/webhook/{id}",
+			handlerFunc: controllers.WebhookDeleteController,
+			method:      http.MethodDelete,
+			operation:   authz.WebhookManage,
+		},
+		{
+			path:        "/webhook/{id}/deliveries",
+			handlerFunc: controllers.WebhookDeliveriesController,
+			method:      http.MethodGet,
+			operation:   authz.WebhookManage,
+		},
+		{
+			path:        "/apikey",
+			handlerFunc: controllers.APIKeyIssueController,
+			method:      http.MethodPost,
+			operation:   authz.APIKeyManage,
+		},
+		{
+			path:        "/apikey",
+			handlerFunc: controllers.APIKeyReadController,
+			method:      http.MethodGet,
+			operation:   authz.APIKeyManage,
+		},
+		{
+			path:        "/apikey/{id}",
+			handlerFunc: controllers.APIKeyReadByIDController,
+			method:      http.MethodGet,
+			operation:   authz.APIKeyManage,
+		},
+		{
+			path:        "/apikey/{id}",
+			handlerFunc: controllers.APIKeyRevokeController,
+			method:      http.MethodDelete,
+			operation:   authz.APIKeyManage,
+		},
+		{
+			path:        "/apikey/{id}/rotate",
+			handlerFunc: controllers.APIKeyRotateController,
+			method:      http.MethodPost,
+			operation:   authz.APIKeyManage,
+		},
+		{
+			path:        "/audit",
+			handlerFunc: controllers.AuditReadController,
+			method:      http.MethodGet,
+			operation:   authz.AuditRead,
+		},
+		{
+			path:        "/audit/verify",
+			handlerFunc: controllers.AuditVerifyController,
+			method:      http.MethodGet,
+			operation:   authz.AuditRead,
 		},
 	}
 
@@ -88,17 +292,37 @@ func NewHTTPRouter(
 	}
 
 	for _, route := range routes {
-		router.HandleFunc(
+		// Controllers for optional features are nil when the feature is
+		// disabled, in which case the route is not registered.
+		if route.handlerFunc == nil {
+			continue
+		}
+
+		var handler http.Handler = telemetryHandlerFunc(route.handlerFunc, route.path)
+
+		for i := len(middleware.Operations) - 1; i >= 0; i-- {
+			handler = middleware.Operations[i](route.operation)(handler)
+		}
+
+		for i := len(middleware.Routes) - 1; i >= 0; i-- {
+			handler = middleware.Routes[i](handler)
+		}
+
+		router.Handle(
 			route.path,
-			telemetryHandlerFunc(route.handlerFunc, route.path),
+			handler,
 		).Methods(route.method)
 	}
 
 	var handler http.Handler = router
 
+	for i := len(middleware.Router) - 1; i >= 0; i-- {
+		handler = middleware.Router[i](handler)
+	}
+
 	if telemetryEnabled {
 		handler = otelhttp.NewHandler(
-			router,
+			handler,
 			"http",
 			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
 				return operation + ": " + r.Method + ": " + r.URL.Path
@@ -107,16 +331,19 @@ func NewHTTPRouter(
 	}
 
 	return &HTTPRouter{
-		handler,
+		&http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
 		logger,
 		port,
-		readHeaderTimeout,
+		make(chan struct{}),
 	}
 }
 
-// Run configures and starts an HTTP server. A go routine is
-// used to listen for context cancellation and triggers
-// server shutdown.
+// Run starts an HTTP server. A go routine is used to listen
+// for context cancellation and triggers server close, which
+// cuts requests that have not drained during Stop.
 func (r *HTTPRouter) Run(ctx context.Context) error {
 	listener, err := net.Listen(
 		"tcp",
@@ -129,14 +356,11 @@ func (r *HTTPRouter) Run(ctx context.Context) error {
 		return err
 	}
 
-	s := http.Server{
-		Handler:           r.handler,
-		ReadHeaderTimeout: r.readHeaderTimeout,
-	}
+	close(r.ready)
 
 	go func() {
 		<-ctx.Done()
-		err := s.Shutdown(ctx)
+		err := r.server.Close()
 		if err != nil {
 			r.logger.Error(err)
 		}
@@ -147,10 +371,26 @@ func (r *HTTPRouter) Run(ctx context.Context) error {
 		r.port,
 	)
 
-	err = s.Serve(listener)
+	err = r.server.Serve(listener)
 	if err == http.ErrServerClosed {
 		return nil
 	}
 
 	return err
 }
+
+// Ready returns once the server is listening.
+func (r *HTTPRouter) Ready(ctx context.Context) error {
+	select {
+	case <-r.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop stops the server accepting connections, and returns once
+// in-flight requests have completed or ctx is done.
+func (r *HTTPRouter) Stop(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonMsg = `{
+	"schema": {
+		"type": "struct",
+		"name": "mysql.go_api_demo.users.Envelope",
+		"fields": [
+			{
+				"type": "struct",
+				"name": "mysql.go_api_demo.users.Value",
+				"field": "before",
+				"optional": true,
+				"fields": [
+					{"type": "string", "field": "id"}
+				]
+			},
+			{
+				"type": "struct",
+				"name": "mysql.go_api_demo.users.Value",
+				"field": "after",
+				"optional": true,
+				"fields": [
+					{"type": "string", "field": "id"},
+					{"type": "string", "field": "first_name"},
+					{"type": "int64", "name": "io.debezium.time.Timestamp", "field": "created_at"}
+				]
+			},
+			{"type": "string", "field": "op"},
+			{"type": "int64", "field": "ts_ms", "optional": true}
+		]
+	},
+	"payload": {
+		"before": null,
+		"after": {
+			"id": "673b3c8c-3589-4b77-af89-94dcda52a861",
+			"first_name": "john",
+			"created_at": 1639512014000
+		},
+		"op": "c",
+		"ts_ms": 1639512013850
+	}
+}`
+
+func TestJSONDecoder_Decode(t *testing.T) {
+	cases := map[string]struct {
+		msg         []byte
+		expected    interface{}
+		expectedErr bool
+	}{
+		"invalid json": {
+			[]byte(`{"schema":`),
+			nil,
+			true,
+		},
+		"schema missing": {
+			[]byte(`{"before": null, "after": {"id": "1"}}`),
+			nil,
+			true,
+		},
+		"success": {
+			[]byte(jsonMsg),
+			map[string]interface{}{
+				"before": nil,
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         "673b3c8c-3589-4b77-af89-94dcda52a861",
+						"first_name": "john",
+						"created_at": int64(1639512014000),
+					},
+				},
+				"op": "c",
+				"ts_ms": map[string]interface{}{
+					"long": int64(1639512013850),
+				},
+			},
+			false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			native, err := NewJSONDecoder().Decode(c.msg)
+
+			assert.Equal(t, c.expected, native)
+
+			if c.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// wireHeaderLen is the number of bytes used by the Confluent schema
+// registry for the magic byte and schema ID.
+const wireHeaderLen = 5
+
+type protobufDecoder struct {
+	msgType protoreflect.MessageType
+}
+
+var _ decoder = (*protobufDecoder)(nil)
+
+// NewProtobufDecoder returns a decoder for messages produced by the Confluent
+// protobuf converter. Messages are unmarshalled into msgType, which must be
+// compatible with the schema registered for the topic.
+func NewProtobufDecoder(msgType protoreflect.MessageType) *protobufDecoder {
+	return &protobufDecoder{
+		msgType: msgType,
+	}
+}
+
+// ValueName returns the full name of the message used for the before field
+// of the envelope (e.g., mysql.go_api_demo.users.Value).
+func (d *protobufDecoder) ValueName() (string, error) {
+	fd := d.msgType.Descriptor().Fields().ByName("before")
+	if fd == nil || fd.Message() == nil {
+		return "", errors.New("message does not contain a before message")
+	}
+
+	return string(fd.Message().FullName()), nil
+}
+
+// Decode accepts a slice of bytes, discarding the magic byte, schema ID and
+// message indexes used by the Confluent schema registry, and returns the
+// message in the same shape as the native form produced by goavro.
+// See https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format
+func (d *protobufDecoder) Decode(msg []byte) (interface{}, error) {
+	if len(msg) < wireHeaderLen {
+		return nil, errors.New("could not decode msg: too short")
+	}
+
+	payload, err := skipMessageIndexes(msg[wireHeaderLen:])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode msg")
+	}
+
+	m := d.msgType.New().Interface()
+
+	if err := proto.Unmarshal(payload, m); err != nil {
+		return nil, errors.Wrap(err, "could not decode msg")
+	}
+
+	return nativeFromMessage(m.ProtoReflect()), nil
+}
+
+// skipMessageIndexes removes the zig-zag encoded array of message indexes
+// that precedes the payload. An array containing only the first message
+// is written as a single 0 byte.
+func skipMessageIndexes(b []byte) ([]byte, error) {
+	count, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+
+	b = b[n:]
+
+	for i := int64(0); i < protowire.DecodeZigZag(count); i++ {
+		_, n = protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+
+		b = b[n:]
+	}
+
+	return b, nil
+}
+
+// protobufKinds maps protobuf scalar kinds to the names used by goavro
+// when representing a non-null value within a union.
+var protobufKinds = map[protoreflect.Kind]string{
+	protoreflect.Int32Kind:    "int",
+	protoreflect.Sint32Kind:   "int",
+	protoreflect.Sfixed32Kind: "int",
+	protoreflect.Int64Kind:    "long",
+	protoreflect.Sint64Kind:   "long",
+	protoreflect.Sfixed64Kind: "long",
+	protoreflect.FloatKind:    "float",
+	protoreflect.DoubleKind:   "double",
+	protoreflect.BoolKind:     "boolean",
+	protoreflect.StringKind:   "string",
+	protoreflect.BytesKind:    "bytes",
+	protoreflect.EnumKind:     "string",
+}
+
+// nativeFromMessage converts m to a map keyed by field name. Fields declared
+// as optional are either nil or wrapped in a map keyed by type name (the full
+// name for messages), mirroring the Avro union representation that the
+// processors expect.
+func nativeFromMessage(m protoreflect.Message) map[string]interface{} {
+	fields := m.Descriptor().Fields()
+	native := make(map[string]interface{}, fields.Len())
+
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+
+		if fd.IsList() || fd.IsMap() {
+			continue
+		}
+
+		if fd.HasOptionalKeyword() && !m.Has(fd) {
+			native[string(fd.Name())] = nil
+			continue
+		}
+
+		var (
+			val      interface{}
+			typeName = protobufKinds[fd.Kind()]
+		)
+
+		switch {
+		case fd.Message() != nil:
+			val = nativeFromMessage(m.Get(fd).Message())
+			typeName = string(fd.Message().FullName())
+		case fd.Enum() != nil:
+			if ev := fd.Enum().Values().ByNumber(m.Get(fd).Enum()); ev != nil {
+				val = string(ev.Name())
+			}
+		default:
+			val = m.Get(fd).Interface()
+		}
+
+		if fd.HasOptionalKeyword() {
+			val = map[string]interface{}{
+				typeName: val,
+			}
+		}
+
+		native[string(fd.Name())] = val
+	}
+
+	return native
+}
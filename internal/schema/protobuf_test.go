@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"testing"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProtobufDecoder_Decode(t *testing.T) {
+	envelope, err := proto.Marshal(&pb.Envelope{
+		After: &pb.Value{
+			Id:        "673b3c8c-3589-4b77-af89-94dcda52a861",
+			FirstName: "john",
+			LastName:  "smith",
+			CreatedAt: 1639512014000,
+			Version:   1,
+			TenantId:  "acme",
+		},
+		Source: &pb.Source{
+			Name: "mysql",
+			TsMs: 1639512013000,
+		},
+		Op:   "c",
+		TsMs: proto.Int64(1639512013850),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Magic byte, schema ID and a single 0 byte for the message indexes.
+	header := []byte{0, 0, 0, 0, 1, 0}
+
+	cases := map[string]struct {
+		msg         []byte
+		expected    interface{}
+		expectedErr bool
+	}{
+		"msg too short": {
+			[]byte{0, 0},
+			nil,
+			true,
+		},
+		"invalid payload": {
+			append(header, 0xff),
+			nil,
+			true,
+		},
+		"success": {
+			append(header, envelope...),
+			map[string]interface{}{
+				"before": nil,
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         "673b3c8c-3589-4b77-af89-94dcda52a861",
+						"first_name": "john",
+						"last_name":  "smith",
+						"created_at": int64(1639512014000),
+						"version":    int64(1),
+						"deleted_at": nil,
+						"tenant_id":  "acme",
+					},
+				},
+				"source": map[string]interface{}{
+					"version":   "",
+					"connector": "",
+					"name":      "mysql",
+					"ts_ms":     int64(1639512013000),
+					"snapshot":  "",
+					"db":        "",
+					"table":     "",
+					"server_id": int64(0),
+					"file":      "",
+					"pos":       int64(0),
+					"row":       int32(0),
+				},
+				"op": "c",
+				"ts_ms": map[string]interface{}{
+					"long": int64(1639512013850),
+				},
+			},
+			false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			decoder := NewProtobufDecoder((&pb.Envelope{}).ProtoReflect().Type())
+
+			native, err := decoder.Decode(c.msg)
+
+			if c.expectedErr {
+				assert.Error(t, err)
+				assert.Nil(t, native)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, native)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const latest = "latest"
+
+type subjectVersion struct {
+	subject string
+	version int
+}
+
+// fileRegistry serves schemas from a directory, standing in for a
+// Confluent schema registry when one is not available (e.g., offline
+// development and tests).
+//
+// Files are keyed by subject and version using either of the following layouts:
+//
+//	<dir>/<subject>.json|.avsc            (version 1)
+//	<dir>/<subject>/<version>.json|.avsc
+//
+// .avsc files contain the schema itself, whereas .json files use the same
+// format as the schema registry REST API (i.e., {"schema": "..."}) and can
+// override the subject and version, and supply an ID, using the "subject",
+// "version" and "id" fields.
+type fileRegistry struct {
+	bySubjectVersion map[subjectVersion]registryFile
+	latest           map[string]int
+	byID             map[int]registryFile
+}
+
+func newFileRegistry(dir string) (*fileRegistry, error) {
+	r := fileRegistry{
+		bySubjectVersion: make(map[subjectVersion]registryFile),
+		latest:           make(map[string]int),
+		byID:             make(map[int]registryFile),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		ext := filepath.Ext(path)
+
+		if d.IsDir() || (ext != ".json" && ext != ".avsc") {
+			return nil
+		}
+
+		return r.add(dir, path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("file registry error: %w", err)
+	}
+
+	return &r, nil
+}
+
+type registryFile struct {
+	Schema  string `json:"schema"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	ID      int    `json:"id"`
+}
+
+func (r *fileRegistry) add(dir, path string) error {
+	b, err := os.ReadFile(path) // nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return err
+	}
+
+	rf := registryFile{
+		Subject: strings.TrimSuffix(rel, filepath.Ext(rel)),
+		Version: 1,
+	}
+
+	if parent, version := filepath.Split(rf.Subject); parent != "" {
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return fmt.Errorf("%s: version must be an integer", path)
+		}
+
+		rf.Subject = filepath.Clean(parent)
+		rf.Version = v
+	}
+
+	switch filepath.Ext(path) {
+	case ".avsc":
+		rf.Schema = string(b)
+	default:
+		// Files posted to the schema registry with curl (e.g., docker/schema) can
+		// contain line breaks within the schema string, which are not valid JSON.
+		j := strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(string(b))
+
+		if err := json.Unmarshal([]byte(j), &rf); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	sv := subjectVersion{rf.Subject, rf.Version}
+
+	if _, ok := r.bySubjectVersion[sv]; ok {
+		return fmt.Errorf("%s: duplicate subject %s version %d", path, sv.subject, sv.version)
+	}
+
+	r.bySubjectVersion[sv] = rf
+
+	if rf.Version > r.latest[rf.Subject] {
+		r.latest[rf.Subject] = rf.Version
+	}
+
+	if rf.ID != 0 {
+		r.byID[rf.ID] = rf
+	}
+
+	return nil
+}
+
+// schema supports the endpoints used to retrieve schemas from the schema
+// registry, /subjects/{subject}/versions/{version|latest} and /schemas/ids/{id}.
+// The endpoint can be a URL, in which case only the path is used.
+func (r *fileRegistry) schema(endpoint string) (string, int, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", 0, fmt.Errorf("file registry error: %w", err)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 4 && parts[0] == "subjects" && parts[2] == "versions":
+		return r.bySubject(parts[1], parts[3])
+	case len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return "", 0, fmt.Errorf("file registry error: invalid id %s", parts[2])
+		}
+
+		if rf, ok := r.byID[id]; ok {
+			return rf.Schema, rf.ID, nil
+		}
+
+		return "", 0, fmt.Errorf("file registry error: schema %d not found", id)
+	default:
+		return "", 0, fmt.Errorf("file registry error: unsupported endpoint %s", endpoint)
+	}
+}
+
+func (r *fileRegistry) bySubject(
+	subject string,
+	version string,
+) (string, int, error) {
+	v := r.latest[subject]
+
+	if version != latest {
+		var err error
+
+		v, err = strconv.Atoi(version)
+		if err != nil {
+			return "", 0, fmt.Errorf("file registry error: invalid version %s", version)
+		}
+	}
+
+	if rf, ok := r.bySubjectVersion[subjectVersion{subject, v}]; ok {
+		return rf.Schema, rf.ID, nil
+	}
+
+	return "", 0, fmt.Errorf("file registry error: subject %s version %s not found", subject, version)
+}
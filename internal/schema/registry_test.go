@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const recordSchema = `{"type": "record", "name": "Value", "fields": [{"name": "id", "type": "string"}]}`
+
+func TestFileRegistry_Schema(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "users-value"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users-value", "1.avsc"), []byte(recordSchema), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users-value", "2.avsc"), []byte(`"string"`), 0o600))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, "users-key.json"),
+		[]byte(`{"schema": "\"long\"", "id": 7}`),
+		0o600,
+	))
+
+	r, err := newFileRegistry(dir)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		endpoint    string
+		expected    string
+		expectedErr bool
+	}{
+		"subject and version": {
+			"http://localhost:8081/subjects/users-value/versions/1",
+			recordSchema,
+			false,
+		},
+		"latest version": {
+			"/subjects/users-value/versions/latest",
+			`"string"`,
+			false,
+		},
+		"single version subject": {
+			"/subjects/users-key/versions/1",
+			`"long"`,
+			false,
+		},
+		"id": {
+			"/schemas/ids/7",
+			`"long"`,
+			false,
+		},
+		"unknown version": {
+			"/subjects/users-value/versions/3",
+			"",
+			true,
+		},
+		"unsupported endpoint": {
+			"/subjects",
+			"",
+			true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			schema, _, err := r.schema(c.endpoint)
+
+			assert.Equal(t, c.expected, schema)
+
+			if c.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFileClient_GetDecoder(t *testing.T) {
+	client, err := NewFileClient("../../docker/schema/subjects")
+	require.NoError(t, err)
+
+	decoder, err := client.GetDecoder("/subjects/mysql.go_api_demo.users-value/versions/1")
+	require.NoError(t, err)
+
+	native := map[string]interface{}{
+		"before": nil,
+		"after": map[string]interface{}{
+			"mysql.go_api_demo.users.Value": map[string]interface{}{
+				"id":         "673b3c8c-3589-4b77-af89-94dcda52a861",
+				"first_name": "john",
+				"last_name":  "smith",
+				"created_at": int64(1639512014000),
+				"version":    int64(1),
+				"deleted_at": nil,
+				"tenant_id":  "acme",
+			},
+		},
+		"source": map[string]interface{}{
+			"version":   "1.7.1.Final",
+			"connector": "mysql",
+			"name":      "mysql",
+			"ts_ms":     int64(1639512013000),
+			"db":        "go_api_demo",
+			"server_id": int64(1),
+			"file":      "binlog.000002",
+			"pos":       int64(9548),
+			"row":       int32(0),
+		},
+		"op": "c",
+	}
+
+	b, err := decoder.codec.BinaryFromNative(make([]byte, wireHeaderLen), native)
+	require.NoError(t, err)
+
+	decoded, err := decoder.Decode(b)
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		native["after"],
+		decoded.(map[string]interface{})["after"],
+	)
+
+	valueName, err := decoder.ValueName()
+	require.NoError(t, err)
+	assert.Equal(t, "mysql.go_api_demo.users.Value", valueName)
+}
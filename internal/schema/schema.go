@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,21 +16,48 @@ type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// registry returns the schema and schema ID for a schema registry
+// endpoint (e.g., /subjects/{subject}/versions/{version}).
+type registry interface {
+	schema(endpoint string) (string, int, error)
+}
+
 type schemaClient struct {
-	httpClient httpClient
-	codecs     map[string]*goavro.Codec
-	mu         sync.Mutex
+	registry registry
+	codecs   map[string]codecID
+	mu       sync.Mutex
 }
 
+type codecID struct {
+	codec *goavro.Codec
+	id    int
+}
+
+// NewClient returns a client that retrieves schemas from a
+// Confluent schema registry.
 func NewClient(clientTimeout time.Duration) *schemaClient {
 	c := http.Client{
 		Timeout: clientTimeout,
 	}
 
 	return &schemaClient{
-		httpClient: &c,
-		codecs:     make(map[string]*goavro.Codec),
+		registry: &httpRegistry{&c},
+		codecs:   make(map[string]codecID),
+	}
+}
+
+// NewFileClient returns a client that retrieves schemas from the
+// .json and .avsc files in dir (see fileRegistry).
+func NewFileClient(dir string) (*schemaClient, error) {
+	fr, err := newFileRegistry(dir)
+	if err != nil {
+		return nil, err
 	}
+
+	return &schemaClient{
+		registry: fr,
+		codecs:   make(map[string]codecID),
+	}, nil
 }
 
 type decoder interface {
@@ -46,7 +74,7 @@ var _ decoder = (*d)(nil)
 // in the Confluent schema registry.
 // See https://stackoverflow.com/questions/40548909/consume-kafka-avro-messages-in-go
 func (d *d) Decode(msg []byte) (interface{}, error) {
-	nMsg, _, err := d.codec.NativeFromBinary(msg[5:])
+	nMsg, _, err := d.codec.NativeFromBinary(msg[wireHeaderLen:])
 	if err != nil {
 		return nil, errors.Wrap(err, "could not decode msg")
 	}
@@ -54,55 +82,160 @@ func (d *d) Decode(msg []byte) (interface{}, error) {
 	return nMsg, nil
 }
 
+// ValueName returns the full name of the record used for the before field of
+// a Debezium envelope schema (e.g., mysql.go_api_demo.users.Value).
+func (d *d) ValueName() (string, error) {
+	return valueName(d.codec)
+}
+
+type encoder interface {
+	Encode(interface{}) ([]byte, error)
+}
+
+type e struct {
+	codec *goavro.Codec
+	id    int
+}
+
+var _ encoder = (*e)(nil)
+
+// Encode accepts native data (see goavro) and returns the binary encoding
+// prefixed with the magic byte and schema ID used by the Confluent schema
+// registry.
+func (e *e) Encode(native interface{}) ([]byte, error) {
+	header := make([]byte, wireHeaderLen)
+	binary.BigEndian.PutUint32(header[1:], uint32(e.id)) // nolint:gosec
+
+	msg, err := e.codec.BinaryFromNative(header, native)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode msg")
+	}
+
+	return msg, nil
+}
+
+// ValueName returns the full name of the record used for the before field of
+// a Debezium envelope schema (e.g., mysql.go_api_demo.users.Value).
+func (e *e) ValueName() (string, error) {
+	return valueName(e.codec)
+}
+
+// valueName parses the canonical form of the schema, in which names are
+// fully qualified, so the namespace does not need to be resolved separately.
+func valueName(codec *goavro.Codec) (string, error) {
+	type record struct {
+		Fields []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		} `json:"fields"`
+	}
+
+	r := record{}
+
+	err := json.Unmarshal([]byte(codec.CanonicalSchema()), &r)
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse schema")
+	}
+
+	for _, f := range r.Fields {
+		if f.Name != "before" {
+			continue
+		}
+
+		var union []json.RawMessage
+
+		if err := json.Unmarshal(f.Type, &union); err != nil {
+			return "", errors.Wrap(err, "could not parse before field type")
+		}
+
+		for _, t := range union {
+			var named struct {
+				Name string `json:"name"`
+			}
+
+			if err := json.Unmarshal(t, &named); err == nil && named.Name != "" {
+				return named.Name, nil
+			}
+		}
+	}
+
+	return "", errors.New("schema does not contain a before record")
+}
+
 func (c *schemaClient) GetDecoder(endpoint string) (*d, error) {
-	codec, err := c.getSchemaCodec(endpoint)
+	ci, err := c.getSchemaCodec(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d{ci.codec}, nil
+}
+
+// GetEncoder returns an encoder for the schema at endpoint. The endpoint
+// should identify a specific version so that the schema ID is known.
+func (c *schemaClient) GetEncoder(endpoint string) (*e, error) {
+	ci, err := c.getSchemaCodec(endpoint)
 	if err != nil {
 		return nil, err
 	}
 
-	return &d{codec}, nil
+	return &e{ci.codec, ci.id}, nil
 }
 
-func (c *schemaClient) getSchemaCodec(endpoint string) (*goavro.Codec, error) {
-	if codec, ok := c.codecs[endpoint]; ok {
-		return codec, nil
+func (c *schemaClient) getSchemaCodec(endpoint string) (codecID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ci, ok := c.codecs[endpoint]; ok {
+		return ci, nil
 	}
 
+	schema, id, err := c.registry.schema(endpoint)
+	if err != nil {
+		return codecID{}, err
+	}
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return codecID{}, fmt.Errorf("new codec error: %w", err)
+	}
+
+	c.codecs[endpoint] = codecID{codec, id}
+
+	return c.codecs[endpoint], nil
+}
+
+type httpRegistry struct {
+	httpClient httpClient
+}
+
+func (r *httpRegistry) schema(endpoint string) (string, int, error) {
 	req, err := http.NewRequest(
 		http.MethodGet,
 		endpoint,
 		nil,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("new request error: %w", err)
+		return "", 0, fmt.Errorf("new request error: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("schema registry request error: %w", err)
+		return "", 0, fmt.Errorf("schema registry request error: %w", err)
 	}
 	defer resp.Body.Close()
 
 	type schema struct {
 		Schema interface{} `json:"schema"`
+		ID     int         `json:"id"`
 	}
 
 	s := schema{}
 
 	err = json.NewDecoder(resp.Body).Decode(&s)
 	if err != nil {
-		return nil, fmt.Errorf("decoding response from schema registry error: %w", err)
-	}
-
-	codec, err := goavro.NewCodec(fmt.Sprintf("%v", s.Schema))
-	if err != nil {
-		return nil, fmt.Errorf("new codec error: %w", err)
+		return "", 0, fmt.Errorf("decoding response from schema registry error: %w", err)
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.codecs[endpoint] = codec
-
-	return codec, nil
+	return fmt.Sprintf("%v", s.Schema), s.ID, nil
 }
@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_ValueName(t *testing.T) {
+	cases := map[string]struct {
+		schema      string
+		expected    string
+		expectedErr bool
+	}{
+		"namespace inherited from envelope": {
+			`{
+				"type": "record",
+				"name": "Envelope",
+				"namespace": "mysql.go_api_demo_staging.users",
+				"fields": [
+					{
+						"name": "before",
+						"type": ["null", {"type": "record", "name": "Value", "fields": [{"name": "id", "type": "string"}]}]
+					},
+					{"name": "after", "type": ["null", "Value"]}
+				]
+			}`,
+			"mysql.go_api_demo_staging.users.Value",
+			false,
+		},
+		"before missing": {
+			`{
+				"type": "record",
+				"name": "Envelope",
+				"fields": [{"name": "op", "type": "string"}]
+			}`,
+			"",
+			true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			codec, err := goavro.NewCodec(c.schema)
+			require.NoError(t, err)
+
+			valueName, err := (&d{codec}).ValueName()
+
+			assert.Equal(t, c.expected, valueName)
+
+			if c.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
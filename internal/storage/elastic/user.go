@@ -13,12 +13,16 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
-const usrs = "users"
+const (
+	usrs                   = "users"
+	versionTypeExternalGTE = "external_gte"
+)
 
 type search interface {
 	Perform(request *http.Request) (*http.Response, error)
@@ -57,47 +61,188 @@ func NewUserSearch(
 	return &us, nil
 }
 
+// docID returns the ID of the document for the user with the id, which is
+// prefixed with the tenant carried by ctx as user IDs are only unique
+// within a tenant.
+func docID(ctx context.Context, id string) string {
+	return fmt.Sprintf("%s:%s", tenant.ID(ctx), id)
+}
+
 type elasticUser struct {
 	CreatedAt time.Time `json:"created_at"`
+	TenantID  string    `json:"tenant_id"`
 	ID        string    `json:"id"`
 	FullName  string    `json:"full_name"`
 	FirstName string    `json:"first_name"`
 	LastName  string    `json:"last_name"`
+	Version   int64     `json:"version"`
 }
 
 func (s *userSearch) Create(
 	ctx context.Context,
 	users ...user.User,
 ) error {
-	var wg sync.WaitGroup
-	responses := make(chan r)
+	reqs, err := indexRequests(ctx, nil, users...)
+	if err != nil {
+		return err
+	}
+
+	return s.do(ctx, reqs...)
+}
+
+// Upsert indexes users using external versioning so that Elasticsearch
+// rejects documents with a version lower than the one already indexed.
+// Rejections (409 Conflict) are treated as skipped writes rather than errors.
+func (s *userSearch) Upsert(
+	ctx context.Context,
+	version int64,
+	users ...user.User,
+) error {
+	v := int(version)
+
+	reqs, err := indexRequests(ctx, &v, users...)
+	if err != nil {
+		return err
+	}
+
+	return s.do(ctx, reqs...)
+}
+
+// Delete removes users using external versioning. Elasticsearch retains
+// the version of deleted documents for index.gc_deletes (60s by default),
+// during which stale upserts are rejected.
+func (s *userSearch) Delete(
+	ctx context.Context,
+	version int64,
+	ids ...string,
+) error {
+	v := int(version)
+	reqs := make([]esapi.Request, 0, len(ids))
+
+	for _, id := range ids {
+		reqs = append(reqs, esapi.DeleteRequest{
+			Index:       usrs,
+			DocumentID:  docID(ctx, id),
+			Version:     &v,
+			VersionType: versionTypeExternalGTE,
+			Refresh:     "false",
+		})
+	}
+
+	return s.do(ctx, reqs...)
+}
+
+// Export returns the indexed user.
+func (s *userSearch) Export(
+	ctx context.Context,
+	id string,
+) (user.SubjectData, error) {
+	req := esapi.GetRequest{
+		Index:      usrs,
+		DocumentID: docID(ctx, id),
+	}
+
+	resp, err := req.Do(ctx, s.search)
+	if err != nil {
+		return user.SubjectData{}, errors.Errorf("%s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return user.SubjectData{}, user.ErrNotFound
+	}
+
+	if resp.IsError() {
+		return user.SubjectData{}, fmt.Errorf("status: %d", resp.StatusCode)
+	}
+
+	doc := hit{}
+
+	if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return user.SubjectData{}, errors.Errorf("%s", err)
+	}
+
+	return user.SubjectData{
+		User: &user.User{
+			CreatedAt: doc.Source.CreatedAt,
+			TenantID:  doc.Source.TenantID,
+			ID:        doc.Source.ID,
+			FirstName: doc.Source.FirstName,
+			LastName:  doc.Source.LastName,
+			Version:   doc.Source.Version,
+		},
+	}, nil
+}
+
+// Erase deletes the indexed user using the current time as the version,
+// so that change events for the user that are consumed after the erasure
+// do not index the user again (see Delete). The protection is bounded by
+// index.gc_deletes: a change event for the user consumed once the version
+// of the deleted document has been discarded (after 60s by default) indexes
+// the user again.
+func (s *userSearch) Erase(
+	ctx context.Context,
+	id string,
+) error {
+	return s.Delete(ctx, time.Now().UnixMilli(), id)
+}
+
+func indexRequests(
+	ctx context.Context,
+	version *int,
+	users ...user.User,
+) ([]esapi.Request, error) {
+	reqs := make([]esapi.Request, 0, len(users))
 
 	for _, u := range users {
 		eU := elasticUser{
+			TenantID:  tenant.ID(ctx),
 			ID:        u.ID,
 			FullName:  fmt.Sprintf("%s %s", u.FirstName, u.LastName),
 			FirstName: u.FirstName,
 			LastName:  u.LastName,
 			CreatedAt: u.CreatedAt,
+			Version:   u.Version,
 		}
 
 		j, err := json.Marshal(eU)
 		if err != nil {
-			return errors.Errorf("%s", err)
+			return nil, errors.Errorf("%s", err)
 		}
 
+		req := esapi.IndexRequest{
+			Index:      usrs,
+			DocumentID: docID(ctx, u.ID),
+			Body:       strings.NewReader(string(j)),
+			Refresh:    "false",
+		}
+
+		if version != nil {
+			req.Version = version
+			req.VersionType = versionTypeExternalGTE
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	return reqs, nil
+}
+
+// do performs reqs concurrently and combines any errors. Version conflicts
+// and missing documents are not treated as errors.
+func (s *userSearch) do(
+	ctx context.Context,
+	reqs ...esapi.Request,
+) error {
+	var wg sync.WaitGroup
+	responses := make(chan r)
+
+	for _, req := range reqs {
 		wg.Add(1)
 
-		go func(uID string, j []byte) {
+		go func(req esapi.Request) {
 			defer wg.Done()
 
-			req := esapi.IndexRequest{
-				Index:      usrs,
-				DocumentID: uID,
-				Body:       strings.NewReader(string(j)),
-				Refresh:    "false",
-			}
-
 			resp, err := req.Do(ctx, s.search)
 			if err != nil {
 				responses <- r{err: err}
@@ -109,9 +254,8 @@ func (s *userSearch) Create(
 			responses <- r{
 				statusCode: resp.StatusCode,
 				isError:    resp.IsError(),
-				err:        err,
 			}
-		}(u.ID, j)
+		}(req)
 	}
 
 	go func() {
@@ -122,6 +266,14 @@ func (s *userSearch) Create(
 	var err error
 
 	for resp := range responses {
+		if resp.err == nil && resp.isError {
+			switch resp.statusCode {
+			case http.StatusConflict, http.StatusNotFound:
+			default:
+				resp.err = fmt.Errorf("status: %d", resp.statusCode)
+			}
+		}
+
 		if resp.err != nil {
 			switch err {
 			case nil:
@@ -139,14 +291,37 @@ func (s *userSearch) Create(
 	return err
 }
 
+// Search matches searchTerm against the names and IDs of the users of
+// the tenant carried by ctx.
 func (s *userSearch) Search(
 	ctx context.Context,
 	searchTerm string,
 ) ([]user.User, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"query_string": map[string]interface{}{
+						"query":  fmt.Sprintf("*%s*", searchTerm),
+						"fields": []string{"id", "first_name", "last_name", "full_name"},
+					},
+				},
+				"filter": map[string]interface{}{
+					"term": map[string]interface{}{
+						"tenant_id.keyword": tenant.ID(ctx),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
 	req := esapi.SearchRequest{
 		Index:          []string{usrs},
 		DocvalueFields: []string{"first_name.keyword", "last_name.keyword"},
-		Query:          fmt.Sprintf("*%s*", searchTerm),
+		Body:           strings.NewReader(string(body)),
 	}
 
 	resp, err := req.Do(ctx, s.search)
@@ -189,9 +364,11 @@ func (s *userSearch) Search(
 	for _, v := range h.Hits.HitsHits {
 		u := user.User{
 			CreatedAt: v.Source.CreatedAt,
+			TenantID:  v.Source.TenantID,
 			ID:        v.Source.ID,
 			FirstName: v.Source.FirstName,
 			LastName:  v.Source.LastName,
+			Version:   v.Source.Version,
 		}
 
 		users = append(users, u)
@@ -219,18 +396,20 @@ type h struct {
 }
 
 type hh struct {
-	HitsHits []s `json:"hits"`
+	HitsHits []hit `json:"hits"`
 }
 
-type s struct {
+type hit struct {
 	Source u `json:"_source"`
 }
 
 type u struct {
 	CreatedAt time.Time `json:"created_at"`
+	TenantID  string    `json:"tenant_id"`
 	ID        string    `json:"id"`
 	FirstName string    `json:"first_name"`
 	LastName  string    `json:"last_name"`
+	Version   int64     `json:"version"`
 }
 
 type instrumentSearch struct {
@@ -258,3 +437,20 @@ func (s *instrumentSearch) Perform(request *http.Request) (*http.Response, error
 
 	return s.search.Perform(request)
 }
+
+// Check pings the cluster, for health checks.
+func (s *userSearch) Check(ctx context.Context) error {
+	req := esapi.PingRequest{}
+
+	resp, err := req.Do(ctx, s.search)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return fmt.Errorf("status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
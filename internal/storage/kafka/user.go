@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/pkg/errors"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+type writer interface {
+	WriteMessages(context.Context, ...kafkago.Message) error
+}
+
+type UserTopics struct {
+	writer writer
+	topics []string
+}
+
+// NewUserTopics returns UserTopics for compacted topics holding messages
+// keyed by tenant and user ID (e.g., those written by the outbox relay,
+// see user.Event.Key). writer must
+// not have a topic set, as the topic is set on each message.
+func NewUserTopics(
+	writer writer,
+	topics ...string,
+) *UserTopics {
+	return &UserTopics{
+		writer: writer,
+		topics: topics,
+	}
+}
+
+// Erase writes a tombstone (i.e., a message with a nil value) for the user
+// to each topic, so that compaction removes the messages for the user.
+// Compaction is asynchronous, so messages remain readable until it runs.
+//
+// Messages written by Debezium are keyed by the Avro encoded key of the
+// row, and are tombstoned by Debezium when the row is deleted.
+func (t *UserTopics) Erase(
+	ctx context.Context,
+	id string,
+) error {
+	msgs := make([]kafkago.Message, 0, len(t.topics))
+
+	for _, topic := range t.topics {
+		msgs = append(msgs, kafkago.Message{
+			Topic: topic,
+			Key:   []byte(tenant.ID(ctx) + ":" + id),
+		})
+	}
+
+	if err := t.writer.WriteMessages(ctx, msgs...); err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type writerMock struct {
+	err  error
+	msgs []kafkago.Message
+}
+
+func (m *writerMock) WriteMessages(_ context.Context, msgs ...kafkago.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.msgs = append(m.msgs, msgs...)
+
+	return nil
+}
+
+func TestUserTopics_Erase(t *testing.T) {
+	w := &writerMock{}
+
+	topics := NewUserTopics(w, "users", "users-events")
+
+	err := topics.Erase(
+		tenant.NewContext(context.Background(), "acme"),
+		"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]kafkago.Message{
+			{Topic: "users", Key: []byte("acme:0a81dec3-3638-4eb4-b04a-83d744f5f3a8")},
+			{Topic: "users-events", Key: []byte("acme:0a81dec3-3638-4eb4-b04a-83d744f5f3a8")},
+		},
+		w.msgs,
+	)
+
+	for _, msg := range w.msgs {
+		assert.Nil(t, msg.Value)
+	}
+}
+
+func TestUserTopics_EraseError(t *testing.T) {
+	topics := NewUserTopics(&writerMock{err: errors.New("write error")}, "users")
+
+	err := topics.Erase(context.Background(), "0a81dec3-3638-4eb4-b04a-83d744f5f3a8")
+	assert.EqualError(t, err, "write error")
+}
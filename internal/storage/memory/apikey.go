@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+)
+
+// APIKeyStorage holds keys by ID. Keys are read by ID only within the
+// tenant carried by the context, but are read by hash across tenants.
+type APIKeyStorage struct {
+	keys map[string]apikey.Key
+	mu   sync.RWMutex
+}
+
+func NewAPIKeyStorage() *APIKeyStorage {
+	return &APIKeyStorage{
+		keys: make(map[string]apikey.Key),
+	}
+}
+
+func (s *APIKeyStorage) CreateKey(
+	ctx context.Context,
+	key apikey.Key,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key.TenantID = tenant.ID(ctx)
+	s.keys[key.ID] = key
+
+	return nil
+}
+
+func (s *APIKeyStorage) ReadKeys(ctx context.Context) ([]apikey.Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []apikey.Key
+
+	for _, k := range s.keys {
+		if k.TenantID == tenant.ID(ctx) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (s *APIKeyStorage) ReadKey(
+	ctx context.Context,
+	id string,
+) (apikey.Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tenantKey(ctx, id)
+}
+
+func (s *APIKeyStorage) ReadKeyByHash(
+	_ context.Context,
+	hash string,
+) (apikey.Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+
+	return apikey.Key{}, apikey.ErrNotFound
+}
+
+// RotateKey does not rotate revoked keys.
+func (s *APIKeyStorage) RotateKey(
+	ctx context.Context,
+	id string,
+	hash string,
+	prefix string,
+	at time.Time,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, err := s.tenantKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if k.Revoked() {
+		return apikey.ErrNotFound
+	}
+
+	k.Hash = hash
+	k.Prefix = prefix
+	k.RotatedAt = &at
+	s.keys[id] = k
+
+	return nil
+}
+
+// RevokeKey does not change the revocation time of revoked keys.
+func (s *APIKeyStorage) RevokeKey(
+	ctx context.Context,
+	id string,
+	at time.Time,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, err := s.tenantKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !k.Revoked() {
+		k.RevokedAt = &at
+		s.keys[id] = k
+	}
+
+	return nil
+}
+
+func (s *APIKeyStorage) TouchKey(
+	_ context.Context,
+	id string,
+	at time.Time,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if k, ok := s.keys[id]; ok {
+		k.LastUsedAt = &at
+		s.keys[id] = k
+	}
+
+	return nil
+}
+
+// tenantKey returns the key with the ID if it belongs to the tenant
+// carried by ctx. The caller must hold the lock.
+func (s *APIKeyStorage) tenantKey(
+	ctx context.Context,
+	id string,
+) (apikey.Key, error) {
+	k, ok := s.keys[id]
+	if !ok || k.TenantID != tenant.ID(ctx) {
+		return apikey.Key{}, apikey.ErrNotFound
+	}
+
+	return k, nil
+}
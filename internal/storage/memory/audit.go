@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+)
+
+// AuditStorage holds the chain of entries of each tenant.
+type AuditStorage struct {
+	chains map[string][]audit.Entry
+	mu     sync.RWMutex
+}
+
+func NewAuditStorage() *AuditStorage {
+	return &AuditStorage{
+		chains: make(map[string][]audit.Entry),
+	}
+}
+
+func (s *AuditStorage) AppendEntries(
+	ctx context.Context,
+	entries ...audit.Entry,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenantID := tenant.ID(ctx)
+	chain := s.chains[tenantID]
+
+	var (
+		seq  int64
+		hash string
+	)
+
+	if len(chain) > 0 {
+		seq, hash = chain[len(chain)-1].Seq, chain[len(chain)-1].Hash
+	}
+
+	for _, e := range entries {
+		e.TenantID = tenantID
+		e = audit.Chain(e, seq, hash)
+		seq, hash = e.Seq, e.Hash
+
+		chain = append(chain, e)
+	}
+
+	s.chains[tenantID] = chain
+
+	return nil
+}
+
+func (s *AuditStorage) ReadEntries(
+	ctx context.Context,
+	filter audit.Filter,
+) ([]audit.Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []audit.Entry
+
+	for _, e := range s.chains[tenant.ID(ctx)] {
+		if len(entries) == filter.Limit {
+			break
+		}
+
+		if filter.Actor != "" && e.Actor != filter.Actor {
+			continue
+		}
+
+		if filter.TargetID != "" && e.TargetID != filter.TargetID {
+			continue
+		}
+
+		if !filter.From.IsZero() && e.OccurredAt.Before(filter.From) {
+			continue
+		}
+
+		if !filter.To.IsZero() && !e.OccurredAt.Before(filter.To) {
+			continue
+		}
+
+		if e.Seq <= filter.After {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
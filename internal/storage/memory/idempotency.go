@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
+)
+
+type idempotencyRecord struct {
+	expiresAt time.Time
+	record    idempotency.Record
+}
+
+type IdempotencyStore struct {
+	records map[string]idempotencyRecord
+	now     func() time.Time
+	mu      sync.Mutex
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+// NewIdempotencyStore returns a store in which completed records expire
+// after ttl, and records without a response expire after lockTTL. Expired
+// records are removed when a record is next begun.
+func NewIdempotencyStore(
+	ttl time.Duration,
+	lockTTL time.Duration,
+) *IdempotencyStore {
+	return &IdempotencyStore{
+		records: make(map[string]idempotencyRecord),
+		now:     time.Now,
+		ttl:     ttl,
+		lockTTL: lockTTL,
+	}
+}
+
+func (s *IdempotencyStore) Begin(
+	_ context.Context,
+	key string,
+	fingerprint string,
+) (idempotency.Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	for k, r := range s.records {
+		if !now.Before(r.expiresAt) {
+			delete(s.records, k)
+		}
+	}
+
+	if r, ok := s.records[key]; ok {
+		return r.record, false, nil
+	}
+
+	s.records[key] = idempotencyRecord{
+		expiresAt: now.Add(s.lockTTL),
+		record: idempotency.Record{
+			Fingerprint: fingerprint,
+		},
+	}
+
+	return idempotency.Record{}, true, nil
+}
+
+func (s *IdempotencyStore) Complete(
+	_ context.Context,
+	key string,
+	record idempotency.Record,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = idempotencyRecord{
+		expiresAt: s.now().Add(s.ttl),
+		record:    record,
+	}
+
+	return nil
+}
+
+func (s *IdempotencyStore) Release(
+	_ context.Context,
+	key string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
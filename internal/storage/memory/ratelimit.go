@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/ratelimit"
+)
+
+// rateLimitSweepInterval is the minimum interval between removals of
+// expired rate limit state.
+const rateLimitSweepInterval = time.Minute
+
+type rateLimitState struct {
+	expiresAt time.Time
+	bucket    ratelimit.Bucket
+	window    ratelimit.Window
+}
+
+type RateLimiter struct {
+	states    map[string]*rateLimitState
+	now       func() time.Time
+	sweptAt   time.Time
+	mu        sync.Mutex
+	algorithm ratelimit.Algorithm
+}
+
+// NewRateLimiter returns a limiter, local to this instance, that limits
+// requests with algorithm. Expired state is removed periodically when
+// requests are limited.
+func NewRateLimiter(
+	algorithm ratelimit.Algorithm,
+) *RateLimiter {
+	return &RateLimiter{
+		states:    make(map[string]*rateLimitState),
+		now:       time.Now,
+		algorithm: algorithm,
+	}
+}
+
+func (l *RateLimiter) Allow(
+	_ context.Context,
+	key string,
+	limit ratelimit.Limit,
+) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	if now.Sub(l.sweptAt) >= rateLimitSweepInterval {
+		for k, s := range l.states {
+			if !now.Before(s.expiresAt) {
+				delete(l.states, k)
+			}
+		}
+
+		l.sweptAt = now
+	}
+
+	s, ok := l.states[key]
+	if !ok {
+		s = &rateLimitState{}
+		l.states[key] = s
+	}
+
+	var (
+		allowed    bool
+		retryAfter time.Duration
+	)
+
+	// State expires once the bucket would be full, or the window would
+	// no longer count any requests.
+	switch l.algorithm {
+	case ratelimit.SlidingWindow:
+		allowed, retryAfter = s.window.Take(now, limit)
+		s.expiresAt = s.window.Start.Add(2 * limit.Period)
+	default:
+		allowed, retryAfter = s.bucket.Take(now, limit)
+		s.expiresAt = now.Add(limit.Period * time.Duration(limit.Burst) / time.Duration(limit.Requests))
+	}
+
+	return allowed, retryAfter, nil
+}
@@ -2,22 +2,40 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 )
 
+// UserStorage holds users by tenant, and by ID within each tenant.
 type UserStorage struct {
-	users map[string]user.User
+	users map[string]map[string]user.User
 	mu    sync.Mutex
 }
 
 func NewUserStorage() *UserStorage {
 	return &UserStorage{
-		users: make(map[string]user.User),
+		users: make(map[string]map[string]user.User),
 	}
 }
 
+// tenantUsers returns the users of the tenant carried by ctx. The caller
+// must hold the lock.
+func (u *UserStorage) tenantUsers(ctx context.Context) map[string]user.User {
+	tenantID := tenant.ID(ctx)
+
+	users, ok := u.users[tenantID]
+	if !ok {
+		users = make(map[string]user.User)
+		u.users[tenantID] = users
+	}
+
+	return users
+}
+
 func (u *UserStorage) Create(
 	ctx context.Context,
 	users ...user.User,
@@ -25,19 +43,207 @@ func (u *UserStorage) Create(
 	u.mu.Lock()
 	defer u.mu.Unlock()
 
+	tenantUsers := u.tenantUsers(ctx)
+
 	for _, usr := range users {
-		u.users[usr.ID] = usr
+		usr.TenantID = tenant.ID(ctx)
+		tenantUsers[usr.ID] = usr
 	}
 
 	return nil
 }
 
-func (u *UserStorage) Read(context.Context) ([]user.User, error) {
+// Read excludes soft deleted users.
+func (u *UserStorage) Read(ctx context.Context) ([]user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	var users []user.User
 
-	for _, u := range u.users {
-		users = append(users, u)
+	for _, usr := range u.tenantUsers(ctx) {
+		if usr.DeletedAt == nil {
+			users = append(users, usr)
+		}
 	}
 
 	return users, nil
 }
+
+// ReadPage uses the ID of the last user in the page as the cursor, and
+// excludes soft deleted users.
+func (u *UserStorage) ReadPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) ([]user.User, string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	tenantUsers := u.tenantUsers(ctx)
+
+	var ids []string
+
+	for id, usr := range tenantUsers {
+		if id > cursor && usr.DeletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	if len(ids) <= limit {
+		limit = len(ids)
+		cursor = ""
+	} else {
+		cursor = ids[limit-1]
+	}
+
+	users := make([]user.User, 0, limit)
+
+	for _, id := range ids[:limit] {
+		users = append(users, tenantUsers[id])
+	}
+
+	return users, cursor, nil
+}
+
+func (u *UserStorage) ReadByID(
+	ctx context.Context,
+	id string,
+) (user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usr, ok := u.tenantUsers(ctx)[id]
+	if !ok || usr.DeletedAt != nil {
+		return user.User{}, user.ErrNotFound
+	}
+
+	return usr, nil
+}
+
+func (u *UserStorage) Update(
+	ctx context.Context,
+	expectedVersion int64,
+	usr user.User,
+) (user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	stored, ok := u.tenantUsers(ctx)[usr.ID]
+	if !ok || stored.DeletedAt != nil {
+		return user.User{}, user.ErrNotFound
+	}
+
+	if stored.Version != expectedVersion {
+		return user.User{}, user.ErrVersionMismatch
+	}
+
+	stored.FirstName = usr.FirstName
+	stored.LastName = usr.LastName
+	stored.Version++
+
+	u.tenantUsers(ctx)[usr.ID] = stored
+
+	return stored, nil
+}
+
+func (u *UserStorage) SoftDelete(
+	ctx context.Context,
+	id string,
+) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usr, ok := u.tenantUsers(ctx)[id]
+	if !ok || usr.DeletedAt != nil {
+		return user.ErrNotFound
+	}
+
+	now := time.Now()
+
+	usr.DeletedAt = &now
+	usr.Version++
+
+	u.tenantUsers(ctx)[id] = usr
+
+	return nil
+}
+
+func (u *UserStorage) Restore(
+	ctx context.Context,
+	id string,
+) (user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usr, ok := u.tenantUsers(ctx)[id]
+	if !ok || usr.DeletedAt == nil {
+		return user.User{}, user.ErrNotFound
+	}
+
+	usr.DeletedAt = nil
+	usr.Version++
+
+	u.tenantUsers(ctx)[id] = usr
+
+	return usr, nil
+}
+
+// Purge deletes users across all tenants.
+func (u *UserStorage) Purge(
+	_ context.Context,
+	deletedBefore time.Time,
+	limit int,
+) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var n int
+
+	for _, tenantUsers := range u.users {
+		for id, usr := range tenantUsers {
+			if n == limit {
+				return n, nil
+			}
+
+			if usr.DeletedAt != nil && usr.DeletedAt.Before(deletedBefore) {
+				delete(tenantUsers, id)
+				n++
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// Export returns the user, including when soft deleted.
+func (u *UserStorage) Export(
+	ctx context.Context,
+	id string,
+) (user.SubjectData, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usr, ok := u.tenantUsers(ctx)[id]
+	if !ok {
+		return user.SubjectData{}, user.ErrNotFound
+	}
+
+	return user.SubjectData{
+		User: &usr,
+	}, nil
+}
+
+// Erase deletes the user, including when soft deleted.
+func (u *UserStorage) Erase(
+	ctx context.Context,
+	id string,
+) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	delete(u.tenantUsers(ctx), id)
+
+	return nil
+}
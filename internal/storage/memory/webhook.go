@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+)
+
+// WebhookStorage scopes subscriptions and deliveries to the tenant carried
+// by the context.
+type WebhookStorage struct {
+	subscriptions map[string]webhook.Subscription
+	deliveries    map[string][]webhook.Delivery
+	pending       map[string]webhook.Pending
+	mu            sync.RWMutex
+}
+
+func NewWebhookStorage() *WebhookStorage {
+	return &WebhookStorage{
+		subscriptions: make(map[string]webhook.Subscription),
+		deliveries:    make(map[string][]webhook.Delivery),
+		pending:       make(map[string]webhook.Pending),
+	}
+}
+
+func (w *WebhookStorage) CreateSubscription(
+	ctx context.Context,
+	subscription webhook.Subscription,
+) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	subscription.TenantID = tenant.ID(ctx)
+
+	w.subscriptions[subscription.ID] = subscription
+
+	return nil
+}
+
+func (w *WebhookStorage) ReadSubscriptions(ctx context.Context) ([]webhook.Subscription, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	tenantID := tenant.ID(ctx)
+
+	subscriptions := make([]webhook.Subscription, 0, len(w.subscriptions))
+
+	for _, s := range w.subscriptions {
+		if s.TenantID == tenantID {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return subscriptions, nil
+}
+
+func (w *WebhookStorage) ReadSubscription(
+	ctx context.Context,
+	id string,
+) (webhook.Subscription, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	s, ok := w.subscriptions[id]
+	if !ok || s.TenantID != tenant.ID(ctx) {
+		return webhook.Subscription{}, webhook.ErrNotFound
+	}
+
+	return s, nil
+}
+
+func (w *WebhookStorage) UpdateSubscription(
+	ctx context.Context,
+	subscription webhook.Subscription,
+) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s, ok := w.subscriptions[subscription.ID]
+	if !ok || s.TenantID != tenant.ID(ctx) {
+		return webhook.ErrNotFound
+	}
+
+	subscription.TenantID = s.TenantID
+
+	w.subscriptions[subscription.ID] = subscription
+
+	return nil
+}
+
+func (w *WebhookStorage) DeleteSubscription(
+	ctx context.Context,
+	id string,
+) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s, ok := w.subscriptions[id]
+	if !ok || s.TenantID != tenant.ID(ctx) {
+		return webhook.ErrNotFound
+	}
+
+	delete(w.subscriptions, id)
+	delete(w.deliveries, id)
+
+	for pid, p := range w.pending {
+		if p.SubscriptionID == id {
+			delete(w.pending, pid)
+		}
+	}
+
+	return nil
+}
+
+func (w *WebhookStorage) CreateDelivery(
+	ctx context.Context,
+	delivery webhook.Delivery,
+) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delivery.TenantID = tenant.ID(ctx)
+
+	w.deliveries[delivery.SubscriptionID] = append(
+		w.deliveries[delivery.SubscriptionID],
+		delivery,
+	)
+
+	return nil
+}
+
+func (w *WebhookStorage) ReadDeliveries(
+	ctx context.Context,
+	subscriptionID string,
+	limit int,
+) ([]webhook.Delivery, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	tenantID := tenant.ID(ctx)
+
+	stored := w.deliveries[subscriptionID]
+	deliveries := make([]webhook.Delivery, 0, min(limit, len(stored)))
+
+	for i := len(stored) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if stored[i].TenantID == tenantID {
+			deliveries = append(deliveries, stored[i])
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (w *WebhookStorage) CreatePending(
+	ctx context.Context,
+	pending ...webhook.Pending,
+) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tenantID := tenant.ID(ctx)
+
+	for _, p := range pending {
+		if w.isPending(p.SubscriptionID, p.EventID) {
+			continue
+		}
+
+		p.TenantID = tenantID
+
+		w.pending[p.ID] = p
+	}
+
+	return nil
+}
+
+func (w *WebhookStorage) isPending(
+	subscriptionID string,
+	eventID string,
+) bool {
+	for _, p := range w.pending {
+		if p.SubscriptionID == subscriptionID && p.EventID == eventID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *WebhookStorage) ClaimPending(
+	_ context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) ([]webhook.Pending, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var due []webhook.Pending
+
+	for _, p := range w.pending {
+		if !p.NextAttemptAt.After(now) {
+			due = append(due, p)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for _, p := range due {
+		p.NextAttemptAt = now.Add(lease)
+
+		w.pending[p.ID] = p
+	}
+
+	return due, nil
+}
+
+func (w *WebhookStorage) ReschedulePending(
+	_ context.Context,
+	pending webhook.Pending,
+) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	p, ok := w.pending[pending.ID]
+	if !ok {
+		return nil
+	}
+
+	p.Attempt = pending.Attempt
+	p.NextAttemptAt = pending.NextAttemptAt
+
+	w.pending[p.ID] = p
+
+	return nil
+}
+
+func (w *WebhookStorage) DeletePending(
+	_ context.Context,
+	id string,
+) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.pending, id)
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+)
+
+const apiKeyColumns = "id, tenant_id, name, key_hash, key_prefix, roles, scopes, quota, created_at, rotated_at, last_used_at, revoked_at"
+
+type APIKeyStorage struct {
+	db           DB
+	queryTimeout time.Duration
+}
+
+func NewAPIKeyStorage(
+	db DB,
+	queryTimeout time.Duration,
+) *APIKeyStorage {
+	return &APIKeyStorage{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// CreateKey creates the key for the tenant carried by ctx.
+func (s *APIKeyStorage) CreateKey(
+	ctx context.Context,
+	key apikey.Key,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		s.queryTimeout,
+	)
+	defer cancel()
+
+	roles, err := json.Marshal(key.Roles)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`
+INSERT INTO api_keys(id, tenant_id, name, key_hash, key_prefix, roles, scopes, quota, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`,
+		key.ID,
+		tenant.ID(ctx),
+		key.Name,
+		key.Hash,
+		key.Prefix,
+		roles,
+		scopes,
+		key.Quota,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (s *APIKeyStorage) ReadKeys(ctx context.Context) ([]apikey.Key, error) {
+	return s.readKeys(
+		ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE tenant_id = ? ORDER BY created_at",
+		tenant.ID(ctx),
+	)
+}
+
+func (s *APIKeyStorage) ReadKey(
+	ctx context.Context,
+	id string,
+) (apikey.Key, error) {
+	return s.readKey(
+		ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE tenant_id = ? AND id = ?",
+		tenant.ID(ctx),
+		id,
+	)
+}
+
+func (s *APIKeyStorage) ReadKeyByHash(
+	ctx context.Context,
+	hash string,
+) (apikey.Key, error) {
+	return s.readKey(
+		ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ?",
+		hash,
+	)
+}
+
+func (s *APIKeyStorage) readKey(
+	ctx context.Context,
+	qry string,
+	args ...interface{},
+) (apikey.Key, error) {
+	keys, err := s.readKeys(ctx, qry, args...)
+	if err != nil {
+		return apikey.Key{}, err
+	}
+
+	if len(keys) == 0 {
+		return apikey.Key{}, apikey.ErrNotFound
+	}
+
+	return keys[0], nil
+}
+
+func (s *APIKeyStorage) readKeys(
+	ctx context.Context,
+	qry string,
+	args ...interface{},
+) ([]apikey.Key, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		s.queryTimeout,
+	)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		qry,
+		args...,
+	)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+	defer rows.Close()
+
+	var keys []apikey.Key
+
+	for rows.Next() {
+		var (
+			k          apikey.Key
+			roles      []byte
+			scopes     []byte
+			rotatedAt  sql.NullTime
+			lastUsedAt sql.NullTime
+			revokedAt  sql.NullTime
+		)
+
+		err := rows.Scan(
+			&k.ID,
+			&k.TenantID,
+			&k.Name,
+			&k.Hash,
+			&k.Prefix,
+			&roles,
+			&scopes,
+			&k.Quota,
+			&k.CreatedAt,
+			&rotatedAt,
+			&lastUsedAt,
+			&revokedAt,
+		)
+		if err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		if err := json.Unmarshal(roles, &k.Roles); err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		if err := json.Unmarshal(scopes, &k.Scopes); err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		k.RotatedAt = timePtr(rotatedAt)
+		k.LastUsedAt = timePtr(lastUsedAt)
+		k.RevokedAt = timePtr(revokedAt)
+
+		keys = append(keys, k)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	return keys, nil
+}
+
+// RotateKey does not rotate revoked keys.
+func (s *APIKeyStorage) RotateKey(
+	ctx context.Context,
+	id string,
+	hash string,
+	prefix string,
+	at time.Time,
+) error {
+	return s.exec(
+		ctx,
+		"UPDATE api_keys SET key_hash = ?, key_prefix = ?, rotated_at = ? WHERE tenant_id = ? AND id = ? AND revoked_at IS NULL",
+		hash,
+		prefix,
+		at,
+		tenant.ID(ctx),
+		id,
+	)
+}
+
+// RevokeKey does not change the revocation time of revoked keys.
+func (s *APIKeyStorage) RevokeKey(
+	ctx context.Context,
+	id string,
+	at time.Time,
+) error {
+	err := s.exec(
+		ctx,
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE tenant_id = ? AND id = ?",
+		at,
+		tenant.ID(ctx),
+		id,
+	)
+
+	// MySQL reports rows changed rather than rows matched, so no rows
+	// are affected when the key is already revoked.
+	if errors.Is(err, apikey.ErrNotFound) {
+		_, err = s.ReadKey(ctx, id)
+	}
+
+	return err
+}
+
+// TouchKey does not return apikey.ErrNotFound, as no rows are affected
+// when the key was last used at the same time.
+func (s *APIKeyStorage) TouchKey(
+	ctx context.Context,
+	id string,
+	at time.Time,
+) error {
+	err := s.exec(
+		ctx,
+		"UPDATE api_keys SET last_used_at = ? WHERE id = ?",
+		at,
+		id,
+	)
+	if errors.Is(err, apikey.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// exec returns apikey.ErrNotFound if the statement changes no rows.
+func (s *APIKeyStorage) exec(
+	ctx context.Context,
+	qry string,
+	args ...interface{},
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		s.queryTimeout,
+	)
+	defer cancel()
+
+	res, err := s.db.ExecContext(
+		ctx,
+		qry,
+		args...,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	if n == 0 {
+		return apikey.ErrNotFound
+	}
+
+	return nil
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
@@ -0,0 +1,277 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+)
+
+const auditColumns = "tenant_id, seq, occurred_at, actor, operation, resource, target_id, request_id, trace_id, client_ip, outcome, status, prev_hash, hash"
+
+// auditPlaceholders is the number of placeholders used to insert each
+// entry.
+const auditPlaceholders = 14
+
+// AuditStorage appends entries to the chain of the tenant carried by the
+// context. Appends to each chain are serialised by locking the head of the
+// chain, and the table rejects updates and deletes.
+//
+// As every mutating call appends twice (a pending entry, then its
+// outcome), the row lock on the head of the chain serialises the mutating
+// calls of each tenant, and bounds their throughput by the latency of the
+// appends (see k6/audit.js).
+type AuditStorage struct {
+	db           DB
+	queryTimeout time.Duration
+}
+
+func NewAuditStorage(
+	db DB,
+	queryTimeout time.Duration,
+) *AuditStorage {
+	return &AuditStorage{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (s *AuditStorage) AppendEntries(
+	ctx context.Context,
+	entries ...audit.Entry,
+) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		s.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	tenantID := tenant.ID(ctx)
+
+	// Upserting the head locks it until the transaction ends.
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO audit_log_heads(tenant_id, seq, hash) VALUES (?, 0, '') ON DUPLICATE KEY UPDATE tenant_id = tenant_id",
+		tenantID,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	var (
+		seq  int64
+		hash string
+	)
+
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT seq, hash FROM audit_log_heads WHERE tenant_id = ? FOR UPDATE",
+		tenantID,
+	).Scan(&seq, &hash)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	chained := make([]audit.Entry, 0, len(entries))
+
+	for _, e := range entries {
+		e = audit.Chain(auditRow(tenantID, e), seq, hash)
+		seq, hash = e.Seq, e.Hash
+
+		chained = append(chained, e)
+	}
+
+	size := maxRows(auditPlaceholders)
+
+	for start := 0; start < len(chained); start += size {
+		end := min(start+size, len(chained))
+
+		if err := insertEntries(ctx, tx, chained[start:end]...); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE audit_log_heads SET seq = ?, hash = ? WHERE tenant_id = ?",
+		seq,
+		hash,
+		tenantID,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+// auditRow returns e as it is stored, so that its hash matches the hash
+// of the entry when read.
+func auditRow(tenantID string, e audit.Entry) audit.Entry {
+	e.OccurredAt = e.OccurredAt.UTC().Truncate(time.Microsecond)
+	e.TenantID = tenantID
+	e.Actor = truncate(e.Actor, 255)
+	e.Operation = truncate(e.Operation, 100)
+	e.Resource = truncate(e.Resource, 255)
+	e.TargetID = truncate(e.TargetID, 36)
+	e.RequestID = truncate(e.RequestID, 100)
+	e.TraceID = truncate(e.TraceID, 32)
+	e.ClientIP = truncate(e.ClientIP, 45)
+	e.Outcome = truncate(e.Outcome, 20)
+	e.Status = truncate(e.Status, 20)
+
+	return e
+}
+
+// truncate returns s truncated to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
+
+func insertEntries(
+	ctx context.Context,
+	eq execQuerier,
+	entries ...audit.Entry,
+) error {
+	values := make([]string, 0, len(entries))
+	args := make([]interface{}, 0, len(entries)*auditPlaceholders)
+
+	for _, e := range entries {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(
+			args,
+			e.TenantID,
+			e.Seq,
+			e.OccurredAt,
+			e.Actor,
+			e.Operation,
+			e.Resource,
+			e.TargetID,
+			e.RequestID,
+			e.TraceID,
+			e.ClientIP,
+			e.Outcome,
+			e.Status,
+			e.PrevHash,
+			e.Hash,
+		)
+	}
+
+	_, err := eq.ExecContext(
+		ctx,
+		"INSERT INTO audit_log("+auditColumns+") VALUES "+strings.Join(values, ", "),
+		args...,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (s *AuditStorage) ReadEntries(
+	ctx context.Context,
+	filter audit.Filter,
+) ([]audit.Entry, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		s.queryTimeout,
+	)
+	defer cancel()
+
+	where := []string{"tenant_id = ?"}
+	args := []interface{}{tenant.ID(ctx)}
+
+	if filter.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+
+	if filter.TargetID != "" {
+		where = append(where, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+
+	if !filter.From.IsZero() {
+		where = append(where, "occurred_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+
+	if !filter.To.IsZero() {
+		where = append(where, "occurred_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	if filter.After > 0 {
+		where = append(where, "seq > ?")
+		args = append(args, filter.After)
+	}
+
+	args = append(args, filter.Limit)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT "+auditColumns+" FROM audit_log WHERE "+strings.Join(where, " AND ")+" ORDER BY seq LIMIT ?",
+		args...,
+	)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+
+	for rows.Next() {
+		var e audit.Entry
+
+		err := rows.Scan(
+			&e.TenantID,
+			&e.Seq,
+			&e.OccurredAt,
+			&e.Actor,
+			&e.Operation,
+			&e.Resource,
+			&e.TargetID,
+			&e.RequestID,
+			&e.TraceID,
+			&e.ClientIP,
+			&e.Outcome,
+			&e.Status,
+			&e.PrevHash,
+			&e.Hash,
+		)
+		if err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	return entries, nil
+}
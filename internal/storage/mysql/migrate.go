@@ -0,0 +1,226 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratemysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Migrations are embedded so that the binary can migrate the schema
+// without the migration files being deployed alongside it.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+const migrationsDir = "migrations"
+
+// migrateLock evaluates to the name of the advisory lock held while
+// migrating on start, which is qualified with the name of the database as
+// locks are server wide.
+const migrateLock = "CONCAT(DATABASE(), '.migrate')"
+
+// ErrMigrateLockTimeout is returned if the advisory lock is not acquired
+// within the timeout, as another instance is migrating.
+var ErrMigrateLockTimeout = errors.New("timed out waiting for migration lock")
+
+// Migration is a migration, and whether it has been applied.
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// MigrationStatus is the version of the schema, whether the migration to
+// the version failed (i.e., is dirty), and every migration.
+type MigrationStatus struct {
+	Version    uint        `json:"version"`
+	Dirty      bool        `json:"dirty"`
+	Migrations []Migration `json:"migrations"`
+}
+
+type migrator struct {
+	db      *sql.DB
+	migrate *migrate.Migrate
+	source  source.Driver
+}
+
+// NewMigrator returns a migrator that applies the embedded migrations to
+// db, which must permit multiple statements (e.g., for triggers).
+func NewMigrator(db *sql.DB) (*migrator, error) {
+	src, err := iofs.New(Migrations, migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := migratemysql.WithInstance(db, &migratemysql.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance(
+		"iofs",
+		src,
+		"mysql",
+		driver,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrator{
+		db,
+		m,
+		src,
+	}, nil
+}
+
+// Up applies steps migrations, or all pending migrations if steps is 0.
+func (mg *migrator) Up(steps int) error {
+	var err error
+
+	switch {
+	case steps > 0:
+		err = mg.migrate.Steps(steps)
+	default:
+		err = mg.migrate.Up()
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+// Down rolls back steps migrations.
+func (mg *migrator) Down(steps int) error {
+	if steps < 1 {
+		return errors.New("steps must be at least 1")
+	}
+
+	err := mg.migrate.Steps(-steps)
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+// Force sets the version of the schema without migrating, clearing the
+// dirty state once a failed migration has been fixed manually.
+func (mg *migrator) Force(version int) error {
+	return mg.migrate.Force(version)
+}
+
+// Status returns the version of the schema and every migration.
+func (mg *migrator) Status() (MigrationStatus, error) {
+	version, dirty, err := mg.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{}, err
+	}
+
+	status := MigrationStatus{
+		Version: version,
+		Dirty:   dirty,
+	}
+
+	migrations, err := migrations(mg.source)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	for _, m := range migrations {
+		m.Applied = m.Version <= version && !(dirty && m.Version == version)
+		status.Migrations = append(status.Migrations, m)
+	}
+
+	return status, nil
+}
+
+// UpLocked applies all pending migrations while holding an advisory lock,
+// so that instances migrating on start wait for the first to finish, rather
+// than failing on the lock held by golang-migrate, which times out after
+// 10 seconds.
+func (mg *migrator) UpLocked(
+	ctx context.Context,
+	timeout time.Duration,
+) error {
+	conn, err := mg.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var acquired sql.NullInt64
+
+	err = conn.QueryRowContext(
+		ctx,
+		"SELECT GET_LOCK("+migrateLock+", ?)",
+		int(timeout.Seconds()),
+	).Scan(&acquired)
+	if err != nil {
+		return err
+	}
+
+	if acquired.Int64 != 1 {
+		return ErrMigrateLockTimeout
+	}
+
+	defer func() {
+		_, _ = conn.ExecContext(
+			context.WithoutCancel(ctx),
+			"SELECT RELEASE_LOCK("+migrateLock+")",
+		)
+	}()
+
+	return mg.Up(0)
+}
+
+// Close closes the source and db.
+func (mg *migrator) Close() error {
+	srcErr, dbErr := mg.migrate.Close()
+
+	return errors.Join(srcErr, dbErr)
+}
+
+// migrations returns the migrations in src in order of version.
+func migrations(src source.Driver) ([]Migration, error) {
+	var migrations []Migration
+
+	version, err := src.First()
+
+	for err == nil {
+		var (
+			r    io.ReadCloser
+			name string
+		)
+
+		r, name, err = src.ReadUp(version)
+		if err != nil {
+			return nil, err
+		}
+		_ = r.Close()
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+		})
+
+		version, err = src.Next(version)
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return migrations, nil
+}
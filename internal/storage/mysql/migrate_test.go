@@ -0,0 +1,44 @@
+package mysql
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	src, err := iofs.New(Migrations, migrationsDir)
+	require.NoError(t, err)
+
+	migrations, err := migrations(src)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, uint(i+1), m.Version, "versions are sequential")
+		assert.NotEmpty(t, m.Name)
+
+		r, _, err := src.ReadDown(m.Version)
+		if assert.NoError(t, err, "version %d has a down migration", m.Version) {
+			_ = r.Close()
+		}
+	}
+
+	files, err := fs.Glob(Migrations, migrationsDir+"/*.sql")
+	require.NoError(t, err)
+
+	for _, file := range files {
+		assert.True(
+			t,
+			strings.HasSuffix(file, ".up.sql") || strings.HasSuffix(file, ".down.sql"),
+			"%s is an up or down migration",
+			file,
+		)
+	}
+
+	assert.Len(t, files, 2*len(migrations))
+}
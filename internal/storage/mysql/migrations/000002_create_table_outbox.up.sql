@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `outbox` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `aggregate_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `event_type` varchar(100) NOT NULL,
+  `payload` json NOT NULL,
+  `created_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
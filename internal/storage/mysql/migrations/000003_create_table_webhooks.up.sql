@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `webhook_subscriptions` (
+  `id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `url` varchar(2048) NOT NULL,
+  `secret` varchar(100) NOT NULL,
+  `event_types` json NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `subscription_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `event_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `event_type` varchar(100) NOT NULL,
+  `attempt` int NOT NULL,
+  `status_code` int NOT NULL,
+  `error` text NOT NULL,
+  `duration_ms` bigint NOT NULL,
+  `attempted_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `subscription_id_attempted_at` (`subscription_id`, `attempted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
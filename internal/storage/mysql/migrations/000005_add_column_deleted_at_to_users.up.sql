@@ -0,0 +1 @@
+ALTER TABLE `users` ADD COLUMN `deleted_at` datetime NULL DEFAULT NULL, ADD INDEX `idx_users_deleted_at` (`deleted_at`);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `outbox` DROP INDEX `idx_outbox_tenant_id_aggregate_id`, DROP COLUMN `tenant_id`;
+
+ALTER TABLE `users` DROP PRIMARY KEY, ADD PRIMARY KEY (`id`), DROP COLUMN `tenant_id`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `users` ADD COLUMN `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'default', DROP PRIMARY KEY, ADD PRIMARY KEY (`tenant_id`, `id`);
+
+ALTER TABLE `outbox` ADD COLUMN `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'default', ADD INDEX `idx_outbox_tenant_id_aggregate_id` (`tenant_id`, `aggregate_id`);
\ No newline at end of file
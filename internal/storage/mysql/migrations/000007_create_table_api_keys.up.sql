@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+  `id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `key_hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `key_prefix` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `roles` json NOT NULL,
+  `scopes` json NOT NULL,
+  `quota` int NOT NULL,
+  `created_at` datetime NOT NULL,
+  `rotated_at` datetime DEFAULT NULL,
+  `last_used_at` datetime DEFAULT NULL,
+  `revoked_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `key_hash` (`key_hash`),
+  KEY `tenant_id_created_at` (`tenant_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `audit_log_heads`;
+
+DROP TABLE IF EXISTS `audit_log`;
\ No newline at end of file
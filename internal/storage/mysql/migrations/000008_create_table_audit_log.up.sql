@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `seq` bigint unsigned NOT NULL,
+  `occurred_at` datetime(6) NOT NULL,
+  `actor` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `operation` varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `resource` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `target_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `request_id` varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `trace_id` varchar(32) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `client_ip` varchar(45) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `outcome` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `status` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `prev_hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  PRIMARY KEY (`tenant_id`, `seq`),
+  KEY `tenant_id_occurred_at` (`tenant_id`, `occurred_at`),
+  KEY `tenant_id_actor_occurred_at` (`tenant_id`, `actor`, `occurred_at`),
+  KEY `tenant_id_target_id_occurred_at` (`tenant_id`, `target_id`, `occurred_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `audit_log_heads` (
+  `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `seq` bigint unsigned NOT NULL,
+  `hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  PRIMARY KEY (`tenant_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TRIGGER `audit_log_before_update` BEFORE UPDATE ON `audit_log` FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER `audit_log_before_delete` BEFORE DELETE ON `audit_log` FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `webhook_deliveries` DROP INDEX `tenant_id_subscription_id_attempted_at`, ADD INDEX `subscription_id_attempted_at` (`subscription_id`, `attempted_at`), DROP COLUMN `tenant_id`;
+
+ALTER TABLE `webhook_subscriptions` DROP INDEX `tenant_id_created_at`, DROP COLUMN `tenant_id`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `webhook_subscriptions` ADD COLUMN `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'default', ADD INDEX `tenant_id_created_at` (`tenant_id`, `created_at`);
+
+ALTER TABLE `webhook_deliveries` ADD COLUMN `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'default', DROP INDEX `subscription_id_attempted_at`, ADD INDEX `tenant_id_subscription_id_attempted_at` (`tenant_id`, `subscription_id`, `attempted_at`);
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `webhook_pending_deliveries` (
+  `id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `tenant_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `subscription_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `event_id` varchar(36) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `event` mediumblob NOT NULL,
+  `attempt` int NOT NULL,
+  `next_attempt_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `subscription_id_event_id` (`subscription_id`, `event_id`),
+  KEY `next_attempt_at` (`next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
@@ -0,0 +1,11 @@
+package mysql
+
+// maxPlaceholders is the maximum number of placeholders that MySQL
+// permits in a prepared statement.
+const maxPlaceholders = 65535
+
+// maxRows returns the maximum number of rows that can be inserted with a
+// single statement when each row uses placeholdersPerRow placeholders.
+func maxRows(placeholdersPerRow int) int {
+	return maxPlaceholders / placeholdersPerRow
+}
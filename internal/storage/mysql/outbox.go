@@ -0,0 +1,311 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	sqldriver "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+// OutboxStorage reads and removes events written to the outbox table
+// by UserStorage.
+type OutboxStorage struct {
+	db           DB
+	queryTimeout time.Duration
+}
+
+func NewOutboxStorage(
+	db DB,
+	queryTimeout time.Duration,
+) *OutboxStorage {
+	return &OutboxStorage{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// eventPlaceholders is the number of placeholders used to insert each event.
+const eventPlaceholders = 5
+
+// insertEvents inserts events using as few statements as the placeholder
+// limit permits.
+func insertEvents(
+	ctx context.Context,
+	eq execQuerier,
+	events ...user.Event,
+) error {
+	size := maxRows(eventPlaceholders)
+
+	for start := 0; start < len(events); start += size {
+		end := min(start+size, len(events))
+
+		if err := insertEventsChunk(ctx, eq, events[start:end]...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertEventsChunk(
+	ctx context.Context,
+	eq execQuerier,
+	events ...user.Event,
+) error {
+	values := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*eventPlaceholders)
+
+	for _, evt := range events {
+		payload, err := json.Marshal(evt)
+		if err != nil {
+			return errors.Errorf("%s", err)
+		}
+
+		values = append(values, "(?, ?, ?, ?, ?)")
+		args = append(args, evt.TenantID())
+		args = append(args, evt.UserID())
+		args = append(args, evt.Type)
+		args = append(args, payload)
+		args = append(args, evt.OccurredAt)
+	}
+
+	qry := fmt.Sprintf(
+		"INSERT INTO outbox(tenant_id, aggregate_id, event_type, payload, created_at) VALUES %s",
+		strings.Join(
+			values,
+			",",
+		),
+	)
+
+	_, err := eq.ExecContext(ctx,
+		qry,
+		args...,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+// queryEvents returns the events in the outbox for the user of the tenant
+// with the ID.
+func queryEvents(
+	ctx context.Context,
+	eq execQuerier,
+	tenantID string,
+	id string,
+) ([]user.Event, error) {
+	rows, err := eq.QueryContext(
+		ctx,
+		`
+SELECT payload
+FROM outbox
+WHERE tenant_id = ? AND aggregate_id = ?
+ORDER BY id
+`,
+		tenantID,
+		id,
+	)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+	defer rows.Close()
+
+	var events []user.Event
+
+	for rows.Next() {
+		var (
+			payload []byte
+			evt     user.Event
+		)
+
+		if err := rows.Scan(&payload); err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		events = append(events, evt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	return events, nil
+}
+
+// errLockNowait is the number of the error returned when a locking read
+// using NOWAIT encounters a row locked by another transaction.
+const errLockNowait = 3572
+
+// Relay passes up to limit of the oldest events in the outbox to publish
+// and deletes them once publish returns without error. Rows are locked for
+// the duration of the transaction, and a relay that encounters rows locked
+// by another returns without publishing, so that only one relay publishes
+// at a time and events for the same user are published in order, however
+// many relays run. Rows with a payload that cannot be decoded are moved to
+// the outbox_dead_letters table, and passed to deadLettered once committed,
+// rather than blocking the outbox. The number of rows removed from the
+// outbox is returned.
+func (o *OutboxStorage) Relay(
+	ctx context.Context,
+	limit int,
+	publish func(context.Context, []user.Event) error,
+	deadLettered func(context.Context, error),
+) (int, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		o.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	qry := `
+SELECT id, payload
+FROM outbox
+ORDER BY id
+LIMIT ?
+FOR UPDATE NOWAIT
+`
+
+	rows, err := tx.QueryContext(
+		ctx,
+		qry,
+		limit,
+	)
+	if isLockNowait(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Errorf("%s", err)
+	}
+	defer rows.Close()
+
+	var (
+		ids         []interface{}
+		events      []user.Event
+		deadLetters = map[uint64]error{}
+	)
+
+	for rows.Next() {
+		var (
+			id      uint64
+			payload []byte
+			evt     user.Event
+		)
+
+		err := rows.Scan(
+			&id,
+			&payload,
+		)
+		if err != nil {
+			return 0, errors.Errorf("%s", err)
+		}
+
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			deadLetters[id] = errors.Errorf("outbox id %d: %s", id, err)
+			continue
+		}
+
+		ids = append(ids, id)
+		events = append(events, evt)
+	}
+	if err = rows.Err(); isLockNowait(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Errorf("%s", err)
+	}
+
+	if len(events) > 0 {
+		if err := publish(ctx, events); err != nil {
+			return 0, err
+		}
+
+		qry = fmt.Sprintf(
+			"DELETE FROM outbox WHERE id IN (%s)",
+			strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","),
+		)
+
+		_, err = tx.ExecContext(
+			ctx,
+			qry,
+			ids...,
+		)
+		if err != nil {
+			return 0, errors.Errorf("%s", err)
+		}
+	}
+
+	for id, cause := range deadLetters {
+		if err := deadLetter(ctx, tx, id, cause); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, errors.Errorf("%s", err)
+	}
+
+	for _, cause := range deadLetters {
+		deadLettered(ctx, cause)
+	}
+
+	return len(events) + len(deadLetters), nil
+}
+
+// deadLetter moves the outbox row with the id to the outbox_dead_letters
+// table, recording cause.
+func deadLetter(
+	ctx context.Context,
+	tx execQuerier,
+	id uint64,
+	cause error,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`
+INSERT INTO outbox_dead_letters(id, tenant_id, aggregate_id, event_type, payload, created_at, error, dead_lettered_at)
+SELECT id, tenant_id, aggregate_id, event_type, payload, created_at, ?, ?
+FROM outbox
+WHERE id = ?
+`,
+		cause.Error(),
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM outbox WHERE id = ?",
+		id,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func isLockNowait(err error) bool {
+	var mysqlErr *sqldriver.MySQLError
+
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errLockNowait
+}
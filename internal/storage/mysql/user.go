@@ -7,28 +7,45 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 )
 
 type DB interface {
+	execQuerier
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type execQuerier interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
+// UserStorage scopes all reads and changes, other than Purge, to the
+// tenant carried by the context.
 type UserStorage struct {
-	db           DB
-	queryTimeout time.Duration
+	db            DB
+	queryTimeout  time.Duration
+	outboxEnabled bool
 }
 
+// NewUserStorage returns a UserStorage. When outboxEnabled is true, each
+// change to users (e.g., Create) writes an event to the outbox table within
+// the same transaction as the change (see OutboxStorage).
 func NewUserStorage(
 	db DB,
 	queryTimeout time.Duration,
+	outboxEnabled bool,
 ) *UserStorage {
 	return &UserStorage{
-		db:           db,
-		queryTimeout: queryTimeout,
+		db:            db,
+		queryTimeout:  queryTimeout,
+		outboxEnabled: outboxEnabled,
 	}
 }
 
@@ -42,26 +59,102 @@ func (u *UserStorage) Create(
 	)
 	defer cancel()
 
+	users = withTenant(ctx, users)
+
+	// Users that are inserted with more than one statement are inserted
+	// within a transaction so that either all or none are created.
+	if !u.outboxEnabled && len(users) <= maxRows(userPlaceholders) {
+		return insertUsers(ctx, u.db, users...)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	err = insertUsers(ctx, tx, users...)
+	if err != nil {
+		return err
+	}
+
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceContext)
+
+	events := make([]user.Event, 0, len(users))
+
+	for i := range users {
+		events = append(events, user.Event{
+			ID:           uuid.New().String(),
+			Type:         user.EventCreated,
+			After:        &users[i],
+			OccurredAt:   users[i].CreatedAt,
+			TraceContext: traceContext,
+		})
+	}
+
+	err = insertEvents(ctx, tx, events...)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+// userPlaceholders is the number of placeholders used to insert each user.
+const userPlaceholders = 6
+
+// insertUsers inserts users using as few statements as the placeholder
+// limit permits.
+func insertUsers(
+	ctx context.Context,
+	eq execQuerier,
+	users ...user.User,
+) error {
+	size := maxRows(userPlaceholders)
+
+	for start := 0; start < len(users); start += size {
+		end := min(start+size, len(users))
+
+		if err := insertUsersChunk(ctx, eq, users[start:end]...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertUsersChunk(
+	ctx context.Context,
+	eq execQuerier,
+	users ...user.User,
+) error {
 	values := make([]string, 0, len(users))
-	args := make([]interface{}, 0, len(users)*4)
+	args := make([]interface{}, 0, len(users)*userPlaceholders)
 
 	for _, usr := range users {
-		values = append(values, "(?, ?, ?, ?)")
+		values = append(values, "(?, ?, ?, ?, ?, ?)")
+		args = append(args, usr.TenantID)
 		args = append(args, usr.ID)
 		args = append(args, usr.FirstName)
 		args = append(args, usr.LastName)
 		args = append(args, usr.CreatedAt)
+		args = append(args, usr.Version)
 	}
 
 	qry := fmt.Sprintf(
-		"INSERT INTO users(id, first_name, last_name, created_at) VALUES %s",
+		"INSERT INTO users(tenant_id, id, first_name, last_name, created_at, version) VALUES %s",
 		strings.Join(
 			values,
 			",",
 		),
 	)
 
-	_, err := u.db.ExecContext(ctx,
+	_, err := eq.ExecContext(ctx,
 		qry,
 		args...,
 	)
@@ -72,21 +165,497 @@ func (u *UserStorage) Create(
 	return nil
 }
 
+// userColumns are selected by all queries that read users.
+const userColumns = "tenant_id, id, first_name, last_name, created_at, version, deleted_at"
+
+// Read excludes soft deleted users.
 func (u *UserStorage) Read(ctx context.Context) ([]user.User, error) {
+	return u.read(
+		ctx,
+		`
+SELECT `+userColumns+`
+FROM users
+WHERE tenant_id = ? AND deleted_at IS NULL
+`,
+		tenant.ID(ctx),
+	)
+}
+
+// ReadPage uses the ID of the last user in the page as the cursor, and
+// excludes soft deleted users.
+func (u *UserStorage) ReadPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) ([]user.User, string, error) {
+	users, err := u.read(
+		ctx,
+		`
+SELECT `+userColumns+`
+FROM users
+WHERE tenant_id = ? AND id > ? AND deleted_at IS NULL
+ORDER BY id
+LIMIT ?
+`,
+		tenant.ID(ctx),
+		cursor,
+		limit,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(users) < limit {
+		return users, "", nil
+	}
+
+	return users, users[len(users)-1].ID, nil
+}
+
+// ReadByID returns user.ErrNotFound if the user is soft deleted.
+func (u *UserStorage) ReadByID(
+	ctx context.Context,
+	id string,
+) (user.User, error) {
+	users, err := u.read(
+		ctx,
+		`
+SELECT `+userColumns+`
+FROM users
+WHERE tenant_id = ? AND id = ? AND deleted_at IS NULL
+`,
+		tenant.ID(ctx),
+		id,
+	)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	if len(users) == 0 {
+		return user.User{}, user.ErrNotFound
+	}
+
+	return users[0], nil
+}
+
+// Update locks the row for the user so that the user before the change
+// can be written to the outbox, and then updates the row only if the
+// stored version still equals expectedVersion. Soft deleted users cannot
+// be updated.
+func (u *UserStorage) Update(
+	ctx context.Context,
+	expectedVersion int64,
+	usr user.User,
+) (user.User, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		u.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user.User{}, errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	before, err := lockUser(ctx, tx, usr.ID, false)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`
+UPDATE users
+SET first_name = ?, last_name = ?, version = version + 1
+WHERE tenant_id = ? AND id = ? AND version = ?
+`,
+		usr.FirstName,
+		usr.LastName,
+		before.TenantID,
+		usr.ID,
+		expectedVersion,
+	)
+	if err != nil {
+		return user.User{}, errors.Errorf("%s", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return user.User{}, errors.Errorf("%s", err)
+	}
+
+	if n == 0 {
+		return user.User{}, user.ErrVersionMismatch
+	}
+
+	after := before
+	after.FirstName = usr.FirstName
+	after.LastName = usr.LastName
+	after.Version = expectedVersion + 1
+
+	if err = u.commit(ctx, tx, newEvent(ctx, user.EventUpdated, &before, &after)); err != nil {
+		return user.User{}, err
+	}
+
+	return after, nil
+}
+
+// SoftDelete sets deleted_at, and increments the version, of the user.
+func (u *UserStorage) SoftDelete(
+	ctx context.Context,
+	id string,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		u.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	before, err := lockUser(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`
+UPDATE users
+SET deleted_at = ?, version = version + 1
+WHERE tenant_id = ? AND id = ?
+`,
+		time.Now(),
+		before.TenantID,
+		id,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return u.commit(ctx, tx, newEvent(ctx, user.EventDeleted, &before, nil))
+}
+
+// Restore clears deleted_at, and increments the version, of the user.
+func (u *UserStorage) Restore(
+	ctx context.Context,
+	id string,
+) (user.User, error) {
 	ctx, cancel := context.WithTimeout(
 		ctx,
 		u.queryTimeout,
 	)
 	defer cancel()
 
-	qry := `
-SELECT id, first_name, last_name, created_at
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user.User{}, errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	before, err := lockUser(ctx, tx, id, true)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`
+UPDATE users
+SET deleted_at = NULL, version = version + 1
+WHERE tenant_id = ? AND id = ?
+`,
+		before.TenantID,
+		id,
+	)
+	if err != nil {
+		return user.User{}, errors.Errorf("%s", err)
+	}
+
+	after := before
+	after.DeletedAt = nil
+	after.Version++
+
+	if err = u.commit(ctx, tx, newEvent(ctx, user.EventRestored, &before, &after)); err != nil {
+		return user.User{}, err
+	}
+
+	return after, nil
+}
+
+// Purge locks the users to be purged so that they cannot be restored
+// while they are being deleted. Users are purged across all tenants.
+func (u *UserStorage) Purge(
+	ctx context.Context,
+	deletedBefore time.Time,
+	limit int,
+) (int, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		u.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	users, err := query(
+		ctx,
+		tx,
+		`
+SELECT `+userColumns+`
+FROM users
+WHERE deleted_at < ?
+ORDER BY deleted_at
+LIMIT ?
+FOR UPDATE
+`,
+		deletedBefore,
+		min(limit, maxPlaceholders/2),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(users) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]interface{}, 0, len(users)*2)
+	events := make([]user.Event, 0, len(users))
+
+	for i := range users {
+		keys = append(keys, users[i].TenantID, users[i].ID)
+		events = append(events, newEvent(ctx, user.EventPurged, &users[i], nil))
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			"DELETE FROM users WHERE (tenant_id, id) IN (%s)",
+			strings.TrimSuffix(strings.Repeat("(?, ?),", len(users)), ","),
+		),
+		keys...,
+	)
+	if err != nil {
+		return 0, errors.Errorf("%s", err)
+	}
+
+	if err = u.commit(ctx, tx, events...); err != nil {
+		return 0, err
+	}
+
+	return len(users), nil
+}
+
+// Export returns the user, including when soft deleted, together with
+// any events for the user in the outbox that have yet to be relayed.
+func (u *UserStorage) Export(
+	ctx context.Context,
+	id string,
+) (user.SubjectData, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		u.queryTimeout,
+	)
+	defer cancel()
+
+	users, err := query(
+		ctx,
+		u.db,
+		`
+SELECT `+userColumns+`
 FROM users
-`
+WHERE tenant_id = ? AND id = ?
+`,
+		tenant.ID(ctx),
+		id,
+	)
+	if err != nil {
+		return user.SubjectData{}, err
+	}
 
-	rows, err := u.db.QueryContext(
+	events, err := queryEvents(ctx, u.db, tenant.ID(ctx), id)
+	if err != nil {
+		return user.SubjectData{}, err
+	}
+
+	if len(users) == 0 && len(events) == 0 {
+		return user.SubjectData{}, user.ErrNotFound
+	}
+
+	data := user.SubjectData{
+		Events: events,
+	}
+
+	if len(users) > 0 {
+		data.User = &users[0]
+	}
+
+	return data, nil
+}
+
+// Erase deletes the user, including when soft deleted, together with any
+// events for the user in the outbox, so that they are not relayed after
+// the user has been erased. No event is written for the erasure as it
+// would contain the data being erased.
+func (u *UserStorage) Erase(
+	ctx context.Context,
+	id string,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		u.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM users WHERE tenant_id = ? AND id = ?", tenant.ID(ctx), id)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM outbox WHERE tenant_id = ? AND aggregate_id = ?", tenant.ID(ctx), id)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+// lockUser reads the user with the ID, locking the row until tx ends.
+// user.ErrNotFound is returned if no user exists with the ID that is soft
+// deleted, when deleted is true, or that is not, when deleted is false.
+func lockUser(
+	ctx context.Context,
+	tx *sql.Tx,
+	id string,
+	deleted bool,
+) (user.User, error) {
+	condition := "deleted_at IS NULL"
+	if deleted {
+		condition = "deleted_at IS NOT NULL"
+	}
+
+	users, err := query(
+		ctx,
+		tx,
+		`
+SELECT `+userColumns+`
+FROM users
+WHERE tenant_id = ? AND id = ? AND `+condition+`
+FOR UPDATE
+`,
+		tenant.ID(ctx),
+		id,
+	)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	if len(users) == 0 {
+		return user.User{}, user.ErrNotFound
+	}
+
+	return users[0], nil
+}
+
+// withTenant returns a copy of users belonging to the tenant carried
+// by ctx.
+func withTenant(ctx context.Context, users []user.User) []user.User {
+	tenantUsers := make([]user.User, len(users))
+
+	for i, usr := range users {
+		usr.TenantID = tenant.ID(ctx)
+		tenantUsers[i] = usr
+	}
+
+	return tenantUsers
+}
+
+// newEvent returns an event of eventType carrying the trace context
+// from ctx.
+func newEvent(
+	ctx context.Context,
+	eventType string,
+	before *user.User,
+	after *user.User,
+) user.Event {
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceContext)
+
+	return user.Event{
+		ID:           uuid.New().String(),
+		Type:         eventType,
+		Before:       before,
+		After:        after,
+		OccurredAt:   time.Now(),
+		TraceContext: traceContext,
+	}
+}
+
+// commit writes events to the outbox, when enabled, before committing tx.
+func (u *UserStorage) commit(
+	ctx context.Context,
+	tx *sql.Tx,
+	events ...user.Event,
+) error {
+	if u.outboxEnabled {
+		if err := insertEvents(ctx, tx, events...); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (u *UserStorage) read(
+	ctx context.Context,
+	qry string,
+	args ...interface{},
+) ([]user.User, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		u.queryTimeout,
+	)
+	defer cancel()
+
+	return query(ctx, u.db, qry, args...)
+}
+
+// query returns the users selected by qry, which must select userColumns.
+func query(
+	ctx context.Context,
+	eq execQuerier,
+	qry string,
+	args ...interface{},
+) ([]user.User, error) {
+	rows, err := eq.QueryContext(
 		ctx,
 		qry,
+		args...,
 	)
 	if err != nil {
 		return nil, errors.Errorf("%s", err)
@@ -96,18 +665,28 @@ FROM users
 	var users []user.User
 
 	for rows.Next() {
-		var u user.User
+		var (
+			u         user.User
+			deletedAt sql.NullTime
+		)
 
 		err := rows.Scan(
+			&u.TenantID,
 			&u.ID,
 			&u.FirstName,
 			&u.LastName,
 			&u.CreatedAt,
+			&u.Version,
+			&deletedAt,
 		)
 		if err != nil {
 			return nil, errors.Errorf("%s", err)
 		}
 
+		if deletedAt.Valid {
+			u.DeletedAt = &deletedAt.Time
+		}
+
 		users = append(users, u)
 	}
 	if err = rows.Err(); err != nil {
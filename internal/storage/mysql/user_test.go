@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type execQuerierMock struct {
+	rows []int
+	args []int
+}
+
+func (m *execQuerierMock) ExecContext(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.rows = append(m.rows, strings.Count(query, "(?, ?, ?, ?, ?, ?)"))
+	m.args = append(m.args, len(args))
+
+	return nil, nil
+}
+
+func (m *execQuerierMock) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, nil
+}
+
+func TestInsertUsers(t *testing.T) {
+	size := maxRows(userPlaceholders)
+
+	cases := []struct {
+		name         string
+		users        int
+		expectedRows []int
+	}{
+		{
+			"single statement",
+			2,
+			[]int{2},
+		},
+		{
+			"placeholder limit",
+			size,
+			[]int{size},
+		},
+		{
+			"chunked",
+			2*size + 1,
+			[]int{size, size, 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			eq := &execQuerierMock{}
+
+			err := insertUsers(context.Background(), eq, make([]user.User, c.users)...)
+			assert.NoError(t, err)
+
+			assert.Equal(t, c.expectedRows, eq.rows)
+
+			for _, args := range eq.args {
+				assert.LessOrEqual(t, args, maxPlaceholders)
+			}
+		})
+	}
+}
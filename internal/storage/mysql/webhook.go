@@ -0,0 +1,569 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+)
+
+// WebhookStorage scopes subscriptions and deliveries to the tenant carried
+// by the context.
+type WebhookStorage struct {
+	db           DB
+	queryTimeout time.Duration
+}
+
+func NewWebhookStorage(
+	db DB,
+	queryTimeout time.Duration,
+) *WebhookStorage {
+	return &WebhookStorage{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (w *WebhookStorage) CreateSubscription(
+	ctx context.Context,
+	subscription webhook.Subscription,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	_, err = w.db.ExecContext(
+		ctx,
+		"INSERT INTO webhook_subscriptions(tenant_id, id, url, secret, event_types, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		tenant.ID(ctx),
+		subscription.ID,
+		subscription.URL,
+		subscription.Secret,
+		eventTypes,
+		subscription.CreatedAt,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookStorage) ReadSubscriptions(ctx context.Context) ([]webhook.Subscription, error) {
+	return w.readSubscriptions(
+		ctx,
+		`
+SELECT tenant_id, id, url, secret, event_types, created_at
+FROM webhook_subscriptions
+WHERE tenant_id = ?
+ORDER BY created_at
+`,
+		tenant.ID(ctx),
+	)
+}
+
+func (w *WebhookStorage) ReadSubscription(
+	ctx context.Context,
+	id string,
+) (webhook.Subscription, error) {
+	subscriptions, err := w.readSubscriptions(
+		ctx,
+		`
+SELECT tenant_id, id, url, secret, event_types, created_at
+FROM webhook_subscriptions
+WHERE tenant_id = ? AND id = ?
+`,
+		tenant.ID(ctx),
+		id,
+	)
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+
+	if len(subscriptions) == 0 {
+		return webhook.Subscription{}, webhook.ErrNotFound
+	}
+
+	return subscriptions[0], nil
+}
+
+func (w *WebhookStorage) readSubscriptions(
+	ctx context.Context,
+	qry string,
+	args ...interface{},
+) ([]webhook.Subscription, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	rows, err := w.db.QueryContext(
+		ctx,
+		qry,
+		args...,
+	)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []webhook.Subscription
+
+	for rows.Next() {
+		var (
+			s          webhook.Subscription
+			eventTypes []byte
+		)
+
+		err := rows.Scan(
+			&s.TenantID,
+			&s.ID,
+			&s.URL,
+			&s.Secret,
+			&eventTypes,
+			&s.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		if err := json.Unmarshal(eventTypes, &s.EventTypes); err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		subscriptions = append(subscriptions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (w *WebhookStorage) UpdateSubscription(
+	ctx context.Context,
+	subscription webhook.Subscription,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	res, err := w.db.ExecContext(
+		ctx,
+		"UPDATE webhook_subscriptions SET url = ?, event_types = ? WHERE tenant_id = ? AND id = ?",
+		subscription.URL,
+		eventTypes,
+		tenant.ID(ctx),
+		subscription.ID,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	// MySQL reports rows changed rather than rows matched, so no rows
+	// are affected when the subscription is unchanged.
+	err = notFoundIfNoRows(res)
+	if errors.Is(err, webhook.ErrNotFound) {
+		_, err = w.ReadSubscription(ctx, subscription.ID)
+	}
+
+	return err
+}
+
+// DeleteSubscription also deletes the delivery log and pending deliveries
+// for the subscription.
+func (w *WebhookStorage) DeleteSubscription(
+	ctx context.Context,
+	id string,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	res, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM webhook_subscriptions WHERE tenant_id = ? AND id = ?",
+		tenant.ID(ctx),
+		id,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	if err := notFoundIfNoRows(res); err != nil {
+		return err
+	}
+
+	for _, qry := range []string{
+		"DELETE FROM webhook_deliveries WHERE tenant_id = ? AND subscription_id = ?",
+		"DELETE FROM webhook_pending_deliveries WHERE tenant_id = ? AND subscription_id = ?",
+	} {
+		_, err = tx.ExecContext(
+			ctx,
+			qry,
+			tenant.ID(ctx),
+			id,
+		)
+		if err != nil {
+			return errors.Errorf("%s", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookStorage) CreateDelivery(
+	ctx context.Context,
+	delivery webhook.Delivery,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	_, err := w.db.ExecContext(
+		ctx,
+		`
+INSERT INTO webhook_deliveries(tenant_id, id, subscription_id, event_id, event_type, attempt, status_code, error, duration_ms, attempted_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`,
+		tenant.ID(ctx),
+		delivery.ID,
+		delivery.SubscriptionID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.Duration.Milliseconds(),
+		delivery.AttemptedAt,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookStorage) ReadDeliveries(
+	ctx context.Context,
+	subscriptionID string,
+	limit int,
+) ([]webhook.Delivery, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	qry := `
+SELECT tenant_id, id, subscription_id, event_id, event_type, attempt, status_code, error, duration_ms, attempted_at
+FROM webhook_deliveries
+WHERE tenant_id = ? AND subscription_id = ?
+ORDER BY attempted_at DESC
+LIMIT ?
+`
+
+	rows, err := w.db.QueryContext(
+		ctx,
+		qry,
+		tenant.ID(ctx),
+		subscriptionID,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+	defer rows.Close()
+
+	var deliveries []webhook.Delivery
+
+	for rows.Next() {
+		var (
+			d          webhook.Delivery
+			durationMs int64
+		)
+
+		err := rows.Scan(
+			&d.TenantID,
+			&d.ID,
+			&d.SubscriptionID,
+			&d.EventID,
+			&d.EventType,
+			&d.Attempt,
+			&d.StatusCode,
+			&d.Error,
+			&durationMs,
+			&d.AttemptedAt,
+		)
+		if err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		d.Duration = time.Duration(durationMs) * time.Millisecond
+
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	return deliveries, nil
+}
+
+// pendingPlaceholders is the number of placeholders used to insert each
+// pending delivery.
+const pendingPlaceholders = 8
+
+func (w *WebhookStorage) CreatePending(
+	ctx context.Context,
+	pending ...webhook.Pending,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	size := maxRows(pendingPlaceholders)
+
+	for start := 0; start < len(pending); start += size {
+		end := min(start+size, len(pending))
+
+		if err := w.createPendingChunk(ctx, pending[start:end]...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *WebhookStorage) createPendingChunk(
+	ctx context.Context,
+	pending ...webhook.Pending,
+) error {
+	values := make([]string, 0, len(pending))
+	args := make([]interface{}, 0, len(pending)*pendingPlaceholders)
+
+	for _, p := range pending {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, tenant.ID(ctx))
+		args = append(args, p.ID)
+		args = append(args, p.SubscriptionID)
+		args = append(args, p.EventID)
+		args = append(args, p.Event)
+		args = append(args, p.Attempt)
+		args = append(args, p.NextAttemptAt)
+		args = append(args, p.CreatedAt)
+	}
+
+	// Deliveries that are already pending are ignored, by way of the
+	// unique key on subscription_id and event_id.
+	qry := fmt.Sprintf(
+		"INSERT IGNORE INTO webhook_pending_deliveries(tenant_id, id, subscription_id, event_id, event, attempt, next_attempt_at, created_at) VALUES %s",
+		strings.Join(
+			values,
+			",",
+		),
+	)
+
+	_, err := w.db.ExecContext(
+		ctx,
+		qry,
+		args...,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+// ClaimPending locks the due rows, skipping rows locked by other claimants,
+// and defers them by lease within the same transaction, so that concurrent
+// dispatchers claim different deliveries.
+func (w *WebhookStorage) ClaimPending(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) ([]webhook.Pending, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	qry := `
+SELECT tenant_id, id, subscription_id, event_id, event, attempt, next_attempt_at, created_at
+FROM webhook_pending_deliveries
+WHERE next_attempt_at <= ?
+ORDER BY next_attempt_at
+LIMIT ?
+FOR UPDATE SKIP LOCKED
+`
+
+	rows, err := tx.QueryContext(
+		ctx,
+		qry,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+	defer rows.Close()
+
+	var (
+		ids     []interface{}
+		pending []webhook.Pending
+	)
+
+	for rows.Next() {
+		var p webhook.Pending
+
+		err := rows.Scan(
+			&p.TenantID,
+			&p.ID,
+			&p.SubscriptionID,
+			&p.EventID,
+			&p.Event,
+			&p.Attempt,
+			&p.NextAttemptAt,
+			&p.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Errorf("%s", err)
+		}
+
+		ids = append(ids, p.ID)
+		pending = append(pending, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	qry = fmt.Sprintf(
+		"UPDATE webhook_pending_deliveries SET next_attempt_at = ? WHERE id IN (%s)",
+		strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","),
+	)
+
+	_, err = tx.ExecContext(
+		ctx,
+		qry,
+		append([]interface{}{now.Add(lease)}, ids...)...,
+	)
+	if err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
+
+	return pending, nil
+}
+
+func (w *WebhookStorage) ReschedulePending(
+	ctx context.Context,
+	pending webhook.Pending,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	_, err := w.db.ExecContext(
+		ctx,
+		"UPDATE webhook_pending_deliveries SET attempt = ?, next_attempt_at = ? WHERE id = ?",
+		pending.Attempt,
+		pending.NextAttemptAt,
+		pending.ID,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookStorage) DeletePending(
+	ctx context.Context,
+	id string,
+) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		w.queryTimeout,
+	)
+	defer cancel()
+
+	_, err := w.db.ExecContext(
+		ctx,
+		"DELETE FROM webhook_pending_deliveries WHERE id = ?",
+		id,
+	)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func notFoundIfNoRows(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	if n == 0 {
+		return webhook.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bendbennett/go-api-demo/internal/apikey"
+)
+
+const (
+	apiKey      = "apikey"
+	apiKeyQuota = "apikey_quota"
+)
+
+type apiKeyRedis interface {
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	TxPipeline() redis.Pipeliner
+}
+
+type apiKeyCache struct {
+	cache apiKeyRedis
+	now   func() time.Time
+	ttl   time.Duration
+}
+
+// NewAPIKeyCache returns a cache in which keys expire after ttl, and which
+// counts the requests made with each key for quotas. The cache shares the
+// connection of rdb.
+func NewAPIKeyCache(
+	rdb apiKeyRedis,
+	ttl time.Duration,
+) *apiKeyCache {
+	return &apiKeyCache{
+		cache: rdb,
+		now:   time.Now,
+		ttl:   ttl,
+	}
+}
+
+func (c *apiKeyCache) ReadKeyByHash(
+	ctx context.Context,
+	hash string,
+) (apikey.Key, error) {
+	v, err := c.cache.Get(ctx, fmt.Sprintf("%v:%v", apiKey, hash)).Bytes()
+	if err == redis.Nil {
+		return apikey.Key{}, apikey.ErrNotFound
+	}
+	if err != nil {
+		return apikey.Key{}, errors.Errorf("%s", err)
+	}
+
+	k := apikey.Key{}
+
+	if err := json.Unmarshal(v, &k); err != nil {
+		return apikey.Key{}, errors.Errorf("%s", err)
+	}
+
+	return k, nil
+}
+
+func (c *apiKeyCache) CacheKey(
+	ctx context.Context,
+	key apikey.Key,
+) error {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	err = c.cache.Set(
+		ctx,
+		fmt.Sprintf("%v:%v", apiKey, key.Hash),
+		b,
+		c.ttl,
+	).Err()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (c *apiKeyCache) EvictKey(
+	ctx context.Context,
+	hash string,
+) error {
+	err := c.cache.Del(
+		ctx,
+		fmt.Sprintf("%v:%v", apiKey, hash),
+	).Err()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+// Increment counts requests under a key for each window (e.g.,
+// apikey_quota:<id>:<window start>), which expires with the window.
+func (c *apiKeyCache) Increment(
+	ctx context.Context,
+	id string,
+	window time.Duration,
+) (int64, time.Time, error) {
+	start := c.now().Truncate(window)
+	k := fmt.Sprintf("%v:%v:%v", apiKeyQuota, id, start.Unix())
+
+	pipe := c.cache.TxPipeline()
+	incr := pipe.Incr(ctx, k)
+	pipe.ExpireAt(ctx, k, start.Add(window))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, time.Time{}, errors.Errorf("%s", err)
+	}
+
+	return incr.Val(), start.Add(window), nil
+}
@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
+)
+
+const idempotencyKey = "idempotency"
+
+type idempotencyCache interface {
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+}
+
+type idempotencyStore struct {
+	cache   idempotencyCache
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+// NewIdempotencyStore returns a store in which completed records expire
+// after ttl, and records without a response expire after lockTTL. The
+// store shares the connection of rdb.
+func NewIdempotencyStore(
+	rdb idempotencyCache,
+	ttl time.Duration,
+	lockTTL time.Duration,
+) *idempotencyStore {
+	return &idempotencyStore{
+		cache:   rdb,
+		ttl:     ttl,
+		lockTTL: lockTTL,
+	}
+}
+
+func (s *idempotencyStore) Begin(
+	ctx context.Context,
+	key string,
+	fingerprint string,
+) (idempotency.Record, bool, error) {
+	b, err := json.Marshal(idempotency.Record{
+		Fingerprint: fingerprint,
+	})
+	if err != nil {
+		return idempotency.Record{}, false, errors.Errorf("%s", err)
+	}
+
+	k := fmt.Sprintf("%v:%v", idempotencyKey, key)
+
+	// The record may expire between SETNX and GET, in which case the
+	// record is set on the next attempt.
+	for attempt := 0; attempt < 2; attempt++ {
+		ok, err := s.cache.SetNX(ctx, k, b, s.lockTTL).Result()
+		if err != nil {
+			return idempotency.Record{}, false, errors.Errorf("%s", err)
+		}
+
+		if ok {
+			return idempotency.Record{}, true, nil
+		}
+
+		v, err := s.cache.Get(ctx, k).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return idempotency.Record{}, false, errors.Errorf("%s", err)
+		}
+
+		rec := idempotency.Record{}
+
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return idempotency.Record{}, false, errors.Errorf("%s", err)
+		}
+
+		return rec, false, nil
+	}
+
+	return idempotency.Record{}, false, idempotency.ErrInProgress
+}
+
+func (s *idempotencyStore) Complete(
+	ctx context.Context,
+	key string,
+	record idempotency.Record,
+) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	err = s.cache.Set(
+		ctx,
+		fmt.Sprintf("%v:%v", idempotencyKey, key),
+		b,
+		s.ttl,
+	).Err()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (s *idempotencyStore) Release(
+	ctx context.Context,
+	key string,
+) error {
+	err := s.cache.Del(
+		ctx,
+		fmt.Sprintf("%v:%v", idempotencyKey, key),
+	).Err()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
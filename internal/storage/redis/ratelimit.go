@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bendbennett/go-api-demo/internal/ratelimit"
+)
+
+const rateLimitKey = "ratelimit"
+
+// tokenBucketScript takes a token from the bucket in KEYS[1], refilled at
+// ARGV[1] requests per ARGV[3] milliseconds up to ARGV[2] tokens, as of
+// ARGV[4] (milliseconds since the epoch). It returns the milliseconds until
+// a token is available, or 0 if a token was taken.
+var tokenBucketScript = redis.NewScript(`
+local requests = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local rate = requests / period
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+	ts = now
+end
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", ts)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate))
+return wait
+`)
+
+// slidingWindowScript counts a request in the window in KEYS[1] if the
+// requests in the current ARGV[3] millisecond period, plus those in the
+// previous period weighted by its overlap with the window, are fewer than
+// ARGV[1] as of ARGV[4] (milliseconds since the epoch). It returns the
+// milliseconds until the request would be counted, or 0 if it was.
+var slidingWindowScript = redis.NewScript(`
+local requests = tonumber(ARGV[1])
+local period = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local start = now - (now % period)
+local window = redis.call("HMGET", KEYS[1], "start", "current", "previous")
+local current = tonumber(window[2]) or 0
+local previous = tonumber(window[3]) or 0
+local windowStart = tonumber(window[1])
+if windowStart ~= start then
+	if windowStart == start - period then
+		previous = current
+	else
+		previous = 0
+	end
+	current = 0
+end
+local elapsed = now - start
+local wait = 0
+if previous * (1 - elapsed / period) + current + 1 <= requests then
+	current = current + 1
+elseif current >= requests then
+	wait = math.ceil(period - elapsed + period * (1 - (requests - 1) / current))
+else
+	wait = math.ceil(period * (1 - (requests - 1 - current) / previous) - elapsed)
+end
+redis.call("HSET", KEYS[1], "start", start, "current", current, "previous", previous)
+redis.call("PEXPIRE", KEYS[1], 2 * period)
+return wait
+`)
+
+type rateLimiter struct {
+	cache  redis.Scripter
+	script *redis.Script
+	now    func() time.Time
+}
+
+// NewRateLimiter returns a limiter, shared by all instances using rdb,
+// that limits requests with algorithm.
+func NewRateLimiter(
+	rdb redis.Scripter,
+	algorithm ratelimit.Algorithm,
+) *rateLimiter {
+	script := tokenBucketScript
+
+	if algorithm == ratelimit.SlidingWindow {
+		script = slidingWindowScript
+	}
+
+	return &rateLimiter{
+		cache:  rdb,
+		script: script,
+		now:    time.Now,
+	}
+}
+
+func (l *rateLimiter) Allow(
+	ctx context.Context,
+	key string,
+	limit ratelimit.Limit,
+) (bool, time.Duration, error) {
+	wait, err := l.script.Run(
+		ctx,
+		l.cache,
+		[]string{fmt.Sprintf("%v:%v", rateLimitKey, key)},
+		limit.Requests,
+		limit.Burst,
+		limit.Period.Milliseconds(),
+		l.now().UnixMilli(),
+	).Int64()
+	if err != nil {
+		return false, 0, errors.Errorf("%s", err)
+	}
+
+	if wait > 0 {
+		return false, time.Duration(wait) * time.Millisecond, nil
+	}
+
+	return true, 0, nil
+}
@@ -4,33 +4,87 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel"
 
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	"github.com/redis/go-redis/v9"
 )
 
-const usr = "user"
+const (
+	usr        = "user"
+	usrVersion = "user_version"
+)
+
+// key returns the key, prefixed with the tenant carried by ctx (e.g.,
+// tenant:default:user:<id>), under which the user with the id, or its
+// version when prefix is usrVersion, is cached.
+func key(ctx context.Context, prefix string, id string) string {
+	return fmt.Sprintf("tenant:%v:%v:%v", tenant.ID(ctx), prefix, id)
+}
+
+// upsertScript sets each user key (KEYS[i]) and the corresponding version
+// key (KEYS[i+1]) unless the stored version is greater than the supplied
+// version. ARGV[1] holds the TTL of version keys in milliseconds, followed
+// by version and user pairs in the same order as KEYS.
+const upsertScript = `
+local ttl = ARGV[1]
+for i = 1, #KEYS, 2 do
+  local version = tonumber(ARGV[i + 1])
+  local current = tonumber(redis.call('GET', KEYS[i + 1]))
+  if current == nil or version >= current then
+    redis.call('SET', KEYS[i], ARGV[i + 2])
+    redis.call('SET', KEYS[i + 1], version, 'PX', ttl)
+  end
+end
+return 0
+`
+
+// deleteScript deletes each user key (KEYS[i]) unless the version stored
+// in the corresponding version key (KEYS[i+1]) is greater than the supplied
+// version (ARGV[1]). The version key is retained, until it expires after
+// the TTL in milliseconds (ARGV[2]), so that stale upserts that arrive
+// after a delete are also skipped.
+const deleteScript = `
+local version = tonumber(ARGV[1])
+local ttl = ARGV[2]
+for i = 1, #KEYS, 2 do
+  local current = tonumber(redis.call('GET', KEYS[i + 1]))
+  if current == nil or version >= current then
+    redis.call('DEL', KEYS[i])
+    redis.call('SET', KEYS[i + 1], version, 'PX', ttl)
+  end
+end
+return 0
+`
 
 type cache interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+	Get(ctx context.Context, key string) *redis.StringCmd
 	MGet(ctx context.Context, keys ...string) *redis.SliceCmd
 	MSet(ctx context.Context, values ...interface{}) *redis.StatusCmd
 	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
 }
 
 type userCache struct {
-	cache cache
+	cache      cache
+	versionTTL time.Duration
 }
 
+// NewUserCache returns a cache, and its client, which can be shared
+// (e.g., by the rate limiter) and must be closed. The versions of users
+// expire after versionTTL.
 func NewUserCache(
 	redisConf redis.Options,
+	versionTTL time.Duration,
 	telemetryEnabled bool,
-) (*userCache, io.Closer, error) {
+) (*userCache, *redis.Client, error) {
 	rdb := redis.NewClient(
 		&redis.Options{
 			Addr:     redisConf.Addr,
@@ -48,7 +102,8 @@ func NewUserCache(
 	}
 
 	return &userCache{
-		cache: rdb,
+		cache:      rdb,
+		versionTTL: versionTTL,
 	}, rdb, nil
 }
 
@@ -60,12 +115,14 @@ func (c *userCache) Create(ctx context.Context, users ...user.User) error {
 	usrMap := make(map[string]interface{}, len(users))
 
 	for _, u := range users {
+		u.TenantID = tenant.ID(ctx)
+
 		mUsr, err := json.Marshal(u)
 		if err != nil {
 			return errors.Errorf("%s", err)
 		}
 
-		usrMap[fmt.Sprintf("%v:%v", usr, u.ID)] = mUsr
+		usrMap[key(ctx, usr, u.ID)] = mUsr
 	}
 
 	err := c.cache.MSet(ctx, usrMap).Err()
@@ -76,11 +133,69 @@ func (c *userCache) Create(ctx context.Context, users ...user.User) error {
 	return nil
 }
 
+// Upsert stores users along with the version (e.g., the version of the
+// user in the change event) so that replayed or out-of-order events do
+// not overwrite newer data.
+func (c *userCache) Upsert(
+	ctx context.Context,
+	version int64,
+	users ...user.User,
+) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(users)*2)
+	args := make([]interface{}, 0, len(users)*2+1)
+
+	args = append(args, c.versionTTL.Milliseconds())
+
+	for _, u := range users {
+		mUsr, err := json.Marshal(u)
+		if err != nil {
+			return errors.Errorf("%s", err)
+		}
+
+		keys = append(keys, key(ctx, usr, u.ID), key(ctx, usrVersion, u.ID))
+		args = append(args, version, mUsr)
+	}
+
+	err := c.cache.Eval(ctx, upsertScript, keys, args...).Err()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (c *userCache) Delete(
+	ctx context.Context,
+	version int64,
+	ids ...string,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(ids)*2)
+
+	for _, id := range ids {
+		keys = append(keys, key(ctx, usr, id), key(ctx, usrVersion, id))
+	}
+
+	err := c.cache.Eval(ctx, deleteScript, keys, version, c.versionTTL.Milliseconds()).Err()
+	if err != nil {
+		return errors.Errorf("%s", err)
+	}
+
+	return nil
+}
+
 func (c *userCache) Read(ctx context.Context) ([]user.User, error) {
 	iter := c.cache.Scan(
 		ctx,
 		0,
-		fmt.Sprintf("%v:*", usr),
+		key(ctx, usr, "*"),
 		0,
 	).Iterator()
 
@@ -103,25 +218,147 @@ func (c *userCache) Read(ctx context.Context) ([]user.User, error) {
 		keys...,
 	)
 
-	users := make([]user.User, len(mg.Val()))
+	if err := mg.Err(); err != nil {
+		return nil, errors.Errorf("%s", err)
+	}
 
-	for k, v := range mg.Val() {
-		u := user.User{}
+	users := make([]user.User, 0, len(mg.Val()))
 
-		if _, ok := v.(string); !ok {
+	for _, v := range mg.Val() {
+		// The user was deleted after the scan.
+		if v == nil {
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok {
 			return users, errors.New("could not assert user val as string")
 		}
 
-		if err := json.Unmarshal([]byte(v.(string)), &u); err != nil {
+		u := user.User{}
+
+		if err := json.Unmarshal([]byte(s), &u); err != nil {
 			return users, errors.Errorf("%s", err)
 		}
 
-		users[k] = u
+		users = append(users, u)
 	}
 
 	return users, nil
 }
 
+// ReadPage uses the Redis SCAN cursor as the cursor, and limit as the
+// SCAN count hint. As with SCAN, a page may contain fewer or more than
+// limit users, and users that are created or deleted while paging may or
+// may not be returned.
+func (c *userCache) ReadPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) ([]user.User, string, error) {
+	var scanCursor uint64
+
+	if cursor != "" {
+		sc, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, "", errors.Errorf("cursor invalid: %s", err)
+		}
+
+		scanCursor = sc
+	}
+
+	keys, next, err := c.cache.Scan(
+		ctx,
+		scanCursor,
+		key(ctx, usr, "*"),
+		int64(limit),
+	).Result()
+	if err != nil {
+		return nil, "", errors.Errorf("%s", err)
+	}
+
+	if next == 0 {
+		cursor = ""
+	} else {
+		cursor = strconv.FormatUint(next, 10)
+	}
+
+	if len(keys) == 0 {
+		return nil, cursor, nil
+	}
+
+	mg := c.cache.MGet(
+		ctx,
+		keys...,
+	)
+	if err := mg.Err(); err != nil {
+		return nil, "", errors.Errorf("%s", err)
+	}
+
+	var users []user.User
+
+	for _, v := range mg.Val() {
+		// The user was deleted after the scan.
+		if v == nil {
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return nil, "", errors.New("could not assert user val as string")
+		}
+
+		u := user.User{}
+
+		if err := json.Unmarshal([]byte(s), &u); err != nil {
+			return nil, "", errors.Errorf("%s", err)
+		}
+
+		users = append(users, u)
+	}
+
+	return users, cursor, nil
+}
+
+// Export returns the cached user.
+func (c *userCache) Export(
+	ctx context.Context,
+	id string,
+) (user.SubjectData, error) {
+	v, err := c.cache.Get(ctx, key(ctx, usr, id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return user.SubjectData{}, user.ErrNotFound
+	}
+	if err != nil {
+		return user.SubjectData{}, errors.Errorf("%s", err)
+	}
+
+	u := user.User{}
+
+	if err := json.Unmarshal([]byte(v), &u); err != nil {
+		return user.SubjectData{}, errors.Errorf("%s", err)
+	}
+
+	return user.SubjectData{
+		User: &u,
+	}, nil
+}
+
+// Erase deletes the cached user using the current time as the version,
+// which is greater than any version of the user.
+// The version key, which holds no personal data, is retained so that
+// change events for the user that are consumed after the erasure do not
+// cache the user again. The protection is bounded by versionTTL
+// (USER_CACHE_VERSION_TTL): a change event for the user consumed once the
+// version key has expired (e.g., by a consumer that lags, or replays the
+// topic, by more than versionTTL) caches the user again.
+func (c *userCache) Erase(
+	ctx context.Context,
+	id string,
+) error {
+	return c.Delete(ctx, time.Now().UnixMilli(), id)
+}
+
 type instrumentCache struct{}
 
 func (ic instrumentCache) DialHook(next redis.DialHook) redis.DialHook {
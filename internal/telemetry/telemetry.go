@@ -20,8 +20,10 @@ import (
 )
 
 type telemetry struct {
-	logger log.Logger
-	conf   config.Telemetry
+	logger  log.Logger
+	conf    config.Telemetry
+	meters  *sdkmetric.MeterProvider
+	tracers *sdktrace.TracerProvider
 }
 
 func NewTelemetry(
@@ -34,58 +36,56 @@ func NewTelemetry(
 	}, nil
 }
 
-func (t *telemetry) Run(ctx context.Context) error {
-	var meterProvider *sdkmetric.MeterProvider
-	var tracerProvider *sdktrace.TracerProvider
-
-	if t.conf.Enabled {
-		resource, err := resource.New(ctx,
-			resource.WithFromEnv(),
-			resource.WithProcess(),
-			resource.WithTelemetrySDK(),
-			resource.WithHost(),
-			resource.WithAttributes(
-				// Service name used in traces and metrics (exported_job).
-				semconv.ServiceNameKey.String(t.conf.ServiceName),
-			),
-		)
-		if err != nil {
-			return err
-		}
-
-		if meterProvider, err = t.meterProvider(ctx, resource); err != nil {
-			return err
-		}
-
-		if tracerProvider, err = t.tracerProvider(ctx, resource); err != nil {
-			return err
-		}
+// Start registers the meter and tracer providers, so that they are
+// registered before components that are instrumented are started.
+func (t *telemetry) Start(ctx context.Context) error {
+	if !t.conf.Enabled {
+		return nil
 	}
 
-	<-ctx.Done()
+	resource, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithProcess(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(
+			// Service name used in traces and metrics (exported_job).
+			semconv.ServiceNameKey.String(t.conf.ServiceName),
+		),
+	)
+	if err != nil {
+		return err
+	}
 
-	var providerShutdownErr error
+	if t.meters, err = t.meterProvider(ctx, resource); err != nil {
+		return err
+	}
 
-	if tracerProvider != nil {
-		err := tracerProvider.Shutdown(ctx)
+	if t.tracers, err = t.tracerProvider(ctx, resource); err != nil {
+		return err
+	}
 
-		if !errors.Is(err, context.Canceled) {
-			t.logger.Error(err)
-			providerShutdownErr = errors.Join(err)
-		}
+	return nil
+}
 
-		t.logger.Infof(err.Error())
-	}
+func (t *telemetry) Run(ctx context.Context) error {
+	<-ctx.Done()
 
-	if meterProvider != nil {
-		err := meterProvider.Shutdown(ctx)
+	return nil
+}
+
+// Stop flushes and shuts down the providers. Telemetry is stopped after
+// the components that are instrumented, so that their spans and metrics
+// are exported.
+func (t *telemetry) Stop(ctx context.Context) error {
+	var providerShutdownErr error
 
-		if !errors.Is(err, context.Canceled) {
-			t.logger.Error(err)
-			providerShutdownErr = errors.Join(providerShutdownErr, err)
-		}
+	if t.tracers != nil {
+		providerShutdownErr = t.tracers.Shutdown(ctx)
+	}
 
-		t.logger.Infof(err.Error())
+	if t.meters != nil {
+		providerShutdownErr = errors.Join(providerShutdownErr, t.meters.Shutdown(ctx))
 	}
 
 	return providerShutdownErr
@@ -0,0 +1,150 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bendbennett/go-api-demo/internal/grpcutil"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcInfraPrefix is the prefix of the full method names of services,
+// such as reflection and health, that are not tenant-scoped.
+const grpcInfraPrefix = "/grpc."
+
+type r struct {
+	header    string
+	defaultID string
+}
+
+// NewResolver returns a resolver that identifies the tenant of a request
+// from the tenant already carried by the request context (e.g., from a
+// token claim or API key), or otherwise from the header. Requests with a
+// header that differs from the tenant of the context are rejected, so the
+// tenant of a credential cannot be overridden. Requests that identify no
+// tenant belong to defaultID, or are rejected if defaultID is empty.
+func NewResolver(
+	header string,
+	defaultID string,
+) *r {
+	return &r{
+		header,
+		defaultID,
+	}
+}
+
+func (rs *r) resolve(ctx context.Context, headerVal string) (string, error) {
+	id, ok := FromContext(ctx)
+
+	switch {
+	case ok && headerVal != "" && headerVal != id:
+		return "", ErrMismatch
+	case ok:
+	case headerVal != "":
+		id = headerVal
+	case rs.defaultID != "":
+		id = rs.defaultID
+	default:
+		return "", ErrMissing
+	}
+
+	if !Valid(id) {
+		return "", ErrInvalid
+	}
+
+	return id, nil
+}
+
+// Middleware adds the tenant to the context of HTTP requests, responding
+// with 400 Bad Request if the tenant is missing or invalid, and with 403
+// Forbidden if it does not match the credential.
+func (rs *r) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id, err := rs.resolve(req.Context(), req.Header.Get(rs.header))
+		if err != nil {
+			code := http.StatusBadRequest
+			if errors.Is(err, ErrMismatch) {
+				code = http.StatusForbidden
+			}
+
+			response.WriteErrorResponse(
+				w,
+				code,
+				err.Error(),
+				nil,
+			)
+
+			return
+		}
+
+		next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), id)))
+	})
+}
+
+func (rs *r) grpcContext(ctx context.Context) (context.Context, error) {
+	var headerVal string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(strings.ToLower(rs.header)); len(v) > 0 {
+			headerVal = v[0]
+		}
+	}
+
+	id, err := rs.resolve(ctx, headerVal)
+	if err != nil {
+		code := codes.InvalidArgument
+		if errors.Is(err, ErrMismatch) {
+			code = codes.PermissionDenied
+		}
+
+		return nil, status.Error(code, err.Error())
+	}
+
+	return NewContext(ctx, id), nil
+}
+
+// UnaryInterceptor adds the tenant, from the metadata key matching the
+// header, to the context of unary gRPC calls.
+func (rs *r) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if strings.HasPrefix(info.FullMethod, grpcInfraPrefix) {
+		return handler(ctx, req)
+	}
+
+	ctx, err := rs.grpcContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamInterceptor adds the tenant, from the metadata key matching the
+// header, to the context of streaming gRPC calls.
+func (rs *r) StreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if strings.HasPrefix(info.FullMethod, grpcInfraPrefix) {
+		return handler(srv, ss)
+	}
+
+	ctx, err := rs.grpcContext(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, grpcutil.NewServerStream(ctx, ss))
+}
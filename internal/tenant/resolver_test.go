@@ -0,0 +1,231 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestResolver_Middleware(t *testing.T) {
+	cases := []struct {
+		name             string
+		defaultID        string
+		ctx              context.Context
+		header           string
+		expectedStatus   int
+		expectedTenantID string
+	}{
+		{
+			"header",
+			"default",
+			context.Background(),
+			"acme",
+			http.StatusOK,
+			"acme",
+		},
+		{
+			"context",
+			"default",
+			NewContext(context.Background(), "globex"),
+			"",
+			http.StatusOK,
+			"globex",
+		},
+		{
+			"header matches context",
+			"default",
+			NewContext(context.Background(), "globex"),
+			"globex",
+			http.StatusOK,
+			"globex",
+		},
+		{
+			"header does not match context",
+			"default",
+			NewContext(context.Background(), "globex"),
+			"acme",
+			http.StatusForbidden,
+			"",
+		},
+		{
+			"default",
+			"default",
+			context.Background(),
+			"",
+			http.StatusOK,
+			"default",
+		},
+		{
+			"missing",
+			"",
+			context.Background(),
+			"",
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"invalid",
+			"default",
+			context.Background(),
+			"Acme:1",
+			http.StatusBadRequest,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var tenantID string
+
+			handler := NewResolver("X-Tenant-ID", c.defaultID).Middleware(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					tenantID, _ = FromContext(r.Context())
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/user", nil).WithContext(c.ctx)
+
+			if c.header != "" {
+				req.Header.Set("X-Tenant-ID", c.header)
+			}
+
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, c.expectedStatus, rec.Code)
+			assert.Equal(t, c.expectedTenantID, tenantID)
+		})
+	}
+}
+
+func TestResolver_UnaryInterceptor(t *testing.T) {
+	cases := []struct {
+		name             string
+		defaultID        string
+		method           string
+		md               metadata.MD
+		expectedCode     codes.Code
+		expectedTenantID string
+	}{
+		{
+			"metadata",
+			"default",
+			"/user.User/Read",
+			metadata.Pairs("x-tenant-id", "acme"),
+			codes.OK,
+			"acme",
+		},
+		{
+			"default",
+			"default",
+			"/user.User/Read",
+			nil,
+			codes.OK,
+			"default",
+		},
+		{
+			"missing",
+			"",
+			"/user.User/Read",
+			nil,
+			codes.InvalidArgument,
+			"",
+		},
+		{
+			"invalid",
+			"default",
+			"/user.User/Read",
+			metadata.Pairs("x-tenant-id", "Acme:1"),
+			codes.InvalidArgument,
+			"",
+		},
+		{
+			"infrastructure service is not tenant-scoped",
+			"",
+			"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+			nil,
+			codes.OK,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var tenantID string
+
+			ctx := metadata.NewIncomingContext(context.Background(), c.md)
+
+			_, err := NewResolver("X-Tenant-ID", c.defaultID).UnaryInterceptor(
+				ctx,
+				nil,
+				&grpc.UnaryServerInfo{FullMethod: c.method},
+				func(ctx context.Context, _ interface{}) (interface{}, error) {
+					tenantID, _ = FromContext(ctx)
+					return nil, nil
+				},
+			)
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+			assert.Equal(t, c.expectedTenantID, tenantID)
+		})
+	}
+}
+
+func TestResolver_UnaryInterceptor_Mismatch(t *testing.T) {
+	ctx := metadata.NewIncomingContext(
+		NewContext(context.Background(), "globex"),
+		metadata.Pairs("x-tenant-id", "acme"),
+	)
+
+	_, err := NewResolver("X-Tenant-ID", "default").UnaryInterceptor(
+		ctx,
+		nil,
+		&grpc.UnaryServerInfo{FullMethod: "/user.User/Read"},
+		func(context.Context, interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	)
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+type serverStreamMock struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (m *serverStreamMock) Context() context.Context {
+	return m.ctx
+}
+
+func TestResolver_StreamInterceptor(t *testing.T) {
+	var tenantID string
+
+	ss := &serverStreamMock{
+		ctx: metadata.NewIncomingContext(
+			context.Background(),
+			metadata.Pairs("x-tenant-id", "acme"),
+		),
+	}
+
+	err := NewResolver("X-Tenant-ID", "").StreamInterceptor(
+		nil,
+		ss,
+		&grpc.StreamServerInfo{FullMethod: "/user.User/Watch"},
+		func(_ interface{}, ss grpc.ServerStream) error {
+			tenantID, _ = FromContext(ss.Context())
+			return nil
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", tenantID)
+}
@@ -0,0 +1,56 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// Default is the tenant to which users that were stored before tenancy
+// was introduced belong.
+const Default = "default"
+
+var (
+	// ErrMissing is returned when a request does not identify a tenant
+	// and no default tenant is configured.
+	ErrMissing = errors.New("tenant missing")
+	// ErrInvalid is returned when a request identifies a tenant with an
+	// ID that is not valid.
+	ErrInvalid = errors.New("tenant invalid")
+	// ErrMismatch is returned when a request identifies a tenant with the
+	// header that differs from the tenant of its credential (e.g., token).
+	ErrMismatch = errors.New("tenant does not match credential")
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,35}$`)
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the tenant ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant ID carried by ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+
+	return id, ok && id != ""
+}
+
+// ID returns the tenant ID carried by ctx, or Default if ctx does not
+// carry a tenant (e.g., in background jobs that are not tenant-scoped).
+func ID(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+
+	return Default
+}
+
+// Valid reports whether id may be used as a tenant ID. Tenant IDs are
+// used in keys, document IDs and index names so are restricted to lower
+// case alphanumerics, hyphens and underscores.
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
@@ -0,0 +1,101 @@
+package consume
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bendbennett/go-api-demo/internal/format"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/google/uuid"
+)
+
+// eventNamespace is used to derive event IDs, so that redelivery of the
+// same change results in an event with the same ID.
+var eventNamespace = uuid.MustParse("6c7f3b5e-8a9d-4e2f-b1c0-3d5a7e9f1b2c")
+
+var opEvents = map[string]string{
+	opCreate: user.EventCreated,
+	opUpdate: user.EventUpdated,
+	opDelete: user.EventDeleted,
+}
+
+type eventProcessor struct {
+	eventHandler user.EventHandler
+	valueName    string
+}
+
+// NewEventProcessor returns a processor that converts change events on
+// records in recordNamespace (see NewProcessor) to user.Event and passes
+// them to eventHandler.
+func NewEventProcessor(
+	eventHandler user.EventHandler,
+	recordNamespace string,
+) *eventProcessor {
+	return &eventProcessor{
+		eventHandler: eventHandler,
+		valueName:    fmt.Sprintf("%s.Value", recordNamespace),
+	}
+}
+
+// Process ignores snapshot reads (r) as these do not represent a change
+// to the user. Updates that soft delete or restore a user result in
+// user.EventDeleted and user.EventRestored respectively, updates to a user
+// that remains soft deleted are ignored, and deletes of a soft deleted user
+// (i.e., purges) result in user.EventPurged.
+func (p *eventProcessor) Process(
+	ctx context.Context,
+	data any,
+) error {
+	env, userBeforeAfter, err := decode(data, p.valueName)
+	if err != nil {
+		return err
+	}
+
+	if env.Op == opRead {
+		return nil
+	}
+
+	eventType, ok := opEvents[env.Op]
+	if !ok {
+		return fmt.Errorf("op %q: not implemented", env.Op)
+	}
+
+	before, after := userBeforeAfter.before, userBeforeAfter.after
+
+	switch {
+	case env.Op == opUpdate && before.DeletedAt == nil && after.DeletedAt != nil:
+		eventType = user.EventDeleted
+		after = user.User{}
+	case env.Op == opUpdate && before.DeletedAt != nil && after.DeletedAt == nil:
+		eventType = user.EventRestored
+	case env.Op == opUpdate && after.DeletedAt != nil:
+		return nil
+	case env.Op == opDelete && before.DeletedAt != nil:
+		eventType = user.EventPurged
+	}
+
+	evt := user.Event{
+		OccurredAt: format.MsecToTime(env.Source.TsMs),
+		Type:       eventType,
+	}
+
+	if before != (user.User{}) {
+		evt.Before = &before
+	}
+
+	if after != (user.User{}) {
+		evt.After = &after
+	}
+
+	if evt.UserID() == "" {
+		return fmt.Errorf("op %q: before and after values missing", env.Op)
+	}
+
+	evt.ID = uuid.NewSHA1(
+		eventNamespace,
+		[]byte(fmt.Sprintf("%s:%s:%s:%d", env.Op, evt.TenantID(), evt.UserID(), env.Source.TsMs)),
+	).String()
+
+	return p.eventHandler.Handle(tenant.NewContext(ctx, evt.TenantID()), evt)
+}
@@ -0,0 +1,245 @@
+package consume
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/format"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type eventHandlerMock struct {
+	events []user.Event
+}
+
+func (m *eventHandlerMock) Handle(_ context.Context, evt user.Event) error {
+	m.events = append(m.events, evt)
+	return nil
+}
+
+func TestEventProcessor_Process(t *testing.T) {
+	value := func(id string) map[string]interface{} {
+		return map[string]interface{}{
+			"mysql.go_api_demo.users.Value": map[string]interface{}{
+				"id": id,
+			},
+		}
+	}
+
+	deletedValue := func(id string) map[string]interface{} {
+		return map[string]interface{}{
+			"mysql.go_api_demo.users.Value": map[string]interface{}{
+				"id": id,
+				"deleted_at": map[string]interface{}{
+					"long": int64(1639512013000),
+				},
+			},
+		}
+	}
+
+	deletedAt := format.MsecToTime(1639512013000)
+
+	cases := map[string]struct {
+		data           any
+		expectedBefore *user.User
+		expectedAfter  *user.User
+		expectedType   string
+		expectedErr    string
+	}{
+		"create emits created event": {
+			map[string]interface{}{
+				"after":  value("1"),
+				"before": nil,
+				"op":     "c",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			nil,
+			&user.User{TenantID: "default", ID: "1"},
+			user.EventCreated,
+			"",
+		},
+		"update emits updated event": {
+			map[string]interface{}{
+				"after":  value("1"),
+				"before": value("1"),
+				"op":     "u",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			&user.User{TenantID: "default", ID: "1"},
+			&user.User{TenantID: "default", ID: "1"},
+			user.EventUpdated,
+			"",
+		},
+		"delete emits deleted event": {
+			map[string]interface{}{
+				"after":  nil,
+				"before": value("1"),
+				"op":     "d",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			&user.User{TenantID: "default", ID: "1"},
+			nil,
+			user.EventDeleted,
+			"",
+		},
+		"soft delete emits deleted event": {
+			map[string]interface{}{
+				"after":  deletedValue("1"),
+				"before": value("1"),
+				"op":     "u",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			&user.User{TenantID: "default", ID: "1"},
+			nil,
+			user.EventDeleted,
+			"",
+		},
+		"restore emits restored event": {
+			map[string]interface{}{
+				"after":  value("1"),
+				"before": deletedValue("1"),
+				"op":     "u",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			&user.User{TenantID: "default", ID: "1", DeletedAt: &deletedAt},
+			&user.User{TenantID: "default", ID: "1"},
+			user.EventRestored,
+			"",
+		},
+		"update of soft deleted user is ignored": {
+			map[string]interface{}{
+				"after":  deletedValue("1"),
+				"before": deletedValue("1"),
+				"op":     "u",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			nil,
+			nil,
+			"",
+			"",
+		},
+		"delete of soft deleted user emits purged event": {
+			map[string]interface{}{
+				"after":  nil,
+				"before": deletedValue("1"),
+				"op":     "d",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			&user.User{TenantID: "default", ID: "1", DeletedAt: &deletedAt},
+			nil,
+			user.EventPurged,
+			"",
+		},
+		"snapshot read is ignored": {
+			map[string]interface{}{
+				"after": value("1"),
+				"op":    "r",
+			},
+			nil,
+			nil,
+			"",
+			"",
+		},
+		"missing values returns error": {
+			map[string]interface{}{
+				"op": "c",
+			},
+			nil,
+			nil,
+			"",
+			`op "c": before and after values missing`,
+		},
+		"unknown op returns error": {
+			map[string]interface{}{
+				"op": "t",
+			},
+			nil,
+			nil,
+			"",
+			`op "t": not implemented`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			eventHandler := &eventHandlerMock{}
+
+			processor := NewEventProcessor(
+				eventHandler,
+				"mysql.go_api_demo.users",
+			)
+
+			err := processor.Process(
+				context.Background(),
+				c.data,
+			)
+
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				assert.Empty(t, eventHandler.events)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			if c.expectedType == "" {
+				assert.Empty(t, eventHandler.events)
+				return
+			}
+
+			assert.Len(t, eventHandler.events, 1)
+
+			evt := eventHandler.events[0]
+
+			if c.expectedBefore != nil {
+				c.expectedBefore.CreatedAt = format.MsecToTime(0)
+			}
+			if c.expectedAfter != nil {
+				c.expectedAfter.CreatedAt = format.MsecToTime(0)
+			}
+
+			assert.Equal(t, c.expectedType, evt.Type)
+			assert.Equal(t, c.expectedBefore, evt.Before)
+			assert.Equal(t, c.expectedAfter, evt.After)
+			assert.Equal(t, format.MsecToTime(1639512013000), evt.OccurredAt)
+			assert.NotEmpty(t, evt.ID)
+		})
+	}
+}
+
+func TestEventProcessor_Process_IDIsDeterministic(t *testing.T) {
+	data := map[string]interface{}{
+		"after": map[string]interface{}{
+			"mysql.go_api_demo.users.Value": map[string]interface{}{
+				"id": "1",
+			},
+		},
+		"op": "c",
+		"source": map[string]interface{}{
+			"ts_ms": int64(1639512013000),
+		},
+	}
+
+	eventHandler := &eventHandlerMock{}
+	processor := NewEventProcessor(eventHandler, "mysql.go_api_demo.users")
+
+	assert.NoError(t, processor.Process(context.Background(), data))
+	assert.NoError(t, processor.Process(context.Background(), data))
+
+	assert.Equal(t, eventHandler.events[0].ID, eventHandler.events[1].ID)
+}
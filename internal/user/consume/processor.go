@@ -2,68 +2,126 @@ package consume
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/bendbennett/go-api-demo/internal/format"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	"github.com/mitchellh/mapstructure"
 )
 
+// Debezium op codes.
+// See https://debezium.io/documentation/reference/stable/connectors/mysql.html#mysql-create-events
+const (
+	opCreate = "c"
+	opUpdate = "u"
+	opDelete = "d"
+	opRead   = "r"
+)
+
 type processor struct {
-	creator user.Creator
+	upserterDeleter user.UpserterDeleter
+	valueName       string
 }
 
+// NewProcessor returns a processor for change events on records in
+// recordNamespace (i.e., <topic prefix>.<database>.<table>), for
+// example, mysql.go_api_demo.users.
 func NewProcessor(
-	creator user.Creator,
+	upserterDeleter user.UpserterDeleter,
+	recordNamespace string,
 ) *processor {
 	return &processor{
-		creator,
+		upserterDeleter: upserterDeleter,
+		valueName:       fmt.Sprintf("%s.Value", recordNamespace),
 	}
 }
 
-// Process examines userBefore and userAfter to determine whether to create, update or delete.
-// The first case checks whether the before and after values are the same (i.e., no changes),
-// which should only occur for tombstone events (see consumer consume func) which should be
-// filtered out in the consumer, but we are just being defensive.
-// The second case checks whether before is empty, in which case a user is being created.
-// TODO: Implement update and delete.
+// Process uses the op code in the envelope to determine whether to upsert or delete.
+// Creates, updates and snapshot reads (r) are all handled as upserts of the after
+// value, whereas deletes use the ID from the before value. The version of the user,
+// which is incremented by every change to the row, is passed as the version so
+// that the sinks can skip writes that are older than the data they already hold.
+// Timestamps (e.g., source.ts_ms) are not used, as the clocks of the instances
+// making changes may be skewed. Deletes (i.e., purges) are passed the version
+// following that of the before value, as the row no longer exists. Users that
+// have been soft deleted are deleted from the sinks so that they are excluded
+// from cached reads and search. The sinks are written to within the tenant of
+// the user.
 func (p *processor) Process(
 	ctx context.Context,
 	data any,
 ) error {
-	beforeAfter := beforeAfter{}
-
-	err := mapstructure.Decode(data, &beforeAfter)
+	env, userBeforeAfter, err := decode(data, p.valueName)
 	if err != nil {
 		return err
 	}
 
-	userBeforeAfter := beforeAfter.UserBeforeAfter()
+	switch env.Op {
+	case opCreate, opUpdate, opRead:
+		if userBeforeAfter.after == (user.User{}) {
+			return fmt.Errorf("op %q: after value missing", env.Op)
+		}
+
+		ctx = tenant.NewContext(ctx, userBeforeAfter.after.TenantID)
+
+		if userBeforeAfter.after.DeletedAt != nil {
+			return p.upserterDeleter.Delete(ctx, userBeforeAfter.after.Version, userBeforeAfter.after.ID)
+		}
 
-	switch {
-	case userBeforeAfter.before == userBeforeAfter.after:
-		return nil
-	case userBeforeAfter.before == (user.User{}):
-		return p.creator.Create(ctx, userBeforeAfter.after)
+		return p.upserterDeleter.Upsert(ctx, userBeforeAfter.after.Version, userBeforeAfter.after)
+	case opDelete:
+		if userBeforeAfter.before.ID == "" {
+			return fmt.Errorf("op %q: before value missing", env.Op)
+		}
+
+		ctx = tenant.NewContext(ctx, userBeforeAfter.before.TenantID)
+
+		return p.upserterDeleter.Delete(ctx, userBeforeAfter.before.Version+1, userBeforeAfter.before.ID)
 	default:
-		return errors.New("not implemented")
+		return fmt.Errorf("op %q: not implemented", env.Op)
 	}
 }
 
-type beforeAfter struct {
-	Before value
-	After  value
+func decode(
+	data any,
+	valueName string,
+) (envelope, userBeforeAfter, error) {
+	env := envelope{}
+
+	err := mapstructure.Decode(data, &env)
+	if err != nil {
+		return envelope{}, userBeforeAfter{}, err
+	}
+
+	userBeforeAfter, err := env.UserBeforeAfter(valueName)
+	if err != nil {
+		return envelope{}, userBeforeAfter, err
+	}
+
+	return env, userBeforeAfter, nil
 }
 
-type value struct {
-	Value usr `mapstructure:"mysql.go_api_demo.users.Value"`
+type envelope struct {
+	Before map[string]usr
+	After  map[string]usr
+	Op     string
+	Source source
+}
+
+type source struct {
+	TsMs int64 `mapstructure:"ts_ms"`
 }
 
 type usr struct {
-	ID        string `mapstructure:"id"`
-	FirstName string `mapstructure:"first_name"`
-	LastName  string `mapstructure:"last_name"`
-	CreatedAt int64  `mapstructure:"created_at"`
+	TenantID  string           `mapstructure:"tenant_id"`
+	ID        string           `mapstructure:"id"`
+	FirstName string           `mapstructure:"first_name"`
+	LastName  string           `mapstructure:"last_name"`
+	CreatedAt int64            `mapstructure:"created_at"`
+	Version   int64            `mapstructure:"version"`
+	DeletedAt map[string]int64 `mapstructure:"deleted_at"`
 }
 
 type userBeforeAfter struct {
@@ -71,24 +129,76 @@ type userBeforeAfter struct {
 	after  user.User
 }
 
-// UserBeforeAfter converts beforeAfter to struct
+// UserBeforeAfter converts envelope to struct
 // containing user.User for before and after.
 //
+// Before and after are either nil or hold a single value keyed
+// by valueName. An error is returned if the value is keyed by any
+// other name, as this indicates that the event originates from a
+// different server, database or table than the one configured.
+//
 // The CreatedAt timestamp (io.debezium.time.Timestamp) is an
-// int64 that represents the unix timestamp in msec.
-func (bf beforeAfter) UserBeforeAfter() userBeforeAfter {
+// int64 that represents the unix timestamp in msec. DeletedAt is
+// nullable and is therefore either nil or a union keyed by "long".
+// TenantID is tenant.Default for events that were produced before
+// tenancy was introduced.
+func (bf envelope) UserBeforeAfter(valueName string) (userBeforeAfter, error) {
+	before, err := valueUser(bf.Before, valueName)
+	if err != nil {
+		return userBeforeAfter{}, fmt.Errorf("before: %w", err)
+	}
+
+	after, err := valueUser(bf.After, valueName)
+	if err != nil {
+		return userBeforeAfter{}, fmt.Errorf("after: %w", err)
+	}
+
 	return userBeforeAfter{
-		before: user.User{
-			CreatedAt: format.MsecToTime(bf.Before.Value.CreatedAt),
-			ID:        bf.Before.Value.ID,
-			FirstName: bf.Before.Value.FirstName,
-			LastName:  bf.Before.Value.LastName,
-		},
-		after: user.User{
-			CreatedAt: format.MsecToTime(bf.After.Value.CreatedAt),
-			ID:        bf.After.Value.ID,
-			FirstName: bf.After.Value.FirstName,
-			LastName:  bf.After.Value.LastName,
-		},
+		before: before,
+		after:  after,
+	}, nil
+}
+
+func valueUser(
+	value map[string]usr,
+	valueName string,
+) (user.User, error) {
+	if len(value) == 0 {
+		return user.User{}, nil
+	}
+
+	v, ok := value[valueName]
+	if !ok {
+		names := make([]string, 0, len(value))
+
+		for name := range value {
+			names = append(names, name)
+		}
+
+		return user.User{}, fmt.Errorf(
+			"envelope mismatch: expected %s, got %s",
+			valueName,
+			strings.Join(names, ", "),
+		)
 	}
+
+	u := user.User{
+		CreatedAt: format.MsecToTime(v.CreatedAt),
+		TenantID:  v.TenantID,
+		ID:        v.ID,
+		FirstName: v.FirstName,
+		LastName:  v.LastName,
+		Version:   v.Version,
+	}
+
+	if u.TenantID == "" {
+		u.TenantID = tenant.Default
+	}
+
+	if deletedAt, ok := v.DeletedAt["long"]; ok {
+		t := format.MsecToTime(deletedAt)
+		u.DeletedAt = &t
+	}
+
+	return u, nil
 }
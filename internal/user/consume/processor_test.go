@@ -6,82 +6,276 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	"github.com/mitchellh/mapstructure"
 	"github.com/stretchr/testify/assert"
 )
 
-type creatorMock struct {
-	hasBeenCalled bool
+type upserterDeleterMock struct {
+	upserted []user.User
+	deleted  []string
+	version  int64
+	tenantID string
 }
 
-func (m *creatorMock) Create(context.Context, ...user.User) error {
-	m.hasBeenCalled = true
+func (m *upserterDeleterMock) Upsert(ctx context.Context, version int64, users ...user.User) error {
+	m.upserted = append(m.upserted, users...)
+	m.version = version
+	m.tenantID, _ = tenant.FromContext(ctx)
 	return nil
 }
 
-func (m *creatorMock) HasBeenCalled() bool {
-	return m.hasBeenCalled
+func (m *upserterDeleterMock) Delete(ctx context.Context, version int64, ids ...string) error {
+	m.deleted = append(m.deleted, ids...)
+	m.version = version
+	m.tenantID, _ = tenant.FromContext(ctx)
+	return nil
 }
 
-// TODO: Test should confirm that Create() is called on creatorMock
-// when before == empty user
 func TestProcessor_Process(t *testing.T) {
 	cases := map[string]struct {
-		creator              *creatorMock
-		data                 any
-		creatorHasBeenCalled bool
-		expectedErr          error
+		data             any
+		expectedUpserted []user.User
+		expectedDeleted  []string
+		expectedVersion  int64
+		expectedTenantID string
+		expectedErr      error
 	}{
-		"no processing required": {
-			&creatorMock{},
+		"create calls upsert": {
 			map[string]interface{}{
 				"after": map[string]interface{}{
 					"mysql.go_api_demo.users.Value": map[string]interface{}{
-						"id": "1",
+						"id":      "1",
+						"version": int64(1),
+					},
+				},
+				"before": nil,
+				"op":     "c",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			[]user.User{{TenantID: "default", ID: "1", Version: 1}},
+			nil,
+			1,
+			"default",
+			nil,
+		},
+		"snapshot read calls upsert": {
+			map[string]interface{}{
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":      "1",
+						"version": int64(1),
+					},
+				},
+				"before": nil,
+				"op":     "r",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
+			},
+			[]user.User{{TenantID: "default", ID: "1", Version: 1}},
+			nil,
+			1,
+			"default",
+			nil,
+		},
+		"update calls upsert with after": {
+			map[string]interface{}{
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         "1",
+						"first_name": "jane",
+						"version":    int64(2),
 					},
 				},
 				"before": map[string]interface{}{
 					"mysql.go_api_demo.users.Value": map[string]interface{}{
-						"id": "1",
+						"id":         "1",
+						"first_name": "john",
 					},
 				},
+				"op": "u",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512014000),
+				},
 			},
-			false,
+			[]user.User{{TenantID: "default", ID: "1", FirstName: "jane", Version: 2}},
+			nil,
+			2,
+			"default",
 			nil,
 		},
-		"create called": {
-			&creatorMock{},
+		"update with skewed timestamp calls upsert with version": {
 			map[string]interface{}{
 				"after": map[string]interface{}{
 					"mysql.go_api_demo.users.Value": map[string]interface{}{
-						"id": "1",
+						"id":         "1",
+						"first_name": "joan",
+						"version":    int64(3),
+					},
+				},
+				"before": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         "1",
+						"first_name": "jane",
+						"version":    int64(2),
+					},
+				},
+				"op": "u",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512012000),
+				},
+			},
+			[]user.User{{TenantID: "default", ID: "1", FirstName: "joan", Version: 3}},
+			nil,
+			3,
+			"default",
+			nil,
+		},
+		"soft delete calls delete": {
+			map[string]interface{}{
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":      "1",
+						"version": int64(2),
+						"deleted_at": map[string]interface{}{
+							"long": int64(1639512014000),
+						},
+					},
+				},
+				"before": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":         "1",
+						"version":    int64(1),
+						"deleted_at": nil,
+					},
+				},
+				"op": "u",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512014000),
+				},
+			},
+			nil,
+			[]string{"1"},
+			2,
+			"default",
+			nil,
+		},
+		"delete calls delete": {
+			map[string]interface{}{
+				"after": nil,
+				"before": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"id":      "1",
+						"version": int64(3),
+					},
+				},
+				"op": "d",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512015000),
+				},
+			},
+			nil,
+			[]string{"1"},
+			4,
+			"default",
+			nil,
+		},
+		"create carries tenant": {
+			map[string]interface{}{
+				"after": map[string]interface{}{
+					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"tenant_id": "acme",
+						"id":        "1",
+						"version":   int64(1),
 					},
 				},
 				"before": nil,
+				"op":     "c",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512013000),
+				},
 			},
-			true,
+			[]user.User{{TenantID: "acme", ID: "1", Version: 1}},
+			nil,
+			1,
+			"acme",
 			nil,
 		},
-		"unimplemented error": {
-			&creatorMock{},
+		"delete carries tenant": {
 			map[string]interface{}{
 				"after": nil,
 				"before": map[string]interface{}{
 					"mysql.go_api_demo.users.Value": map[string]interface{}{
+						"tenant_id": "acme",
+						"id":        "1",
+						"version":   int64(3),
+					},
+				},
+				"op": "d",
+				"source": map[string]interface{}{
+					"ts_ms": int64(1639512015000),
+				},
+			},
+			nil,
+			[]string{"1"},
+			4,
+			"acme",
+			nil,
+		},
+		"envelope mismatch returns error": {
+			map[string]interface{}{
+				"after": map[string]interface{}{
+					"mysql.go_api_demo_staging.users.Value": map[string]interface{}{
 						"id": "1",
 					},
 				},
+				"before": nil,
+				"op":     "c",
 			},
-			false,
-			errors.New("not implemented"),
+			nil,
+			nil,
+			0,
+			"",
+			errors.New("after: envelope mismatch: expected mysql.go_api_demo.users.Value, " +
+				"got mysql.go_api_demo_staging.users.Value"),
+		},
+		"create without after returns error": {
+			map[string]interface{}{
+				"after":  nil,
+				"before": nil,
+				"op":     "c",
+			},
+			nil,
+			nil,
+			0,
+			"",
+			errors.New(`op "c": after value missing`),
+		},
+		"unknown op returns error": {
+			map[string]interface{}{
+				"after":  nil,
+				"before": nil,
+				"op":     "t",
+			},
+			nil,
+			nil,
+			0,
+			"",
+			errors.New(`op "t": not implemented`),
 		},
 	}
 
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
+			upserterDeleter := &upserterDeleterMock{}
+
 			processor := NewProcessor(
-				c.creator,
+				upserterDeleter,
+				"mysql.go_api_demo.users",
 			)
 
 			err := processor.Process(
@@ -89,8 +283,15 @@ func TestProcessor_Process(t *testing.T) {
 				c.data,
 			)
 
-			assert.Equal(t, c.creatorHasBeenCalled, c.creator.HasBeenCalled())
-			assert.Equal(t, c.expectedErr, err)
+			assert.Equal(t, c.expectedUpserted, upserterDeleter.upserted)
+			assert.Equal(t, c.expectedDeleted, upserterDeleter.deleted)
+			assert.Equal(t, c.expectedVersion, upserterDeleter.version)
+			assert.Equal(t, c.expectedTenantID, upserterDeleter.tenantID)
+			if c.expectedErr != nil {
+				assert.EqualError(t, err, c.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
 		})
 	}
 }
@@ -207,7 +408,7 @@ func Benchmark_Extract(b *testing.B) {
 }
 
 func Benchmark_MapExtract(b *testing.B) {
-	m := beforeAfter{}
+	m := envelope{}
 
 	for n := 0; n < b.N; n++ {
 		_ = mapstructure.Decode(msg, &m)
@@ -1,6 +1,89 @@
 package create
 
-type inputData struct {
+import (
+	"context"
+
+	"github.com/bendbennett/go-api-demo/internal/validate"
+)
+
+// IdempotencyKeyHeader and IdempotencyKeyMetadata hold the key used to
+// identify retries of a create request, so that a user is created once
+// however many times the request is retried.
+const (
+	IdempotencyKeyHeader   = "Idempotency-Key"
+	IdempotencyKeyMetadata = "idempotency-key"
+)
+
+// maxIdempotencyKeyLength is the maximum length of an idempotency key.
+const maxIdempotencyKeyLength = 255
+
+// maxBatchSize is the maximum number of users that are created by each
+// call to the interactor when creating users in batches.
+const maxBatchSize = 1000
+
+// InputData holds the fields from which a user is created, with the rules
+// by which they are validated. It is also decoded from the rows of imports
+// (see user/transfer), so that imported users are validated as those
+// created through the API.
+type InputData struct {
 	FirstName string `json:"first_name" validate:"required,min=3,max=100"`
 	LastName  string `json:"last_name" validate:"required,min=3,max=100"`
 }
+
+// batchResult holds either the created user or the validation errors for
+// the input at Index within a batch.
+type batchResult struct {
+	ViewModel *viewModel
+	Errors    map[string]string
+	Index     int
+}
+
+// createBatch validates each input, and creates the users for the inputs
+// that are valid. Results are returned in the same order as inputs, with
+// offset added to each index.
+func createBatch(
+	ctx context.Context,
+	validator validate.Validator,
+	interactor interactor,
+	presenter presenter,
+	offset int,
+	inputs []InputData,
+) ([]batchResult, error) {
+	results := make([]batchResult, len(inputs))
+
+	var (
+		valid   []InputData
+		indexes []int
+	)
+
+	for n, input := range inputs {
+		results[n].Index = offset + n
+
+		if errs := validator.ValidateStruct(input); errs != nil {
+			results[n].Errors = errs
+			continue
+		}
+
+		valid = append(valid, input)
+		indexes = append(indexes, n)
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	od, err := interactor.createBatch(
+		ctx,
+		valid,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for n, o := range od {
+		vm := presenter.viewModel(o)
+		results[indexes[n]].ViewModel = &vm
+	}
+
+	return results, nil
+}
@@ -2,11 +2,18 @@ package create
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	user "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
 	"github.com/bendbennett/go-api-demo/internal/log"
 	"github.com/bendbennett/go-api-demo/internal/validate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type grpcController struct {
@@ -18,6 +25,8 @@ type grpcController struct {
 
 type GRPCController interface {
 	Create(context.Context, *user.CreateRequest) (*user.UserResponse, error)
+	CreateBatch(user.User_CreateBatchServer) error
+	CreateBatchStream(user.User_CreateBatchStreamServer) error
 }
 
 func NewGRPCController(
@@ -38,7 +47,7 @@ func (c *grpcController) Create(
 	ctx context.Context,
 	req *user.CreateRequest,
 ) (*user.UserResponse, error) {
-	input := inputData{
+	input := InputData{
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 	}
@@ -49,15 +58,59 @@ func (c *grpcController) Create(
 		return nil, fmt.Errorf("%v", errs)
 	}
 
-	od, err := c.interactor.create(
-		ctx,
-		input,
+	var key string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(IdempotencyKeyMetadata); len(v) > 0 {
+			key = v[0]
+		}
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"%s must be at most %d characters",
+			IdempotencyKeyMetadata,
+			maxIdempotencyKeyLength,
+		)
+	}
+
+	var (
+		od       outputData
+		replayed bool
+		err      error
 	)
-	if err != nil {
+
+	if key == "" {
+		od, err = c.interactor.create(
+			ctx,
+			input,
+		)
+	} else {
+		od, replayed, err = c.interactor.createIdempotent(
+			ctx,
+			key,
+			input,
+		)
+	}
+
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused):
+		c.logger.InfofContext(ctx, "idempotency key %q: %v", key, err)
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, idempotency.ErrInProgress):
+		c.logger.InfofContext(ctx, "idempotency key %q: %v", key, err)
+		return nil, status.Error(codes.Aborted, err.Error())
+	case err != nil:
 		c.logger.ErrorContext(ctx, err)
 		return nil, err
 	}
 
+	if replayed {
+		// Fails only if called outside of a gRPC handler (e.g., in tests).
+		_ = grpc.SetHeader(ctx, metadata.Pairs("idempotent-replayed", "true"))
+	}
+
 	vm := c.presenter.viewModel(od)
 
 	return &user.UserResponse{
@@ -65,5 +118,139 @@ func (c *grpcController) Create(
 		FirstName: vm.FirstName,
 		LastName:  vm.LastName,
 		CreatedAt: vm.CreatedAt,
+		Version:   vm.Version,
 	}, nil
 }
+
+// CreateBatch receives users until the client closes the stream, creating
+// them in batches of up to maxBatchSize, and returns a result for each user
+// keyed by the order in which it was received. Batches created before an
+// error occurs are not rolled back. Results are held until the stream is
+// closed, so CreateBatchStream is better suited to large imports.
+func (c *grpcController) CreateBatch(
+	stream user.User_CreateBatchServer,
+) error {
+	var results []*user.CreateBatchResult
+
+	err := c.createBatches(
+		stream,
+		func(res []*user.CreateBatchResult) error {
+			results = append(results, res...)
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(&user.CreateBatchResponse{
+		Results: results,
+	})
+}
+
+// CreateBatchStream is CreateBatch, but sends a response with the results
+// for each batch as soon as the batch has been created, so that results are
+// not held for the whole stream, and clients know which users were created
+// if an error occurs part way through.
+func (c *grpcController) CreateBatchStream(
+	stream user.User_CreateBatchStreamServer,
+) error {
+	return c.createBatches(
+		stream,
+		func(results []*user.CreateBatchResult) error {
+			return stream.Send(&user.CreateBatchResponse{
+				Results: results,
+			})
+		},
+	)
+}
+
+type createRequestReceiver interface {
+	Context() context.Context
+	Recv() (*user.CreateRequest, error)
+}
+
+// createBatches receives users until the client closes the stream, creating
+// them in batches of up to maxBatchSize, and passes the results for each
+// batch, keyed by the order in which users were received, to send once the
+// batch has been created.
+func (c *grpcController) createBatches(
+	stream createRequestReceiver,
+	send func([]*user.CreateBatchResult) error,
+) error {
+	ctx := stream.Context()
+
+	var (
+		received int
+		inputs   []InputData
+	)
+
+	flush := func() error {
+		res, err := createBatch(
+			ctx,
+			c.validator,
+			c.interactor,
+			c.presenter,
+			received-len(inputs),
+			inputs,
+		)
+		if err != nil {
+			c.logger.ErrorContext(ctx, err)
+			return err
+		}
+
+		results := make([]*user.CreateBatchResult, 0, len(res))
+
+		for _, r := range res {
+			br := &user.CreateBatchResult{
+				Index:  int32(r.Index),
+				Errors: r.Errors,
+			}
+
+			if r.ViewModel != nil {
+				br.User = &user.UserResponse{
+					Id:        r.ViewModel.ID,
+					FirstName: r.ViewModel.FirstName,
+					LastName:  r.ViewModel.LastName,
+					CreatedAt: r.ViewModel.CreatedAt,
+					Version:   r.ViewModel.Version,
+				}
+			}
+
+			results = append(results, br)
+		}
+
+		inputs = inputs[:0]
+
+		return send(results)
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		received++
+
+		inputs = append(inputs, InputData{
+			FirstName: req.FirstName,
+			LastName:  req.LastName,
+		})
+
+		if len(inputs) == maxBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(inputs) > 0 {
+		return flush()
+	}
+
+	return nil
+}
@@ -2,13 +2,51 @@ package create
 
 import (
 	"context"
+	"errors"
+	"io"
 	"testing"
 
 	pb "github.com/bendbennett/go-api-demo/generated"
 	"github.com/bendbennett/go-api-demo/internal/validate"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 )
 
+// createBatchServerMock implements both pb.User_CreateBatchServer and
+// pb.User_CreateBatchStreamServer.
+type createBatchServerMock struct {
+	grpc.ServerStream
+	requests  []*pb.CreateRequest
+	responses []*pb.CreateBatchResponse
+	closed    bool
+}
+
+func (m *createBatchServerMock) Context() context.Context {
+	return context.Background()
+}
+
+func (m *createBatchServerMock) Recv() (*pb.CreateRequest, error) {
+	if len(m.requests) == 0 {
+		return nil, io.EOF
+	}
+
+	req := m.requests[0]
+	m.requests = m.requests[1:]
+
+	return req, nil
+}
+
+func (m *createBatchServerMock) Send(resp *pb.CreateBatchResponse) error {
+	m.responses = append(m.responses, resp)
+	return nil
+}
+
+func (m *createBatchServerMock) SendAndClose(resp *pb.CreateBatchResponse) error {
+	m.responses = append(m.responses, resp)
+	m.closed = true
+	return nil
+}
+
 func TestGRPC_Create(t *testing.T) {
 	cases := []struct {
 		name             string
@@ -56,6 +94,7 @@ func TestGRPC_Create(t *testing.T) {
 				FirstName: "john",
 				LastName:  "smith",
 				CreatedAt: "2006-01-02T15:04:05-0700",
+				Version:   1,
 			},
 			false,
 		},
@@ -82,3 +121,169 @@ func TestGRPC_Create(t *testing.T) {
 		})
 	}
 }
+
+// createBatchRequests returns maxBatchSize valid requests followed by an
+// invalid request and a valid request.
+func createBatchRequests() []*pb.CreateRequest {
+	var requests []*pb.CreateRequest
+
+	for n := 0; n < maxBatchSize; n++ {
+		requests = append(requests, &pb.CreateRequest{FirstName: "john", LastName: "smith"})
+	}
+
+	return append(
+		requests,
+		&pb.CreateRequest{FirstName: "ab", LastName: "smith"},
+		&pb.CreateRequest{FirstName: "joanna", LastName: "smithson"},
+	)
+}
+
+// assertCreateBatchResults asserts the results for createBatchRequests.
+func assertCreateBatchResults(t *testing.T, results []*pb.CreateBatchResult) {
+	t.Helper()
+
+	assert.Len(t, results, maxBatchSize+2)
+
+	for n, r := range results {
+		assert.Equal(t, int32(n), r.Index)
+	}
+
+	assert.Equal(t, "john", results[0].User.Id)
+	assert.Nil(t, results[maxBatchSize].User)
+	assert.Equal(
+		t,
+		map[string]string{"first_name": "first_name must be at least 3 characters in length"},
+		results[maxBatchSize].Errors,
+	)
+	assert.Equal(t, &pb.UserResponse{
+		Id:        "joanna",
+		FirstName: "joanna",
+		LastName:  "smithson",
+		CreatedAt: "2021-12-14T20:00:13Z",
+		Version:   1,
+	}, results[maxBatchSize+1].User)
+}
+
+func TestGRPC_CreateBatch(t *testing.T) {
+	validator, err := validate.NewValidator()
+	assert.NoError(t, err)
+
+	interactor := &interactorMock{}
+
+	controller := NewGRPCController(
+		validator,
+		interactor,
+		NewPresenter(),
+		loggerMock{},
+	)
+
+	stream := &createBatchServerMock{
+		requests: createBatchRequests(),
+	}
+
+	assert.NoError(t, controller.CreateBatch(stream))
+
+	// Users are created in batches of up to maxBatchSize, with a single
+	// response holding the results for every user.
+	assert.Equal(t, []int{maxBatchSize, 1}, interactor.batchSizes)
+	assert.True(t, stream.closed)
+	assert.Len(t, stream.responses, 1)
+
+	assertCreateBatchResults(t, stream.responses[0].Results)
+}
+
+func TestGRPC_CreateBatch_Error(t *testing.T) {
+	controller := NewGRPCController(
+		&validatorMock{},
+		&interactorMockError{},
+		&presenterMock{},
+		loggerMock{},
+	)
+
+	stream := &createBatchServerMock{
+		requests: []*pb.CreateRequest{{FirstName: "john", LastName: "smith"}},
+	}
+
+	err := controller.CreateBatch(stream)
+
+	assert.EqualError(t, err, "interactor create batch error")
+	assert.Empty(t, stream.responses)
+}
+
+func TestGRPC_CreateBatchStream(t *testing.T) {
+	validator, err := validate.NewValidator()
+	assert.NoError(t, err)
+
+	interactor := &interactorMock{}
+
+	controller := NewGRPCController(
+		validator,
+		interactor,
+		NewPresenter(),
+		loggerMock{},
+	)
+
+	stream := &createBatchServerMock{
+		requests: createBatchRequests(),
+	}
+
+	assert.NoError(t, controller.CreateBatchStream(stream))
+
+	// Users are created in batches of up to maxBatchSize, with a response
+	// sent for each batch.
+	assert.Equal(t, []int{maxBatchSize, 1}, interactor.batchSizes)
+	assert.False(t, stream.closed)
+	assert.Len(t, stream.responses, 2)
+	assert.Len(t, stream.responses[0].Results, maxBatchSize)
+
+	var results []*pb.CreateBatchResult
+
+	for _, resp := range stream.responses {
+		results = append(results, resp.Results...)
+	}
+
+	assertCreateBatchResults(t, results)
+}
+
+// interactorMockErrorAfter fails to create batches once n have been created.
+type interactorMockErrorAfter struct {
+	interactorMock
+	n int
+}
+
+func (m *interactorMockErrorAfter) createBatch(ctx context.Context, inputs []InputData) ([]outputData, error) {
+	if len(m.batchSizes) == m.n {
+		return nil, errors.New("interactor create batch error")
+	}
+
+	return m.interactorMock.createBatch(ctx, inputs)
+}
+
+func TestGRPC_CreateBatchStream_ErrorAfterBatchCreated(t *testing.T) {
+	validator, err := validate.NewValidator()
+	assert.NoError(t, err)
+
+	var requests []*pb.CreateRequest
+
+	for n := 0; n < maxBatchSize+1; n++ {
+		requests = append(requests, &pb.CreateRequest{FirstName: "john", LastName: "smith"})
+	}
+
+	controller := NewGRPCController(
+		validator,
+		&interactorMockErrorAfter{n: 1},
+		NewPresenter(),
+		loggerMock{},
+	)
+
+	stream := &createBatchServerMock{
+		requests: requests,
+	}
+
+	err = controller.CreateBatchStream(stream)
+	assert.EqualError(t, err, "interactor create batch error")
+
+	// The results of the batch created before the error have been sent.
+	assert.Len(t, stream.responses, 1)
+	assert.Len(t, stream.responses[0].Results, maxBatchSize)
+}
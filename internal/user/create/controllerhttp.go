@@ -2,8 +2,11 @@ package create
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
 	"github.com/bendbennett/go-api-demo/internal/log"
 	"github.com/bendbennett/go-api-demo/internal/response"
 	"github.com/bendbennett/go-api-demo/internal/validate"
@@ -18,6 +21,7 @@ type httpController struct {
 
 type HTTPController interface {
 	Create(w http.ResponseWriter, r *http.Request)
+	CreateBatch(w http.ResponseWriter, r *http.Request)
 }
 
 func NewHTTPController(
@@ -39,7 +43,18 @@ func (c *httpController) Create(
 	r *http.Request,
 ) {
 	ctx := r.Context()
-	input := inputData{}
+	input := InputData{}
+
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{IdempotencyKeyHeader: fmt.Sprintf("must be at most %d characters", maxIdempotencyKeyLength)},
+		)
+		return
+	}
 
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
@@ -65,9 +80,116 @@ func (c *httpController) Create(
 		return
 	}
 
-	od, err := c.interactor.create(
+	var (
+		od       outputData
+		replayed bool
+	)
+
+	if key == "" {
+		od, err = c.interactor.create(
+			ctx,
+			input,
+		)
+	} else {
+		od, replayed, err = c.interactor.createIdempotent(
+			ctx,
+			key,
+			input,
+		)
+	}
+
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused),
+		errors.Is(err, idempotency.ErrInProgress):
+		c.logger.InfofContext(ctx, "idempotency key %q: %v", key, err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusConflict,
+			err.Error(),
+			nil,
+		)
+		return
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+
+	vm := c.presenter.viewModel(od)
+
+	response.WriteResponse(
+		w,
+		http.StatusCreated,
+		output(vm),
+	)
+}
+
+type output struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+	Version   int64  `json:"version"`
+}
+
+type batchInput struct {
+	Users []InputData `json:"users"`
+}
+
+type batchOutput struct {
+	Results []batchResultOutput `json:"results"`
+}
+
+type batchResultOutput struct {
+	User   *output           `json:"user,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+	Index  int               `json:"index"`
+}
+
+// CreateBatch creates the valid users in the request body, and returns a
+// result for each user, keyed by its index in the request. The response
+// status is 201 if all users are created, and 207 if any users fail
+// validation.
+func (c *httpController) CreateBatch(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+	input := batchInput{}
+
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		c.logger.ErrorfContext(ctx, "json body invalid: %v", err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{"body": "json invalid"},
+		)
+		return
+	}
+
+	if len(input.Users) == 0 || len(input.Users) > maxBatchSize {
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{"users": fmt.Sprintf("must contain between 1 and %d users", maxBatchSize)},
+		)
+		return
+	}
+
+	results, err := createBatch(
 		ctx,
-		input,
+		c.validator,
+		c.interactor,
+		c.presenter,
+		0,
+		input.Users,
 	)
 	if err != nil {
 		c.logger.ErrorContext(ctx, err)
@@ -75,18 +197,31 @@ func (c *httpController) Create(
 		return
 	}
 
-	vm := c.presenter.viewModel(od)
+	statusCode := http.StatusCreated
 
-	type output struct {
-		ID        string `json:"id"`
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		CreatedAt string `json:"created_at"`
+	out := batchOutput{
+		Results: make([]batchResultOutput, 0, len(results)),
+	}
+
+	for _, res := range results {
+		ro := batchResultOutput{
+			Errors: res.Errors,
+			Index:  res.Index,
+		}
+
+		if res.ViewModel != nil {
+			o := output(*res.ViewModel)
+			ro.User = &o
+		} else {
+			statusCode = http.StatusMultiStatus
+		}
+
+		out.Results = append(out.Results, ro)
 	}
 
 	response.WriteResponse(
 		w,
-		http.StatusCreated,
-		output(vm),
+		statusCode,
+		out,
 	)
 }
@@ -10,7 +10,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
 	"github.com/bendbennett/go-api-demo/internal/validate"
 	"github.com/stretchr/testify/assert"
 )
@@ -30,19 +32,62 @@ func (m *validatorMockInputInvalid) ValidateStruct(input interface{}) map[string
 }
 
 type interactorMock struct {
+	batchSizes []int
 }
 
-func (m *interactorMock) create(context.Context, inputData) (outputData, error) {
+func (m *interactorMock) create(context.Context, InputData) (outputData, error) {
 	return outputData{}, nil
 }
 
+// createIdempotent reports the output data as replayed when the key is
+// "replayed".
+func (m *interactorMock) createIdempotent(_ context.Context, key string, _ InputData) (outputData, bool, error) {
+	return outputData{}, key == "replayed", nil
+}
+
+// createBatch returns output data with the first name of each user as
+// its ID, recording the size of each batch.
+func (m *interactorMock) createBatch(_ context.Context, inputs []InputData) ([]outputData, error) {
+	m.batchSizes = append(m.batchSizes, len(inputs))
+
+	od := make([]outputData, 0, len(inputs))
+
+	for _, in := range inputs {
+		od = append(od, outputData{
+			CreatedAt: createdAt(),
+			Version:   1,
+			ID:        in.FirstName,
+			FirstName: in.FirstName,
+			LastName:  in.LastName,
+		})
+	}
+
+	return od, nil
+}
+
 type interactorMockError struct {
 }
 
-func (m *interactorMockError) create(context.Context, inputData) (outputData, error) {
+func (m *interactorMockError) create(context.Context, InputData) (outputData, error) {
 	return outputData{}, errors.New("interactor create error")
 }
 
+func (m *interactorMockError) createIdempotent(context.Context, string, InputData) (outputData, bool, error) {
+	return outputData{}, false, errors.New("interactor create idempotent error")
+}
+
+type interactorMockKeyReused struct {
+	interactorMock
+}
+
+func (m *interactorMockKeyReused) createIdempotent(context.Context, string, InputData) (outputData, bool, error) {
+	return outputData{}, false, idempotency.ErrKeyReused
+}
+
+func (m *interactorMockError) createBatch(context.Context, []InputData) ([]outputData, error) {
+	return nil, errors.New("interactor create batch error")
+}
+
 type presenterMock struct {
 }
 
@@ -52,6 +97,7 @@ func (pm *presenterMock) viewModel(outputData) viewModel {
 		FirstName: "john",
 		LastName:  "smith",
 		CreatedAt: "2006-01-02T15:04:05-0700",
+		Version:   1,
 	}
 }
 
@@ -127,7 +173,8 @@ func TestRest_Create(t *testing.T) {
 									"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 									"first_name": "john",
 									"last_name": "smith",
-									"created_at": "2006-01-02T15:04:05-0700"
+									"created_at": "2006-01-02T15:04:05-0700",
+									"version": 1
 								}`,
 		},
 	}
@@ -155,3 +202,219 @@ func TestRest_Create(t *testing.T) {
 		})
 	}
 }
+
+func TestRest_CreateIdempotent(t *testing.T) {
+	cases := []struct {
+		name             string
+		interactor       interactor
+		key              string
+		expectedStatus   int
+		expectedReplayed string
+	}{
+		{
+			"key too long",
+			&interactorMock{},
+			strings.Repeat("k", maxIdempotencyKeyLength+1),
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"key reused",
+			&interactorMockKeyReused{},
+			"key",
+			http.StatusConflict,
+			"",
+		},
+		{
+			"interactor create idempotent error",
+			&interactorMockError{},
+			"key",
+			http.StatusInternalServerError,
+			"",
+		},
+		{
+			"created",
+			&interactorMock{},
+			"key",
+			http.StatusCreated,
+			"",
+		},
+		{
+			"replayed",
+			&interactorMock{},
+			"replayed",
+			http.StatusCreated,
+			"true",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(
+				http.MethodPost,
+				"/user",
+				strings.NewReader(`{"first_name": "john", "last_name": "smith"}`),
+			)
+			r.Header.Set(IdempotencyKeyHeader, c.key)
+			w := httptest.NewRecorder()
+
+			controller := NewHTTPController(
+				&validatorMock{},
+				c.interactor,
+				&presenterMock{},
+				loggerMock{},
+			)
+
+			controller.Create(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.Equal(t, c.expectedReplayed, w.Header().Get("Idempotent-Replayed"))
+		})
+	}
+}
+
+func createdAt() time.Time {
+	createdAt, _ := time.Parse(time.RFC3339, "2021-12-14T20:00:13Z")
+
+	return createdAt
+}
+
+func TestRest_CreateBatch(t *testing.T) {
+	cases := []struct {
+		name                 string
+		interactor           interactor
+		body                 io.Reader
+		expectedStatus       int
+		expectedResponseBody string
+	}{
+		{
+			"json unmarshall error",
+			&interactorMock{},
+			strings.NewReader(`{"users":`),
+			http.StatusBadRequest,
+			`{
+				"message": "failed validation",
+				"errors": {
+					"body": "json invalid"
+				}
+			}`,
+		},
+		{
+			"no users",
+			&interactorMock{},
+			strings.NewReader(`{"users": []}`),
+			http.StatusBadRequest,
+			`{
+				"message": "failed validation",
+				"errors": {
+					"users": "must contain between 1 and 1000 users"
+				}
+			}`,
+		},
+		{
+			"too many users",
+			&interactorMock{},
+			strings.NewReader(`{"users": [` + strings.Repeat(`{"first_name": "john", "last_name": "smith"},`, maxBatchSize) + `{}]}`),
+			http.StatusBadRequest,
+			`{
+				"message": "failed validation",
+				"errors": {
+					"users": "must contain between 1 and 1000 users"
+				}
+			}`,
+		},
+		{
+			"interactor create batch error",
+			&interactorMockError{},
+			strings.NewReader(`{"users": [{"first_name": "john", "last_name": "smith"}]}`),
+			http.StatusInternalServerError,
+			`{
+				"message": "internal server error"
+			}`,
+		},
+		{
+			"all created",
+			&interactorMock{},
+			strings.NewReader(`{"users": [{"first_name": "john", "last_name": "smith"}, {"first_name": "joanna", "last_name": "smithson"}]}`),
+			http.StatusCreated,
+			`{
+				"results": [
+					{
+						"index": 0,
+						"user": {
+							"id": "john",
+							"first_name": "john",
+							"last_name": "smith",
+							"created_at": "2021-12-14T20:00:13Z",
+							"version": 1
+						}
+					},
+					{
+						"index": 1,
+						"user": {
+							"id": "joanna",
+							"first_name": "joanna",
+							"last_name": "smithson",
+							"created_at": "2021-12-14T20:00:13Z",
+							"version": 1
+						}
+					}
+				]
+			}`,
+		},
+		{
+			"some invalid",
+			&interactorMock{},
+			strings.NewReader(`{"users": [{"first_name": "ab", "last_name": "smith"}, {"first_name": "joanna", "last_name": "smithson"}, {"first_name": "john"}]}`),
+			http.StatusMultiStatus,
+			`{
+				"results": [
+					{
+						"index": 0,
+						"errors": {
+							"first_name": "first_name must be at least 3 characters in length"
+						}
+					},
+					{
+						"index": 1,
+						"user": {
+							"id": "joanna",
+							"first_name": "joanna",
+							"last_name": "smithson",
+							"created_at": "2021-12-14T20:00:13Z",
+							"version": 1
+						}
+					},
+					{
+						"index": 2,
+						"errors": {
+							"last_name": "last_name is a required field"
+						}
+					}
+				]
+			}`,
+		},
+	}
+
+	validator, err := validate.NewValidator()
+	assert.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/users/batch", c.body)
+			w := httptest.NewRecorder()
+
+			controller := NewHTTPController(
+				validator,
+				c.interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			controller.CreateBatch(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.JSONEq(t, c.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -4,30 +4,39 @@ import (
 	"context"
 	"time"
 
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	"github.com/google/uuid"
 )
 
 type i struct {
-	userCreator user.Creator
+	userCreator      user.Creator
+	idempotencyStore idempotency.Store
 }
 
 type interactor interface {
-	create(context.Context, inputData) (outputData, error)
+	create(context.Context, InputData) (outputData, error)
+	createIdempotent(ctx context.Context, key string, in InputData) (outputData, bool, error)
+	createBatch(context.Context, []InputData) ([]outputData, error)
 }
 
 var _ interactor = (*i)(nil)
 
 func NewInteractor(
 	userCreator user.Creator,
+	idempotencyStore idempotency.Store,
 ) *i {
 	return &i{
 		userCreator,
+		idempotencyStore,
 	}
 }
 
 type outputData struct {
 	CreatedAt time.Time
+	Version   int64
 	ID        string
 	FirstName string
 	LastName  string
@@ -35,13 +44,14 @@ type outputData struct {
 
 func (i *i) create(
 	ctx context.Context,
-	inputData inputData,
+	in InputData,
 ) (outputData, error) {
 	u := user.User{
 		ID:        uuid.New().String(),
-		FirstName: inputData.FirstName,
-		LastName:  inputData.LastName,
+		FirstName: in.FirstName,
+		LastName:  in.LastName,
 		CreatedAt: time.Now(),
+		Version:   1,
 	}
 
 	err := i.userCreator.Create(
@@ -52,10 +62,76 @@ func (i *i) create(
 		return outputData{}, err
 	}
 
+	audit.AddTargets(ctx, u.ID)
+
 	return outputData{
 		ID:        u.ID,
 		FirstName: u.FirstName,
 		LastName:  u.LastName,
 		CreatedAt: u.CreatedAt,
+		Version:   u.Version,
 	}, nil
 }
+
+// createIdempotent creates a user unless key has already been used to
+// create a user from the same input, in which case the output from the
+// original request is returned together with true. Keys are scoped to the
+// tenant so that tenants cannot replay each other's responses.
+func (i *i) createIdempotent(
+	ctx context.Context,
+	key string,
+	in InputData,
+) (outputData, bool, error) {
+	return idempotency.Do(
+		ctx,
+		i.idempotencyStore,
+		"tenant:"+tenant.ID(ctx)+":user.create:"+key,
+		in,
+		func() (outputData, error) {
+			return i.create(ctx, in)
+		},
+	)
+}
+
+// createBatch creates all users in a single call to the user creator, so
+// either all or none are created.
+func (i *i) createBatch(
+	ctx context.Context,
+	inputs []InputData,
+) ([]outputData, error) {
+	users := make([]user.User, 0, len(inputs))
+
+	for _, in := range inputs {
+		users = append(users, user.User{
+			ID:        uuid.New().String(),
+			FirstName: in.FirstName,
+			LastName:  in.LastName,
+			CreatedAt: time.Now(),
+			Version:   1,
+		})
+	}
+
+	err := i.userCreator.Create(
+		ctx,
+		users...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	od := make([]outputData, 0, len(users))
+
+	for _, u := range users {
+		audit.AddTargets(ctx, u.ID)
+
+		od = append(od, outputData{
+			ID:        u.ID,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			CreatedAt: u.CreatedAt,
+			Version:   u.Version,
+		})
+	}
+
+	return od, nil
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/bendbennett/go-api-demo/internal/idempotency"
+	"github.com/bendbennett/go-api-demo/internal/storage/memory"
 	"github.com/bendbennett/go-api-demo/internal/user"
 	"github.com/bendbennett/go-api-demo/internal/validate"
 	"github.com/stretchr/testify/assert"
@@ -18,9 +21,11 @@ func (m *creatorMockError) Create(context.Context, ...user.User) error {
 }
 
 type creatorMock struct {
+	users []user.User
 }
 
-func (m *creatorMock) Create(context.Context, ...user.User) error {
+func (m *creatorMock) Create(_ context.Context, users ...user.User) error {
+	m.users = append(m.users, users...)
 	return nil
 }
 
@@ -52,10 +57,11 @@ func TestInteractor_Create(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			interactor := NewInteractor(
 				c.creator,
+				memory.NewIdempotencyStore(time.Hour, time.Minute),
 			)
 			od, err := interactor.create(
 				context.Background(),
-				inputData{
+				InputData{
 					FirstName: "john",
 					LastName:  "smith",
 				},
@@ -76,3 +82,74 @@ func TestInteractor_Create(t *testing.T) {
 		})
 	}
 }
+
+func TestInteractor_CreateBatch(t *testing.T) {
+	inputs := []InputData{
+		{
+			FirstName: "john",
+			LastName:  "smith",
+		},
+		{
+			FirstName: "joanna",
+			LastName:  "smithson",
+		},
+	}
+
+	t.Run("creator returns error", func(t *testing.T) {
+		interactor := NewInteractor(&creatorMockError{}, memory.NewIdempotencyStore(time.Hour, time.Minute))
+
+		od, err := interactor.createBatch(context.Background(), inputs)
+
+		assert.Error(t, err)
+		assert.Nil(t, od)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		creator := &creatorMock{}
+		interactor := NewInteractor(creator, memory.NewIdempotencyStore(time.Hour, time.Minute))
+
+		od, err := interactor.createBatch(context.Background(), inputs)
+
+		assert.NoError(t, err)
+		assert.Len(t, od, 2)
+		assert.Len(t, creator.users, 2)
+
+		for n, o := range od {
+			assert.True(t, validate.IsUUID(o.ID))
+			assert.Equal(t, creator.users[n].ID, o.ID)
+			assert.Equal(t, inputs[n].FirstName, o.FirstName)
+			assert.Equal(t, inputs[n].LastName, o.LastName)
+		}
+	})
+}
+
+func TestInteractor_CreateIdempotent(t *testing.T) {
+	creator := &creatorMock{}
+	interactor := NewInteractor(creator, memory.NewIdempotencyStore(time.Hour, time.Minute))
+
+	in := InputData{
+		FirstName: "john",
+		LastName:  "smith",
+	}
+
+	od, replayed, err := interactor.createIdempotent(context.Background(), "key", in)
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+
+	replay, replayed, err := interactor.createIdempotent(context.Background(), "key", in)
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, od.ID, replay.ID)
+	assert.True(t, od.CreatedAt.Equal(replay.CreatedAt))
+	assert.Len(t, creator.users, 1)
+
+	_, _, err = interactor.createIdempotent(
+		context.Background(),
+		"key",
+		InputData{
+			FirstName: "joanna",
+			LastName:  "smith",
+		},
+	)
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+}
@@ -20,6 +20,7 @@ type viewModel struct {
 	FirstName string
 	LastName  string
 	CreatedAt string
+	Version   int64
 }
 
 func (p *p) viewModel(od outputData) viewModel {
@@ -28,5 +29,6 @@ func (p *p) viewModel(od outputData) viewModel {
 		FirstName: od.FirstName,
 		LastName:  od.LastName,
 		CreatedAt: od.CreatedAt.Format(time.RFC3339),
+		Version:   od.Version,
 	}
 }
@@ -0,0 +1,183 @@
+package gdpr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/gorilla/mux"
+)
+
+type httpController struct {
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Export(w http.ResponseWriter, r *http.Request)
+	Erase(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+type exportUser struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+	DeletedAt string `json:"deleted_at,omitempty"`
+	Version   int64  `json:"version"`
+}
+
+type exportEvent struct {
+	Before     *exportUser `json:"before"`
+	After      *exportUser `json:"after"`
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt string      `json:"occurred_at"`
+}
+
+type exportStore struct {
+	User   *exportUser   `json:"user"`
+	Store  string        `json:"store"`
+	Events []exportEvent `json:"events"`
+}
+
+type exportOutput struct {
+	ID         string        `json:"id"`
+	ExportedAt string        `json:"exported_at"`
+	Stores     []exportStore `json:"stores"`
+}
+
+type eraseOutcome struct {
+	Store    string `json:"store"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+type eraseOutput struct {
+	ID       string         `json:"id"`
+	ErasedAt string         `json:"erased_at"`
+	Stores   []eraseOutcome `json:"stores"`
+	Complete bool           `json:"complete"`
+}
+
+// Export responds with the data held about the user by each store.
+func (c *httpController) Export(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	od, err := c.interactor.export(
+		ctx,
+		mux.Vars(r)["id"],
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		response.WriteErrorResponse(
+			w,
+			http.StatusNotFound,
+			err.Error(),
+			nil,
+		)
+		return
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	vm := c.presenter.exportViewModel(od)
+
+	stores := make([]exportStore, 0, len(vm.Stores))
+
+	for _, s := range vm.Stores {
+		events := make([]exportEvent, 0, len(s.Events))
+
+		for _, e := range s.Events {
+			events = append(events, exportEvent{
+				Before:     (*exportUser)(e.Before),
+				After:      (*exportUser)(e.After),
+				ID:         e.ID,
+				Type:       e.Type,
+				OccurredAt: e.OccurredAt,
+			})
+		}
+
+		stores = append(stores, exportStore{
+			User:   (*exportUser)(s.User),
+			Store:  s.Store,
+			Events: events,
+		})
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		exportOutput{
+			ID:         vm.ID,
+			ExportedAt: vm.ExportedAt,
+			Stores:     stores,
+		},
+	)
+}
+
+// Erase erases the user from every store and responds with the outcome
+// for each store. The status is 500 if erasure from any store failed, in
+// which case the request can be repeated.
+func (c *httpController) Erase(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	od := c.interactor.erase(
+		ctx,
+		mux.Vars(r)["id"],
+	)
+
+	vm := c.presenter.eraseViewModel(od)
+
+	stores := make([]eraseOutcome, 0, len(vm.Stores))
+
+	for _, s := range vm.Stores {
+		if s.Status == statusFailed {
+			c.logger.ErrorfContext(ctx, "erase %s from %s: %s", vm.ID, s.Store, s.Error)
+		}
+
+		stores = append(stores, eraseOutcome(s))
+	}
+
+	status := http.StatusOK
+
+	if !vm.Complete {
+		status = http.StatusInternalServerError
+	}
+
+	response.WriteResponse(
+		w,
+		status,
+		eraseOutput{
+			ID:       vm.ID,
+			ErasedAt: vm.ErasedAt,
+			Stores:   stores,
+			Complete: vm.Complete,
+		},
+	)
+}
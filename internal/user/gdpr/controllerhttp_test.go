@@ -0,0 +1,262 @@
+package gdpr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type interactorMock struct {
+	err      error
+	outcomes []outcome
+}
+
+func (m *interactorMock) export(_ context.Context, id string) (exportOutputData, error) {
+	if m.err != nil {
+		return exportOutputData{}, m.err
+	}
+
+	deletedAt := createdAt()
+
+	return exportOutputData{
+		ExportedAt: createdAt(),
+		ID:         id,
+		Stores: []storeData{
+			{
+				Name: "mysql",
+				Data: user.SubjectData{
+					User: &user.User{
+						CreatedAt: createdAt(),
+						DeletedAt: &deletedAt,
+						ID:        id,
+						FirstName: "john",
+						LastName:  "smith",
+						Version:   2,
+					},
+					Events: []user.Event{
+						{
+							OccurredAt: createdAt(),
+							After: &user.User{
+								CreatedAt: createdAt(),
+								ID:        id,
+								FirstName: "john",
+								LastName:  "smith",
+								Version:   1,
+							},
+							ID:   "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+							Type: user.EventCreated,
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (m *interactorMock) erase(_ context.Context, id string) eraseOutputData {
+	return eraseOutputData{
+		ErasedAt: createdAt(),
+		ID:       id,
+		Outcomes: m.outcomes,
+	}
+}
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+func TestRest_Export(t *testing.T) {
+	cases := []struct {
+		name                 string
+		interactor           interactor
+		expectedStatus       int
+		expectedResponseBody string
+	}{
+		{
+			"not found",
+			&interactorMock{err: user.ErrNotFound},
+			http.StatusNotFound,
+			`{
+									"message": "user not found"
+								}`,
+		},
+		{
+			"interactor export error",
+			&interactorMock{err: errors.New("interactor export error")},
+			http.StatusInternalServerError,
+			`{
+									"message": "internal server error"
+								}`,
+		},
+		{
+			"success",
+			&interactorMock{},
+			http.StatusOK,
+			`{
+									"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+									"exported_at": "2021-12-14T20:00:13Z",
+									"stores": [
+										{
+											"store": "mysql",
+											"user": {
+												"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+												"first_name": "john",
+												"last_name": "smith",
+												"created_at": "2021-12-14T20:00:13Z",
+												"deleted_at": "2021-12-14T20:00:13Z",
+												"version": 2
+											},
+											"events": [
+												{
+													"id": "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+													"type": "user.created",
+													"occurred_at": "2021-12-14T20:00:13Z",
+													"before": null,
+													"after": {
+														"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+														"first_name": "john",
+														"last_name": "smith",
+														"created_at": "2021-12-14T20:00:13Z",
+														"version": 1
+													}
+												}
+											]
+										}
+									]
+								}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/admin/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8/export", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8"})
+			w := httptest.NewRecorder()
+
+			controller := NewHTTPController(
+				c.interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			controller.Export(w, r)
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
+
+func TestRest_Erase(t *testing.T) {
+	cases := []struct {
+		name                 string
+		interactor           interactor
+		expectedStatus       int
+		expectedResponseBody string
+	}{
+		{
+			"incomplete",
+			&interactorMock{
+				outcomes: []outcome{
+					{Store: "mysql", Status: statusErased, Verified: true},
+					{Store: "redis", Status: statusFailed, Err: errors.New("erase error")},
+				},
+			},
+			http.StatusInternalServerError,
+			`{
+									"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+									"erased_at": "2021-12-14T20:00:13Z",
+									"complete": false,
+									"stores": [
+										{
+											"store": "mysql",
+											"status": "erased",
+											"verified": true
+										},
+										{
+											"store": "redis",
+											"status": "failed",
+											"error": "erase error",
+											"verified": false
+										}
+									]
+								}`,
+		},
+		{
+			"complete",
+			&interactorMock{
+				outcomes: []outcome{
+					{Store: "mysql", Status: statusErased, Verified: true},
+					{Store: "kafka", Status: statusErased},
+					{Store: "logs", Status: statusSkipped},
+				},
+			},
+			http.StatusOK,
+			`{
+									"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+									"erased_at": "2021-12-14T20:00:13Z",
+									"complete": true,
+									"stores": [
+										{
+											"store": "mysql",
+											"status": "erased",
+											"verified": true
+										},
+										{
+											"store": "kafka",
+											"status": "erased",
+											"verified": false
+										},
+										{
+											"store": "logs",
+											"status": "skipped",
+											"verified": false
+										}
+									]
+								}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/admin/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8/erase", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8"})
+			w := httptest.NewRecorder()
+
+			controller := NewHTTPController(
+				c.interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			controller.Erase(w, r)
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package gdpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+// Outcomes of erasure for each store.
+const (
+	statusErased  = "erased"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// Store is a named store that holds personal data about users. Exporter
+// is nil for stores that cannot be read by user (e.g., Kafka topics), and
+// Eraser is nil for stores from which data is only removed by retention
+// (e.g., logs).
+type Store struct {
+	Exporter user.SubjectExporter
+	Eraser   user.SubjectEraser
+	Name     string
+}
+
+type i struct {
+	stores []Store
+}
+
+type interactor interface {
+	export(ctx context.Context, id string) (exportOutputData, error)
+	erase(ctx context.Context, id string) eraseOutputData
+}
+
+var _ interactor = (*i)(nil)
+
+// NewInteractor returns an interactor that exports from, and erases from,
+// stores in the order given.
+func NewInteractor(
+	stores ...Store,
+) *i {
+	return &i{
+		stores,
+	}
+}
+
+type exportOutputData struct {
+	ExportedAt time.Time
+	ID         string
+	Stores     []storeData
+}
+
+type storeData struct {
+	Name string
+	Data user.SubjectData
+}
+
+type eraseOutputData struct {
+	ErasedAt time.Time
+	ID       string
+	Outcomes []outcome
+}
+
+type outcome struct {
+	Err      error
+	Store    string
+	Status   string
+	Verified bool
+}
+
+// Complete returns true if no store failed to erase the user.
+func (od eraseOutputData) Complete() bool {
+	for _, o := range od.Outcomes {
+		if o.Status == statusFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// export returns the data held about the user by each store, omitting
+// stores that hold none. user.ErrNotFound is returned if no store holds
+// data about the user, and an error is returned if any store cannot be
+// read, as the export would otherwise be incomplete.
+func (i *i) export(
+	ctx context.Context,
+	id string,
+) (exportOutputData, error) {
+	od := exportOutputData{
+		ExportedAt: time.Now(),
+		ID:         id,
+	}
+
+	for _, s := range i.stores {
+		if s.Exporter == nil {
+			continue
+		}
+
+		data, err := s.Exporter.Export(ctx, id)
+		if errors.Is(err, user.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return exportOutputData{}, fmt.Errorf("%s: %w", s.Name, err)
+		}
+
+		od.Stores = append(od.Stores, storeData{
+			Name: s.Name,
+			Data: data,
+		})
+	}
+
+	if len(od.Stores) == 0 {
+		return exportOutputData{}, user.ErrNotFound
+	}
+
+	return od, nil
+}
+
+// erase erases the user from every store, continuing when a store fails
+// so that as much data as possible is erased, and returns the outcome
+// for each store. Erasure is verified by exporting from the store once
+// erased, for stores that can be exported from. Erasure is idempotent, so
+// a partial erasure can be completed by repeating it.
+func (i *i) erase(
+	ctx context.Context,
+	id string,
+) eraseOutputData {
+	od := eraseOutputData{
+		ErasedAt: time.Now(),
+		ID:       id,
+	}
+
+	for _, s := range i.stores {
+		od.Outcomes = append(od.Outcomes, eraseStore(ctx, s, id))
+	}
+
+	return od
+}
+
+func eraseStore(
+	ctx context.Context,
+	s Store,
+	id string,
+) outcome {
+	o := outcome{
+		Store: s.Name,
+	}
+
+	if s.Eraser == nil {
+		o.Status = statusSkipped
+		return o
+	}
+
+	if err := s.Eraser.Erase(ctx, id); err != nil {
+		o.Status = statusFailed
+		o.Err = err
+		return o
+	}
+
+	o.Status = statusErased
+
+	if s.Exporter == nil {
+		return o
+	}
+
+	_, err := s.Exporter.Export(ctx, id)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		o.Verified = true
+	case err != nil:
+		o.Err = fmt.Errorf("verify: %w", err)
+	default:
+		o.Status = statusFailed
+		o.Err = errors.New("verify: data held after erasure")
+	}
+
+	return o
+}
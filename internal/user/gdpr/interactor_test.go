@@ -0,0 +1,233 @@
+package gdpr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type storeMock struct {
+	users     map[string]user.User
+	exportErr error
+	eraseErr  error
+	retain    bool
+}
+
+func (m *storeMock) Export(_ context.Context, id string) (user.SubjectData, error) {
+	if m.exportErr != nil {
+		return user.SubjectData{}, m.exportErr
+	}
+
+	u, ok := m.users[id]
+	if !ok {
+		return user.SubjectData{}, user.ErrNotFound
+	}
+
+	return user.SubjectData{User: &u}, nil
+}
+
+func (m *storeMock) Erase(_ context.Context, id string) error {
+	if m.eraseErr != nil {
+		return m.eraseErr
+	}
+
+	if !m.retain {
+		delete(m.users, id)
+	}
+
+	return nil
+}
+
+func createdAt() time.Time {
+	createdAt, _ := time.Parse(time.RFC3339, "2021-12-14T20:00:13Z")
+
+	return createdAt
+}
+
+func newStoreMock() *storeMock {
+	return &storeMock{
+		users: map[string]user.User{
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8": {
+				CreatedAt: createdAt(),
+				ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName: "john",
+				LastName:  "smith",
+				Version:   1,
+			},
+		},
+	}
+}
+
+func TestInteractor_Export(t *testing.T) {
+	u := newStoreMock().users["0a81dec3-3638-4eb4-b04a-83d744f5f3a8"]
+
+	cases := []struct {
+		name           string
+		id             string
+		stores         []Store
+		expectedStores []storeData
+		expectedErr    string
+	}{
+		{
+			"not found",
+			"1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			[]Store{
+				{Name: "mysql", Exporter: newStoreMock()},
+			},
+			nil,
+			"user not found",
+		},
+		{
+			"export error",
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			[]Store{
+				{Name: "mysql", Exporter: newStoreMock()},
+				{Name: "redis", Exporter: &storeMock{exportErr: errors.New("export error")}},
+			},
+			nil,
+			"redis: export error",
+		},
+		{
+			"stores without data or exporter are omitted",
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			[]Store{
+				{Name: "mysql", Exporter: newStoreMock()},
+				{Name: "redis", Exporter: &storeMock{}},
+				{Name: "kafka"},
+			},
+			[]storeData{
+				{Name: "mysql", Data: user.SubjectData{User: &u}},
+			},
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := NewInteractor(c.stores...)
+
+			od, err := interactor.export(
+				context.Background(),
+				c.id,
+			)
+
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.id, od.ID)
+			assert.Equal(t, c.expectedStores, od.Stores)
+		})
+	}
+}
+
+func TestInteractor_Erase(t *testing.T) {
+	cases := []struct {
+		name             string
+		stores           []Store
+		expectedOutcomes []outcome
+		expectedComplete bool
+	}{
+		{
+			"erased and verified",
+			func() []Store {
+				s := newStoreMock()
+				return []Store{{Name: "mysql", Exporter: s, Eraser: s}}
+			}(),
+			[]outcome{
+				{Store: "mysql", Status: statusErased, Verified: true},
+			},
+			true,
+		},
+		{
+			"erased without exporter is not verified",
+			[]Store{{Name: "kafka", Eraser: &storeMock{}}},
+			[]outcome{
+				{Store: "kafka", Status: statusErased},
+			},
+			true,
+		},
+		{
+			"store without eraser is skipped",
+			[]Store{{Name: "logs"}},
+			[]outcome{
+				{Store: "logs", Status: statusSkipped},
+			},
+			true,
+		},
+		{
+			"erase error fails and remaining stores are erased",
+			func() []Store {
+				s := newStoreMock()
+				return []Store{
+					{Name: "redis", Eraser: &storeMock{eraseErr: errors.New("erase error")}},
+					{Name: "mysql", Exporter: s, Eraser: s},
+				}
+			}(),
+			[]outcome{
+				{Store: "redis", Status: statusFailed, Err: errors.New("erase error")},
+				{Store: "mysql", Status: statusErased, Verified: true},
+			},
+			false,
+		},
+		{
+			"data held after erasure fails",
+			func() []Store {
+				s := newStoreMock()
+				s.retain = true
+				return []Store{{Name: "mysql", Exporter: s, Eraser: s}}
+			}(),
+			[]outcome{
+				{Store: "mysql", Status: statusFailed, Err: errors.New("verify: data held after erasure")},
+			},
+			false,
+		},
+		{
+			"verify error is not verified",
+			[]Store{{
+				Name:     "elasticsearch",
+				Exporter: &storeMock{exportErr: errors.New("export error")},
+				Eraser:   &storeMock{},
+			}},
+			[]outcome{
+				{Store: "elasticsearch", Status: statusErased, Err: errors.New("verify: export error")},
+			},
+			true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := NewInteractor(c.stores...)
+
+			od := interactor.erase(
+				context.Background(),
+				"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			)
+
+			assert.Equal(t, "0a81dec3-3638-4eb4-b04a-83d744f5f3a8", od.ID)
+			assert.Len(t, od.Outcomes, len(c.expectedOutcomes))
+
+			for k, o := range od.Outcomes {
+				assert.Equal(t, c.expectedOutcomes[k].Store, o.Store)
+				assert.Equal(t, c.expectedOutcomes[k].Status, o.Status)
+				assert.Equal(t, c.expectedOutcomes[k].Verified, o.Verified)
+
+				if c.expectedOutcomes[k].Err == nil {
+					assert.NoError(t, o.Err)
+					continue
+				}
+
+				assert.EqualError(t, o.Err, c.expectedOutcomes[k].Err.Error())
+			}
+
+			assert.Equal(t, c.expectedComplete, od.Complete())
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package gdpr
+
+import (
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+type p struct {
+}
+
+type presenter interface {
+	exportViewModel(od exportOutputData) exportViewModel
+	eraseViewModel(od eraseOutputData) eraseViewModel
+}
+
+var _ presenter = (*p)(nil)
+
+func NewPresenter() presenter {
+	return &p{}
+}
+
+type exportViewModel struct {
+	ID         string
+	ExportedAt string
+	Stores     []storeViewModel
+}
+
+type storeViewModel struct {
+	User   *usr
+	Store  string
+	Events []evt
+}
+
+type usr struct {
+	ID        string
+	FirstName string
+	LastName  string
+	CreatedAt string
+	DeletedAt string
+	Version   int64
+}
+
+type evt struct {
+	Before     *usr
+	After      *usr
+	ID         string
+	Type       string
+	OccurredAt string
+}
+
+type eraseViewModel struct {
+	ID       string
+	ErasedAt string
+	Stores   []outcomeViewModel
+	Complete bool
+}
+
+type outcomeViewModel struct {
+	Store    string
+	Status   string
+	Error    string
+	Verified bool
+}
+
+func (p *p) exportViewModel(od exportOutputData) exportViewModel {
+	stores := make([]storeViewModel, 0, len(od.Stores))
+
+	for _, s := range od.Stores {
+		events := make([]evt, 0, len(s.Data.Events))
+
+		for _, e := range s.Data.Events {
+			events = append(events, evt{
+				Before:     userViewModel(e.Before),
+				After:      userViewModel(e.After),
+				ID:         e.ID,
+				Type:       e.Type,
+				OccurredAt: e.OccurredAt.Format(time.RFC3339),
+			})
+		}
+
+		stores = append(stores, storeViewModel{
+			User:   userViewModel(s.Data.User),
+			Store:  s.Name,
+			Events: events,
+		})
+	}
+
+	return exportViewModel{
+		ID:         od.ID,
+		ExportedAt: od.ExportedAt.Format(time.RFC3339),
+		Stores:     stores,
+	}
+}
+
+func (p *p) eraseViewModel(od eraseOutputData) eraseViewModel {
+	stores := make([]outcomeViewModel, 0, len(od.Outcomes))
+
+	for _, o := range od.Outcomes {
+		ovm := outcomeViewModel{
+			Store:    o.Store,
+			Status:   o.Status,
+			Verified: o.Verified,
+		}
+
+		if o.Err != nil {
+			ovm.Error = o.Err.Error()
+		}
+
+		stores = append(stores, ovm)
+	}
+
+	return eraseViewModel{
+		ID:       od.ID,
+		ErasedAt: od.ErasedAt.Format(time.RFC3339),
+		Stores:   stores,
+		Complete: od.Complete(),
+	}
+}
+
+func userViewModel(u *user.User) *usr {
+	if u == nil {
+		return nil
+	}
+
+	vm := &usr{
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		CreatedAt: u.CreatedAt.Format(time.RFC3339),
+		Version:   u.Version,
+	}
+
+	if u.DeletedAt != nil {
+		vm.DeletedAt = u.DeletedAt.Format(time.RFC3339)
+	}
+
+	return vm
+}
@@ -0,0 +1,61 @@
+package gdpr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenter_Export(t *testing.T) {
+	presenter := NewPresenter()
+
+	deletedAt := createdAt()
+
+	vm := presenter.exportViewModel(exportOutputData{
+		ExportedAt: createdAt(),
+		ID:         "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		Stores: []storeData{
+			{
+				Name: "redis",
+				Data: user.SubjectData{
+					User: &user.User{
+						CreatedAt: createdAt(),
+						DeletedAt: &deletedAt,
+						ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+						FirstName: "john",
+						LastName:  "smith",
+						Version:   2,
+					},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, "0a81dec3-3638-4eb4-b04a-83d744f5f3a8", vm.ID)
+	assert.Equal(t, "2021-12-14T20:00:13Z", vm.ExportedAt)
+	assert.Len(t, vm.Stores, 1)
+	assert.Equal(t, "redis", vm.Stores[0].Store)
+	assert.Equal(t, "john", vm.Stores[0].User.FirstName)
+	assert.Equal(t, "2021-12-14T20:00:13Z", vm.Stores[0].User.DeletedAt)
+	assert.Empty(t, vm.Stores[0].Events)
+}
+
+func TestPresenter_Erase(t *testing.T) {
+	presenter := NewPresenter()
+
+	vm := presenter.eraseViewModel(eraseOutputData{
+		ErasedAt: createdAt(),
+		ID:       "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		Outcomes: []outcome{
+			{Store: "mysql", Status: statusErased, Verified: true},
+			{Store: "redis", Status: statusFailed, Err: errors.New("erase error")},
+		},
+	})
+
+	assert.Equal(t, "2021-12-14T20:00:13Z", vm.ErasedAt)
+	assert.False(t, vm.Complete)
+	assert.Equal(t, outcomeViewModel{Store: "mysql", Status: statusErased, Verified: true}, vm.Stores[0])
+	assert.Equal(t, outcomeViewModel{Store: "redis", Status: statusFailed, Error: "erase error"}, vm.Stores[1])
+}
@@ -0,0 +1,76 @@
+package purge
+
+import (
+	"context"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+type p struct {
+	purger    user.Purger
+	log       log.Logger
+	retention time.Duration
+	interval  time.Duration
+	batchSize int
+}
+
+// NewPurger returns a purger that hard deletes users that were soft
+// deleted longer ago than the configured retention.
+func NewPurger(
+	conf config.UserPurge,
+	purger user.Purger,
+	log log.Logger,
+) *p {
+	return &p{
+		purger:    purger,
+		log:       log,
+		retention: conf.Retention,
+		interval:  conf.Interval,
+		batchSize: conf.BatchSize,
+	}
+}
+
+// Run purges users every interval, in batches of batchSize, until ctx
+// is cancelled.
+func (p *p) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.log.Infof(ctx.Err().Error())
+			return nil
+		case <-ticker.C:
+		}
+
+		p.purge(ctx)
+	}
+}
+
+func (p *p) purge(ctx context.Context) {
+	deletedBefore := time.Now().Add(-p.retention)
+
+	var total int
+
+	for {
+		n, err := p.purger.Purge(ctx, deletedBefore, p.batchSize)
+		if err != nil {
+			p.log.ErrorContext(ctx, err)
+			break
+		}
+
+		total += n
+
+		if n < p.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		p.log.InfofContext(ctx, "purged %d users deleted before %s", total, deletedBefore.Format(time.RFC3339))
+	}
+}
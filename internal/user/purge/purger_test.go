@@ -0,0 +1,120 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type purgerMock struct {
+	err           error
+	deletedBefore []time.Time
+	remaining     int
+}
+
+func (m *purgerMock) Purge(_ context.Context, deletedBefore time.Time, limit int) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	m.deletedBefore = append(m.deletedBefore, deletedBefore)
+
+	n := min(m.remaining, limit)
+	m.remaining -= n
+
+	return n, nil
+}
+
+type logMock struct {
+	errs []error
+}
+
+func (l *logMock) Panic(error)                                           {}
+func (l *logMock) Panicf(string, ...interface{})                         {}
+func (l *logMock) Error(error)                                           {}
+func (l *logMock) ErrorContext(_ context.Context, err error)             { l.errs = append(l.errs, err) }
+func (l *logMock) Errorf(string, ...interface{})                         {}
+func (l *logMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (l *logMock) Infof(string, ...interface{})                          {}
+func (l *logMock) InfofContext(context.Context, string, ...interface{})  {}
+
+func TestPurger_Purge(t *testing.T) {
+	cases := map[string]struct {
+		purger        *purgerMock
+		expectedCalls int
+		expectedErrs  int
+	}{
+		"nothing to purge": {
+			&purgerMock{},
+			1,
+			0,
+		},
+		"partial batch": {
+			&purgerMock{remaining: 3},
+			1,
+			0,
+		},
+		"full batches are repeated until drained": {
+			&purgerMock{remaining: 25},
+			3,
+			0,
+		},
+		"exact multiple of batch size": {
+			&purgerMock{remaining: 20},
+			3,
+			0,
+		},
+		"purge error is logged": {
+			&purgerMock{err: errors.New("purge error")},
+			0,
+			1,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			l := &logMock{}
+
+			p := NewPurger(
+				config.UserPurge{
+					Retention: 30 * 24 * time.Hour,
+					BatchSize: 10,
+				},
+				c.purger,
+				l,
+			)
+
+			start := time.Now()
+
+			p.purge(context.Background())
+
+			assert.Len(t, c.purger.deletedBefore, c.expectedCalls)
+			assert.Len(t, l.errs, c.expectedErrs)
+			assert.Zero(t, c.purger.remaining)
+
+			for _, deletedBefore := range c.purger.deletedBefore {
+				assert.WithinDuration(t, start.Add(-30*24*time.Hour), deletedBefore, time.Second)
+			}
+		})
+	}
+}
+
+func TestPurger_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := NewPurger(
+		config.UserPurge{
+			Interval:  time.Millisecond,
+			BatchSize: 10,
+		},
+		&purgerMock{},
+		&logMock{},
+	)
+
+	assert.NoError(t, p.Run(ctx))
+}
@@ -13,8 +13,16 @@ type grpcController struct {
 	logger     log.Logger
 }
 
+// Page sizes used by ListUsers when the requested page size is not set or
+// exceeds the maximum.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
 type GRPCController interface {
 	Read(context.Context, *user.ReadRequest) (*user.UsersResponse, error)
+	ListUsers(*user.ListUsersRequest, user.User_ListUsersServer) error
 }
 
 func NewGRPCController(
@@ -41,6 +49,54 @@ func (c *grpcController) Read(
 		return nil, err
 	}
 
+	return c.usersResponse(od), nil
+}
+
+// ListUsers streams users in pages of up to req.PageSize users, so that
+// the response is not limited by the maximum gRPC message size.
+func (c *grpcController) ListUsers(
+	req *user.ListUsersRequest,
+	stream user.User_ListUsersServer,
+) error {
+	ctx := stream.Context()
+
+	pageSize := int(req.PageSize)
+
+	switch {
+	case pageSize <= 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	var cursor string
+
+	for {
+		od, next, err := c.interactor.readPage(
+			ctx,
+			cursor,
+			pageSize,
+		)
+		if err != nil {
+			c.logger.ErrorContext(ctx, err)
+			return err
+		}
+
+		if len(od) > 0 {
+			if err := stream.Send(c.usersResponse(od)); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+
+		cursor = next
+	}
+}
+
+func (c *grpcController) usersResponse(od outputData) *user.UsersResponse {
 	vm := c.presenter.viewModel(od)
 
 	var users []*user.UserResponse
@@ -53,11 +109,12 @@ func (c *grpcController) Read(
 				FirstName: u.FirstName,
 				LastName:  u.LastName,
 				CreatedAt: u.CreatedAt,
+				Version:   u.Version,
 			},
 		)
 	}
 
 	return &user.UsersResponse{
 		Users: users,
-	}, nil
+	}
 }
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/user"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 )
 
 type interactorMock struct {
@@ -16,6 +18,14 @@ func (m *interactorMock) read(context.Context) (outputData, error) {
 	return outputData{}, nil
 }
 
+func (m *interactorMock) readPage(context.Context, string, int) (outputData, string, error) {
+	return outputData{}, "", nil
+}
+
+func (m *interactorMock) readByID(context.Context, string) (outputData, error) {
+	return outputData{{}}, nil
+}
+
 type interactorMockError struct {
 }
 
@@ -23,6 +33,58 @@ func (m *interactorMockError) read(context.Context) (outputData, error) {
 	return outputData{}, errors.New("interactor read error")
 }
 
+func (m *interactorMockError) readPage(context.Context, string, int) (outputData, string, error) {
+	return outputData{}, "", errors.New("interactor read page error")
+}
+
+func (m *interactorMockError) readByID(context.Context, string) (outputData, error) {
+	return outputData{}, errors.New("interactor read by id error")
+}
+
+type interactorMockNotFound struct {
+	interactorMock
+}
+
+func (m *interactorMockNotFound) readByID(context.Context, string) (outputData, error) {
+	return outputData{}, user.ErrNotFound
+}
+
+// interactorMockPaged returns the page for each cursor, recording the
+// limit requested.
+type interactorMockPaged struct {
+	pages  map[string]outputData
+	next   map[string]string
+	limits []int
+}
+
+func (m *interactorMockPaged) read(context.Context) (outputData, error) {
+	return outputData{}, nil
+}
+
+func (m *interactorMockPaged) readPage(_ context.Context, cursor string, limit int) (outputData, string, error) {
+	m.limits = append(m.limits, limit)
+
+	return m.pages[cursor], m.next[cursor], nil
+}
+
+func (m *interactorMockPaged) readByID(context.Context, string) (outputData, error) {
+	return outputData{}, nil
+}
+
+type listUsersServerMock struct {
+	grpc.ServerStream
+	responses []*pb.UsersResponse
+}
+
+func (m *listUsersServerMock) Context() context.Context {
+	return context.Background()
+}
+
+func (m *listUsersServerMock) Send(resp *pb.UsersResponse) error {
+	m.responses = append(m.responses, resp)
+	return nil
+}
+
 type presenterMock struct {
 }
 
@@ -33,12 +95,14 @@ func (pm *presenterMock) viewModel(outputData) viewModel {
 			FirstName: "john",
 			LastName:  "smith",
 			CreatedAt: "2006-01-02T15:04:05-0700",
+			Version:   1,
 		},
 		{
 			ID:        "1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 			FirstName: "joanna",
 			LastName:  "smithson",
 			CreatedAt: "2006-01-02T16:04:05-0700",
+			Version:   2,
 		},
 	}
 }
@@ -84,12 +148,14 @@ func TestGRPC_Read(t *testing.T) {
 						FirstName: "john",
 						LastName:  "smith",
 						CreatedAt: "2006-01-02T15:04:05-0700",
+						Version:   1,
 					},
 					{
 						Id:        "1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 						FirstName: "joanna",
 						LastName:  "smithson",
 						CreatedAt: "2006-01-02T16:04:05-0700",
+						Version:   2,
 					},
 				},
 			},
@@ -117,3 +183,92 @@ func TestGRPC_Read(t *testing.T) {
 		})
 	}
 }
+
+func TestGRPC_ListUsers(t *testing.T) {
+	john := item{
+		CreatedAt: createdAt(),
+		ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		FirstName: "john",
+		LastName:  "smith",
+	}
+
+	joanna := item{
+		CreatedAt: createdAt(),
+		ID:        "1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		FirstName: "joanna",
+		LastName:  "smithson",
+	}
+
+	cases := []struct {
+		name              string
+		pageSize          int32
+		expectedLimits    []int
+		expectedResponses int
+	}{
+		{
+			"page size defaulted",
+			0,
+			[]int{defaultPageSize, defaultPageSize, defaultPageSize},
+			2,
+		},
+		{
+			"page size limited to max",
+			maxPageSize + 1,
+			[]int{maxPageSize, maxPageSize, maxPageSize},
+			2,
+		},
+		{
+			"page size requested",
+			1,
+			[]int{1, 1, 1},
+			2,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := &interactorMockPaged{
+				pages: map[string]outputData{
+					"":  {john},
+					"1": {joanna},
+				},
+				next: map[string]string{
+					"":  "1",
+					"1": "2",
+				},
+			}
+
+			controller := NewGRPCController(
+				interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			stream := &listUsersServerMock{}
+
+			err := controller.ListUsers(&pb.ListUsersRequest{PageSize: c.pageSize}, stream)
+			assert.NoError(t, err)
+
+			assert.Equal(t, c.expectedLimits, interactor.limits)
+
+			// Empty pages are not sent.
+			assert.Len(t, stream.responses, c.expectedResponses)
+			assert.Equal(t, john.ID, stream.responses[0].Users[0].Id)
+			assert.Equal(t, joanna.ID, stream.responses[1].Users[0].Id)
+		})
+	}
+}
+
+func TestGRPC_ListUsers_Error(t *testing.T) {
+	controller := NewGRPCController(
+		&interactorMockError{},
+		NewPresenter(),
+		loggerMock{},
+	)
+
+	stream := &listUsersServerMock{}
+
+	err := controller.ListUsers(&pb.ListUsersRequest{}, stream)
+	assert.EqualError(t, err, "interactor read page error")
+	assert.Empty(t, stream.responses)
+}
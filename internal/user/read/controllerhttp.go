@@ -1,10 +1,13 @@
 package read
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/bendbennett/go-api-demo/internal/log"
 	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/gorilla/mux"
 )
 
 type httpController struct {
@@ -15,6 +18,7 @@ type httpController struct {
 
 type HTTPController interface {
 	Read(w http.ResponseWriter, r *http.Request)
+	ReadByID(w http.ResponseWriter, r *http.Request)
 }
 
 func NewHTTPController(
@@ -29,6 +33,14 @@ func NewHTTPController(
 	}
 }
 
+type output struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+	Version   int64  `json:"version"`
+}
+
 func (c *httpController) Read(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -44,22 +56,15 @@ func (c *httpController) Read(
 		return
 	}
 
-	type user struct {
-		ID        string `json:"id"`
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		CreatedAt string `json:"created_at"`
-	}
-
 	var (
 		vm    = c.presenter.viewModel(od)
-		users = []user{}
+		users = []output{}
 	)
 
 	for _, u := range vm {
 		users = append(
 			users,
-			user(u),
+			output(u),
 		)
 	}
 
@@ -69,3 +74,42 @@ func (c *httpController) Read(
 		users,
 	)
 }
+
+// ReadByID sets the ETag header to the version of the user, which is
+// required in the If-Match header when the user is updated.
+func (c *httpController) ReadByID(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	od, err := c.interactor.readByID(
+		ctx,
+		mux.Vars(r)["id"],
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		response.WriteErrorResponse(
+			w,
+			http.StatusNotFound,
+			err.Error(),
+			nil,
+		)
+		return
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	vm := c.presenter.viewModel(od)[0]
+
+	w.Header().Set("ETag", response.ETag(vm.Version))
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		output(vm),
+	)
+}
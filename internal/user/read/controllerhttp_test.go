@@ -37,13 +37,15 @@ func TestRest_Create(t *testing.T) {
 										"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 										"first_name": "john",
 										"last_name": "smith",
-										"created_at": "2006-01-02T15:04:05-0700"
+										"created_at": "2006-01-02T15:04:05-0700",
+										"version": 1
 									},
 																	{
 										"id": "1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 										"first_name": "joanna",
 										"last_name": "smithson",
-										"created_at": "2006-01-02T16:04:05-0700"
+										"created_at": "2006-01-02T16:04:05-0700",
+										"version": 2
 									}
 								]`,
 		},
@@ -71,3 +73,68 @@ func TestRest_Create(t *testing.T) {
 		})
 	}
 }
+
+func TestRest_ReadByID(t *testing.T) {
+	cases := []struct {
+		name                 string
+		interactor           interactor
+		expectedStatus       int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			"interactor read by id error",
+			&interactorMockError{},
+			http.StatusInternalServerError,
+			"",
+			`{
+  									"message": "internal server error"
+								}`,
+		},
+		{
+			"not found",
+			&interactorMockNotFound{},
+			http.StatusNotFound,
+			"",
+			`{
+  									"message": "user not found"
+								}`,
+		},
+		{
+			"success",
+			&interactorMock{},
+			http.StatusOK,
+			`"1"`,
+			`{
+									"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+									"first_name": "john",
+									"last_name": "smith",
+									"created_at": "2006-01-02T15:04:05-0700",
+									"version": 1
+								}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8", nil)
+			w := httptest.NewRecorder()
+
+			controller := NewHTTPController(
+				c.interactor,
+				&presenterMock{},
+				loggerMock{},
+			)
+
+			controller.ReadByID(w, r)
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.Equal(t, c.expectedETag, w.Header().Get("ETag"))
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
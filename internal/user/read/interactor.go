@@ -8,20 +8,28 @@ import (
 )
 
 type i struct {
-	userReader user.Reader
+	userReader     user.Reader
+	userByIDReader user.ByIDReader
 }
 
 type interactor interface {
 	read(context.Context) (outputData, error)
+	readPage(ctx context.Context, cursor string, limit int) (outputData, string, error)
+	readByID(ctx context.Context, id string) (outputData, error)
 }
 
 var _ interactor = (*i)(nil)
 
+// NewInteractor returns an interactor that reads users by ID with
+// userByIDReader, so that the version of the user is current, and all
+// other reads with userReader.
 func NewInteractor(
 	userReader user.Reader,
+	userByIDReader user.ByIDReader,
 ) *i {
 	return &i{
 		userReader,
+		userByIDReader,
 	}
 }
 
@@ -29,6 +37,7 @@ type outputData []item
 
 type item struct {
 	CreatedAt time.Time
+	Version   int64
 	ID        string
 	FirstName string
 	LastName  string
@@ -44,6 +53,44 @@ func (i *i) read(
 		return outputData{}, err
 	}
 
+	return toOutputData(users), nil
+}
+
+func (i *i) readPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) (outputData, string, error) {
+	users, next, err := i.userReader.ReadPage(
+		ctx,
+		cursor,
+		limit,
+	)
+	if err != nil {
+		return outputData{}, "", err
+	}
+
+	return toOutputData(users), next, nil
+}
+
+// readByID returns output data holding the single user with the ID, or
+// user.ErrNotFound.
+func (i *i) readByID(
+	ctx context.Context,
+	id string,
+) (outputData, error) {
+	u, err := i.userByIDReader.ReadByID(
+		ctx,
+		id,
+	)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	return toOutputData([]user.User{u}), nil
+}
+
+func toOutputData(users []user.User) outputData {
 	var od outputData
 
 	for _, u := range users {
@@ -51,6 +98,7 @@ func (i *i) read(
 			od,
 			item{
 				CreatedAt: u.CreatedAt,
+				Version:   u.Version,
 				ID:        u.ID,
 				FirstName: u.FirstName,
 				LastName:  u.LastName,
@@ -58,5 +106,5 @@ func (i *i) read(
 		)
 	}
 
-	return od, nil
+	return od
 }
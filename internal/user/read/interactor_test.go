@@ -18,6 +18,10 @@ func (m *readerMockError) Read(context.Context) ([]user.User, error) {
 	return []user.User{}, errors.New("reader read error")
 }
 
+func (m *readerMockError) ReadPage(context.Context, string, int) ([]user.User, string, error) {
+	return nil, "", errors.New("reader read page error")
+}
+
 type readerMock struct {
 }
 
@@ -28,10 +32,30 @@ func (m *readerMock) Read(context.Context) ([]user.User, error) {
 			FirstName: "john",
 			LastName:  "smith",
 			CreatedAt: createdAt(),
+			Version:   1,
 		},
 	}, nil
 }
 
+func (m *readerMockError) ReadByID(context.Context, string) (user.User, error) {
+	return user.User{}, errors.New("reader read by id error")
+}
+
+func (m *readerMock) ReadByID(_ context.Context, id string) (user.User, error) {
+	users, err := m.Read(context.Background())
+	if users[0].ID != id {
+		return user.User{}, user.ErrNotFound
+	}
+
+	return users[0], err
+}
+
+func (m *readerMock) ReadPage(_ context.Context, cursor string, _ int) ([]user.User, string, error) {
+	users, err := m.Read(context.Background())
+
+	return users, cursor + "1", err
+}
+
 func createdAt() time.Time {
 	createdAt, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05-0700")
 
@@ -60,6 +84,7 @@ func TestInteractor_Create(t *testing.T) {
 					FirstName: "john",
 					LastName:  "smith",
 					CreatedAt: createdAt(),
+					Version:   1,
 				},
 			},
 			false,
@@ -70,6 +95,7 @@ func TestInteractor_Create(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			interactor := NewInteractor(
 				c.reader,
+				&readerMock{},
 			)
 			od, err := interactor.read(
 				context.Background(),
@@ -85,3 +111,115 @@ func TestInteractor_Create(t *testing.T) {
 		})
 	}
 }
+
+func TestInteractor_ReadPage(t *testing.T) {
+	cases := []struct {
+		name               string
+		reader             user.Reader
+		expectedOutputData outputData
+		expectedNext       string
+		returnsErr         bool
+	}{
+		{
+			"reader returns error",
+			&readerMockError{},
+			outputData{},
+			"",
+			true,
+		},
+		{
+			"success",
+			&readerMock{},
+			outputData{
+				{
+					ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					FirstName: "john",
+					LastName:  "smith",
+					CreatedAt: createdAt(),
+					Version:   1,
+				},
+			},
+			"cursor1",
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := NewInteractor(
+				c.reader,
+				&readerMock{},
+			)
+			od, next, err := interactor.readPage(
+				context.Background(),
+				"cursor",
+				10,
+			)
+
+			if c.returnsErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, c.expectedOutputData, od)
+			assert.Equal(t, c.expectedNext, next)
+		})
+	}
+}
+
+func TestInteractor_ReadByID(t *testing.T) {
+	cases := []struct {
+		name               string
+		reader             user.ByIDReader
+		id                 string
+		expectedOutputData outputData
+		expectedErr        error
+	}{
+		{
+			"reader returns error",
+			&readerMockError{},
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			outputData{},
+			errors.New("reader read by id error"),
+		},
+		{
+			"not found",
+			&readerMock{},
+			"1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			outputData{},
+			user.ErrNotFound,
+		},
+		{
+			"success",
+			&readerMock{},
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			outputData{
+				{
+					ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+					FirstName: "john",
+					LastName:  "smith",
+					CreatedAt: createdAt(),
+					Version:   1,
+				},
+			},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := NewInteractor(
+				&readerMock{},
+				c.reader,
+			)
+			od, err := interactor.readByID(
+				context.Background(),
+				c.id,
+			)
+
+			assert.Equal(t, c.expectedErr, err)
+			assert.Equal(t, c.expectedOutputData, od)
+		})
+	}
+}
@@ -22,6 +22,7 @@ type usr struct {
 	FirstName string
 	LastName  string
 	CreatedAt string
+	Version   int64
 }
 
 func (p *p) viewModel(od outputData) viewModel {
@@ -35,6 +36,7 @@ func (p *p) viewModel(od outputData) viewModel {
 				FirstName: u.FirstName,
 				LastName:  u.LastName,
 				CreatedAt: u.CreatedAt.Format(time.RFC3339),
+				Version:   u.Version,
 			},
 		)
 	}
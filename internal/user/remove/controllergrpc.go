@@ -0,0 +1,51 @@
+package remove
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type grpcController struct {
+	interactor interactor
+	logger     log.Logger
+}
+
+type GRPCController interface {
+	Delete(context.Context, *pb.DeleteRequest) (*pb.DeleteResponse, error)
+}
+
+func NewGRPCController(
+	interactor interactor,
+	logger log.Logger,
+) *grpcController {
+	return &grpcController{
+		interactor,
+		logger,
+	}
+}
+
+func (c *grpcController) Delete(
+	ctx context.Context,
+	req *pb.DeleteRequest,
+) (*pb.DeleteResponse, error) {
+	err := c.interactor.remove(
+		ctx,
+		req.Id,
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		return nil, err
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
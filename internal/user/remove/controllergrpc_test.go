@@ -0,0 +1,58 @@
+package remove
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPC_Delete(t *testing.T) {
+	cases := []struct {
+		name             string
+		interactor       interactor
+		expectedResponse *pb.DeleteResponse
+		expectedCode     codes.Code
+	}{
+		{
+			"not found",
+			&interactorMock{err: user.ErrNotFound},
+			nil,
+			codes.NotFound,
+		},
+		{
+			"interactor remove error",
+			&interactorMock{err: errors.New("interactor remove error")},
+			nil,
+			codes.Unknown,
+		},
+		{
+			"success",
+			&interactorMock{},
+			&pb.DeleteResponse{},
+			codes.OK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewGRPCController(
+				c.interactor,
+				loggerMock{},
+			)
+
+			resp, err := controller.Delete(
+				context.Background(),
+				&pb.DeleteRequest{Id: "0a81dec3-3638-4eb4-b04a-83d744f5f3a8"},
+			)
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+			assert.Equal(t, c.expectedResponse, resp)
+		})
+	}
+}
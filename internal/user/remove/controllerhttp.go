@@ -0,0 +1,59 @@
+package remove
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/gorilla/mux"
+)
+
+type httpController struct {
+	interactor interactor
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	interactor interactor,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		interactor,
+		logger,
+	}
+}
+
+func (c *httpController) Delete(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	err := c.interactor.remove(
+		ctx,
+		mux.Vars(r)["id"],
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		response.WriteErrorResponse(
+			w,
+			http.StatusNotFound,
+			err.Error(),
+			nil,
+		)
+		return
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
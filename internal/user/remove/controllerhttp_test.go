@@ -0,0 +1,95 @@
+package remove
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type interactorMock struct {
+	err error
+}
+
+func (m *interactorMock) remove(context.Context, string) error {
+	return m.err
+}
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+func TestRest_Delete(t *testing.T) {
+	cases := []struct {
+		name                 string
+		interactor           interactor
+		expectedStatus       int
+		expectedResponseBody string
+	}{
+		{
+			"not found",
+			&interactorMock{err: user.ErrNotFound},
+			http.StatusNotFound,
+			`{
+									"message": "user not found"
+								}`,
+		},
+		{
+			"interactor remove error",
+			&interactorMock{err: errors.New("interactor remove error")},
+			http.StatusInternalServerError,
+			`{
+									"message": "internal server error"
+								}`,
+		},
+		{
+			"success",
+			&interactorMock{},
+			http.StatusNoContent,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8"})
+			w := httptest.NewRecorder()
+
+			controller := NewHTTPController(
+				c.interactor,
+				loggerMock{},
+			)
+
+			controller.Delete(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+
+			if c.expectedResponseBody == "" {
+				assert.Empty(t, w.Body.String())
+				return
+			}
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
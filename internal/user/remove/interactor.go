@@ -0,0 +1,35 @@
+package remove
+
+import (
+	"context"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+type i struct {
+	userSoftDeleter user.SoftDeleter
+}
+
+type interactor interface {
+	remove(ctx context.Context, id string) error
+}
+
+var _ interactor = (*i)(nil)
+
+func NewInteractor(
+	userSoftDeleter user.SoftDeleter,
+) *i {
+	return &i{
+		userSoftDeleter,
+	}
+}
+
+// remove soft deletes the user, which can be restored until it is purged.
+// user.ErrNotFound is returned if the user does not exist or has already
+// been deleted.
+func (i *i) remove(
+	ctx context.Context,
+	id string,
+) error {
+	return i.userSoftDeleter.SoftDelete(ctx, id)
+}
@@ -0,0 +1,68 @@
+package remove
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type softDeleterMock struct {
+	deleted map[string]bool
+}
+
+func (m *softDeleterMock) SoftDelete(_ context.Context, id string) error {
+	deleted, ok := m.deleted[id]
+	if !ok || deleted {
+		return user.ErrNotFound
+	}
+
+	m.deleted[id] = true
+
+	return nil
+}
+
+func TestInteractor_Remove(t *testing.T) {
+	cases := []struct {
+		name        string
+		id          string
+		expectedErr error
+	}{
+		{
+			"not found",
+			"1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			user.ErrNotFound,
+		},
+		{
+			"already deleted",
+			"2a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			user.ErrNotFound,
+		},
+		{
+			"success",
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := NewInteractor(
+				&softDeleterMock{
+					deleted: map[string]bool{
+						"0a81dec3-3638-4eb4-b04a-83d744f5f3a8": false,
+						"2a81dec3-3638-4eb4-b04a-83d744f5f3a8": true,
+					},
+				},
+			)
+
+			err := interactor.remove(
+				context.Background(),
+				c.id,
+			)
+
+			assert.ErrorIs(t, err, c.expectedErr)
+		})
+	}
+}
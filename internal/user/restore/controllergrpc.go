@@ -0,0 +1,62 @@
+package restore
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type grpcController struct {
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type GRPCController interface {
+	Restore(context.Context, *pb.RestoreRequest) (*pb.UserResponse, error)
+}
+
+func NewGRPCController(
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *grpcController {
+	return &grpcController{
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+func (c *grpcController) Restore(
+	ctx context.Context,
+	req *pb.RestoreRequest,
+) (*pb.UserResponse, error) {
+	od, err := c.interactor.restore(
+		ctx,
+		req.Id,
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		return nil, err
+	}
+
+	vm := c.presenter.viewModel(od)
+
+	return &pb.UserResponse{
+		Id:        vm.ID,
+		FirstName: vm.FirstName,
+		LastName:  vm.LastName,
+		CreatedAt: vm.CreatedAt,
+		Version:   vm.Version,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPC_Restore(t *testing.T) {
+	cases := []struct {
+		name             string
+		interactor       interactor
+		expectedResponse *pb.UserResponse
+		expectedCode     codes.Code
+	}{
+		{
+			"not found",
+			&interactorMock{err: user.ErrNotFound},
+			nil,
+			codes.NotFound,
+		},
+		{
+			"interactor restore error",
+			&interactorMock{err: errors.New("interactor restore error")},
+			nil,
+			codes.Unknown,
+		},
+		{
+			"success",
+			&interactorMock{},
+			&pb.UserResponse{
+				Id:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName: "john",
+				LastName:  "smith",
+				CreatedAt: "2021-12-14T20:00:13Z",
+				Version:   3,
+			},
+			codes.OK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewGRPCController(
+				c.interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			resp, err := controller.Restore(
+				context.Background(),
+				&pb.RestoreRequest{Id: "0a81dec3-3638-4eb4-b04a-83d744f5f3a8"},
+			)
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+			assert.Equal(t, c.expectedResponse, resp)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package restore
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/gorilla/mux"
+)
+
+type httpController struct {
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Restore(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+type output struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+	Version   int64  `json:"version"`
+}
+
+func (c *httpController) Restore(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	od, err := c.interactor.restore(
+		ctx,
+		mux.Vars(r)["id"],
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		response.WriteErrorResponse(
+			w,
+			http.StatusNotFound,
+			err.Error(),
+			nil,
+		)
+		return
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	vm := c.presenter.viewModel(od)
+
+	w.Header().Set("ETag", response.ETag(vm.Version))
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		output(vm),
+	)
+}
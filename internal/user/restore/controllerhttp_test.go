@@ -0,0 +1,111 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type interactorMock struct {
+	err error
+}
+
+func (m *interactorMock) restore(_ context.Context, id string) (outputData, error) {
+	if m.err != nil {
+		return outputData{}, m.err
+	}
+
+	return outputData{
+		CreatedAt: createdAt(),
+		Version:   3,
+		ID:        id,
+		FirstName: "john",
+		LastName:  "smith",
+	}, nil
+}
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+func TestRest_Restore(t *testing.T) {
+	cases := []struct {
+		name                 string
+		interactor           interactor
+		expectedStatus       int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			"not found",
+			&interactorMock{err: user.ErrNotFound},
+			http.StatusNotFound,
+			"",
+			`{
+									"message": "user not found"
+								}`,
+		},
+		{
+			"interactor restore error",
+			&interactorMock{err: errors.New("interactor restore error")},
+			http.StatusInternalServerError,
+			"",
+			`{
+									"message": "internal server error"
+								}`,
+		},
+		{
+			"success",
+			&interactorMock{},
+			http.StatusOK,
+			`"3"`,
+			`{
+									"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+									"first_name": "john",
+									"last_name": "smith",
+									"created_at": "2021-12-14T20:00:13Z",
+									"version": 3
+								}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8/restore", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8"})
+			w := httptest.NewRecorder()
+
+			controller := NewHTTPController(
+				c.interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			controller.Restore(w, r)
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.Equal(t, c.expectedETag, w.Header().Get("ETag"))
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
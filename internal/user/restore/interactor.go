@@ -0,0 +1,54 @@
+package restore
+
+import (
+	"context"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+type i struct {
+	userRestorer user.Restorer
+}
+
+type interactor interface {
+	restore(ctx context.Context, id string) (outputData, error)
+}
+
+var _ interactor = (*i)(nil)
+
+func NewInteractor(
+	userRestorer user.Restorer,
+) *i {
+	return &i{
+		userRestorer,
+	}
+}
+
+type outputData struct {
+	CreatedAt time.Time
+	Version   int64
+	ID        string
+	FirstName string
+	LastName  string
+}
+
+// restore returns user.ErrNotFound if the user has not been soft deleted,
+// or has already been purged.
+func (i *i) restore(
+	ctx context.Context,
+	id string,
+) (outputData, error) {
+	u, err := i.userRestorer.Restore(ctx, id)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	return outputData{
+		CreatedAt: u.CreatedAt,
+		Version:   u.Version,
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+	}, nil
+}
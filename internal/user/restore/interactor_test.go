@@ -0,0 +1,96 @@
+package restore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type restorerMock struct {
+	user user.User
+}
+
+func (m *restorerMock) Restore(_ context.Context, id string) (user.User, error) {
+	if id != m.user.ID || m.user.DeletedAt == nil {
+		return user.User{}, user.ErrNotFound
+	}
+
+	m.user.DeletedAt = nil
+	m.user.Version++
+
+	return m.user, nil
+}
+
+func createdAt() time.Time {
+	createdAt, _ := time.Parse(time.RFC3339, "2021-12-14T20:00:13Z")
+
+	return createdAt
+}
+
+func TestInteractor_Restore(t *testing.T) {
+	deletedAt := createdAt().Add(time.Hour)
+
+	cases := []struct {
+		name               string
+		id                 string
+		deletedAt          *time.Time
+		expectedOutputData outputData
+		expectedErr        error
+	}{
+		{
+			"not found",
+			"1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			&deletedAt,
+			outputData{},
+			user.ErrNotFound,
+		},
+		{
+			"not deleted",
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			outputData{},
+			user.ErrNotFound,
+		},
+		{
+			"success",
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			&deletedAt,
+			outputData{
+				CreatedAt: createdAt(),
+				Version:   3,
+				ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName: "john",
+				LastName:  "smith",
+			},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := NewInteractor(
+				&restorerMock{
+					user: user.User{
+						CreatedAt: createdAt(),
+						DeletedAt: c.deletedAt,
+						ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+						FirstName: "john",
+						LastName:  "smith",
+						Version:   2,
+					},
+				},
+			)
+
+			od, err := interactor.restore(
+				context.Background(),
+				c.id,
+			)
+
+			assert.ErrorIs(t, err, c.expectedErr)
+			assert.Equal(t, c.expectedOutputData, od)
+		})
+	}
+}
@@ -71,6 +71,7 @@ func (c *grpcController) Search(
 				FirstName: u.FirstName,
 				LastName:  u.LastName,
 				CreatedAt: u.CreatedAt,
+				Version:   u.Version,
 			},
 		)
 	}
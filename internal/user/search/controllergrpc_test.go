@@ -35,12 +35,14 @@ func (pm *presenterMock) viewModel(outputData) viewModel {
 			FirstName: "john",
 			LastName:  "smith",
 			CreatedAt: "2006-01-02T15:04:05-0700",
+			Version:   1,
 		},
 		{
 			ID:        "1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 			FirstName: "joanna",
 			LastName:  "smithson",
 			CreatedAt: "2006-01-02T16:04:05-0700",
+			Version:   2,
 		},
 	}
 }
@@ -94,12 +96,14 @@ func TestGRPC_Search(t *testing.T) {
 						FirstName: "john",
 						LastName:  "smith",
 						CreatedAt: "2006-01-02T15:04:05-0700",
+						Version:   1,
 					},
 					{
 						Id:        "1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 						FirstName: "joanna",
 						LastName:  "smithson",
 						CreatedAt: "2006-01-02T16:04:05-0700",
+						Version:   2,
 					},
 				},
 			},
@@ -79,6 +79,7 @@ func (c *httpController) Search(
 		FirstName string `json:"first_name"`
 		LastName  string `json:"last_name"`
 		CreatedAt string `json:"created_at"`
+		Version   int64  `json:"version"`
 	}
 
 	var (
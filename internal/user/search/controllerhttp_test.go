@@ -57,13 +57,15 @@ func TestRest_Search(t *testing.T) {
 										"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 										"first_name": "john",
 										"last_name": "smith",
-										"created_at": "2006-01-02T15:04:05-0700"
+										"created_at": "2006-01-02T15:04:05-0700",
+										"version": 1
 									},
 																	{
 										"id": "1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
 										"first_name": "joanna",
 										"last_name": "smithson",
-										"created_at": "2006-01-02T16:04:05-0700"
+										"created_at": "2006-01-02T16:04:05-0700",
+										"version": 2
 									}
 								]`,
 		},
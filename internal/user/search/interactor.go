@@ -31,6 +31,7 @@ type outputData []item
 
 type item struct {
 	CreatedAt time.Time
+	Version   int64
 	ID        string
 	FirstName string
 	LastName  string
@@ -52,6 +53,7 @@ func (i *i) search(
 			od,
 			item{
 				CreatedAt: u.CreatedAt,
+				Version:   u.Version,
 				ID:        u.ID,
 				FirstName: u.FirstName,
 				LastName:  u.LastName,
@@ -0,0 +1,134 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+)
+
+type httpController struct {
+	interactor Interactor
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Export(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	interactor Interactor,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		interactor,
+		logger,
+	}
+}
+
+// Export streams all users in the format given by the format query
+// parameter, which defaults to csv.
+func (c *httpController) Export(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = FormatCSV
+	}
+
+	if !IsFormat(format) {
+		writeFormatInvalid(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, format))
+	w.WriteHeader(http.StatusOK)
+
+	// The status has been sent, so errors can only be logged. The response
+	// is truncated, which the client detects from the chunked encoding.
+	if err := c.interactor.Export(ctx, format, w); err != nil {
+		c.logger.ErrorContext(ctx, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Import creates users from the request body in the format given by the
+// format query parameter or, if this is not set, the Content-Type header.
+// The response status is 201 if all rows are imported, and 207 if any
+// rows fail, in which case the report includes the row errors.
+func (c *httpController) Import(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatFromContentType(r.Header.Get("Content-Type"))
+	}
+
+	if !IsFormat(format) {
+		writeFormatInvalid(w)
+		return
+	}
+
+	report, err := c.interactor.Import(ctx, format, r.Body)
+
+	switch {
+	case errors.Is(err, ErrInputInvalid):
+		c.logger.InfofContext(ctx, "import invalid: %v", err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{"body": err.Error()},
+		)
+		return
+	case err != nil:
+		c.logger.ErrorfContext(ctx, "import failed after creating %d users: %v", report.Created, err)
+		response.Write500Response(w)
+		return
+	}
+
+	statusCode := http.StatusCreated
+
+	if report.Failed > 0 {
+		statusCode = http.StatusMultiStatus
+	}
+
+	response.WriteResponse(
+		w,
+		statusCode,
+		report,
+	)
+}
+
+func formatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case ContentType(FormatCSV):
+		return FormatCSV
+	case ContentType(FormatNDJSON):
+		return FormatNDJSON
+	default:
+		return ""
+	}
+}
+
+func writeFormatInvalid(w http.ResponseWriter) {
+	response.WriteErrorResponse(
+		w,
+		http.StatusBadRequest,
+		"failed validation",
+		map[string]string{"format": fmt.Sprintf("must be one of %s, %s", FormatCSV, FormatNDJSON)},
+	)
+}
@@ -0,0 +1,193 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+// interactorMock records the format requested and returns report and err.
+type interactorMock struct {
+	report Report
+	err    error
+	format string
+}
+
+func (m *interactorMock) Export(_ context.Context, format string, w io.Writer) error {
+	m.format = format
+	_, _ = io.WriteString(w, "id,first_name,last_name,created_at\n")
+
+	return m.err
+}
+
+func (m *interactorMock) Import(_ context.Context, format string, _ io.Reader) (Report, error) {
+	m.format = format
+
+	return m.report, m.err
+}
+
+func TestHTTPController_Export(t *testing.T) {
+	cases := []struct {
+		name                string
+		target              string
+		expectedStatus      int
+		expectedFormat      string
+		expectedContentType string
+	}{
+		{
+			"defaults to csv",
+			"/user/export",
+			http.StatusOK,
+			FormatCSV,
+			"text/csv",
+		},
+		{
+			"ndjson",
+			"/user/export?format=ndjson",
+			http.StatusOK,
+			FormatNDJSON,
+			"application/x-ndjson",
+		},
+		{
+			"format invalid",
+			"/user/export?format=xml",
+			http.StatusBadRequest,
+			"",
+			"application/json",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := &interactorMock{}
+			controller := NewHTTPController(interactor, loggerMock{})
+
+			r := httptest.NewRequest(http.MethodGet, c.target, nil)
+			w := httptest.NewRecorder()
+
+			controller.Export(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.Equal(t, c.expectedFormat, interactor.format)
+			assert.Equal(t, c.expectedContentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestHTTPController_Export_Error(t *testing.T) {
+	controller := NewHTTPController(&interactorMock{err: errors.New("read error")}, loggerMock{})
+
+	r := httptest.NewRequest(http.MethodGet, "/user/export", nil)
+	w := httptest.NewRecorder()
+
+	// The handler is aborted so that the truncated response is not
+	// mistaken for a complete export.
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		controller.Export(w, r)
+	})
+}
+
+func TestHTTPController_Import(t *testing.T) {
+	cases := []struct {
+		name           string
+		target         string
+		contentType    string
+		interactor     *interactorMock
+		expectedStatus int
+		expectedFormat string
+		expectedBody   string
+	}{
+		{
+			"format from query",
+			"/user/import?format=ndjson",
+			"text/plain",
+			&interactorMock{report: Report{Errors: []RowError{}, Created: 2}},
+			http.StatusCreated,
+			FormatNDJSON,
+			`{"errors":[],"created":2,"failed":0}`,
+		},
+		{
+			"format from content type",
+			"/user/import",
+			"text/csv; charset=utf-8",
+			&interactorMock{report: Report{Errors: []RowError{}, Created: 2}},
+			http.StatusCreated,
+			FormatCSV,
+			`{"errors":[],"created":2,"failed":0}`,
+		},
+		{
+			"format missing",
+			"/user/import",
+			"",
+			&interactorMock{},
+			http.StatusBadRequest,
+			"",
+			`{"errors":{"format":"must be one of csv, ndjson"},"message":"failed validation"}`,
+		},
+		{
+			"row errors",
+			"/user/import?format=csv",
+			"",
+			&interactorMock{report: Report{
+				Errors:  []RowError{{map[string]string{"row": "wrong number of fields"}, 2}},
+				Created: 1,
+				Failed:  1,
+			}},
+			http.StatusMultiStatus,
+			FormatCSV,
+			`{"errors":[{"errors":{"row":"wrong number of fields"},"row":2}],"created":1,"failed":1}`,
+		},
+		{
+			"input invalid",
+			"/user/import?format=csv",
+			"",
+			&interactorMock{err: ErrInputInvalid},
+			http.StatusBadRequest,
+			FormatCSV,
+			`{"errors":{"body":"input invalid"},"message":"failed validation"}`,
+		},
+		{
+			"interactor error",
+			"/user/import?format=csv",
+			"",
+			&interactorMock{err: errors.New("create error")},
+			http.StatusInternalServerError,
+			FormatCSV,
+			`{"message":"internal server error"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewHTTPController(c.interactor, loggerMock{})
+
+			r := httptest.NewRequest(http.MethodPost, c.target, strings.NewReader(""))
+			r.Header.Set("Content-Type", c.contentType)
+			w := httptest.NewRecorder()
+
+			controller.Import(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.Equal(t, c.expectedFormat, c.interactor.format)
+			assert.JSONEq(t, c.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/bendbennett/go-api-demo/internal/user/create"
+)
+
+// Formats in which users are imported and exported.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// csvHeader is written as the first row of exports. Imports require the
+// first_name and last_name columns, and ignore any other columns, so that
+// exports can be imported.
+var csvHeader = []string{"id", "first_name", "last_name", "created_at"}
+
+// ErrInputInvalid is wrapped by errors returned when the format, or the
+// input as a whole (e.g., the CSV header), is invalid.
+var ErrInputInvalid = errors.New("input invalid")
+
+var errFormatInvalid = fmt.Errorf(
+	"%w: format must be one of %s, %s",
+	ErrInputInvalid,
+	FormatCSV,
+	FormatNDJSON,
+)
+
+// IsFormat returns true if users can be imported and exported in format.
+func IsFormat(format string) bool {
+	return format == FormatCSV || format == FormatNDJSON
+}
+
+// ContentType returns the media type for format.
+func ContentType(format string) string {
+	if format == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+
+	return "text/csv"
+}
+
+type record struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+}
+
+type encoder interface {
+	encode(record) error
+	flush() error
+}
+
+func newEncoder(
+	format string,
+	w io.Writer,
+) (encoder, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+
+		return &csvEncoder{cw}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{json.NewEncoder(w)}, nil
+	default:
+		return nil, errFormatInvalid
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) encode(r record) error {
+	return e.w.Write([]string{r.ID, r.FirstName, r.LastName, r.CreatedAt})
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonEncoder struct {
+	e *json.Encoder
+}
+
+func (e *ndjsonEncoder) encode(r record) error {
+	return e.e.Encode(r)
+}
+
+func (e *ndjsonEncoder) flush() error {
+	return nil
+}
+
+// errRow is returned by decoders for rows that cannot be decoded but do
+// not prevent subsequent rows from being decoded.
+type errRow struct {
+	err error
+}
+
+func (e errRow) Error() string {
+	return e.err.Error()
+}
+
+type decoder interface {
+	// decode returns io.EOF once all rows have been decoded.
+	decode() (create.InputData, error)
+}
+
+func newDecoder(
+	format string,
+	r io.Reader,
+) (decoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVDecoder(r)
+	case FormatNDJSON:
+		return &ndjsonDecoder{bufio.NewScanner(r)}, nil
+	default:
+		return nil, errFormatInvalid
+	}
+}
+
+type csvDecoder struct {
+	r         *csv.Reader
+	firstName int
+	lastName  int
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: header invalid: %v", ErrInputInvalid, err)
+	}
+
+	d := &csvDecoder{
+		r:         cr,
+		firstName: slices.Index(header, "first_name"),
+		lastName:  slices.Index(header, "last_name"),
+	}
+
+	if d.firstName == -1 || d.lastName == -1 {
+		return nil, fmt.Errorf("%w: header invalid: first_name and last_name columns are required", ErrInputInvalid)
+	}
+
+	return d, nil
+}
+
+func (d *csvDecoder) decode() (create.InputData, error) {
+	rec, err := d.r.Read()
+	if err == io.EOF {
+		return create.InputData{}, err
+	}
+
+	var pe *csv.ParseError
+
+	if errors.As(err, &pe) {
+		return create.InputData{}, errRow{pe.Err}
+	}
+	if err != nil {
+		return create.InputData{}, err
+	}
+
+	if len(rec) <= max(d.firstName, d.lastName) {
+		return create.InputData{}, errRow{errors.New("wrong number of fields")}
+	}
+
+	return create.InputData{
+		FirstName: rec[d.firstName],
+		LastName:  rec[d.lastName],
+	}, nil
+}
+
+type ndjsonDecoder struct {
+	s *bufio.Scanner
+}
+
+func (d *ndjsonDecoder) decode() (create.InputData, error) {
+	if !d.s.Scan() {
+		if err := d.s.Err(); err != nil {
+			return create.InputData{}, err
+		}
+
+		return create.InputData{}, io.EOF
+	}
+
+	line := bytes.TrimSpace(d.s.Bytes())
+
+	if len(line) == 0 {
+		return create.InputData{}, errRow{errors.New("empty line")}
+	}
+
+	in := create.InputData{}
+
+	if err := json.Unmarshal(line, &in); err != nil {
+		return create.InputData{}, errRow{errors.New("json invalid")}
+	}
+
+	return in, nil
+}
@@ -0,0 +1,203 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/audit"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/google/uuid"
+)
+
+// batchSize is the number of users read for each page when exporting, and
+// created by each call to the user creator when importing.
+const batchSize = 1000
+
+// maxRowErrors is the maximum number of row errors included in a report,
+// so that the size of the report is bounded when importing a large file
+// in the wrong layout. All failed rows are counted.
+const maxRowErrors = 1000
+
+type i struct {
+	validator validate.Validator
+	userStore user.CreatorReader
+	batchSize int
+}
+
+// Interactor imports and exports users. It is used by the HTTP controller
+// and by CLI subcommands.
+type Interactor interface {
+	Export(ctx context.Context, format string, w io.Writer) error
+	Import(ctx context.Context, format string, r io.Reader) (Report, error)
+}
+
+var _ Interactor = (*i)(nil)
+
+func NewInteractor(
+	validator validate.Validator,
+	userStore user.CreatorReader,
+) *i {
+	return &i{
+		validator: validator,
+		userStore: userStore,
+		batchSize: batchSize,
+	}
+}
+
+// Report summarises an import. Rows are numbered from 1, excluding the
+// CSV header. Errors holds the first maxRowErrors row errors.
+type Report struct {
+	Errors  []RowError `json:"errors"`
+	Created int        `json:"created"`
+	Failed  int        `json:"failed"`
+}
+
+type RowError struct {
+	Errors map[string]string `json:"errors"`
+	Row    int               `json:"row"`
+}
+
+// Export writes all users to w in format, reading a page of users at a time
+// so that memory use does not grow with the number of users.
+func (i *i) Export(
+	ctx context.Context,
+	format string,
+	w io.Writer,
+) error {
+	enc, err := newEncoder(format, w)
+	if err != nil {
+		return err
+	}
+
+	var cursor string
+
+	for {
+		users, next, err := i.userStore.ReadPage(
+			ctx,
+			cursor,
+			i.batchSize,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			err := enc.encode(record{
+				ID:        u.ID,
+				FirstName: u.FirstName,
+				LastName:  u.LastName,
+				CreatedAt: u.CreatedAt.Format(time.RFC3339),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := enc.flush(); err != nil {
+			return err
+		}
+
+		if next == "" {
+			return nil
+		}
+
+		cursor = next
+	}
+}
+
+// Import reads users from r in format, and creates the users in rows that
+// are valid in batches. Rows that cannot be decoded or fail validation are
+// reported and skipped. If an error is returned, batches created before the
+// error occurred are not rolled back, and the report includes these.
+func (i *i) Import(
+	ctx context.Context,
+	format string,
+	r io.Reader,
+) (Report, error) {
+	report := Report{
+		Errors: []RowError{},
+	}
+
+	dec, err := newDecoder(format, r)
+	if err != nil {
+		return report, err
+	}
+
+	batch := make([]user.User, 0, i.batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := i.userStore.Create(ctx, batch...); err != nil {
+			return err
+		}
+
+		for _, u := range batch {
+			audit.AddTargets(ctx, u.ID)
+		}
+
+		report.Created += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for row := 1; ; row++ {
+		in, err := dec.decode()
+		if err == io.EOF {
+			break
+		}
+
+		var re errRow
+
+		if errors.As(err, &re) {
+			report.addError(row, map[string]string{"row": re.Error()})
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		if errs := i.validator.ValidateStruct(in); errs != nil {
+			report.addError(row, errs)
+			continue
+		}
+
+		batch = append(batch, user.User{
+			ID:        uuid.New().String(),
+			FirstName: in.FirstName,
+			LastName:  in.LastName,
+			CreatedAt: time.Now(),
+			Version:   1,
+		})
+
+		if len(batch) == i.batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, flush()
+}
+
+func (r *Report) addError(
+	row int,
+	errs map[string]string,
+) {
+	r.Failed++
+
+	if len(r.Errors) == maxRowErrors {
+		return
+	}
+
+	r.Errors = append(r.Errors, RowError{
+		Errors: errs,
+		Row:    row,
+	})
+}
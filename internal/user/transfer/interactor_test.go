@@ -0,0 +1,227 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/storage/memory"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storeMockError struct {
+	memory.UserStorage
+}
+
+func (m *storeMockError) Create(context.Context, ...user.User) error {
+	return errors.New("create error")
+}
+
+func newTestInteractor(t *testing.T, store user.CreatorReader) *i {
+	t.Helper()
+
+	validator, err := validate.NewValidator()
+	require.NoError(t, err)
+
+	interactor := NewInteractor(validator, store)
+	interactor.batchSize = 2
+
+	return interactor
+}
+
+func TestInteractor_Import(t *testing.T) {
+	cases := []struct {
+		name            string
+		format          string
+		input           string
+		expectedReport  Report
+		expectedCreated []string
+		expectedErr     string
+	}{
+		{
+			"csv",
+			FormatCSV,
+			"last_name,first_name\nsmith,john\nsmithson,joanna\nsmithers,jo\njones,jack\n",
+			Report{
+				Errors: []RowError{
+					{map[string]string{"first_name": "first_name must be at least 3 characters in length"}, 3},
+				},
+				Created: 3,
+				Failed:  1,
+			},
+			[]string{"jack", "joanna", "john"},
+			"",
+		},
+		{
+			"csv export layout",
+			FormatCSV,
+			"id,first_name,last_name,created_at\n1,john,smith,2021-12-14T20:00:13Z\n",
+			Report{
+				Errors:  []RowError{},
+				Created: 1,
+			},
+			[]string{"john"},
+			"",
+		},
+		{
+			"csv missing fields",
+			FormatCSV,
+			"first_name,last_name\njohn\njoanna,smithson\n",
+			Report{
+				Errors: []RowError{
+					{map[string]string{"row": "wrong number of fields"}, 1},
+				},
+				Created: 1,
+				Failed:  1,
+			},
+			[]string{"joanna"},
+			"",
+		},
+		{
+			"csv header invalid",
+			FormatCSV,
+			"name\njohn\n",
+			Report{
+				Errors: []RowError{},
+			},
+			nil,
+			"input invalid: header invalid: first_name and last_name columns are required",
+		},
+		{
+			"ndjson",
+			FormatNDJSON,
+			`{"first_name":"john","last_name":"smith"}` + "\n" +
+				`{"first_name":"joanna"` + "\n" +
+				"\n" +
+				`{"first_name":"jack","last_name":"jones"}` + "\n",
+			Report{
+				Errors: []RowError{
+					{map[string]string{"row": "json invalid"}, 2},
+					{map[string]string{"row": "empty line"}, 3},
+				},
+				Created: 2,
+				Failed:  2,
+			},
+			[]string{"jack", "john"},
+			"",
+		},
+		{
+			"format invalid",
+			"xml",
+			"",
+			Report{
+				Errors: []RowError{},
+			},
+			nil,
+			"input invalid: format must be one of csv, ndjson",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := memory.NewUserStorage()
+			interactor := newTestInteractor(t, store)
+
+			report, err := interactor.Import(context.Background(), c.format, strings.NewReader(c.input))
+
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				assert.ErrorIs(t, err, ErrInputInvalid)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, c.expectedReport, report)
+
+			users, err := store.Read(context.Background())
+			require.NoError(t, err)
+
+			var firstNames []string
+
+			for _, u := range users {
+				assert.True(t, validate.IsUUID(u.ID))
+				firstNames = append(firstNames, u.FirstName)
+			}
+
+			assert.ElementsMatch(t, c.expectedCreated, firstNames)
+		})
+	}
+}
+
+func TestInteractor_Import_CreateError(t *testing.T) {
+	interactor := newTestInteractor(t, &storeMockError{})
+
+	report, err := interactor.Import(
+		context.Background(),
+		FormatCSV,
+		strings.NewReader("first_name,last_name\njohn,smith\n"),
+	)
+
+	assert.EqualError(t, err, "create error")
+	assert.Equal(t, 0, report.Created)
+}
+
+func TestInteractor_Export(t *testing.T) {
+	createdAt, err := time.Parse(time.RFC3339, "2021-12-14T20:00:13Z")
+	require.NoError(t, err)
+
+	store := memory.NewUserStorage()
+
+	// More users than the batch size so that more than one page is read.
+	require.NoError(t, store.Create(
+		context.Background(),
+		user.User{CreatedAt: createdAt, ID: "1", FirstName: "john", LastName: "smith"},
+		user.User{CreatedAt: createdAt, ID: "2", FirstName: "joanna", LastName: "smithson, jr"},
+		user.User{CreatedAt: createdAt, ID: "3", FirstName: "jack", LastName: "jones"},
+	))
+
+	cases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			"csv",
+			FormatCSV,
+			"id,first_name,last_name,created_at\n" +
+				"1,john,smith,2021-12-14T20:00:13Z\n" +
+				"2,joanna,\"smithson, jr\",2021-12-14T20:00:13Z\n" +
+				"3,jack,jones,2021-12-14T20:00:13Z\n",
+		},
+		{
+			"ndjson",
+			FormatNDJSON,
+			`{"id":"1","first_name":"john","last_name":"smith","created_at":"2021-12-14T20:00:13Z"}` + "\n" +
+				`{"id":"2","first_name":"joanna","last_name":"smithson, jr","created_at":"2021-12-14T20:00:13Z"}` + "\n" +
+				`{"id":"3","first_name":"jack","last_name":"jones","created_at":"2021-12-14T20:00:13Z"}` + "\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := newTestInteractor(t, store)
+
+			buf := &bytes.Buffer{}
+
+			assert.NoError(t, interactor.Export(context.Background(), c.format, buf))
+			assert.Equal(t, c.expected, buf.String())
+		})
+	}
+}
+
+func TestReport_AddError(t *testing.T) {
+	report := Report{}
+
+	for n := 1; n <= maxRowErrors+1; n++ {
+		report.addError(n, map[string]string{"row": "invalid"})
+	}
+
+	assert.Equal(t, maxRowErrors+1, report.Failed)
+	assert.Len(t, report.Errors, maxRowErrors)
+}
@@ -0,0 +1,10 @@
+package update
+
+// IfMatchHeader holds the entity tag of the version of the user that the
+// client expects to update.
+const IfMatchHeader = "If-Match"
+
+type inputData struct {
+	FirstName string `json:"first_name" validate:"required,min=3,max=100"`
+	LastName  string `json:"last_name" validate:"required,min=3,max=100"`
+}
@@ -0,0 +1,93 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type grpcController struct {
+	validator  validate.Validator
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type GRPCController interface {
+	Update(context.Context, *pb.UpdateRequest) (*pb.UserResponse, error)
+}
+
+func NewGRPCController(
+	validator validate.Validator,
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *grpcController {
+	return &grpcController{
+		validator,
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+// Update requires expected_version to hold the version of the user, as
+// returned when the user is read, so that changes made since the user was
+// read are not overwritten.
+func (c *grpcController) Update(
+	ctx context.Context,
+	req *pb.UpdateRequest,
+) (*pb.UserResponse, error) {
+	if req.ExpectedVersion < 1 {
+		return nil, status.Error(
+			codes.InvalidArgument,
+			"expected_version required",
+		)
+	}
+
+	input := inputData{
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	}
+
+	errs := c.validator.ValidateStruct(input)
+	if errs != nil {
+		c.logger.InfofContext(ctx, "input invalid: %v", errs)
+		return nil, fmt.Errorf("%v", errs)
+	}
+
+	od, err := c.interactor.update(
+		ctx,
+		req.Id,
+		req.ExpectedVersion,
+		input,
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, user.ErrVersionMismatch):
+		c.logger.InfofContext(ctx, "expected_version %d: %v", req.ExpectedVersion, err)
+		return nil, status.Error(codes.Aborted, err.Error())
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		return nil, err
+	}
+
+	vm := c.presenter.viewModel(od)
+
+	return &pb.UserResponse{
+		Id:        vm.ID,
+		FirstName: vm.FirstName,
+		LastName:  vm.LastName,
+		CreatedAt: vm.CreatedAt,
+		Version:   vm.Version,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPC_Update(t *testing.T) {
+	cases := []struct {
+		name             string
+		validator        validate.Validator
+		interactor       interactor
+		request          *pb.UpdateRequest
+		expectedResponse *pb.UserResponse
+		expectedCode     codes.Code
+	}{
+		{
+			"expected version missing",
+			&validatorMock{},
+			&interactorMock{},
+			&pb.UpdateRequest{
+				Id:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName: "joanna",
+				LastName:  "smithson",
+			},
+			nil,
+			codes.InvalidArgument,
+		},
+		{
+			"input invalid",
+			&validatorMockInputInvalid{},
+			&interactorMock{},
+			&pb.UpdateRequest{
+				Id:              "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName:       "ab",
+				ExpectedVersion: 1,
+			},
+			nil,
+			codes.Unknown,
+		},
+		{
+			"not found",
+			&validatorMock{},
+			&interactorMock{err: user.ErrNotFound},
+			&pb.UpdateRequest{
+				Id:              "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName:       "joanna",
+				LastName:        "smithson",
+				ExpectedVersion: 1,
+			},
+			nil,
+			codes.NotFound,
+		},
+		{
+			"version mismatch",
+			&validatorMock{},
+			&interactorMock{err: user.ErrVersionMismatch},
+			&pb.UpdateRequest{
+				Id:              "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName:       "joanna",
+				LastName:        "smithson",
+				ExpectedVersion: 1,
+			},
+			nil,
+			codes.Aborted,
+		},
+		{
+			"interactor update error",
+			&validatorMock{},
+			&interactorMock{err: errors.New("interactor update error")},
+			&pb.UpdateRequest{
+				Id:              "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName:       "joanna",
+				LastName:        "smithson",
+				ExpectedVersion: 1,
+			},
+			nil,
+			codes.Unknown,
+		},
+		{
+			"success",
+			&validatorMock{},
+			&interactorMock{},
+			&pb.UpdateRequest{
+				Id:              "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName:       "joanna",
+				LastName:        "smithson",
+				ExpectedVersion: 1,
+			},
+			&pb.UserResponse{
+				Id:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName: "joanna",
+				LastName:  "smithson",
+				CreatedAt: "2021-12-14T20:00:13Z",
+				Version:   2,
+			},
+			codes.OK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewGRPCController(
+				c.validator,
+				c.interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			resp, err := controller.Update(
+				context.Background(),
+				c.request,
+			)
+
+			assert.Equal(t, c.expectedCode, status.Code(err))
+			assert.Equal(t, c.expectedResponse, resp)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/gorilla/mux"
+)
+
+type httpController struct {
+	validator  validate.Validator
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Update(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	validator validate.Validator,
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		validator,
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+type output struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+	Version   int64  `json:"version"`
+}
+
+// Update requires the If-Match header to hold the ETag of the user, as
+// returned when the user is read, so that changes made since the user was
+// read are not overwritten.
+func (c *httpController) Update(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+	input := inputData{}
+
+	ifMatch := r.Header.Get(IfMatchHeader)
+	if ifMatch == "" {
+		response.WriteErrorResponse(
+			w,
+			http.StatusPreconditionRequired,
+			"precondition required",
+			map[string]string{IfMatchHeader: "required"},
+		)
+		return
+	}
+
+	expectedVersion, err := response.ParseETag(ifMatch)
+	if err != nil {
+		c.logger.InfofContext(ctx, "%s %q: %v", IfMatchHeader, ifMatch, err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{IfMatchHeader: "invalid"},
+		)
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		c.logger.ErrorfContext(ctx, "json body invalid: %v", err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{"body": "json invalid"},
+		)
+		return
+	}
+
+	errs := c.validator.ValidateStruct(input)
+	if errs != nil {
+		c.logger.InfofContext(ctx, "input invalid: %v", errs)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			errs,
+		)
+		return
+	}
+
+	od, err := c.interactor.update(
+		ctx,
+		mux.Vars(r)["id"],
+		expectedVersion,
+		input,
+	)
+
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		response.WriteErrorResponse(
+			w,
+			http.StatusNotFound,
+			err.Error(),
+			nil,
+		)
+		return
+	case errors.Is(err, user.ErrVersionMismatch):
+		c.logger.InfofContext(ctx, "%s %q: %v", IfMatchHeader, ifMatch, err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusPreconditionFailed,
+			err.Error(),
+			nil,
+		)
+		return
+	case err != nil:
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	vm := c.presenter.viewModel(od)
+
+	w.Header().Set("ETag", response.ETag(vm.Version))
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		output(vm),
+	)
+}
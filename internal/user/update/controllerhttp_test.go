@@ -0,0 +1,217 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type validatorMock struct {
+}
+
+func (m *validatorMock) ValidateStruct(interface{}) map[string]string {
+	return nil
+}
+
+type validatorMockInputInvalid struct {
+}
+
+func (m *validatorMockInputInvalid) ValidateStruct(interface{}) map[string]string {
+	return map[string]string{"input": "invalid"}
+}
+
+type interactorMock struct {
+	err error
+}
+
+func (m *interactorMock) update(_ context.Context, id string, expectedVersion int64, in inputData) (outputData, error) {
+	if m.err != nil {
+		return outputData{}, m.err
+	}
+
+	return outputData{
+		CreatedAt: createdAt(),
+		Version:   expectedVersion + 1,
+		ID:        id,
+		FirstName: in.FirstName,
+		LastName:  in.LastName,
+	}, nil
+}
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+func TestRest_Update(t *testing.T) {
+	cases := []struct {
+		name                 string
+		validator            validate.Validator
+		interactor           interactor
+		ifMatch              string
+		body                 io.Reader
+		expectedStatus       int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			"if-match missing",
+			&validatorMock{},
+			&interactorMock{},
+			"",
+			strings.NewReader(`{"first_name": "joanna", "last_name": "smithson"}`),
+			http.StatusPreconditionRequired,
+			"",
+			`{
+									"message": "precondition required",
+									"errors": {
+										"If-Match": "required"
+									}
+								}`,
+		},
+		{
+			"if-match invalid",
+			&validatorMock{},
+			&interactorMock{},
+			`W/"1"`,
+			strings.NewReader(`{"first_name": "joanna", "last_name": "smithson"}`),
+			http.StatusBadRequest,
+			"",
+			`{
+									"message": "failed validation",
+									"errors": {
+										"If-Match": "invalid"
+									}
+								}`,
+		},
+		{
+			"json unmarshall error",
+			&validatorMock{},
+			&interactorMock{},
+			`"1"`,
+			strings.NewReader(`{"first_name:}`),
+			http.StatusBadRequest,
+			"",
+			`{
+									"message": "failed validation",
+									"errors": {
+										"body": "json invalid"
+									}
+								}`,
+		},
+		{
+			"input invalid",
+			&validatorMockInputInvalid{},
+			&interactorMock{},
+			`"1"`,
+			strings.NewReader(`{"first_name": "ab"}`),
+			http.StatusBadRequest,
+			"",
+			`{
+									"message": "failed validation",
+									"errors": {
+										"input": "invalid"
+									}
+								}`,
+		},
+		{
+			"not found",
+			&validatorMock{},
+			&interactorMock{err: user.ErrNotFound},
+			`"1"`,
+			strings.NewReader(`{"first_name": "joanna", "last_name": "smithson"}`),
+			http.StatusNotFound,
+			"",
+			`{
+									"message": "user not found"
+								}`,
+		},
+		{
+			"version mismatch",
+			&validatorMock{},
+			&interactorMock{err: user.ErrVersionMismatch},
+			`"1"`,
+			strings.NewReader(`{"first_name": "joanna", "last_name": "smithson"}`),
+			http.StatusPreconditionFailed,
+			"",
+			`{
+									"message": "user version mismatch"
+								}`,
+		},
+		{
+			"interactor update error",
+			&validatorMock{},
+			&interactorMock{err: errors.New("interactor update error")},
+			`"1"`,
+			strings.NewReader(`{"first_name": "joanna", "last_name": "smithson"}`),
+			http.StatusInternalServerError,
+			"",
+			`{
+									"message": "internal server error"
+								}`,
+		},
+		{
+			"success",
+			&validatorMock{},
+			&interactorMock{},
+			`"1"`,
+			strings.NewReader(`{"first_name": "joanna", "last_name": "smithson"}`),
+			http.StatusOK,
+			`"2"`,
+			`{
+									"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+									"first_name": "joanna",
+									"last_name": "smithson",
+									"created_at": "2021-12-14T20:00:13Z",
+									"version": 2
+								}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/user/0a81dec3-3638-4eb4-b04a-83d744f5f3a8", c.body)
+			r = mux.SetURLVars(r, map[string]string{"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8"})
+			w := httptest.NewRecorder()
+
+			if c.ifMatch != "" {
+				r.Header.Set(IfMatchHeader, c.ifMatch)
+			}
+
+			controller := NewHTTPController(
+				c.validator,
+				c.interactor,
+				NewPresenter(),
+				loggerMock{},
+			)
+
+			controller.Update(w, r)
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			assert.Equal(t, c.expectedETag, w.Header().Get("ETag"))
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
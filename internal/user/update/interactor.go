@@ -0,0 +1,64 @@
+package update
+
+import (
+	"context"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+type i struct {
+	userUpdater user.Updater
+}
+
+type interactor interface {
+	update(ctx context.Context, id string, expectedVersion int64, in inputData) (outputData, error)
+}
+
+var _ interactor = (*i)(nil)
+
+func NewInteractor(
+	userUpdater user.Updater,
+) *i {
+	return &i{
+		userUpdater,
+	}
+}
+
+type outputData struct {
+	CreatedAt time.Time
+	Version   int64
+	ID        string
+	FirstName string
+	LastName  string
+}
+
+// update returns user.ErrVersionMismatch if the user has been changed
+// since expectedVersion was read.
+func (i *i) update(
+	ctx context.Context,
+	id string,
+	expectedVersion int64,
+	inputData inputData,
+) (outputData, error) {
+	u, err := i.userUpdater.Update(
+		ctx,
+		expectedVersion,
+		user.User{
+			ID:        id,
+			FirstName: inputData.FirstName,
+			LastName:  inputData.LastName,
+		},
+	)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	return outputData{
+		CreatedAt: u.CreatedAt,
+		Version:   u.Version,
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+	}, nil
+}
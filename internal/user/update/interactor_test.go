@@ -0,0 +1,103 @@
+package update
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type updaterMock struct {
+	user user.User
+}
+
+func (m *updaterMock) Update(_ context.Context, expectedVersion int64, u user.User) (user.User, error) {
+	if u.ID != m.user.ID {
+		return user.User{}, user.ErrNotFound
+	}
+
+	if expectedVersion != m.user.Version {
+		return user.User{}, user.ErrVersionMismatch
+	}
+
+	m.user.FirstName = u.FirstName
+	m.user.LastName = u.LastName
+	m.user.Version++
+
+	return m.user, nil
+}
+
+func createdAt() time.Time {
+	createdAt, _ := time.Parse(time.RFC3339, "2021-12-14T20:00:13Z")
+
+	return createdAt
+}
+
+func TestInteractor_Update(t *testing.T) {
+	cases := []struct {
+		name               string
+		id                 string
+		expectedVersion    int64
+		expectedOutputData outputData
+		expectedErr        error
+	}{
+		{
+			"not found",
+			"1a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			1,
+			outputData{},
+			user.ErrNotFound,
+		},
+		{
+			"version mismatch",
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			2,
+			outputData{},
+			user.ErrVersionMismatch,
+		},
+		{
+			"success",
+			"0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			1,
+			outputData{
+				CreatedAt: createdAt(),
+				Version:   2,
+				ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+				FirstName: "joanna",
+				LastName:  "smithson",
+			},
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interactor := NewInteractor(
+				&updaterMock{
+					user: user.User{
+						CreatedAt: createdAt(),
+						ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+						FirstName: "john",
+						LastName:  "smith",
+						Version:   1,
+					},
+				},
+			)
+
+			od, err := interactor.update(
+				context.Background(),
+				c.id,
+				c.expectedVersion,
+				inputData{
+					FirstName: "joanna",
+					LastName:  "smithson",
+				},
+			)
+
+			assert.ErrorIs(t, err, c.expectedErr)
+			assert.Equal(t, c.expectedOutputData, od)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package update
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenter_Update(t *testing.T) {
+	presenter := NewPresenter()
+
+	createdAt, err := time.Parse(
+		time.RFC3339,
+		"2015-09-15T14:23:12+07:00")
+	if err != nil {
+		t.Error(err)
+	}
+
+	vm := presenter.viewModel(outputData{
+		CreatedAt: createdAt,
+		Version:   2,
+		ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		FirstName: "john",
+		LastName:  "smith",
+	})
+
+	assert.Equal(t, "0a81dec3-3638-4eb4-b04a-83d744f5f3a8", vm.ID)
+	assert.Equal(t, "john", vm.FirstName)
+	assert.Equal(t, "smith", vm.LastName)
+	assert.Equal(t, "2015-09-15T14:23:12+07:00", vm.CreatedAt)
+	assert.Equal(t, int64(2), vm.Version)
+}
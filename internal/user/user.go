@@ -2,14 +2,36 @@ package user
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrNotFound is returned when no user exists with the requested ID.
+	ErrNotFound = errors.New("user not found")
+	// ErrVersionMismatch is returned when a user is updated with an
+	// expected version that differs from the stored version, meaning that
+	// the user has been changed since it was read.
+	ErrVersionMismatch = errors.New("user version mismatch")
+)
+
+// User is versioned so that concurrent updates are detected. The version
+// is 1 when the user is created, and is incremented by each change.
+//
+// DeletedAt is set when the user is soft deleted. Soft deleted users are
+// excluded from reads until they are restored, or are purged once the
+// retention window has elapsed.
+//
+// Users belong to the tenant carried by the context with which they are
+// created (see tenant.NewContext), and are only visible to that tenant.
 type User struct {
 	CreatedAt time.Time
+	DeletedAt *time.Time
+	TenantID  string
 	ID        string
 	FirstName string
 	LastName  string
+	Version   int64
 }
 
 type CreatorReader interface {
@@ -23,6 +45,81 @@ type Creator interface {
 
 type Reader interface {
 	Read(context.Context) ([]User, error)
+	// ReadPage returns up to limit users following cursor, together with
+	// the cursor for the next page, which is empty once all users have been
+	// read. An empty cursor reads the first page.
+	ReadPage(ctx context.Context, cursor string, limit int) ([]User, string, error)
+}
+
+// ByIDReader returns ErrNotFound from ReadByID if no user exists
+// with the ID.
+type ByIDReader interface {
+	ReadByID(ctx context.Context, id string) (User, error)
+}
+
+// Updater replaces the names of the user with the same ID as u, provided
+// that the stored version equals expectedVersion, and returns the updated
+// user with its incremented version. ErrNotFound is returned if no user
+// exists with the ID, and ErrVersionMismatch if the versions differ.
+type Updater interface {
+	Update(ctx context.Context, expectedVersion int64, u User) (User, error)
+}
+
+// SoftDeleter marks the user with the ID as deleted, returning
+// ErrNotFound if no user, that is not already deleted, exists with the ID.
+type SoftDeleter interface {
+	SoftDelete(ctx context.Context, id string) error
+}
+
+// Restorer clears the deletion of the soft deleted user with the ID, and
+// returns the restored user. ErrNotFound is returned if no soft deleted
+// user exists with the ID (e.g., because it has been purged).
+type Restorer interface {
+	Restore(ctx context.Context, id string) (User, error)
+}
+
+// Purger permanently deletes up to limit users that were soft deleted
+// before deletedBefore, returning the number of users deleted.
+type Purger interface {
+	Purge(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
+}
+
+// SubjectData is the personal data about a user held by a store. User
+// is nil if the store holds no copy of the user, and Events holds events
+// relating to the user that have yet to be published.
+type SubjectData struct {
+	User   *User
+	Events []Event
+}
+
+// SubjectExporter returns the data held about the user with the ID, for
+// subject access requests. ErrNotFound is returned if no data is held.
+type SubjectExporter interface {
+	Export(ctx context.Context, id string) (SubjectData, error)
+}
+
+// SubjectEraser permanently erases all data held about the user with the
+// ID, for erasure requests. Erasing a user about whom no data is held is
+// not an error.
+type SubjectEraser interface {
+	Erase(ctx context.Context, id string) error
+}
+
+type SubjectExporterEraser interface {
+	SubjectExporter
+	SubjectEraser
+}
+
+// Storage is implemented by the store that is the source of truth
+// for users.
+type Storage interface {
+	CreatorReader
+	ByIDReader
+	Updater
+	SoftDeleter
+	Restorer
+	Purger
+	SubjectExporterEraser
 }
 
 type CreatorSearcher interface {
@@ -33,3 +130,83 @@ type CreatorSearcher interface {
 type Searcher interface {
 	Search(ctx context.Context, searchTerm string) ([]User, error)
 }
+
+// Upserter creates or replaces users. Implementations skip users
+// for which a higher version has already been stored.
+type Upserter interface {
+	Upsert(ctx context.Context, version int64, users ...User) error
+}
+
+// Deleter removes users. Implementations skip users for which a
+// higher version has already been stored.
+type Deleter interface {
+	Delete(ctx context.Context, version int64, ids ...string) error
+}
+
+type UpserterDeleter interface {
+	Upserter
+	Deleter
+}
+
+// Event types.
+const (
+	EventCreated  = "user.created"
+	EventUpdated  = "user.updated"
+	EventDeleted  = "user.deleted"
+	EventRestored = "user.restored"
+	EventPurged   = "user.purged"
+)
+
+// Event describes a change to a user. Before is nil for created
+// events and After is nil for deleted and purged events. Before is the
+// soft deleted user for restored events.
+//
+// TraceContext holds the trace context (e.g., traceparent) of the
+// request that made the change, so that it can be propagated when
+// the event is published asynchronously.
+type Event struct {
+	OccurredAt   time.Time
+	Before       *User
+	After        *User
+	TraceContext map[string]string
+	ID           string
+	Type         string
+}
+
+// UserID returns the ID of the user that the event relates to.
+func (e Event) UserID() string {
+	switch {
+	case e.After != nil:
+		return e.After.ID
+	case e.Before != nil:
+		return e.Before.ID
+	default:
+		return ""
+	}
+}
+
+// TenantID returns the ID of the tenant of the user that the event
+// relates to.
+func (e Event) TenantID() string {
+	switch {
+	case e.After != nil:
+		return e.After.TenantID
+	case e.Before != nil:
+		return e.Before.TenantID
+	default:
+		return ""
+	}
+}
+
+// Key returns the key (i.e., <tenant ID>:<user ID>) of the user that the
+// event relates to, which is unique across tenants, for use as a message
+// key.
+func (e Event) Key() string {
+	return e.TenantID() + ":" + e.UserID()
+}
+
+// EventHandler handles change events (e.g., by delivering them to
+// subscribers).
+type EventHandler interface {
+	Handle(context.Context, Event) error
+}
@@ -0,0 +1,104 @@
+package watch
+
+import (
+	user "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/broadcast"
+	"github.com/bendbennett/go-api-demo/internal/log"
+)
+
+type grpcController struct {
+	subscriber Subscriber
+	presenter  presenter
+	logger     log.Logger
+}
+
+type GRPCController interface {
+	Watch(*user.WatchRequest, user.User_WatchServer) error
+}
+
+func NewGRPCController(
+	subscriber Subscriber,
+	presenter presenter,
+	logger log.Logger,
+) *grpcController {
+	return &grpcController{
+		subscriber,
+		presenter,
+		logger,
+	}
+}
+
+// Watch streams user events. Clients that reconnect with the ID of the last
+// event received as req.LastEventId receive the events they missed, provided
+// that these are still held in the broadcaster's buffer. IDs are issued by
+// each process (see broadcast.Message), so clients only resume reliably when
+// reconnecting to the same instance before it restarts. The stream ends when
+// the client cancels, the client falls too far behind or the broadcaster is
+// stopped. Only events for users of the tenant of the call are streamed.
+func (c *grpcController) Watch(
+	req *user.WatchRequest,
+	stream user.User_WatchServer,
+) error {
+	ctx := stream.Context()
+
+	replay, messages, unsubscribe := c.subscriber.Subscribe(req.LastEventId)
+	defer unsubscribe()
+
+	for _, msg := range replay {
+		if !visible(ctx, msg) {
+			continue
+		}
+
+		if err := stream.Send(c.userEvent(msg)); err != nil {
+			c.logger.ErrorContext(ctx, err)
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			if !visible(ctx, msg) {
+				continue
+			}
+
+			if err := stream.Send(c.userEvent(msg)); err != nil {
+				c.logger.ErrorContext(ctx, err)
+				return err
+			}
+		}
+	}
+}
+
+func (c *grpcController) userEvent(msg broadcast.Message) *user.UserEvent {
+	vm := c.presenter.viewModel(msg)
+
+	return &user.UserEvent{
+		Id:         vm.ID,
+		EventId:    vm.EventID,
+		Type:       vm.Type,
+		OccurredAt: vm.OccurredAt,
+		Before:     userResponse(vm.Before),
+		After:      userResponse(vm.After),
+	}
+}
+
+func userResponse(u *usr) *user.UserResponse {
+	if u == nil {
+		return nil
+	}
+
+	return &user.UserResponse{
+		Id:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		CreatedAt: u.CreatedAt,
+		Version:   u.Version,
+	}
+}
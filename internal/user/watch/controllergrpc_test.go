@@ -0,0 +1,146 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/bendbennett/go-api-demo/generated"
+	"github.com/bendbennett/go-api-demo/internal/broadcast"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type watchServerMock struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.UserEvent
+}
+
+func (m *watchServerMock) Context() context.Context {
+	return m.ctx
+}
+
+func (m *watchServerMock) Send(evt *pb.UserEvent) error {
+	m.events <- evt
+	return nil
+}
+
+func TestGRPCController_Watch(t *testing.T) {
+	createdAt, err := time.Parse(time.RFC3339, "2021-12-14T20:00:13Z")
+	assert.NoError(t, err)
+
+	usr := &user.User{
+		CreatedAt: createdAt,
+		TenantID:  "default",
+		ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		FirstName: "john",
+		LastName:  "smith",
+		Version:   1,
+	}
+
+	broadcaster := broadcast.NewBroadcaster(10, 10)
+
+	assert.NoError(t, broadcaster.Handle(context.Background(), user.Event{
+		OccurredAt: createdAt,
+		After:      usr,
+		ID:         "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+		Type:       user.EventCreated,
+	}))
+
+	controller := NewGRPCController(
+		broadcaster,
+		NewPresenter(),
+		loggerMock{},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream := &watchServerMock{
+		ctx:    ctx,
+		events: make(chan *pb.UserEvent, 100),
+	}
+
+	done := make(chan error)
+
+	go func() {
+		done <- controller.Watch(&pb.WatchRequest{LastEventId: 0}, stream)
+	}()
+
+	// The created event precedes the subscription, and is not replayed
+	// as no last event ID was supplied.
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, broadcaster.Handle(context.Background(), user.Event{
+			OccurredAt: createdAt,
+			Before:     usr,
+			ID:         "9d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+			Type:       user.EventDeleted,
+		}))
+
+		return len(stream.events) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	evt := <-stream.events
+
+	assert.Equal(t, user.EventDeleted, evt.Type)
+	assert.Equal(t, "9d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77", evt.EventId)
+	assert.Equal(t, "2021-12-14T20:00:13Z", evt.OccurredAt)
+	assert.Nil(t, evt.After)
+	assert.Equal(t, &pb.UserResponse{
+		Id:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		FirstName: "john",
+		LastName:  "smith",
+		CreatedAt: "2021-12-14T20:00:13Z",
+		Version:   1,
+	}, evt.Before)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestGRPCController_Watch_Resume(t *testing.T) {
+	broadcaster := broadcast.NewBroadcaster(10, 10)
+
+	_, published, unsubscribe := broadcaster.Subscribe(0)
+	defer unsubscribe()
+
+	var publishedIDs []uint64
+
+	for _, tenantID := range []string{"default", "default", "acme", "default"} {
+		assert.NoError(t, broadcaster.Handle(context.Background(), user.Event{
+			After: &user.User{TenantID: tenantID},
+		}))
+
+		publishedIDs = append(publishedIDs, (<-published).ID)
+	}
+
+	controller := NewGRPCController(
+		broadcaster,
+		NewPresenter(),
+		loggerMock{},
+	)
+
+	stream := &watchServerMock{
+		ctx:    context.Background(),
+		events: make(chan *pb.UserEvent, 100),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Stopping the broadcaster ends the stream once missed events
+	// have been replayed. Events for other tenants are not replayed.
+	assert.NoError(t, broadcaster.Run(ctx))
+	assert.NoError(t, controller.Watch(&pb.WatchRequest{LastEventId: publishedIDs[0]}, stream))
+
+	close(stream.events)
+
+	var ids []uint64
+
+	for evt := range stream.events {
+		ids = append(ids, evt.Id)
+	}
+
+	assert.Equal(t, []uint64{publishedIDs[1], publishedIDs[3]}, ids)
+}
@@ -0,0 +1,186 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/broadcast"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+)
+
+// Subscriber is implemented by the broadcaster that user events are
+// streamed from.
+type Subscriber interface {
+	Subscribe(lastID uint64) ([]broadcast.Message, <-chan broadcast.Message, func())
+}
+
+// visible reports whether msg relates to a user of the tenant carried by
+// ctx, as the broadcaster fans out events for all tenants.
+func visible(ctx context.Context, msg broadcast.Message) bool {
+	return msg.Event.TenantID() == tenant.ID(ctx)
+}
+
+type httpController struct {
+	subscriber        Subscriber
+	presenter         presenter
+	logger            log.Logger
+	heartbeatInterval time.Duration
+}
+
+type HTTPController interface {
+	Events(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	subscriber Subscriber,
+	presenter presenter,
+	logger log.Logger,
+	heartbeatInterval time.Duration,
+) *httpController {
+	return &httpController{
+		subscriber,
+		presenter,
+		logger,
+		heartbeatInterval,
+	}
+}
+
+type eventUser struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	CreatedAt string `json:"created_at"`
+	Version   int64  `json:"version"`
+}
+
+type event struct {
+	EventID    string     `json:"event_id"`
+	OccurredAt string     `json:"occurred_at"`
+	Before     *eventUser `json:"before"`
+	After      *eventUser `json:"after"`
+}
+
+// Events streams user events as server-sent events. Clients that reconnect
+// with a Last-Event-ID header receive the events they missed, provided that
+// these are still held in the broadcaster's buffer. IDs are issued by each
+// process (see broadcast.Message), so clients only resume reliably when
+// reconnecting to the same instance before it restarts. The stream ends when
+// the client disconnects or the broadcaster is stopped. Comments are sent at
+// heartbeatInterval to stop idle connections from being closed by proxies.
+// Only events for users of the tenant of the request are streamed.
+func (c *httpController) Events(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		c.logger.ErrorfContext(ctx, "response writer does not support flushing")
+		response.Write500Response(w)
+		return
+	}
+
+	var lastID uint64
+
+	if h := r.Header.Get("Last-Event-ID"); h != "" {
+		id, err := strconv.ParseUint(h, 10, 64)
+		if err != nil {
+			response.WriteErrorResponse(
+				w,
+				http.StatusBadRequest,
+				"failed validation",
+				map[string]string{"Last-Event-ID": "must be a positive integer"},
+			)
+			return
+		}
+
+		lastID = id
+	}
+
+	replay, messages, unsubscribe := c.subscriber.Subscribe(lastID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, msg := range replay {
+		if !visible(ctx, msg) {
+			continue
+		}
+
+		if err := c.write(w, msg); err != nil {
+			c.logger.ErrorContext(ctx, err)
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(c.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			if !visible(ctx, msg) {
+				continue
+			}
+
+			if err := c.write(w, msg); err != nil {
+				c.logger.ErrorContext(ctx, err)
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+func (c *httpController) write(
+	w http.ResponseWriter,
+	msg broadcast.Message,
+) error {
+	vm := c.presenter.viewModel(msg)
+
+	data, err := json.Marshal(
+		event{
+			EventID:    vm.EventID,
+			OccurredAt: vm.OccurredAt,
+			Before:     (*eventUser)(vm.Before),
+			After:      (*eventUser)(vm.After),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(
+		w,
+		"id: %d\nevent: %s\ndata: %s\n\n",
+		vm.ID,
+		vm.Type,
+		data,
+	)
+
+	return err
+}
@@ -0,0 +1,252 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/app"
+	"github.com/bendbennett/go-api-demo/internal/broadcast"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+func TestHTTPController_Events(t *testing.T) {
+	createdAt, err := time.Parse(time.RFC3339, "2021-12-14T20:00:13Z")
+	assert.NoError(t, err)
+
+	created := user.Event{
+		OccurredAt: createdAt,
+		After: &user.User{
+			CreatedAt: createdAt,
+			TenantID:  "default",
+			ID:        "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			FirstName: "john",
+			LastName:  "smith",
+			Version:   1,
+		},
+		ID:   "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+		Type: user.EventCreated,
+	}
+
+	deleted := user.Event{
+		OccurredAt: createdAt,
+		Before:     created.After,
+		ID:         "9d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+		Type:       user.EventDeleted,
+	}
+
+	// Events for other tenants are not streamed.
+	otherTenant := user.Event{
+		OccurredAt: createdAt,
+		After: &user.User{
+			CreatedAt: createdAt,
+			TenantID:  "acme",
+			ID:        "1b81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		},
+		ID:   "7d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+		Type: user.EventCreated,
+	}
+
+	createdData := `{"event_id":"8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77","occurred_at":"2021-12-14T20:00:13Z","before":null,"after":{"id":"0a81dec3-3638-4eb4-b04a-83d744f5f3a8","first_name":"john","last_name":"smith","created_at":"2021-12-14T20:00:13Z","version":1}}`
+	deletedData := `{"event_id":"9d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77","occurred_at":"2021-12-14T20:00:13Z","before":{"id":"0a81dec3-3638-4eb4-b04a-83d744f5f3a8","first_name":"john","last_name":"smith","created_at":"2021-12-14T20:00:13Z","version":1},"after":null}`
+
+	// The IDs issued by the broadcaster start from the time at which it was
+	// created, so the expected IDs are those of the events published, and
+	// lastEventID is an index into them unless it is invalid.
+	cases := []struct {
+		name           string
+		lastEventID    string
+		expectedStatus int
+		expectedBody   func(ids []uint64) string
+	}{
+		{
+			"streams events",
+			"",
+			http.StatusOK,
+			func(ids []uint64) string {
+				return fmt.Sprintf("id: %d\nevent: user.created\ndata: %s\n\n", ids[0], createdData) +
+					fmt.Sprintf("id: %d\nevent: user.deleted\ndata: %s\n\n", ids[2], deletedData)
+			},
+		},
+		{
+			"resumes after last event id",
+			"0",
+			http.StatusOK,
+			func(ids []uint64) string {
+				return fmt.Sprintf("id: %d\nevent: user.deleted\ndata: %s\n\n", ids[2], deletedData)
+			},
+		},
+		{
+			"invalid last event id",
+			"abc",
+			http.StatusBadRequest,
+			func([]uint64) string {
+				return `{"errors":{"Last-Event-ID":"must be a positive integer"},"message":"failed validation"}` + "\n"
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			broadcaster := broadcast.NewBroadcaster(10, 10)
+
+			_, published, unsubscribe := broadcaster.Subscribe(0)
+			defer unsubscribe()
+
+			var ids []uint64
+
+			publish := func() {
+				assert.NoError(t, broadcaster.Handle(context.Background(), created))
+				assert.NoError(t, broadcaster.Handle(context.Background(), otherTenant))
+				assert.NoError(t, broadcaster.Handle(context.Background(), deleted))
+
+				for range 3 {
+					ids = append(ids, (<-published).ID)
+				}
+			}
+
+			lastEventID := c.lastEventID
+
+			if c.lastEventID != "" {
+				// Events published before the client reconnected.
+				publish()
+
+				if n, err := strconv.Atoi(c.lastEventID); err == nil {
+					lastEventID = strconv.FormatUint(ids[n], 10)
+				}
+			}
+
+			controller := NewHTTPController(
+				broadcaster,
+				NewPresenter(),
+				loggerMock{},
+				time.Minute,
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(controller.Events))
+			defer srv.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			require.NoError(t, err)
+
+			if lastEventID != "" {
+				req.Header.Set("Last-Event-ID", lastEventID)
+			}
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, c.expectedStatus, resp.StatusCode)
+
+			if c.lastEventID == "" {
+				// The controller has subscribed once headers are received.
+				publish()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			// Stopping the broadcaster ends the stream.
+			assert.NoError(t, broadcaster.Run(ctx))
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expectedBody(ids), string(body))
+		})
+	}
+}
+
+// server runs srv as an app component, with Stop draining requests as
+// routing.HTTPRouter does.
+type server struct {
+	srv *http.Server
+	lis net.Listener
+}
+
+func (s server) Run(context.Context) error {
+	if err := s.srv.Serve(s.lis); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s server) Stop(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+func TestHTTPController_Events_Shutdown(t *testing.T) {
+	broadcaster := broadcast.NewBroadcaster(10, 10)
+
+	controller := NewHTTPController(
+		broadcaster,
+		NewPresenter(),
+		loggerMock{},
+		time.Minute,
+	)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// The broadcaster follows the server, as in bootstrap, so that it is
+	// stopped first.
+	a := app.New(
+		[]app.Component{
+			server{&http.Server{Handler: http.HandlerFunc(controller.Events)}, lis},
+			broadcaster,
+		},
+		nil,
+		10*time.Second,
+		loggerMock{},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+
+	resp, err := http.Get("http://" + lis.Addr().String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+
+	// Shutdown completes without waiting for the drain timeout, as the
+	// stream of the connected subscriber is ended.
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("shutdown waited for the stream to end")
+	}
+
+	_, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,63 @@
+package watch
+
+import (
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/broadcast"
+	"github.com/bendbennett/go-api-demo/internal/user"
+)
+
+type p struct {
+}
+
+type presenter interface {
+	viewModel(msg broadcast.Message) viewModel
+}
+
+var _ presenter = (*p)(nil)
+
+func NewPresenter() presenter {
+	return &p{}
+}
+
+type viewModel struct {
+	Before     *usr
+	After      *usr
+	ID         uint64
+	EventID    string
+	Type       string
+	OccurredAt string
+}
+
+type usr struct {
+	ID        string
+	FirstName string
+	LastName  string
+	CreatedAt string
+	Version   int64
+}
+
+func (p *p) viewModel(msg broadcast.Message) viewModel {
+	return viewModel{
+		Before:     userViewModel(msg.Event.Before),
+		After:      userViewModel(msg.Event.After),
+		ID:         msg.ID,
+		EventID:    msg.Event.ID,
+		Type:       msg.Event.Type,
+		OccurredAt: msg.Event.OccurredAt.Format(time.RFC3339),
+	}
+}
+
+func userViewModel(u *user.User) *usr {
+	if u == nil {
+		return nil
+	}
+
+	return &usr{
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		CreatedAt: u.CreatedAt.Format(time.RFC3339),
+		Version:   u.Version,
+	}
+}
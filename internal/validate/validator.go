@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"net/url"
 	"reflect"
 	"strings"
 
@@ -58,6 +59,25 @@ func validator(trans universal.Translator) (*v9validator.Validate, error) {
 		return val, err
 	}
 
+	if err := val.RegisterValidation("https_url", httpsURL); err != nil {
+		return val, err
+	}
+
+	err := val.RegisterTranslation(
+		"https_url",
+		trans,
+		func(ut universal.Translator) error {
+			return ut.Add("https_url", "{0} must be a valid https URL", false)
+		},
+		func(ut universal.Translator, fe v9validator.FieldError) string {
+			t, _ := ut.T("https_url", fe.Field())
+			return t
+		},
+	)
+	if err != nil {
+		return val, err
+	}
+
 	val.RegisterTagNameFunc(func(field reflect.StructField) string {
 		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
 
@@ -71,6 +91,16 @@ func validator(trans universal.Translator) (*v9validator.Validate, error) {
 	return val, nil
 }
 
+// httpsURL validates that the field is an absolute https URL with a host.
+func httpsURL(fl v9validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "https" && u.Host != ""
+}
+
 func IsUUID(u string) bool {
 	_, err := uuid.Parse(u)
 	return err == nil
@@ -38,3 +38,30 @@ func TestValidator_ValidateStruct(t *testing.T) {
 
 	assert.Equal(t, expected, validator.ValidateStruct(outerStruct))
 }
+
+func TestValidator_HTTPSURL(t *testing.T) {
+	type input struct {
+		URL string `json:"url" validate:"https_url"`
+	}
+
+	validator, err := NewValidator()
+	if err != nil {
+		t.Error(err)
+	}
+
+	cases := []struct {
+		url      string
+		expected map[string]string
+	}{
+		{"https://example.com/hook", nil},
+		{"http://example.com/hook", map[string]string{"url": "url must be a valid https URL"}},
+		{"https:///hook", map[string]string{"url": "url must be a valid https URL"}},
+		{"not a url", map[string]string{"url": "url must be a valid https URL"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			assert.Equal(t, c.expected, validator.ValidateStruct(input{c.url}))
+		})
+	}
+}
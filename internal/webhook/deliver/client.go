@@ -0,0 +1,68 @@
+package deliver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed is returned when a delivery would connect to an
+// address that is not publicly routable (e.g., loopback, link-local or
+// private), as subscriptions could otherwise be used to reach internal
+// services (i.e., SSRF).
+var ErrAddressNotAllowed = errors.New("address not allowed")
+
+// NewClient returns an HTTP client for delivering webhooks that refuses to
+// connect to addresses that are not publicly routable. Addresses are
+// checked when dialling, rather than when subscriptions are validated, so
+// that hosts resolving (or redirecting) to internal addresses are refused.
+// Proxies are not used, as the address of the proxy would be checked
+// rather than that of the subscription.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		Timeout: timeout,
+	}
+}
+
+func control(_ string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !allowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// allowed reports whether addr is publicly routable.
+func allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is used for carrier-grade NAT (RFC 6598), so is not
+// publicly routable.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
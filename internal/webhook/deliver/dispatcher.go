@@ -0,0 +1,402 @@
+package deliver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/cloudevents"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+	"github.com/google/uuid"
+)
+
+// EventIDHeader contains the ID of the event being delivered, which is
+// the same for every attempt so that receivers can discard duplicates.
+const EventIDHeader = "X-Webhook-Event-ID"
+
+type httpClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// leaseMargin is added to the client timeout to give the period for which
+// a claimed delivery is deferred, which allows for recording the attempt.
+const leaseMargin = time.Minute
+
+type d struct {
+	subscriptions  webhook.SubscriptionReader
+	deliveryLog    webhook.DeliveryCreator
+	queue          webhook.PendingQueue
+	client         httpClient
+	log            log.Logger
+	now            func() time.Time
+	wake           chan struct{}
+	mu             sync.Mutex
+	inflight       sync.WaitGroup
+	stopping       bool
+	source         string
+	workers        int
+	pollInterval   time.Duration
+	lease          time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+var _ user.EventHandler = (*d)(nil)
+
+// NewDispatcher returns a dispatcher that delivers user events to matching
+// subscriptions as signed CloudEvents (structured mode), with source as the
+// event source. Deliveries are recorded in queue until they succeed or
+// exhaust their attempts.
+func NewDispatcher(
+	conf config.Webhook,
+	source string,
+	subscriptions webhook.SubscriptionReader,
+	deliveryLog webhook.DeliveryCreator,
+	queue webhook.PendingQueue,
+	client httpClient,
+	log log.Logger,
+) *d {
+	return &d{
+		subscriptions:  subscriptions,
+		deliveryLog:    deliveryLog,
+		queue:          queue,
+		client:         client,
+		log:            log,
+		now:            time.Now,
+		wake:           make(chan struct{}, 1),
+		source:         source,
+		workers:        conf.Workers,
+		pollInterval:   conf.PollInterval,
+		lease:          conf.Timeout + leaseMargin,
+		maxAttempts:    conf.MaxAttempts,
+		initialBackoff: conf.InitialBackoff,
+		maxBackoff:     conf.MaxBackoff,
+	}
+}
+
+// Handle records a pending delivery of evt to each matching subscription of
+// the tenant of evt, and returns without waiting for the deliveries to be
+// attempted, so that an unresponsive receiver does not hold up the consumer
+// feeding the dispatcher. Recorded deliveries are made by Run, at least once,
+// and receivers discard duplicates using the event ID. An error is returned,
+// so that the event is consumed again, if the deliveries cannot be recorded.
+func (d *d) Handle(
+	ctx context.Context,
+	evt user.Event,
+) error {
+	// Subscriptions, and the deliveries to them, are scoped to the tenant
+	// of the event, so that events are not delivered to other tenants.
+	ctx = tenant.NewContext(ctx, evt.TenantID())
+	tenantID := tenant.ID(ctx)
+
+	subscriptions, err := d.subscriptions.ReadSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var matching []webhook.Subscription
+
+	for _, s := range subscriptions {
+		if s.TenantID == tenantID && s.Matches(evt.Type) {
+			matching = append(matching, s)
+		}
+	}
+
+	if len(matching) == 0 {
+		return nil
+	}
+
+	e, err := cloudevents.FromUserEvent(ctx, d.source, evt)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	now := d.now()
+	pending := make([]webhook.Pending, 0, len(matching))
+
+	for _, s := range matching {
+		pending = append(pending, webhook.Pending{
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			ID:             uuid.New().String(),
+			SubscriptionID: s.ID,
+			EventID:        e.ID,
+			Event:          b,
+		})
+	}
+
+	if err := d.queue.CreatePending(ctx, pending...); err != nil {
+		return err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Run attempts pending deliveries as they fall due, polling every
+// pollInterval and whenever Handle records deliveries, until ctx is
+// cancelled.
+func (d *d) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for d.poll(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			d.log.Infof("%s", ctx.Err())
+			return nil
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// poll claims up to workers due deliveries and attempts them concurrently.
+// True is returned if as many deliveries as workers were claimed, in which
+// case more may be due.
+func (d *d) poll(ctx context.Context) bool {
+	d.mu.Lock()
+	if d.stopping {
+		d.mu.Unlock()
+		return false
+	}
+	d.inflight.Add(1)
+	d.mu.Unlock()
+
+	defer d.inflight.Done()
+
+	pending, err := d.queue.ClaimPending(ctx, d.now(), d.lease, d.workers)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.log.ErrorContext(ctx, err)
+		}
+
+		return false
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(pending))
+
+	for _, p := range pending {
+		go func() {
+			defer wg.Done()
+
+			d.process(ctx, p)
+		}()
+	}
+
+	wg.Wait()
+
+	return len(pending) == d.workers
+}
+
+// Stop stops the claiming of pending deliveries, and waits for in-flight
+// attempts to complete, or for ctx to be done. Deliveries remain pending
+// until attempted once the dispatcher is run again.
+func (d *d) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	d.stopping = true
+	d.mu.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		d.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries not drained: %w", ctx.Err())
+	}
+}
+
+// process makes the next attempt of the pending delivery p, recording it
+// in the delivery log. The delivery is rescheduled with exponential backoff
+// if no response was received or the response indicates that a retry may
+// succeed, and attempts remain, and is otherwise deleted. A delivery that
+// is neither rescheduled nor deleted is claimed again once its lease ends.
+func (d *d) process(
+	ctx context.Context,
+	p webhook.Pending,
+) {
+	ctx = tenant.NewContext(ctx, p.TenantID)
+
+	s, err := d.subscriptions.ReadSubscription(ctx, p.SubscriptionID)
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		d.delete(ctx, p)
+		return
+	case err != nil:
+		d.log.ErrorContext(ctx, err)
+		return
+	}
+
+	var e cloudevents.Event
+
+	if err := json.Unmarshal(p.Event, &e); err != nil {
+		d.log.ErrorfContext(
+			ctx,
+			"webhook delivery of event %s to subscription %s discarded: %v",
+			p.EventID,
+			p.SubscriptionID,
+			err,
+		)
+		d.delete(ctx, p)
+		return
+	}
+
+	attempt := p.Attempt + 1
+
+	statusCode, err := d.attempt(ctx, s, e, attempt)
+	if err != nil && retryable(statusCode) && attempt < d.maxAttempts {
+		p.Attempt = attempt
+		p.NextAttemptAt = d.now().Add(d.backoff(attempt + 1))
+
+		if err := d.queue.ReschedulePending(ctx, p); err != nil {
+			d.log.ErrorContext(ctx, err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		d.log.ErrorfContext(
+			ctx,
+			"webhook delivery of event %s to subscription %s failed: %v",
+			p.EventID,
+			p.SubscriptionID,
+			err,
+		)
+	}
+
+	d.delete(ctx, p)
+}
+
+func (d *d) delete(
+	ctx context.Context,
+	p webhook.Pending,
+) {
+	if err := d.queue.DeletePending(ctx, p.ID); err != nil {
+		d.log.ErrorContext(ctx, err)
+	}
+}
+
+func (d *d) attempt(
+	ctx context.Context,
+	s webhook.Subscription,
+	e cloudevents.Event,
+	attempt int,
+) (int, error) {
+	delivery := webhook.Delivery{
+		AttemptedAt:    time.Now(),
+		ID:             uuid.New().String(),
+		SubscriptionID: s.ID,
+		EventID:        e.ID,
+		EventType:      e.Type,
+		Attempt:        attempt,
+	}
+
+	statusCode, err := d.send(ctx, s, e)
+
+	delivery.Duration = time.Since(delivery.AttemptedAt)
+	delivery.StatusCode = statusCode
+
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if logErr := d.deliveryLog.CreateDelivery(ctx, delivery); logErr != nil {
+		d.log.ErrorContext(ctx, logErr)
+	}
+
+	return statusCode, err
+}
+
+func (d *d) send(
+	ctx context.Context,
+	s webhook.Subscription,
+	e cloudevents.Event,
+) (int, error) {
+	req, err := cloudevents.NewHTTPRequest(ctx, s.URL, e, cloudevents.ModeStructured)
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set(SignatureHeader, Sign(s.Secret, time.Now(), b))
+	req.Header.Set(EventIDHeader, e.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns initialBackoff doubled for each attempt after the
+// second, limited to maxBackoff.
+func (d *d) backoff(attempt int) time.Duration {
+	b := d.initialBackoff
+
+	for n := 2; n < attempt && b < d.maxBackoff; n++ {
+		b *= 2
+	}
+
+	return min(b, d.maxBackoff)
+}
+
+// retryable returns true if no response was received (statusCode is 0),
+// or the status code indicates a temporary failure.
+func retryable(statusCode int) bool {
+	switch {
+	case statusCode == 0,
+		statusCode == http.StatusRequestTimeout,
+		statusCode == http.StatusTooManyRequests,
+		statusCode >= http.StatusInternalServerError:
+		return true
+	default:
+		return false
+	}
+}
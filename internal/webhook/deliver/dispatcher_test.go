@@ -0,0 +1,451 @@
+package deliver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/cloudevents"
+	"github.com/bendbennett/go-api-demo/internal/config"
+	"github.com/bendbennett/go-api-demo/internal/storage/memory"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/bendbennett/go-api-demo/internal/user"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+const secret = "whsec_test"
+
+// queueSpy records the deliveries rescheduled.
+type queueSpy struct {
+	webhook.PendingQueue
+	rescheduled []webhook.Pending
+}
+
+func (q *queueSpy) ReschedulePending(ctx context.Context, p webhook.Pending) error {
+	q.rescheduled = append(q.rescheduled, p)
+
+	return q.PendingQueue.ReschedulePending(ctx, p)
+}
+
+func userEvent() user.Event {
+	return user.Event{
+		OccurredAt: time.Now(),
+		After: &user.User{
+			TenantID:  "default",
+			ID:        "f1e1c3b4-3b0a-4b8a-9b1a-1f1d1e1c1b1a",
+			FirstName: "john",
+			LastName:  "smith",
+		},
+		ID:   "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77",
+		Type: user.EventCreated,
+	}
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	cases := []struct {
+		name             string
+		statusCodes      []int
+		expectedAttempts int
+		expectedBackoffs []time.Duration
+	}{
+		{
+			"success on first attempt",
+			[]int{http.StatusOK},
+			1,
+			nil,
+		},
+		{
+			"retries server errors until success",
+			[]int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusNoContent},
+			3,
+			[]time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			"client error is not retried",
+			[]int{http.StatusBadRequest},
+			1,
+			nil,
+		},
+		{
+			"gives up after max attempts",
+			[]int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			3,
+			[]time.Duration{time.Second, 2 * time.Second},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests int
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				assert.NoError(t, Verify(secret, r.Header.Get(SignatureHeader), body, time.Now(), time.Minute))
+				assert.Equal(t, "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77", r.Header.Get(EventIDHeader))
+
+				req := httptest.NewRequest(r.Method, "/", bytes.NewReader(body))
+				req.Header = r.Header
+
+				e, err := cloudevents.FromHTTPRequest(req)
+				if assert.NoError(t, err) {
+					assert.Equal(t, user.EventCreated, e.Type)
+				}
+
+				w.WriteHeader(c.statusCodes[requests])
+				requests++
+			}))
+			defer srv.Close()
+
+			ctx := context.Background()
+			storage := memory.NewWebhookStorage()
+
+			require.NoError(t, storage.CreateSubscription(ctx, webhook.Subscription{
+				ID:         "sub",
+				URL:        srv.URL,
+				Secret:     secret,
+				EventTypes: []string{user.EventCreated},
+			}))
+
+			queue := &queueSpy{PendingQueue: storage}
+
+			d := NewDispatcher(
+				config.Webhook{
+					Workers:        1,
+					MaxAttempts:    3,
+					InitialBackoff: time.Second,
+					MaxBackoff:     time.Minute,
+				},
+				"/go-api-demo",
+				storage,
+				storage,
+				queue,
+				srv.Client(),
+				loggerMock{},
+			)
+
+			now := time.Now()
+			d.now = func() time.Time {
+				return now
+			}
+
+			require.NoError(t, d.Handle(ctx, userEvent()))
+			assert.Zero(t, requests, "Handle does not deliver")
+
+			var backoffs []time.Duration
+
+			for attempt := 1; attempt <= c.expectedAttempts; attempt++ {
+				require.True(t, d.poll(ctx), "attempt %d is due", attempt)
+				assert.False(t, d.poll(ctx), "attempt %d is not retried before its backoff", attempt)
+
+				if len(queue.rescheduled) < attempt {
+					break
+				}
+
+				next := queue.rescheduled[attempt-1]
+				assert.Equal(t, attempt, next.Attempt)
+
+				backoffs = append(backoffs, next.NextAttemptAt.Sub(now))
+				now = next.NextAttemptAt
+			}
+
+			assert.Equal(t, c.expectedAttempts, requests)
+			assert.Equal(t, c.expectedBackoffs, backoffs)
+
+			pending, err := storage.ClaimPending(ctx, now.Add(time.Hour), 0, 1)
+			require.NoError(t, err)
+			assert.Empty(t, pending, "delivery is no longer pending")
+
+			deliveries, err := storage.ReadDeliveries(ctx, "sub", 10)
+			require.NoError(t, err)
+			require.Len(t, deliveries, c.expectedAttempts)
+
+			// Most recent first.
+			for n, delivery := range deliveries {
+				assert.Equal(t, c.expectedAttempts-n, delivery.Attempt)
+				assert.Equal(t, c.statusCodes[c.expectedAttempts-n-1], delivery.StatusCode)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := &d{
+		initialBackoff: time.Second,
+		maxBackoff:     5 * time.Second,
+	}
+
+	assert.Equal(t, time.Second, d.backoff(2))
+	assert.Equal(t, 2*time.Second, d.backoff(3))
+	assert.Equal(t, 4*time.Second, d.backoff(4))
+	assert.Equal(t, 5*time.Second, d.backoff(5))
+	assert.Equal(t, 5*time.Second, d.backoff(10))
+}
+
+func TestDispatcher_Handle(t *testing.T) {
+	storage := memory.NewWebhookStorage()
+
+	require.NoError(t, storage.CreateSubscription(context.Background(), webhook.Subscription{
+		ID:         "created",
+		URL:        "https://example.com",
+		EventTypes: []string{user.EventCreated},
+	}))
+
+	d := NewDispatcher(
+		config.Webhook{},
+		"/go-api-demo",
+		storage,
+		storage,
+		storage,
+		http.DefaultClient,
+		loggerMock{},
+	)
+
+	// Handling the same event again does not deliver it again.
+	require.NoError(t, d.Handle(context.Background(), userEvent()))
+	require.NoError(t, d.Handle(context.Background(), userEvent()))
+
+	pending, err := storage.ClaimPending(context.Background(), time.Now().Add(time.Hour), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	assert.Equal(t, "default", pending[0].TenantID)
+	assert.Equal(t, "created", pending[0].SubscriptionID)
+	assert.Equal(t, "8d4e8a4c-2d5b-4a8e-a4e4-0e3c1f2b6a77", pending[0].EventID)
+	assert.Zero(t, pending[0].Attempt)
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	release := make(chan struct{})
+
+	// The receiver does not respond until released, and Handle returns
+	// regardless.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	storage := memory.NewWebhookStorage()
+
+	require.NoError(t, storage.CreateSubscription(context.Background(), webhook.Subscription{
+		ID:         "created",
+		URL:        srv.URL,
+		EventTypes: []string{user.EventCreated},
+	}))
+	require.NoError(t, storage.CreateSubscription(context.Background(), webhook.Subscription{
+		ID:         "deleted",
+		URL:        srv.URL,
+		EventTypes: []string{user.EventDeleted},
+	}))
+
+	// Subscriptions of other tenants are not notified.
+	require.NoError(t, storage.CreateSubscription(tenant.NewContext(context.Background(), "acme"), webhook.Subscription{
+		ID:         "other-tenant",
+		URL:        srv.URL,
+		EventTypes: []string{user.EventCreated},
+	}))
+
+	d := NewDispatcher(
+		config.Webhook{
+			Workers:      1,
+			PollInterval: time.Hour,
+			MaxAttempts:  1,
+		},
+		"/go-api-demo",
+		storage,
+		storage,
+		storage,
+		srv.Client(),
+		loggerMock{},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- d.Run(ctx)
+	}()
+
+	handled := make(chan error)
+	go func() {
+		handled <- d.Handle(ctx, userEvent())
+	}()
+
+	select {
+	case err := <-handled:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Handle waited for delivery")
+	}
+
+	close(release)
+
+	// Handle wakes Run, so the delivery is made before the poll interval.
+	assert.Eventually(t, func() bool {
+		deliveries, err := storage.ReadDeliveries(context.Background(), "created", 10)
+		return err == nil && len(deliveries) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	deliveries, err := storage.ReadDeliveries(context.Background(), "deleted", 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	deliveries, err = storage.ReadDeliveries(tenant.NewContext(context.Background(), "acme"), "other-tenant", 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	require.NoError(t, d.Stop(ctx))
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestDispatcher_Stop(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	storage := memory.NewWebhookStorage()
+
+	require.NoError(t, storage.CreateSubscription(context.Background(), webhook.Subscription{
+		ID:         "created",
+		URL:        srv.URL,
+		EventTypes: []string{user.EventCreated},
+	}))
+
+	d := NewDispatcher(
+		config.Webhook{
+			Workers:      1,
+			PollInterval: time.Hour,
+			MaxAttempts:  1,
+		},
+		"/go-api-demo",
+		storage,
+		storage,
+		storage,
+		srv.Client(),
+		loggerMock{},
+	)
+
+	runCtx, cancelRun := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- d.Run(runCtx)
+	}()
+
+	require.NoError(t, d.Handle(context.Background(), userEvent()))
+
+	<-received
+
+	// Stop does not return until the in-flight delivery completes.
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelStop()
+
+	assert.ErrorIs(t, d.Stop(stopCtx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, d.Stop(context.Background()))
+
+	deliveries, err := storage.ReadDeliveries(context.Background(), "created", 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	// Deliveries recorded once stopped remain pending.
+	evt := userEvent()
+	evt.ID = "5f0c1c5e-6d0b-4d43-9a0e-3b8c1f6f2f4d"
+
+	require.NoError(t, d.Handle(context.Background(), evt))
+	assert.False(t, d.poll(runCtx))
+
+	pending, err := storage.ClaimPending(context.Background(), time.Now(), 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	cancelRun()
+	assert.NoError(t, <-done)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"1"}`)
+	sig := Sign(secret, now, body)
+
+	assert.NoError(t, Verify(secret, sig, body, now, time.Minute))
+	assert.ErrorIs(t, Verify("other", sig, body, now, time.Minute), ErrSignatureInvalid)
+	assert.ErrorIs(t, Verify(secret, sig, []byte(`{"id":"2"}`), now, time.Minute), ErrSignatureInvalid)
+	assert.ErrorIs(t, Verify(secret, sig, body, now.Add(2*time.Minute), time.Minute), ErrSignatureInvalid)
+	assert.ErrorIs(t, Verify(secret, "v1=abc", body, now, time.Minute), ErrSignatureInvalid)
+}
+
+func TestAllowed(t *testing.T) {
+	cases := []struct {
+		addr     string
+		expected bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.addr, func(t *testing.T) {
+			assert.Equal(t, c.expected, allowed(netip.MustParseAddr(c.addr)))
+		})
+	}
+}
+
+func TestNewClient_RefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(time.Second).Get(srv.URL)
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+}
@@ -0,0 +1,77 @@
+package deliver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header containing the signature of a delivery.
+const SignatureHeader = "X-Webhook-Signature"
+
+var ErrSignatureInvalid = errors.New("signature invalid")
+
+// Sign returns a signature of the form t=<unix timestamp>,v1=<hex HMAC-SHA256>.
+// The HMAC is computed over the timestamp and body joined by a full stop, so
+// that receivers can reject replayed deliveries with an old timestamp.
+func Sign(
+	secret string,
+	timestamp time.Time,
+	body []byte,
+) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	return fmt.Sprintf("t=%s,v1=%s", t, mac(secret, t, body))
+}
+
+// Verify checks that signature was produced by Sign using secret and body,
+// and that the timestamp is within tolerance of now.
+func Verify(
+	secret string,
+	signature string,
+	body []byte,
+	now time.Time,
+	tolerance time.Duration,
+) error {
+	var t, v1 string
+
+	for _, part := range strings.Split(signature, ",") {
+		k, v, _ := strings.Cut(part, "=")
+
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			v1 = v
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+
+	if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+		return ErrSignatureInvalid
+	}
+
+	if !hmac.Equal([]byte(v1), []byte(mac(secret, t, body))) {
+		return ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,6 @@
+package subscription
+
+type inputData struct {
+	URL        string   `json:"url" validate:"required,https_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=user.created user.updated user.deleted user.restored user.purged"`
+}
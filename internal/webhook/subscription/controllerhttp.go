@@ -0,0 +1,271 @@
+package subscription
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bendbennett/go-api-demo/internal/log"
+	"github.com/bendbennett/go-api-demo/internal/response"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+	"github.com/gorilla/mux"
+)
+
+type httpController struct {
+	validator  validate.Validator
+	interactor interactor
+	presenter  presenter
+	logger     log.Logger
+}
+
+type HTTPController interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	Read(w http.ResponseWriter, r *http.Request)
+	ReadByID(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Deliveries(w http.ResponseWriter, r *http.Request)
+}
+
+func NewHTTPController(
+	validator validate.Validator,
+	interactor interactor,
+	presenter presenter,
+	logger log.Logger,
+) *httpController {
+	return &httpController{
+		validator,
+		interactor,
+		presenter,
+		logger,
+	}
+}
+
+type output struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type deliveryOutput struct {
+	ID          string `json:"id"`
+	EventID     string `json:"event_id"`
+	EventType   string `json:"event_type"`
+	Attempt     int    `json:"attempt"`
+	StatusCode  int    `json:"status_code"`
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+	AttemptedAt string `json:"attempted_at"`
+}
+
+func (c *httpController) Create(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	input, ok := c.input(w, r)
+	if !ok {
+		return
+	}
+
+	od, err := c.interactor.create(
+		ctx,
+		input,
+	)
+	if err != nil {
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusCreated,
+		output(c.presenter.viewModel(od)),
+	)
+}
+
+func (c *httpController) Read(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	od, err := c.interactor.read(
+		ctx,
+	)
+	if err != nil {
+		c.logger.ErrorContext(ctx, err)
+		response.Write500Response(w)
+		return
+	}
+
+	subscriptions := []output{}
+
+	for _, s := range od {
+		subscriptions = append(
+			subscriptions,
+			output(c.presenter.viewModel(s)),
+		)
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		subscriptions,
+	)
+}
+
+func (c *httpController) ReadByID(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	od, err := c.interactor.readByID(
+		ctx,
+		mux.Vars(r)["id"],
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		output(c.presenter.viewModel(od)),
+	)
+}
+
+func (c *httpController) Update(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+
+	input, ok := c.input(w, r)
+	if !ok {
+		return
+	}
+
+	od, err := c.interactor.update(
+		ctx,
+		mux.Vars(r)["id"],
+		input,
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		output(c.presenter.viewModel(od)),
+	)
+}
+
+func (c *httpController) Delete(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	err := c.interactor.delete(
+		r.Context(),
+		mux.Vars(r)["id"],
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *httpController) Deliveries(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	od, err := c.interactor.deliveries(
+		r.Context(),
+		mux.Vars(r)["id"],
+	)
+	if err != nil {
+		c.writeError(w, r, err)
+		return
+	}
+
+	deliveries := []deliveryOutput{}
+
+	for _, d := range c.presenter.deliveriesViewModel(od) {
+		deliveries = append(
+			deliveries,
+			deliveryOutput(d),
+		)
+	}
+
+	response.WriteResponse(
+		w,
+		http.StatusOK,
+		deliveries,
+	)
+}
+
+// input decodes and validates the request body, writing an error
+// response and returning false if either fails.
+func (c *httpController) input(
+	w http.ResponseWriter,
+	r *http.Request,
+) (inputData, bool) {
+	ctx := r.Context()
+	input := inputData{}
+
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		c.logger.ErrorfContext(ctx, "json body invalid: %v", err)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			map[string]string{"body": "json invalid"},
+		)
+		return inputData{}, false
+	}
+
+	errs := c.validator.ValidateStruct(input)
+	if errs != nil {
+		c.logger.InfofContext(ctx, "input invalid: %v", errs)
+		response.WriteErrorResponse(
+			w,
+			http.StatusBadRequest,
+			"failed validation",
+			errs,
+		)
+		return inputData{}, false
+	}
+
+	return input, true
+}
+
+func (c *httpController) writeError(
+	w http.ResponseWriter,
+	r *http.Request,
+	err error,
+) {
+	if errors.Is(err, webhook.ErrNotFound) {
+		response.WriteErrorResponse(
+			w,
+			http.StatusNotFound,
+			"subscription not found",
+			nil,
+		)
+		return
+	}
+
+	c.logger.ErrorContext(r.Context(), err)
+	response.Write500Response(w)
+}
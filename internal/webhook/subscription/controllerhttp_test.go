@@ -0,0 +1,267 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type interactorMock struct {
+	err error
+}
+
+func (m *interactorMock) create(context.Context, inputData) (outputData, error) {
+	return outputData{}, m.err
+}
+
+func (m *interactorMock) read(context.Context) ([]outputData, error) {
+	return []outputData{{}}, m.err
+}
+
+func (m *interactorMock) readByID(context.Context, string) (outputData, error) {
+	return outputData{}, m.err
+}
+
+func (m *interactorMock) update(context.Context, string, inputData) (outputData, error) {
+	return outputData{}, m.err
+}
+
+func (m *interactorMock) delete(context.Context, string) error {
+	return m.err
+}
+
+func (m *interactorMock) deliveries(context.Context, string) ([]deliveryOutputData, error) {
+	return []deliveryOutputData{{}}, m.err
+}
+
+type presenterMock struct {
+}
+
+func (pm *presenterMock) viewModel(outputData) viewModel {
+	return viewModel{
+		ID:         "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		URL:        "https://example.com/hook",
+		Secret:     "whsec_abc",
+		EventTypes: []string{"user.created"},
+		CreatedAt:  "2006-01-02T15:04:05-0700",
+	}
+}
+
+func (pm *presenterMock) deliveriesViewModel([]deliveryOutputData) []deliveryViewModel {
+	return []deliveryViewModel{
+		{
+			ID:          "1",
+			EventID:     "2",
+			EventType:   "user.created",
+			Attempt:     1,
+			StatusCode:  200,
+			DurationMs:  15,
+			AttemptedAt: "2006-01-02T15:04:05-0700",
+		},
+	}
+}
+
+type loggerMock struct {
+}
+
+func (lm loggerMock) Panic(error)                                           {}
+func (lm loggerMock) Panicf(string, ...interface{})                         {}
+func (lm loggerMock) Error(error)                                           {}
+func (lm loggerMock) ErrorContext(context.Context, error)                   {}
+func (lm loggerMock) Errorf(string, ...interface{})                         {}
+func (lm loggerMock) ErrorfContext(context.Context, string, ...interface{}) {}
+func (lm loggerMock) Infof(string, ...interface{})                          {}
+func (lm loggerMock) InfofContext(context.Context, string, ...interface{})  {}
+
+const subscriptionJSON = `{
+	"id": "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+	"url": "https://example.com/hook",
+	"secret": "whsec_abc",
+	"event_types": ["user.created"],
+	"created_at": "2006-01-02T15:04:05-0700"
+}`
+
+func TestHTTPController(t *testing.T) {
+	validator, err := validate.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                 string
+		method               string
+		path                 string
+		body                 io.Reader
+		interactor           interactor
+		expectedStatus       int
+		expectedResponseBody string
+	}{
+		{
+			"create json invalid",
+			http.MethodPost,
+			"/webhook",
+			strings.NewReader(`{"url:}`),
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {"body": "json invalid"}}`,
+		},
+		{
+			"create input invalid",
+			http.MethodPost,
+			"/webhook",
+			strings.NewReader(`{"url": "not a url", "event_types": ["user.unknown"]}`),
+			&interactorMock{},
+			http.StatusBadRequest,
+			`{"message": "failed validation", "errors": {
+				"url": "url must be a valid https URL",
+				"event_types[0]": "event_types[0] must be one of [user.created user.updated user.deleted user.restored user.purged]"
+			}}`,
+		},
+		{
+			"create interactor error",
+			http.MethodPost,
+			"/webhook",
+			strings.NewReader(`{"url": "https://example.com/hook", "event_types": ["user.created"]}`),
+			&interactorMock{errors.New("interactor create error")},
+			http.StatusInternalServerError,
+			`{"message": "internal server error"}`,
+		},
+		{
+			"create success",
+			http.MethodPost,
+			"/webhook",
+			strings.NewReader(`{"url": "https://example.com/hook", "event_types": ["user.created"]}`),
+			&interactorMock{},
+			http.StatusCreated,
+			subscriptionJSON,
+		},
+		{
+			"read success",
+			http.MethodGet,
+			"/webhook",
+			nil,
+			&interactorMock{},
+			http.StatusOK,
+			"[" + subscriptionJSON + "]",
+		},
+		{
+			"read by id not found",
+			http.MethodGet,
+			"/webhook/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{webhook.ErrNotFound},
+			http.StatusNotFound,
+			`{"message": "subscription not found"}`,
+		},
+		{
+			"read by id success",
+			http.MethodGet,
+			"/webhook/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{},
+			http.StatusOK,
+			subscriptionJSON,
+		},
+		{
+			"update not found",
+			http.MethodPut,
+			"/webhook/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			strings.NewReader(`{"url": "https://example.com/hook", "event_types": ["user.created"]}`),
+			&interactorMock{webhook.ErrNotFound},
+			http.StatusNotFound,
+			`{"message": "subscription not found"}`,
+		},
+		{
+			"update success",
+			http.MethodPut,
+			"/webhook/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			strings.NewReader(`{"url": "https://example.com/hook", "event_types": ["user.created"]}`),
+			&interactorMock{},
+			http.StatusOK,
+			subscriptionJSON,
+		},
+		{
+			"delete interactor error",
+			http.MethodDelete,
+			"/webhook/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{errors.New("interactor delete error")},
+			http.StatusInternalServerError,
+			`{"message": "internal server error"}`,
+		},
+		{
+			"delete success",
+			http.MethodDelete,
+			"/webhook/0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+			nil,
+			&interactorMock{},
+			http.StatusNoContent,
+			"",
+		},
+		{
+			"deliveries success",
+			http.MethodGet,
+			"/webhook/0a81dec3-3638-4eb4-b04a-83d744f5f3a8/deliveries",
+			nil,
+			&interactorMock{},
+			http.StatusOK,
+			`[{
+				"id": "1",
+				"event_id": "2",
+				"event_type": "user.created",
+				"attempt": 1,
+				"status_code": 200,
+				"duration_ms": 15,
+				"attempted_at": "2006-01-02T15:04:05-0700"
+			}]`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			controller := NewHTTPController(
+				validator,
+				c.interactor,
+				&presenterMock{},
+				loggerMock{},
+			)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/webhook", controller.Create).Methods(http.MethodPost)
+			router.HandleFunc("/webhook", controller.Read).Methods(http.MethodGet)
+			router.HandleFunc("/webhook/{id}", controller.ReadByID).Methods(http.MethodGet)
+			router.HandleFunc("/webhook/{id}", controller.Update).Methods(http.MethodPut)
+			router.HandleFunc("/webhook/{id}", controller.Delete).Methods(http.MethodDelete)
+			router.HandleFunc("/webhook/{id}/deliveries", controller.Deliveries).Methods(http.MethodGet)
+
+			r := httptest.NewRequest(c.method, c.path, c.body)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+
+			if c.expectedResponseBody == "" {
+				assert.Empty(t, w.Body.String())
+				return
+			}
+
+			// Flatten JSON formatted response body.
+			expectedResponseBody := bytes.NewBuffer(nil)
+			_ = json.Compact(expectedResponseBody, []byte(c.expectedResponseBody))
+
+			assert.JSONEq(t, expectedResponseBody.String(), w.Body.String())
+		})
+	}
+}
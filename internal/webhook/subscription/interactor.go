@@ -0,0 +1,204 @@
+package subscription
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+	"github.com/google/uuid"
+)
+
+// deliveriesLimit is the maximum number of deliveries returned
+// from the delivery log.
+const deliveriesLimit = 100
+
+const secretPrefix = "whsec_"
+
+const secretLen = 32
+
+type storage interface {
+	webhook.SubscriptionStorage
+	webhook.DeliveryReader
+}
+
+type i struct {
+	storage storage
+}
+
+type interactor interface {
+	create(context.Context, inputData) (outputData, error)
+	read(context.Context) ([]outputData, error)
+	readByID(context.Context, string) (outputData, error)
+	update(context.Context, string, inputData) (outputData, error)
+	delete(context.Context, string) error
+	deliveries(context.Context, string) ([]deliveryOutputData, error)
+}
+
+var _ interactor = (*i)(nil)
+
+func NewInteractor(
+	storage storage,
+) *i {
+	return &i{
+		storage,
+	}
+}
+
+// outputData only includes the Secret when the subscription is created.
+type outputData struct {
+	CreatedAt  time.Time
+	ID         string
+	URL        string
+	Secret     string
+	EventTypes []string
+}
+
+type deliveryOutputData struct {
+	AttemptedAt time.Time
+	ID          string
+	EventID     string
+	EventType   string
+	Error       string
+	Attempt     int
+	StatusCode  int
+	Duration    time.Duration
+}
+
+func (i *i) create(
+	ctx context.Context,
+	inputData inputData,
+) (outputData, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return outputData{}, err
+	}
+
+	s := webhook.Subscription{
+		CreatedAt:  time.Now(),
+		ID:         uuid.New().String(),
+		URL:        inputData.URL,
+		Secret:     secret,
+		EventTypes: inputData.EventTypes,
+	}
+
+	err = i.storage.CreateSubscription(
+		ctx,
+		s,
+	)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	od := toOutputData(s)
+	od.Secret = s.Secret
+
+	return od, nil
+}
+
+func (i *i) read(ctx context.Context) ([]outputData, error) {
+	subscriptions, err := i.storage.ReadSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	od := make([]outputData, 0, len(subscriptions))
+
+	for _, s := range subscriptions {
+		od = append(od, toOutputData(s))
+	}
+
+	return od, nil
+}
+
+func (i *i) readByID(
+	ctx context.Context,
+	id string,
+) (outputData, error) {
+	s, err := i.storage.ReadSubscription(ctx, id)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	return toOutputData(s), nil
+}
+
+func (i *i) update(
+	ctx context.Context,
+	id string,
+	inputData inputData,
+) (outputData, error) {
+	s, err := i.storage.ReadSubscription(ctx, id)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	s.URL = inputData.URL
+	s.EventTypes = inputData.EventTypes
+
+	err = i.storage.UpdateSubscription(ctx, s)
+	if err != nil {
+		return outputData{}, err
+	}
+
+	return toOutputData(s), nil
+}
+
+func (i *i) delete(
+	ctx context.Context,
+	id string,
+) error {
+	return i.storage.DeleteSubscription(ctx, id)
+}
+
+func (i *i) deliveries(
+	ctx context.Context,
+	id string,
+) ([]deliveryOutputData, error) {
+	_, err := i.storage.ReadSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := i.storage.ReadDeliveries(ctx, id, deliveriesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	od := make([]deliveryOutputData, 0, len(deliveries))
+
+	for _, d := range deliveries {
+		od = append(od, deliveryOutputData{
+			AttemptedAt: d.AttemptedAt,
+			ID:          d.ID,
+			EventID:     d.EventID,
+			EventType:   d.EventType,
+			Error:       d.Error,
+			Attempt:     d.Attempt,
+			StatusCode:  d.StatusCode,
+			Duration:    d.Duration,
+		})
+	}
+
+	return od, nil
+}
+
+func toOutputData(s webhook.Subscription) outputData {
+	return outputData{
+		CreatedAt:  s.CreatedAt,
+		ID:         s.ID,
+		URL:        s.URL,
+		EventTypes: s.EventTypes,
+	}
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, secretLen)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return secretPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,161 @@
+package subscription
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bendbennett/go-api-demo/internal/storage/memory"
+	"github.com/bendbennett/go-api-demo/internal/tenant"
+	"github.com/bendbennett/go-api-demo/internal/validate"
+	"github.com/bendbennett/go-api-demo/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractor_Create(t *testing.T) {
+	storage := memory.NewWebhookStorage()
+	interactor := NewInteractor(storage)
+
+	od, err := interactor.create(
+		context.Background(),
+		inputData{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{"user.created"},
+		},
+	)
+	require.NoError(t, err)
+
+	assert.True(t, validate.IsUUID(od.ID))
+	assert.False(t, od.CreatedAt.IsZero())
+	assert.Equal(t, "https://example.com/hook", od.URL)
+	assert.Equal(t, []string{"user.created"}, od.EventTypes)
+	assert.True(t, strings.HasPrefix(od.Secret, secretPrefix))
+	assert.Len(t, od.Secret, len(secretPrefix)+secretLen*2)
+
+	s, err := storage.ReadSubscription(context.Background(), od.ID)
+	require.NoError(t, err)
+	assert.Equal(t, od.Secret, s.Secret)
+}
+
+func TestInteractor_ReadOmitsSecret(t *testing.T) {
+	interactor := NewInteractor(memory.NewWebhookStorage())
+
+	created, err := interactor.create(
+		context.Background(),
+		inputData{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{"user.created"},
+		},
+	)
+	require.NoError(t, err)
+
+	od, err := interactor.read(context.Background())
+	require.NoError(t, err)
+	require.Len(t, od, 1)
+	assert.Equal(t, created.ID, od[0].ID)
+	assert.Empty(t, od[0].Secret)
+
+	byID, err := interactor.readByID(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, byID.ID)
+	assert.Empty(t, byID.Secret)
+}
+
+func TestInteractor_Update(t *testing.T) {
+	storage := memory.NewWebhookStorage()
+	interactor := NewInteractor(storage)
+
+	created, err := interactor.create(
+		context.Background(),
+		inputData{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{"user.created"},
+		},
+	)
+	require.NoError(t, err)
+
+	od, err := interactor.update(
+		context.Background(),
+		created.ID,
+		inputData{
+			URL:        "https://example.com/other",
+			EventTypes: []string{"user.deleted"},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/other", od.URL)
+	assert.Equal(t, []string{"user.deleted"}, od.EventTypes)
+
+	s, err := storage.ReadSubscription(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Secret, s.Secret)
+
+	_, err = interactor.update(context.Background(), "unknown", inputData{})
+	assert.ErrorIs(t, err, webhook.ErrNotFound)
+}
+
+func TestInteractor_DeleteAndDeliveries(t *testing.T) {
+	storage := memory.NewWebhookStorage()
+	interactor := NewInteractor(storage)
+
+	created, err := interactor.create(
+		context.Background(),
+		inputData{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{"user.created"},
+		},
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.CreateDelivery(context.Background(), webhook.Delivery{
+		ID:             "1",
+		SubscriptionID: created.ID,
+		Attempt:        1,
+		StatusCode:     500,
+	}))
+
+	deliveries, err := interactor.deliveries(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 500, deliveries[0].StatusCode)
+
+	require.NoError(t, interactor.delete(context.Background(), created.ID))
+
+	_, err = interactor.deliveries(context.Background(), created.ID)
+	assert.ErrorIs(t, err, webhook.ErrNotFound)
+
+	assert.ErrorIs(t, interactor.delete(context.Background(), created.ID), webhook.ErrNotFound)
+}
+
+func TestInteractor_ScopedToTenant(t *testing.T) {
+	interactor := NewInteractor(memory.NewWebhookStorage())
+
+	acme := tenant.NewContext(context.Background(), "acme")
+	globex := tenant.NewContext(context.Background(), "globex")
+
+	created, err := interactor.create(
+		acme,
+		inputData{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{"user.created"},
+		},
+	)
+	require.NoError(t, err)
+
+	od, err := interactor.read(globex)
+	require.NoError(t, err)
+	assert.Empty(t, od)
+
+	_, err = interactor.readByID(globex, created.ID)
+	assert.ErrorIs(t, err, webhook.ErrNotFound)
+
+	_, err = interactor.update(globex, created.ID, inputData{URL: "https://example.com/other"})
+	assert.ErrorIs(t, err, webhook.ErrNotFound)
+
+	assert.ErrorIs(t, interactor.delete(globex, created.ID), webhook.ErrNotFound)
+
+	byID, err := interactor.readByID(acme, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", byID.URL)
+}
@@ -0,0 +1,68 @@
+package subscription
+
+import "time"
+
+type p struct {
+}
+
+type presenter interface {
+	viewModel(data outputData) viewModel
+	deliveriesViewModel(data []deliveryOutputData) []deliveryViewModel
+}
+
+var _ presenter = (*p)(nil)
+
+func NewPresenter() presenter {
+	return &p{}
+}
+
+type viewModel struct {
+	ID         string
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  string
+}
+
+type deliveryViewModel struct {
+	ID          string
+	EventID     string
+	EventType   string
+	Attempt     int
+	StatusCode  int
+	Error       string
+	DurationMs  int64
+	AttemptedAt string
+}
+
+func (p *p) viewModel(od outputData) viewModel {
+	return viewModel{
+		ID:         od.ID,
+		URL:        od.URL,
+		Secret:     od.Secret,
+		EventTypes: od.EventTypes,
+		CreatedAt:  od.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (p *p) deliveriesViewModel(od []deliveryOutputData) []deliveryViewModel {
+	vm := make([]deliveryViewModel, 0, len(od))
+
+	for _, d := range od {
+		vm = append(
+			vm,
+			deliveryViewModel{
+				ID:          d.ID,
+				EventID:     d.EventID,
+				EventType:   d.EventType,
+				Attempt:     d.Attempt,
+				StatusCode:  d.StatusCode,
+				Error:       d.Error,
+				DurationMs:  d.Duration.Milliseconds(),
+				AttemptedAt: d.AttemptedAt.Format(time.RFC3339Nano),
+			},
+		)
+	}
+
+	return vm
+}
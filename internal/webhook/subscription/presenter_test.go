@@ -0,0 +1,70 @@
+package subscription
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenter_ViewModel(t *testing.T) {
+	presenter := NewPresenter()
+
+	createdAt, err := time.Parse(
+		time.RFC3339,
+		"2015-09-15T14:23:12+07:00")
+	if err != nil {
+		t.Error(err)
+	}
+
+	vm := presenter.viewModel(outputData{
+		CreatedAt:  createdAt,
+		ID:         "0a81dec3-3638-4eb4-b04a-83d744f5f3a8",
+		URL:        "https://example.com/hook",
+		Secret:     "whsec_abc",
+		EventTypes: []string{"user.created"},
+	})
+
+	assert.Equal(t, "0a81dec3-3638-4eb4-b04a-83d744f5f3a8", vm.ID)
+	assert.Equal(t, "https://example.com/hook", vm.URL)
+	assert.Equal(t, "whsec_abc", vm.Secret)
+	assert.Equal(t, []string{"user.created"}, vm.EventTypes)
+	assert.Equal(t, "2015-09-15T14:23:12+07:00", vm.CreatedAt)
+}
+
+func TestPresenter_DeliveriesViewModel(t *testing.T) {
+	presenter := NewPresenter()
+
+	attemptedAt, err := time.Parse(
+		time.RFC3339,
+		"2015-09-15T14:23:12+07:00")
+	if err != nil {
+		t.Error(err)
+	}
+
+	vm := presenter.deliveriesViewModel([]deliveryOutputData{
+		{
+			AttemptedAt: attemptedAt,
+			ID:          "1",
+			EventID:     "2",
+			EventType:   "user.created",
+			Error:       "unexpected status code 500",
+			Attempt:     3,
+			StatusCode:  500,
+			Duration:    1500 * time.Millisecond,
+		},
+	})
+
+	assert.Equal(t, []deliveryViewModel{
+		{
+			ID:          "1",
+			EventID:     "2",
+			EventType:   "user.created",
+			Attempt:     3,
+			StatusCode:  500,
+			Error:       "unexpected status code 500",
+			DurationMs:  1500,
+			AttemptedAt: "2015-09-15T14:23:12+07:00",
+		},
+	}, vm)
+}
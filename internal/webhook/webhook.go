@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Subscription is a request to be notified of user events of EventTypes
+// (e.g., user.created) by a POST to URL. Deliveries are signed using Secret.
+// Subscriptions are notified only of the events of their tenant.
+type Subscription struct {
+	CreatedAt  time.Time
+	TenantID   string
+	ID         string
+	URL        string
+	Secret     string
+	EventTypes []string
+}
+
+// Matches returns true if the subscription includes eventType.
+func (s Subscription) Matches(eventType string) bool {
+	for _, et := range s.EventTypes {
+		if et == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Delivery records an attempt to deliver an event to a subscription.
+// StatusCode is 0 when no response was received, in which case Error
+// describes the failure.
+type Delivery struct {
+	AttemptedAt    time.Time
+	TenantID       string
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Error          string
+	Attempt        int
+	StatusCode     int
+	Duration       time.Duration
+}
+
+// Storage scopes subscriptions and deliveries to the tenant carried by the
+// context.
+type Storage interface {
+	SubscriptionStorage
+	DeliveryLog
+	PendingQueue
+}
+
+type SubscriptionStorage interface {
+	SubscriptionCreator
+	SubscriptionReader
+	SubscriptionUpdater
+	SubscriptionDeleter
+}
+
+type SubscriptionCreator interface {
+	CreateSubscription(context.Context, Subscription) error
+}
+
+// SubscriptionReader returns ErrNotFound from ReadSubscription if
+// no subscription exists with the ID.
+type SubscriptionReader interface {
+	ReadSubscriptions(context.Context) ([]Subscription, error)
+	ReadSubscription(ctx context.Context, id string) (Subscription, error)
+}
+
+// SubscriptionUpdater returns ErrNotFound if no subscription exists
+// with the ID.
+type SubscriptionUpdater interface {
+	UpdateSubscription(context.Context, Subscription) error
+}
+
+// SubscriptionDeleter returns ErrNotFound if no subscription exists
+// with the ID.
+type SubscriptionDeleter interface {
+	DeleteSubscription(ctx context.Context, id string) error
+}
+
+type DeliveryLog interface {
+	DeliveryCreator
+	DeliveryReader
+}
+
+type DeliveryCreator interface {
+	CreateDelivery(context.Context, Delivery) error
+}
+
+// DeliveryReader returns the deliveries for a subscription, most
+// recent first, limited to limit.
+type DeliveryReader interface {
+	ReadDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error)
+}
+
+// Pending is a delivery of an event to a subscription that is yet to
+// succeed or exhaust its attempts. Event is the CloudEvent to deliver,
+// encoded as JSON, Attempt is the number of attempts made so far, and the
+// next attempt is due at NextAttemptAt.
+type Pending struct {
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	TenantID       string
+	ID             string
+	SubscriptionID string
+	EventID        string
+	Event          []byte
+	Attempt        int
+}
+
+// PendingQueue holds the deliveries still to be made. Unlike Storage,
+// pending deliveries are claimed across tenants, each carrying its own
+// TenantID.
+type PendingQueue interface {
+	// CreatePending records deliveries using the tenant carried by the
+	// context. A delivery of an event to a subscription that is already
+	// pending is ignored, so that an event handled more than once is not
+	// delivered more than once as a result.
+	CreatePending(context.Context, ...Pending) error
+	// ClaimPending returns up to limit deliveries that are due at now, and
+	// defers them until now plus lease, so that they are not claimed again
+	// while being attempted, but are once lease has passed if the claimant
+	// neither reschedules nor deletes them.
+	ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Pending, error)
+	// ReschedulePending updates Attempt and NextAttemptAt of the delivery.
+	ReschedulePending(context.Context, Pending) error
+	DeletePending(ctx context.Context, id string) error
+}
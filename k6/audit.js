@@ -0,0 +1,32 @@
+import http from 'k6/http';
+import { check } from 'k6';
+
+// Sends concurrent mutating requests within a single tenant, each of which
+// appends to the audit log chain of the tenant while holding the lock on
+// its head.
+export const options = {
+    scenarios: {
+        constant_request_rate: {
+            executor: 'constant-arrival-rate',
+            rate: 200,
+            timeUnit: '1s',
+            duration: '5m',
+            preAllocatedVUs: 50,
+            maxVUs: 400,
+        },
+    },
+};
+
+export default function () {
+    let data = { first_name: "john", last_name: "smith" }
+
+    let params = {
+        headers: {
+            'X-Tenant-ID': __ENV.TENANT || 'default',
+            'X-API-Key': __ENV.API_KEY || '',
+        },
+    };
+
+    let res = http.post(`http://${__ENV.HOST}:3000/user`, JSON.stringify(data), params);
+    check(res, { 'status was 201': (r) => r.status == 201 });
+}
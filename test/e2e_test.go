@@ -39,7 +39,7 @@ func Test_E2E(t *testing.T) {
 	env(t)
 	purge(t)
 
-	a := bootstrap.New()
+	a := bootstrap.New(bootstrap.RoleAll)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -59,6 +59,7 @@ func Test_E2E(t *testing.T) {
 	// User - Read
 	userReadHTTP(t, httpClient)
 	userReadGRPC(t, grpcClient)
+	userListGRPC(t, grpcClient)
 
 	// User - Search
 	userSearchHTTP(t, httpClient)
@@ -579,6 +580,37 @@ func userReadGRPC(t *testing.T, grpcClient *grpcClient) {
 	assert.True(t, !createdAt.IsZero())
 }
 
+// userListGRPC runs after userReadGRPC, by which time both users have been
+// cached, and requests one user per page.
+func userListGRPC(t *testing.T, grpcClient *grpcClient) {
+	stream, err := grpcClient.userClient.ListUsers(
+		context.Background(),
+		&user.ListUsersRequest{PageSize: 1},
+	)
+	require.NoError(t, err)
+
+	var users []*user.UserResponse
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		users = append(users, resp.Users...)
+	}
+
+	assert.Len(t, users, 2)
+
+	for _, u := range users {
+		assert.True(t, validate.IsUUID(u.Id))
+		assert.NotEmpty(t, u.FirstName)
+		assert.NotEmpty(t, u.LastName)
+	}
+}
+
 func userSearchGRPC(t *testing.T, grpcClient *grpcClient) {
 	maxAttempts := 500
 	usersGRPC := &user.UsersResponse{}